@@ -10,7 +10,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"jacobin/globals"
 	"jacobin/log"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -29,10 +34,18 @@ type ResourceEntry struct {
 	Type     ResourceType
 }
 
+// Archive is a jar file. In a multi-release jar (one whose manifest has
+// Multi-Release: true), classes and resources can have versions for later
+// releases of Java, which are in META-INF/versions/N/, where N is the release.
+// The version that's used is the one for the highest release that's no higher
+// than the one Jacobin supports, or else the base entry.
 type Archive struct {
-	Filename   string
-	entryCache map[string]ResourceEntry
-	manifest   map[string]string
+	Filename       string
+	entryCache     map[string]ResourceEntry
+	manifest       map[string]string
+	multiRelease   bool
+	versionEntries map[int]map[string]ResourceEntry // the entries in META-INF/versions/N, by N
+	releases       []int                            // the releases, N, in descending order
 }
 
 type LoadResult struct {
@@ -46,6 +59,7 @@ func NewJarFile(filename string) (*Archive, error) {
 	jarFile.Filename = filename
 	jarFile.entryCache = make(map[string]ResourceEntry)
 	jarFile.manifest = make(map[string]string)
+	jarFile.versionEntries = make(map[int]map[string]ResourceEntry)
 	err := jarFile.scanArchive()
 
 	if err != nil {
@@ -70,12 +84,22 @@ func (archive *Archive) scanArchive() error {
 	for _, file := range reader.File {
 		entry := archive.recordFile(file)
 		if entry.Type == Manifest {
-			if archive.parseManifest(file); err != nil {
+			if err := archive.parseManifest(file); err != nil {
 				return err
 			}
 		}
 	}
 
+	// the versions are only used if the manifest says the jar is multi-release
+	archive.multiRelease = strings.EqualFold(archive.manifest["Multi-Release"], "true")
+	maxRelease := globals.GetGlobalRef().MaxJavaVersion
+	for release := range archive.versionEntries {
+		if release <= maxRelease {
+			archive.releases = append(archive.releases, release)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(archive.releases)))
+
 	return nil
 }
 
@@ -99,36 +123,109 @@ func (archive *Archive) recordFile(file *zip.File) ResourceEntry {
 
 	archive.entryCache[resourceName] = entry
 
+	// an entry in META-INF/versions/N/ is also recorded under the name it has
+	// in that directory, so it can replace the base entry in a multi-release jar
+	if rest, ok := strings.CutPrefix(file.Name, versionsDir); ok {
+		releaseDir, versionedName, found := strings.Cut(rest, "/")
+		release, err := strconv.Atoi(releaseDir)
+		if found && err == nil && release >= 9 && versionedName != "" { // releases start at 9
+			if fileType == ClassFile {
+				versionedName = strings.TrimSuffix(strings.ReplaceAll(versionedName, "/", "."), ".class")
+			}
+			if archive.versionEntries[release] == nil {
+				archive.versionEntries[release] = make(map[string]ResourceEntry)
+			}
+			archive.versionEntries[release][versionedName] =
+				ResourceEntry{Location: file.Name, Name: versionedName, Type: fileType}
+		}
+	}
+
 	return entry
 }
 
-func (archive *Archive) parseManifest(file *zip.File) error {
-	rc, err := file.Open()
+const versionsDir = "META-INF/versions/"
 
-	defer rc.Close()
+// lookup returns the entry for the name, which is the versioned entry for the
+// highest release that has one, in a multi-release jar, or else the base entry
+func (archive *Archive) lookup(name string) (ResourceEntry, bool) {
+	if archive.multiRelease {
+		for _, release := range archive.releases {
+			if entry, ok := archive.versionEntries[release][name]; ok {
+				return entry, true
+			}
+		}
+	}
+	entry, ok := archive.entryCache[name]
+	return entry, ok
+}
 
+// parseManifest reads the attributes of the main section of the manifest,
+// which is the section before the first blank line. (The sections that follow
+// it hold the attributes of individual entries, which aren't used.) As the
+// manifest format specifies, a line that starts with a space continues the
+// previous line, as long values such as Class-Path are broken into lines of
+// at most 72 bytes.
+func (archive *Archive) parseManifest(file *zip.File) error {
+	rc, err := file.Open()
 	if err != nil {
 		return err
 	}
+	defer rc.Close()
 
 	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
 
-	contents := string(data)
+	contents := strings.ReplaceAll(string(data), "\r\n", "\n")
+	contents = strings.ReplaceAll(contents, "\r", "\n")
 
-	lines := strings.Split(contents, "\r\n")
+	var lines []string
+	for _, line := range strings.Split(contents, "\n") {
+		if line == "" {
+			break // the end of the main section
+		}
+		if strings.HasPrefix(line, " ") && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
 
 	for _, line := range lines {
-		parts := strings.Split(line, ":")
-		if len(parts) > 1 {
-			archive.manifest[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		name, value, found := strings.Cut(line, ":")
+		if found {
+			archive.manifest[strings.TrimSpace(name)] = strings.TrimSpace(value)
 		}
 	}
 
 	return nil
 }
 
+// manifestClasspath returns the jars and directories in the Class-Path
+// attribute of the manifest. They're URLs, which are separated by spaces and
+// are relative to the directory of the jar, unless they're file: URLs. URLs of
+// other kinds aren't supported, as in the JDK's launcher.
+func (archive *Archive) manifestClasspath() []string {
+	var entries []string
+	for _, entry := range strings.Fields(archive.manifest["Class-Path"]) {
+		u, err := url.Parse(entry)
+		if err != nil || (u.Scheme != "" && u.Scheme != "file") {
+			_ = log.Log("Class-Path entry "+entry+" in "+archive.Filename+" is not supported. Ignored.",
+				log.WARNING)
+			continue
+		}
+		path := filepath.FromSlash(u.Path)
+		if u.Scheme == "" && !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(archive.Filename), path)
+		}
+		entries = append(entries, path)
+	}
+	return entries
+}
+
 func (archive *Archive) hasResource(name string, resourceType ResourceType) bool {
-	item, ok := archive.entryCache[name]
+	item, ok := archive.lookup(name)
 
 	if !ok {
 		return false
@@ -138,7 +235,7 @@ func (archive *Archive) hasResource(name string, resourceType ResourceType) bool
 }
 
 func (archive *Archive) loadClass(className string) (*LoadResult, error) {
-	item, ok := archive.entryCache[className]
+	item, ok := archive.lookup(className)
 
 	if !ok {
 		err := errors.New(fmt.Sprintf("Unable to load class %s in archive %s", className, archive.Filename))
@@ -149,29 +246,34 @@ func (archive *Archive) loadClass(className string) (*LoadResult, error) {
 		return nil, errors.New(fmt.Sprintf("Class %s in archive %s is not a classfile", className, archive.Filename))
 	}
 
-	reader, err := zip.OpenReader(archive.Filename)
-
-	defer reader.Close()
+	bytes, err := archive.readEntry(item)
 
 	if err != nil {
 		return nil, err
 	}
 
-	file, err := reader.Open(item.Location)
+	return &LoadResult{Data: &bytes, Success: true, ResourceEntry: item}, nil
+}
 
-	defer file.Close()
+// readEntry returns the contents of the entry, which is a class or a resource
+func (archive *Archive) readEntry(item ResourceEntry) ([]byte, error) {
+	reader, err := zip.OpenReader(archive.Filename)
 
 	if err != nil {
 		return nil, err
 	}
 
-	bytes, err := io.ReadAll(file)
+	defer reader.Close()
+
+	file, err := reader.Open(item.Location)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &LoadResult{Data: &bytes, Success: true, ResourceEntry: item}, nil
+	defer file.Close()
+
+	return io.ReadAll(file)
 }
 
 func (archive *Archive) getMainClass() string {
@@ -7,8 +7,11 @@
 package classloader
 
 import (
+	"jacobin/globals"
+	"jacobin/log"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -102,3 +105,135 @@ func TestLoadClassDoesNotExist(t *testing.T) {
 		t.Error("Expected error loading class, but didn't get one.")
 	}
 }
+
+func TestManifestContinuationLinesAndSections(t *testing.T) {
+	jarName := filepath.Join(t.TempDir(), "app.jar")
+	manifest := "Manifest-Version: 1.0\n" +
+		"Main-Class: com.acme.Main\n" +
+		"Class-Path: lib/first.jar lib/sec\n" +
+		" ond.jar file:/opt/shared/common.jar http://example.com/x.jar\n" +
+		"Implementation-URL: https://example.com/app\n" +
+		"\n" +
+		"Name: com/acme/\n" +
+		"Sealed: true\n"
+	writeJar(t, jarName, map[string][]byte{"META-INF/MANIFEST.MF": []byte(manifest)})
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	jar, err := NewJarFile(jarName)
+	if err != nil {
+		t.Fatalf("Unexpected error reading %s: %v", jarName, err)
+	}
+	classpath := jar.manifestClasspath()
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if jar.manifest["Implementation-URL"] != "https://example.com/app" {
+		t.Errorf("Expected a value with a : to be kept whole, got %s", jar.manifest["Implementation-URL"])
+	}
+	if _, ok := jar.manifest["Sealed"]; ok {
+		t.Errorf("Expected the attributes of entries not to be in the main section")
+	}
+
+	dir := filepath.Dir(jarName)
+	expected := []string{filepath.Join(dir, "lib", "first.jar"), filepath.Join(dir, "lib", "second.jar"),
+		filepath.FromSlash("/opt/shared/common.jar")}
+	if strings.Join(classpath, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected the Class-Path %v, got %v", expected, classpath)
+	}
+}
+
+func TestApplyJarManifestAttributes(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	cl := &Classloader{Name: "app", Archives: make(map[string]*Archive)}
+
+	jarName := filepath.Join(t.TempDir(), "app.jar")
+	manifest := "Manifest-Version: 1.0\r\n" +
+		"Add-Opens: java.base/java.lang java.base/java.util malformed\r\n" +
+		"Add-Exports: jdk.internal.vm.ci/jdk.vm.ci.code\r\n" +
+		"Enable-Native-Access: ALL-UNNAMED\r\n"
+	writeJar(t, jarName, map[string][]byte{"META-INF/MANIFEST.MF": []byte(manifest)})
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	err := ApplyJarManifestAttributes(cl, jarName)
+	_ = w.Close()
+	os.Stderr = normalStderr
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	g := globals.GetGlobalRef()
+	if strings.Join(g.AddOpens, " ") != "java.base/java.lang java.base/java.util" {
+		t.Errorf("Expected the packages of Add-Opens, got %v", g.AddOpens)
+	}
+	if strings.Join(g.AddExports, " ") != "jdk.internal.vm.ci/jdk.vm.ci.code" {
+		t.Errorf("Expected the package of Add-Exports, got %v", g.AddExports)
+	}
+	if !g.EnableNativeAccess {
+		t.Errorf("Expected Enable-Native-Access to be set")
+	}
+
+	badJarName := filepath.Join(t.TempDir(), "bad.jar")
+	writeJar(t, badJarName, map[string][]byte{"META-INF/MANIFEST.MF": []byte("Enable-Native-Access: java.base\r\n")})
+	err = ApplyJarManifestAttributes(cl, badJarName)
+	if err == nil || !strings.Contains(err.Error(), "Only ALL-UNNAMED is allowed") {
+		t.Errorf("Expected an error for an Enable-Native-Access other than ALL-UNNAMED, got %v", err)
+	}
+}
+
+func TestMultiReleaseJar(t *testing.T) {
+	globals.InitGlobals("test")
+	globals.GetGlobalRef().MaxJavaVersion = 17
+
+	files := map[string][]byte{
+		"com/acme/Foo.class":                      []byte("base"),
+		"META-INF/versions/11/com/acme/Foo.class": []byte("release 11"),
+		"META-INF/versions/21/com/acme/Foo.class": []byte("release 21"),
+		"META-INF/versions/9/com/acme/New.class":  []byte("release 9"),
+		"config.properties":                       []byte("base"),
+		"META-INF/versions/17/config.properties":  []byte("release 17"),
+	}
+	loadedVersion := func(jar *Archive, className string) string {
+		result, err := jar.loadClass(className)
+		if err != nil {
+			return err.Error()
+		}
+		return string(*result.Data)
+	}
+
+	files["META-INF/MANIFEST.MF"] = []byte("Manifest-Version: 1.0\r\nMulti-Release: true\r\n")
+	jarName := filepath.Join(t.TempDir(), "mr.jar")
+	writeJar(t, jarName, files)
+	jar, err := NewJarFile(jarName)
+	if err != nil {
+		t.Fatalf("Unexpected error reading %s: %v", jarName, err)
+	}
+
+	if version := loadedVersion(jar, "com.acme.Foo"); version != "release 11" {
+		t.Errorf("Expected the highest release up to 17 of com.acme.Foo, got %s", version)
+	}
+	if version := loadedVersion(jar, "com.acme.New"); version != "release 9" {
+		t.Errorf("Expected the release 9 of a class with no base entry, got %s", version)
+	}
+	if !jar.hasResource("com.acme.New", ClassFile) || !jar.hasResource("config.properties", Resource) {
+		t.Errorf("Expected hasResource() to find versioned entries")
+	}
+	if entry, _ := jar.lookup("config.properties"); entry.Location != "META-INF/versions/17/config.properties" {
+		t.Errorf("Expected the release 17 of config.properties, got %s", entry.Location)
+	}
+
+	// in a jar that's not multi-release, the versions are ignored
+	files["META-INF/MANIFEST.MF"] = []byte("Manifest-Version: 1.0\r\n")
+	jarName = filepath.Join(t.TempDir(), "plain.jar")
+	writeJar(t, jarName, files)
+	jar, _ = NewJarFile(jarName)
+	if version := loadedVersion(jar, "com.acme.Foo"); version != "base" {
+		t.Errorf("Expected the base com.acme.Foo in a jar that's not multi-release, got %s", version)
+	}
+	if jar.hasResource("com.acme.New", ClassFile) {
+		t.Errorf("Expected com.acme.New not to be found in a jar that's not multi-release")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Class data sharing (CDS): as the JDK does with its classes.jsa, Jacobin can
+// save the parsed base classes (those that LoadBaseClasses preloads) to an
+// archive in JACOBIN_HOME, so that later runs read them from the archive rather
+// than reading and parsing the class files. Like the gob of JMODMAP, the
+// archive is for one Java version. It also has a hash of the files the classes
+// came from, so that an archive of a JDK that has since changed isn't used.
+//
+// -Xshare:dump creates the archive, -Xshare:auto (the default) uses it if it's
+// there and up to date, and -Xshare:off ignores it.
+
+const (
+	ShareAuto = "auto"
+	ShareOff  = "off"
+	ShareDump = "dump"
+)
+
+// sharedArchive is what's saved in the archive
+type sharedArchive struct {
+	JavaVersion string
+	SourcesHash string
+	Classes     []sharedClass
+}
+
+type sharedClass struct {
+	Name string
+	Data ClData
+}
+
+// SharedArchivePath returns the path of the archive of the current Java version
+func SharedArchivePath() string {
+	global := globals.GetGlobalRef()
+	return filepath.Join(global.JacobinHome, global.JavaVersion+".jsa")
+}
+
+// classSourcesHash returns a hash of the files the base classes are read from:
+// the jmod files or the jimage. The hash is of their names, sizes and times
+// of modification, rather than their contents, so that it's quick to compute.
+func classSourcesHash() (string, error) {
+	var files []string
+	if RuntimeImage != nil {
+		files = []string{RuntimeImage.Filename}
+	} else {
+		var err error
+		files, err = filepath.Glob(filepath.Join(globals.GetGlobalRef().JavaHome, "jmods", "*.jmod"))
+		if err != nil {
+			return "", err
+		}
+		sort.Strings(files)
+	}
+	if len(files) == 0 {
+		return "", errors.New("no jmod files")
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		_, _ = fmt.Fprintf(hash, "%s %d %d\n", filepath.Base(file), stat.Size(), stat.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// DumpSharedArchive parses the base classes and saves them to the archive, for -Xshare:dump.
+func DumpSharedArchive() error {
+	sourcesHash, err := classSourcesHash()
+	if err != nil {
+		return fmt.Errorf("DumpSharedArchive: %w", err)
+	}
+	archive := sharedArchive{JavaVersion: globals.GetGlobalRef().JavaVersion, SourcesHash: sourcesHash}
+
+	dump := func(className string, classBytes []byte) {
+		parsedClass, err := parseClass(className+".class", classBytes)
+		if err != nil {
+			return // as when they're preloaded, classes that don't parse are left out
+		}
+		archive.Classes = append(archive.Classes, sharedClass{Name: className, Data: convertToPostableClass(parsedClass)})
+	}
+	if RuntimeImage != nil {
+		walkBaseImageClasses(dump)
+	} else if err = walkBaseJmodClasses(dump); err != nil {
+		return fmt.Errorf("DumpSharedArchive: %w", err)
+	}
+
+	// the archive is written under another name and then renamed, so that a
+	// run that starts while it's being written doesn't read half an archive
+	archivePath := SharedArchivePath()
+	tempFile, err := os.CreateTemp(filepath.Dir(archivePath), filepath.Base(archivePath)+".*")
+	if err != nil {
+		return fmt.Errorf("DumpSharedArchive: %w", err)
+	}
+	err = gob.NewEncoder(tempFile).Encode(&archive)
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempFile.Name(), archivePath)
+	}
+	if err != nil {
+		_ = os.Remove(tempFile.Name())
+		return fmt.Errorf("DumpSharedArchive: writing %s failed: %w", archivePath, err)
+	}
+
+	msg := fmt.Sprintf("DumpSharedArchive: %d classes saved to %s", len(archive.Classes), archivePath)
+	_ = log.Log(msg, log.CLASS)
+	return nil
+}
+
+// loadSharedArchive posts the classes in the archive to the method area, as
+// the bootstrap classloader's, and returns whether it did. An archive that's
+// missing, unreadable or out of date isn't used.
+func loadSharedArchive() bool {
+	archivePath := SharedArchivePath()
+	file, err := os.Open(archivePath)
+	if err != nil {
+		_ = log.Log("loadSharedArchive: no shared archive "+archivePath, log.CLASS)
+		return false
+	}
+	defer file.Close()
+
+	var archive sharedArchive
+	if err = gob.NewDecoder(file).Decode(&archive); err != nil {
+		msg := fmt.Sprintf("loadSharedArchive: shared archive %s can't be read: %s", archivePath, err.Error())
+		_ = log.Log(msg, log.WARNING)
+		return false
+	}
+	sourcesHash, err := classSourcesHash()
+	if err != nil || archive.JavaVersion != globals.GetGlobalRef().JavaVersion || archive.SourcesHash != sourcesHash {
+		msg := fmt.Sprintf("loadSharedArchive: shared archive %s is out of date. Run with -Xshare:dump to recreate it.",
+			archivePath)
+		_ = log.Log(msg, log.CLASS)
+		return false
+	}
+
+	for i := range archive.Classes {
+		class := &archive.Classes[i]
+		k := &Klass{
+			Status: 'F', // F = format-checked, as when the class is parsed
+			Loader: BootstrapCL.Name,
+			Data:   &class.Data,
+		}
+		MethAreaInsert(class.Name, k)
+		BootstrapCL.recordClass(class.Name, k)
+	}
+	ClassesLock.Lock()
+	BootstrapCL.ClassCount += len(archive.Classes)
+	ClassesLock.Unlock()
+
+	msg := fmt.Sprintf("loadSharedArchive: %d classes loaded from %s", len(archive.Classes), archivePath)
+	_ = log.Log(msg, log.CLASS)
+	return true
+}
@@ -0,0 +1,94 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSharedArchive(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	javaHome, jacobinHome := g.JavaHome, g.JacobinHome
+	defer func() {
+		globals.GetGlobalRef().JavaHome, globals.GetGlobalRef().JacobinHome = javaHome, jacobinHome
+		_ = Init()
+	}()
+
+	// a JDK with only java.base.jmod, whose classlist has java/lang/Class
+	jdk := t.TempDir()
+	jmodName := filepath.Join(jdk, "jmods", "java.base.jmod")
+	writeJmod(t, jmodName, map[string][]byte{
+		"classes/java/lang/Class.class": ClassBytes,
+		"lib/classlist":                 []byte("java/lang/Class\n"),
+	})
+	globals.GetGlobalRef().JavaHome = jdk
+	globals.GetGlobalRef().JacobinHome = t.TempDir()
+
+	_ = Init()
+	if err := DumpSharedArchive(); err != nil {
+		t.Fatalf("Unexpected error dumping the shared archive: %v", err)
+	}
+	if _, err := os.Stat(SharedArchivePath()); err != nil {
+		t.Fatalf("Expected the shared archive to be created, got %v", err)
+	}
+	if MethAreaFetch("java/lang/Class") != nil {
+		t.Errorf("Expected -Xshare:dump not to post the classes it archives")
+	}
+
+	// the classes are loaded from the archive, already parsed
+	LoadBaseClasses()
+	k := MethAreaFetch("java/lang/Class")
+	if k == nil || k.Loader != "bootstrap" || BootstrapCL.FindLoadedClass("java/lang/Class") != k {
+		t.Fatalf("Expected java/lang/Class to be loaded from the shared archive")
+	}
+	parsed, _ := parseClass("Class.class", ClassBytes)
+	expected := convertToPostableClass(parsed)
+	if k.Data.Name != expected.Name || len(k.Data.Methods) != len(expected.Methods) ||
+		len(k.Data.CP.Utf8Refs) != len(expected.CP.Utf8Refs) || k.Data.Methods[0].CodeAttr.MaxStack !=
+		expected.Methods[0].CodeAttr.MaxStack {
+		t.Errorf("Expected the archived class to be the same as the parsed class")
+	}
+
+	// -Xshare:off ignores the archive
+	_ = Init()
+	globals.GetGlobalRef().SharedArchive = ShareOff
+	LoadBaseClasses()
+	globals.GetGlobalRef().SharedArchive = ShareAuto
+	if MethAreaFetch("java/lang/Class") != nil {
+		t.Errorf("Expected -Xshare:off not to load the classes from the shared archive")
+	}
+
+	// an archive of jmods that have since changed is out of date
+	_ = Init()
+	later := time.Now().Add(time.Hour)
+	_ = os.Chtimes(jmodName, later, later)
+	if loadSharedArchive() || MethAreaFetch("java/lang/Class") != nil {
+		t.Errorf("Expected the shared archive of an earlier JDK not to be used")
+	}
+
+	// as is one of another Java version, or one that's not an archive
+	if err := DumpSharedArchive(); err != nil {
+		t.Fatalf("Unexpected error dumping the shared archive: %v", err)
+	}
+	javaVersion := globals.GetGlobalRef().JavaVersion
+	_ = os.Rename(SharedArchivePath(), filepath.Join(globals.GetGlobalRef().JacobinHome, "99.jsa"))
+	globals.GetGlobalRef().JavaVersion = "99"
+	defer func() { globals.GetGlobalRef().JavaVersion = javaVersion }()
+	if loadSharedArchive() {
+		t.Errorf("Expected the shared archive of another Java version not to be used")
+	}
+	_ = os.WriteFile(SharedArchivePath(), []byte("not an archive"), 0644)
+	if loadSharedArchive() {
+		t.Errorf("Expected a file that's not a shared archive not to be used")
+	}
+}
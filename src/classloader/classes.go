@@ -10,7 +10,10 @@ import (
 	"errors"
 	"fmt"
 	"jacobin/log"
+	"jacobin/object"
 	"jacobin/shutdown"
+	"strings"
+	"sync"
 )
 
 type Klass struct {
@@ -50,7 +53,9 @@ type CPool struct {
 	MethodTypes    []uint16
 	NameAndTypes   []NameAndTypeEntry
 	//	StringRefs     []uint16 // all StringRefs are converted into utf8Refs
-	Utf8Refs []string
+	Utf8Refs  []string
+	Strings   []*object.Object // interned String objects for LDC, by CP index; see FetchStringFromCPEntryNumber()
+	ClassKeys []string         // keys of the classes that class, field and method refs resolve to; see ResolveCPClassRef()
 }
 
 type AccessFlags struct {
@@ -196,6 +201,11 @@ type InvokeDynamicEntry struct { // type 18 (invokedynamic data)
 // entry as the Method it's returning.
 func FetchMethodAndCP(class, meth string, methType string) (MTentry, error) {
 
+	// the name under which the method was requested. If the method is found in a
+	// superclass, it's also entered into the MTable under this name, so that
+	// later look-ups (including those by runGframe()) find it directly.
+	origFQN := class + "." + meth + methType
+
 	for {
 	startSearch:
 
@@ -210,9 +220,12 @@ func FetchMethodAndCP(class, meth string, methType string) (MTentry, error) {
 		}
 
 		methFQN := class + "." + meth + methType // FQN = fully qualified name
-		methEntry := MTable[methFQN]
+		methEntry := MTableFetch(methFQN)
 
 		if methEntry.Meth != nil { // we found the entry in the MTable
+			if methFQN != origFQN {
+				addEntry(&MTable, origFQN, methEntry)
+			}
 			if methEntry.MType == 'J' {
 				return MTentry{Meth: methEntry.Meth, MType: 'J'}, nil
 			} else if methEntry.MType == 'G' {
@@ -250,22 +263,11 @@ func FetchMethodAndCP(class, meth string, methType string) (MTentry, error) {
 		for i := 0; i < len(k.Data.Methods); i++ {
 			if k.Data.CP.Utf8Refs[k.Data.Methods[i].Name] == meth &&
 				k.Data.CP.Utf8Refs[k.Data.Methods[i].Desc] == methType {
-				m := k.Data.Methods[i]
-				jme := JmEntry{
-					accessFlags: m.AccessFlags,
-					MaxStack:    m.CodeAttr.MaxStack,
-					MaxLocals:   m.CodeAttr.MaxLocals,
-					Code:        m.CodeAttr.Code,
-					exceptions:  m.CodeAttr.Exceptions,
-					attribs:     m.CodeAttr.Attributes,
-					params:      m.Parameters,
-					deprecated:  m.Deprecated,
-					Cp:          &k.Data.CP,
-				}
-				MTable[methFQN] = MTentry{
+				jme := newJmEntry(k, &k.Data.Methods[i])
+				addEntry(&MTable, methFQN, MTentry{
 					Meth:  jme,
 					MType: 'J',
-				}
+				})
 				return MTentry{Meth: jme, MType: 'J'}, nil
 			}
 		}
@@ -274,7 +276,7 @@ func FetchMethodAndCP(class, meth string, methType string) (MTentry, error) {
 		if class == "java/lang/Object" { // if we're already at the topmost superclass, then stop the loop
 			break
 		} else {
-			class = k.Data.Superclass
+			class = k.RefKey(k.Data.Superclass)
 			goto startSearch
 		}
 	}
@@ -292,6 +294,220 @@ func FetchMethodAndCP(class, meth string, methType string) (MTentry, error) {
 	return MTentry{}, errors.New("method not found") // dummy return needed for tests
 }
 
+// newJmEntry creates the MTable entry of a method of the class
+func newJmEntry(k *Klass, m *Method) JmEntry {
+	return JmEntry{
+		accessFlags: m.AccessFlags,
+		MaxStack:    m.CodeAttr.MaxStack,
+		MaxLocals:   m.CodeAttr.MaxLocals,
+		Code:        m.CodeAttr.Code,
+		exceptions:  m.CodeAttr.Exceptions,
+		attribs:     m.CodeAttr.Attributes,
+		params:      m.Parameters,
+		deprecated:  m.Deprecated,
+		Cp:          &k.Data.CP,
+	}
+}
+
+// javaMethod is a method found by FetchJavaMethod() and the class it's in
+type javaMethod struct {
+	meth  JmEntry
+	class string
+}
+
+// javaMethods caches the methods found by FetchJavaMethod(), by the name under
+// which they were requested
+var javaMethods sync.Map
+
+// FetchJavaMethod finds the Java code of a method in the class or its
+// superclasses, passing over any Go function that stands in for the method
+// in the MTable. It's used to run the JDK's code for the objects that a Go
+// function does not accept (see GmEntry.Accepts). It returns the method and
+// the name of the class it was found in.
+func FetchJavaMethod(class, meth, methType string) (JmEntry, string, error) {
+	origFQN := class + "." + meth + methType
+	if found, ok := javaMethods.Load(origFQN); ok {
+		return found.(javaMethod).meth, found.(javaMethod).class, nil
+	}
+
+	for class != "" {
+		k, err := fetchLoadedClass(class)
+		if err != nil {
+			return JmEntry{}, "", err
+		}
+		if k.Data == nil {
+			break
+		}
+
+		for i := range k.Data.Methods {
+			m := &k.Data.Methods[i]
+			if k.Data.CP.Utf8Refs[m.Name] == meth && k.Data.CP.Utf8Refs[m.Desc] == methType &&
+				len(m.CodeAttr.Code) > 0 { // an abstract method has no code, so keep looking
+				found := javaMethod{meth: newJmEntry(k, m), class: class}
+				javaMethods.Store(origFQN, found)
+				return found.meth, found.class, nil
+			}
+		}
+
+		if class == "java/lang/Object" {
+			break
+		}
+		class = k.RefKey(k.Data.Superclass)
+	}
+	return JmEntry{}, "", errors.New("FetchJavaMethod: no Java code for method " + origFQN)
+}
+
+// fetchLoadedClass returns the class, loading it first if necessary
+func fetchLoadedClass(class string) (*Klass, error) {
+	if MethAreaFetch(class) == nil {
+		if err := LoadClassFromNameOnly(class); err != nil {
+			return nil, err
+		}
+	}
+	if err := WaitForClassStatus(class); err != nil {
+		return nil, err
+	}
+	k := MethAreaFetch(class)
+	if k == nil {
+		return nil, errors.New("class not found: " + class)
+	}
+	return k, nil
+}
+
+// interfaceMethod is a method found by FetchInterfaceMethod(), with the name of
+// the class or interface it was found in
+type interfaceMethod struct {
+	entry MTentry
+	class string
+}
+
+// interfaceMethods caches the methods found by FetchInterfaceMethod(), by the
+// name of the class of the object and the method
+var interfaceMethods sync.Map
+
+// FetchInterfaceMethod finds the method that INVOKEINTERFACE runs for an object
+// of the class (JVMS 5.4.6). This is the method of the class or of its nearest
+// superclass that implements it, or else the maximally specific default method
+// of the interfaces the class implements. It returns the method and the name of
+// the class or interface it was found in. If no class or interface implements
+// the method, or two unrelated interfaces have a default method for it, the
+// error is the message of an AbstractMethodError or IncompatibleClassChangeError.
+func FetchInterfaceMethod(class, meth, methType string) (MTentry, string, error) {
+	origFQN := class + "." + meth + methType
+	if found, ok := interfaceMethods.Load(origFQN); ok {
+		return found.(interfaceMethod).entry, found.(interfaceMethod).class, nil
+	}
+
+	var superinterfaces []string
+	for c := class; c != ""; {
+		if me := MTableFetch(c + "." + meth + methType); me.MType == 'G' { // no need to load the class
+			return me, c, nil
+		}
+		k, err := fetchLoadedClass(c)
+		if err != nil {
+			return MTentry{}, "", err
+		}
+		if found, ok := implementedMethod(c, k, meth, methType); ok {
+			interfaceMethods.Store(origFQN, found)
+			return found.entry, found.class, nil
+		}
+		superinterfaces = append(superinterfaces, directSuperinterfaces(k)...)
+		if c == "java/lang/Object" || k.Data == nil {
+			break
+		}
+		c = k.RefKey(k.Data.Superclass)
+	}
+
+	// the class doesn't implement the method, so look for default methods in
+	// all of its superinterfaces
+	var candidates []interfaceMethod
+	checked := make(map[string]bool)
+	for len(superinterfaces) > 0 {
+		name := superinterfaces[0]
+		superinterfaces = superinterfaces[1:]
+		if checked[name] {
+			continue
+		}
+		checked[name] = true
+		k, err := fetchLoadedClass(name)
+		if err != nil {
+			return MTentry{}, "", err
+		}
+		if found, ok := implementedMethod(name, k, meth, methType); ok {
+			candidates = append(candidates, found)
+		}
+		superinterfaces = append(superinterfaces, directSuperinterfaces(k)...)
+	}
+
+	// a default method is maximally specific if no other candidate's interface
+	// is a subinterface of its interface
+	var maximal []interfaceMethod
+	for _, candidate := range candidates {
+		overridden := false
+		for _, other := range candidates {
+			if other.class != candidate.class && implementsInterface(other.class, candidate.class) {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			maximal = append(maximal, candidate)
+		}
+	}
+
+	switch len(maximal) {
+	case 0:
+		return MTentry{}, "", fmt.Errorf("java.lang.AbstractMethodError: Receiver class %s does not "+
+			"define or inherit an implementation of the resolved method '%s%s'",
+			strings.ReplaceAll(class, "/", "."), meth, methType)
+	case 1:
+		interfaceMethods.Store(origFQN, maximal[0])
+		return maximal[0].entry, maximal[0].class, nil
+	default:
+		var names []string
+		for _, m := range maximal {
+			names = append(names, strings.ReplaceAll(m.class, "/", ".")+"."+meth)
+		}
+		return MTentry{}, "", errors.New("java.lang.IncompatibleClassChangeError: Conflicting default methods: " +
+			strings.Join(names, " "))
+	}
+}
+
+// implementedMethod returns the Go function or the Java code of the method in
+// the class or interface, if it has one that an interface call can run: abstract,
+// static and private methods are passed over.
+func implementedMethod(class string, k *Klass, meth, methType string) (interfaceMethod, bool) {
+	if me := MTableFetch(class + "." + meth + methType); me.MType == 'G' {
+		return interfaceMethod{entry: me, class: class}, true
+	}
+	if k.Data == nil {
+		return interfaceMethod{}, false
+	}
+	for i := range k.Data.Methods {
+		m := &k.Data.Methods[i]
+		if k.Data.CP.Utf8Refs[m.Name] == meth && k.Data.CP.Utf8Refs[m.Desc] == methType &&
+			len(m.CodeAttr.Code) > 0 && m.AccessFlags&(0x0008|0x0002) == 0 { // not static or private
+			return interfaceMethod{entry: MTentry{Meth: newJmEntry(k, m), MType: 'J'}, class: class}, true
+		}
+	}
+	return interfaceMethod{}, false
+}
+
+// directSuperinterfaces returns the keys of the interfaces a class or interface
+// names in its implements or extends clause
+func directSuperinterfaces(k *Klass) []string {
+	if k.Data == nil {
+		return nil
+	}
+	var names []string
+	for _, idx := range k.Data.Interfaces {
+		if int(idx) < len(k.Data.CP.Utf8Refs) {
+			names = append(names, k.RefKey(k.Data.CP.Utf8Refs[idx]))
+		}
+	}
+	return names
+}
+
 // FetchUTF8stringFromCPEntryNumber fetches the UTF8 string using the CP entry number
 // for that string in the designated ClData.CP. Returns "" on error.
 func FetchUTF8stringFromCPEntryNumber(cp *CPool, entry uint16) string {
@@ -310,3 +526,35 @@ func FetchUTF8stringFromCPEntryNumber(cp *CPool, entry uint16) string {
 
 	return cp.Utf8Refs[u.Slot]
 }
+
+// cpStringsMutex protects the String objects cached in the CPs of all classes
+var cpStringsMutex sync.Mutex
+
+// FetchStringFromCPEntryNumber returns the java/lang/String object for the string
+// constant at the given CP entry. (String constants are converted to UTF8 entries
+// when the class is loaded.) The string is resolved only once per CP entry: it is
+// interned in the VM-wide string pool, so that the same literal is the same object
+// in every class, and then cached in the CP. Returns nil on error.
+func FetchStringFromCPEntryNumber(cp *CPool, entry uint16) *object.Object {
+	cpStringsMutex.Lock()
+	defer cpStringsMutex.Unlock()
+
+	if int(entry) < len(cp.Strings) && cp.Strings[entry] != nil {
+		return cp.Strings[entry]
+	}
+
+	str := FetchUTF8stringFromCPEntryNumber(cp, entry)
+	if str == "" && (int(entry) >= len(cp.CpIndex) || cp.CpIndex[entry].Type != UTF8) {
+		return nil // error already logged
+	}
+
+	if len(cp.Strings) < len(cp.CpIndex) {
+		strings := make([]*object.Object, len(cp.CpIndex))
+		copy(strings, cp.Strings)
+		cp.Strings = strings
+	}
+
+	strObj := object.InternGoString(str)
+	cp.Strings[entry] = strObj
+	return strObj
+}
@@ -280,3 +280,59 @@ func TestFetchUTF8stringFromCPEntryNumber(t *testing.T) {
 	_ = wout.Close()
 	os.Stdout = normalStdout
 }
+
+// insertGreeterClass inserts a class or interface with the superinterfaces. Its
+// greet() method has code if withCode is true, and is abstract otherwise.
+func insertGreeterClass(name string, interfaces []string, withGreet, withCode bool) {
+	cp := CPool{Utf8Refs: []string{"greet", "()Ljava/lang/String;"}}
+	data := &ClData{Name: name, Superclass: "java/lang/Object", CP: cp}
+	for _, iface := range interfaces {
+		data.Interfaces = append(data.Interfaces, uint16(len(data.CP.Utf8Refs)))
+		data.CP.Utf8Refs = append(data.CP.Utf8Refs, iface)
+	}
+	if withGreet {
+		m := Method{Name: 0, Desc: 1}
+		if withCode {
+			m.CodeAttr = CodeAttrib{MaxStack: 1, MaxLocals: 1, Code: []byte{0x01, 0xB0}} // aconst_null, areturn
+		}
+		data.Methods = append(data.Methods, m)
+	}
+	MethAreaInsert(name, &Klass{Status: 'F', Loader: "app", Data: data})
+}
+
+func TestFetchInterfaceMethodFindsDefaultMethods(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+	MTable = make(map[string]MTentry)
+	MethAreaInsert("java/lang/Object", &Klass{Status: 'F', Loader: "bootstrap", Data: &ClData{Name: "java/lang/Object"}})
+
+	insertGreeterClass("iface/Greeter", nil, true, true)
+	insertGreeterClass("iface/LoudGreeter", []string{"iface/Greeter"}, true, true)
+	insertGreeterClass("iface/Polite", nil, true, true)
+	insertGreeterClass("iface/Silent", nil, true, false)
+	insertGreeterClass("iface/Loud", []string{"iface/LoudGreeter", "iface/Greeter"}, false, false)
+	insertGreeterClass("iface/Own", []string{"iface/LoudGreeter"}, true, true)
+	insertGreeterClass("iface/Conflicted", []string{"iface/LoudGreeter", "iface/Polite"}, false, false)
+	insertGreeterClass("iface/Unimplemented", []string{"iface/Silent"}, false, false)
+
+	// the most specific default method wins over that of the superinterface
+	if _, class, err := FetchInterfaceMethod("iface/Loud", "greet", "()Ljava/lang/String;"); err != nil ||
+		class != "iface/LoudGreeter" {
+		t.Errorf("Expected the default method of iface/LoudGreeter, got %s, %v", class, err)
+	}
+	// the class's own method wins over any default method
+	if _, class, err := FetchInterfaceMethod("iface/Own", "greet", "()Ljava/lang/String;"); err != nil ||
+		class != "iface/Own" {
+		t.Errorf("Expected the method of iface/Own, got %s, %v", class, err)
+	}
+
+	_, _, err := FetchInterfaceMethod("iface/Conflicted", "greet", "()Ljava/lang/String;")
+	if err == nil || !strings.HasPrefix(err.Error(), "java.lang.IncompatibleClassChangeError") {
+		t.Errorf("Expected IncompatibleClassChangeError for unrelated default methods, got %v", err)
+	}
+	_, _, err = FetchInterfaceMethod("iface/Unimplemented", "greet", "()Ljava/lang/String;")
+	if err == nil || !strings.HasPrefix(err.Error(), "java.lang.AbstractMethodError") {
+		t.Errorf("Expected AbstractMethodError for an abstract method, got %v", err)
+	}
+}
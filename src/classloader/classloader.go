@@ -14,9 +14,9 @@ import (
 	"io/fs"
 	"jacobin/globals"
 	"jacobin/log"
+	"jacobin/object"
 	"jacobin/shutdown"
 	"jacobin/types"
-	"jacobin/util"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -33,8 +33,10 @@ import (
 type Classloader struct {
 	Name       string
 	Parent     string
-	ClassCount int
+	ClassCount int                 // the number of classes this classloader defined
 	Archives   map[string]*Archive // TODO: I think this should be moved to classpath when we make it a thing
+	classes    map[string]*Klass   // the classloader's namespace: the classes it has loaded, by name
+	javaLoader *object.Object      // for a classloader written in Java, its java/lang/ClassLoader object
 }
 
 // AppCL is the application classloader, which loads most of the app's classes
@@ -187,8 +189,28 @@ func CFE(msg string) error { return cfe(msg) }
 // LoadBaseClasses loads a basic set of classes that are found in
 // the JAVA_HOME/jmods/java.base.jmod zip file.
 // In Java 17.0.7, there are currently a total of 6401 embedded classes in java.base.jmod.
-// Based on the lib/classlist member in java.base.jmod, only 1402 class files are actually loaded by this function.
+// Based on the lib/classlist member in java.base.jmod, only 1402 class files are loaded by this function.
+// If there are no jmods, the classes are loaded from the jimage, JAVA_HOME/lib/modules.
+// This preloading is optional (-XX:+PreloadClasslist): by default, it's skipped
+// and each class is parsed when it's first referenced, which makes startup faster.
+// Either way, if there's an up-to-date shared archive of the classes (see cds.go),
+// they're loaded from it, already parsed, unless -Xshare:off was specified.
 func LoadBaseClasses() {
+	if globals.GetGlobalRef().SharedArchive == ShareAuto && loadSharedArchive() {
+		return
+	}
+
+	if !globals.GetGlobalRef().PreloadClasslist {
+		_ = log.Log("LoadBaseClasses: Bootstrap classes will be loaded when they're referenced", log.CLASS)
+		return
+	}
+
+	if RuntimeImage != nil {
+		loadBaseClassesFromImage()
+		_ = log.Log("LoadBaseClasses: Bootstrap classes from "+RuntimeImage.Filename+" have been loaded", log.CLASS)
+		return
+	}
+
 	global := globals.GetGlobalRef()
 	jmodFilePath := global.JavaHome + string(os.PathSeparator) + "jmods" + string(os.PathSeparator) + "java.base.jmod"
 
@@ -211,29 +233,37 @@ func walk(s string, d fs.DirEntry, err error) error {
 	}
 	if !d.IsDir() && strings.HasSuffix(s, ".class") {
 		// Error is discarded b/c it's not clear yet a given class is needed.
-		_, _ = LoadClassFromFile(BootstrapCL, s)
+		_, _ = LoadClassFromFile(&BootstrapCL, s)
 	}
 	return nil
 }
 
 // LoadReferencedClasses loads the classes referenced in the class named clName.
 // It does this by reading the class entries (ClassRefs=7) in the CP and sending the class names it finds
-// there to a go channel that will load the class.
+// there to a go channel, from which goroutines load the classes in the background.
 // Note that CP refers to the class constant pool = the array of records that a method refers to
 // when accessing fields, methods, values, etc.
 // Reference: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.4
 // Note that The class being loaded has records in the CP that indicate all the other classes it interacts with.
 // Thus, classes are preloaded prior to need.
 //
-// Note: per JACOBIN-327, this parallel processing has been temporarily
-// removed--it's not called by any function. It's likely to be reinstated later.
+// This preloading is turned on by -XX:+PreloadReferencedClasses. It's safe to
+// run alongside the program: a class that's referenced while it's being
+// preloaded is waited for (see findClassOnce), not loaded twice.
 func LoadReferencedClasses(clName string) {
 	err := WaitForClassStatus(clName)
 	if err != nil {
 		msg := fmt.Sprintf("LoadReferencedClasses: %s", err.Error())
-		_ = log.Log(msg, log.SEVERE)
-		shutdown.Exit(shutdown.JVM_EXCEPTION)
+		_ = log.Log(msg, log.WARNING)
+		return
 	}
+
+	// if the JDK's classes can't be found, JmodMapFetch() shuts down the JVM,
+	// which mustn't be done from the goroutines, so there's no preloading
+	if RuntimeImage == nil && JmodMapSize() == 0 {
+		return
+	}
+
 	currClass := MethAreaFetch(clName)
 	cpClassCP := currClass.Data.CP
 	classRefs := cpClassCP.ClassRefs
@@ -247,43 +277,39 @@ func LoadReferencedClasses(clName string) {
 		}
 		loaderChannel <- name
 	}
-	globals.LoaderWg.Add(1)
-	go LoadFromLoaderChannel(loaderChannel)
 	close(loaderChannel)
+
+	loaders := min(runtime.GOMAXPROCS(0), len(loaderChannel))
+	for i := 0; i < loaders; i++ {
+		globals.LoaderWg.Add(1)
+		go LoadFromLoaderChannel(loaderChannel)
+	}
 }
 
-// LoadFromLoaderChannel receives a name of a class to load in /java/lang/String format,
-// determines the classloader, checks if the class is already loaded, and loads it if not.
-//
-// Note: per JACOBIN-327, this parallel processing has been temporarily
-// removed--it's not called by any function. It's likely to be reinstated later.
+// LoadFromLoaderChannel receives a name of a class to load in java/lang/String format,
+// checks if the class is already loaded, and loads it through the application
+// classloader if not. Errors are logged, rather than shutting down the JVM: a
+// class that can't be loaded is reported if it's used by the program.
 func LoadFromLoaderChannel(LoaderChannel <-chan string) {
+	defer globals.LoaderWg.Done()
 	for name := range LoaderChannel {
 		present := MethAreaFetch(name)
 		if present != nil { // if the class is already loaded, skip it
 			continue
 		}
 
-		// add entry to the method area, indicating initialization of the load of this class
-		eKI := Klass{
-			Status: 'I', // I = initializing the load
-			Loader: "",
-			Data:   nil,
-		}
-		MethAreaInsert(name, &eKI)
-		err := LoadClassFromNameOnly(util.ConvertToPlatformPathSeparators(name))
-		if err != nil {
-			shutdown.Exit(shutdown.JVM_EXCEPTION)
+		if _, err := AppCL.LoadClass(name); err != nil {
+			msg := fmt.Sprintf("LoadFromLoaderChannel: preloading %s failed: %s", name, err.Error())
+			_ = log.Log(msg, log.CLASS)
 		}
 	}
-	globals.LoaderWg.Done()
 }
 
+// LoadClassFromNameOnly loads the class, which is in java/lang/String format,
+// through the application classloader, which first delegates to its parents.
+// So the classes in the JDK's modules are loaded by the bootstrap classloader
+// and only the others are looked for on the class path.
 func LoadClassFromNameOnly(className string) error {
-	var err error
-
-	jmodFileName := JmodMapFetch(className)
-
 	if className == "" {
 		msg := "LoadClassFromNameOnly: null class name is invalid"
 		_ = log.Log(msg, log.SEVERE)
@@ -297,37 +323,10 @@ func LoadClassFromNameOnly(className string) error {
 		debug.PrintStack()
 		return errors.New(msg)
 	}
-	// Load class from a jmod?
-	if jmodFileName != "" {
-		_ = log.Log("LoadClassFromNameOnly: Load "+className+" from jmod "+jmodFileName, log.CLASS)
-		classBytes, err := GetClassBytes(jmodFileName, className)
-		if err != nil {
-			_ = log.Log("LoadClassFromNameOnly: GetClassBytes className="+className+" from jmodFileName="+jmodFileName+" failed", log.SEVERE)
-			_ = log.Log(err.Error(), log.SEVERE)
-		}
-		_, err = loadClassFromBytes(AppCL, className, classBytes)
-		return err
-	}
-
-	// Load class from a jar file?
-	if len(globals.GetGlobalRef().StartingJar) > 0 {
-		validName := util.ConvertToPlatformPathSeparators(className)
-		_ = log.Log("LoadClassFromNameOnly: LoadClassFromJar "+validName, log.CLASS)
-		_, err = LoadClassFromJar(AppCL, validName, globals.GetGlobalRef().StartingJar)
-		if err != nil {
-			_ = log.Log("LoadClassFromNameOnly: LoadClassFromJar "+validName+" failed", log.SEVERE)
-			_ = log.Log(err.Error(), log.SEVERE)
-		}
-		return err
-	}
 
-	// Loading from a local file system class
-	// TODO: classpath
-	validName := util.ConvertToPlatformPathSeparators(className)
-	_ = log.Log("LoadClassFromNameOnly: Load class from file "+validName, log.CLASS)
-	_, err = LoadClassFromFile(AppCL, validName)
+	_, err := AppCL.LoadClass(className)
 	if err != nil {
-		_ = log.Log("LoadClassFromNameOnly: LoadClassFromFile "+validName+" failed", log.SEVERE)
+		_ = log.Log("LoadClassFromNameOnly: loading "+className+" failed", log.SEVERE)
 		_ = log.Log(err.Error(), log.SEVERE)
 	}
 	return err
@@ -335,7 +334,7 @@ func LoadClassFromNameOnly(className string) error {
 
 // LoadClassFromFile first canonicalizes the filename, and reads
 // the indicated file, and runs it through the classloader.
-func LoadClassFromFile(cl Classloader, fname string) (string, error) {
+func LoadClassFromFile(cl *Classloader, fname string) (string, error) {
 	var filename string
 	if !strings.HasSuffix(fname, ".class") {
 		filename = fname + ".class"
@@ -358,7 +357,10 @@ func LoadClassFromFile(cl Classloader, fname string) (string, error) {
 	return loadClassFromBytes(cl, filename, rawBytes)
 }
 
-func getJarFile(cl Classloader, jarFileName string) (*Archive, error) {
+func getJarFile(cl *Classloader, jarFileName string) (*Archive, error) {
+	// classes can be loaded by several goroutines at once, so the map is locked
+	ClassesLock.Lock()
+	defer ClassesLock.Unlock()
 	archive, exists := cl.Archives[jarFileName]
 
 	if exists {
@@ -376,7 +378,7 @@ func getJarFile(cl Classloader, jarFileName string) (*Archive, error) {
 	return jar, nil
 }
 
-func GetMainClassFromJar(cl Classloader, jarFileName string) (string, error) {
+func GetMainClassFromJar(cl *Classloader, jarFileName string) (string, error) {
 	jar, err := getJarFile(cl, jarFileName)
 
 	if err != nil {
@@ -386,7 +388,45 @@ func GetMainClassFromJar(cl Classloader, jarFileName string) (string, error) {
 	return jar.getMainClass(), nil
 }
 
-func LoadClassFromJar(cl Classloader, filename string, jarFileName string) (string, error) {
+// ApplyJarManifestAttributes records the module access that the manifest of
+// the jar run with -jar grants: the Add-Opens, Add-Exports and
+// Enable-Native-Access attributes. As in the JDK, entries of Add-Opens and
+// Add-Exports that aren't in the form module/package are ignored, and the only
+// value of Enable-Native-Access that's accepted is ALL-UNNAMED.
+func ApplyJarManifestAttributes(cl *Classloader, jarFileName string) error {
+	jar, err := getJarFile(cl, jarFileName)
+	if err != nil {
+		return err
+	}
+	g := globals.GetGlobalRef()
+
+	modulePackages := func(attribute string) []string {
+		var packages []string
+		for _, entry := range strings.Fields(jar.manifest[attribute]) {
+			module, pkg, found := strings.Cut(entry, "/")
+			if !found || module == "" || pkg == "" || strings.Contains(pkg, "/") {
+				_ = log.Log(fmt.Sprintf("%s entry %s in %s is not in the form module/package. Ignored.",
+					attribute, entry, jarFileName), log.WARNING)
+				continue
+			}
+			packages = append(packages, entry)
+		}
+		return packages
+	}
+	g.AddOpens = modulePackages("Add-Opens")
+	g.AddExports = modulePackages("Add-Exports")
+
+	if value, ok := jar.manifest["Enable-Native-Access"]; ok {
+		if value != "ALL-UNNAMED" {
+			return fmt.Errorf("illegal value \"%s\" for Enable-Native-Access manifest attribute. "+
+				"Only ALL-UNNAMED is allowed", value)
+		}
+		g.EnableNativeAccess = true
+	}
+	return nil
+}
+
+func LoadClassFromJar(cl *Classloader, filename string, jarFileName string) (string, error) {
 	jar, err := getJarFile(cl, jarFileName)
 
 	if err != nil {
@@ -403,41 +443,64 @@ func LoadClassFromJar(cl Classloader, filename string, jarFileName string) (stri
 		return "", fmt.Errorf("unable to find file %s in JAR file %s", filename, jarFileName)
 	}
 
-	return ParseAndPostClass(&cl, filename, *result.Data)
+	return ParseAndPostClass(cl, filename, *result.Data)
 }
 
-func loadClassFromBytes(cl Classloader, filename string, rawBytes []byte) (string, error) {
-	return ParseAndPostClass(&cl, filename, rawBytes)
+func loadClassFromBytes(cl *Classloader, filename string, rawBytes []byte) (string, error) {
+	return ParseAndPostClass(cl, filename, rawBytes)
 }
 
 // ParseAndPostClass parses a class, presented as a slice of bytes, and
 // if no errors occurred, posts/loads it to the method area.
 func ParseAndPostClass(cl *Classloader, filename string, rawBytes []byte) (string, error) {
+	fullyParsedClass, err := parseClass(filename, rawBytes)
+	if err != nil {
+		return "", err
+	}
+	return postClass(cl, filename, fullyParsedClass)
+}
+
+// parseClass parses and format-checks a class, presented as a slice of bytes.
+func parseClass(filename string, rawBytes []byte) (*ParsedClass, error) {
 
 	_ = log.Log("ParseAndPostClass: File "+filename+" to be processed", log.CLASS)
 	fullyParsedClass, err := parse(rawBytes)
 	if err != nil {
 		_ = log.Log("ParseAndPostClass: error parsing "+filename+". Exiting.", log.SEVERE)
-		return "", fmt.Errorf("parsing error")
+		return nil, fmt.Errorf("parsing error")
 	}
 
 	// format check the class
 	if formatCheckClass(&fullyParsedClass) != nil {
 		_ = log.Log("ParseAndPostClass: error format-checking "+filename+". Exiting.", log.SEVERE)
-		return "", fmt.Errorf("format-checking error")
+		return nil, fmt.Errorf("format-checking error")
 	}
 	_ = log.Log("Class "+fullyParsedClass.className+" has been format-checked.", log.FINEST)
+	return &fullyParsedClass, nil
+}
 
-	classToPost := convertToPostableClass(&fullyParsedClass)
+// postClass posts a parsed class to the method area and records it in
+// the classloader, which is its defining loader.
+func postClass(cl *Classloader, filename string, fullyParsedClass *ParsedClass) (string, error) {
+	if err := checkProhibitedPackage(cl, fullyParsedClass.className); err != nil {
+		_ = log.Log("ParseAndPostClass: "+err.Error()+" for "+filename, log.SEVERE)
+		return "", err
+	}
+
+	classToPost := convertToPostableClass(fullyParsedClass)
 	eKF := Klass{
 		Status: 'F', // F = format-checked
 		Loader: cl.Name,
 		Data:   &classToPost,
 	}
-	MethAreaInsert(fullyParsedClass.className, &eKF)
+	MethAreaInsert(ClassKey(cl.Name, fullyParsedClass.className), &eKF)
 
-	// // record the class in the classloader
+	// record the class in the classloader, which is its defining loader
 	ClassesLock.Lock()
+	if cl.classes == nil {
+		cl.classes = make(map[string]*Klass)
+	}
+	cl.classes[fullyParsedClass.className] = &eKF
 	cl.ClassCount += 1
 	ClassesLock.Unlock()
 	_ = log.Log("ParseAndPostClass: File "+filename+" fully processed", log.CLASS)
@@ -445,6 +508,17 @@ func ParseAndPostClass(cl *Classloader, filename string, rawBytes []byte) (strin
 	return fullyParsedClass.className, nil
 }
 
+// checkProhibitedPackage returns an error if the classloader isn't allowed to define
+// the class. As in the JDK, only the JDK's own classloaders can define classes
+// in java.*, so that an application can't replace or add to the core classes.
+func checkProhibitedPackage(cl *Classloader, className string) error {
+	if cl.Name != "bootstrap" && cl.Name != "extension" && strings.HasPrefix(className, "java/") {
+		pkg := strings.ReplaceAll(className[:strings.LastIndex(className, "/")], "/", ".")
+		return errors.New("SecurityException: Prohibited package name: " + pkg)
+	}
+	return nil
+}
+
 // load the parsed class into a form suitable for posting to the method area (which is
 // exec.MethArea. This mostly involves copying the data, converting most indexes to uint16
 // and removing some fields we needed in parsing, but which are no longer required.
@@ -714,6 +788,8 @@ func convertToPostableClass(fullyParsedClass *ParsedClass) ClData {
 // GetCountOfLoadedClasses returns the number of classes loaded
 // by the classloader
 func (cl *Classloader) GetCountOfLoadedClasses() int {
+	ClassesLock.RLock()
+	defer ClassesLock.RUnlock()
 	return cl.ClassCount
 }
 
@@ -741,28 +817,35 @@ func Init() error {
 	BootstrapCL.Parent = ""
 	BootstrapCL.ClassCount = 0
 	BootstrapCL.Archives = make(map[string]*Archive)
+	BootstrapCL.classes = make(map[string]*Klass)
 
 	ExtensionCL.Name = "extension"
 	ExtensionCL.Parent = "bootstrap"
 	ExtensionCL.ClassCount = 0
 	ExtensionCL.Archives = make(map[string]*Archive)
+	ExtensionCL.classes = make(map[string]*Klass)
 
 	AppCL.Name = "app"
 	AppCL.Parent = "extension"
 	AppCL.ClassCount = 0
 	AppCL.Archives = make(map[string]*Archive)
-
-	// Launch JmodMap initialisation
-	// commented out: go JmodMapInit()
-	JmodMapInit()
-
-	// Load the base jmod
-	GetBaseJmodBytes()
-	_, err := GetClassBytes("java.base.jmod", "java/lang/String")
-	if err != nil {
-		msg := fmt.Sprintf("classloader.Init: GetClassBytes failed for java/lang/String in java.base.jmod")
-		_ = log.Log(msg, log.SEVERE)
-		shutdown.Exit(shutdown.JVM_EXCEPTION)
+	AppCL.classes = make(map[string]*Klass)
+
+	// without jmods, the JDK's classes are in the jimage, lib/modules
+	CloseJmods()
+	if !openRuntimeImage() {
+		// Launch JmodMap initialisation
+		// commented out: go JmodMapInit()
+		JmodMapInit()
+
+		// Open the base jmod. Its classes are read when they're first needed.
+		OpenBaseJmod()
+		_, err := GetClassBytes("java.base.jmod", "java/lang/String")
+		if err != nil {
+			msg := fmt.Sprintf("classloader.Init: GetClassBytes failed for java/lang/String in java.base.jmod")
+			_ = log.Log(msg, log.SEVERE)
+			shutdown.Exit(shutdown.JVM_EXCEPTION)
+		}
 	}
 
 	// initialize the method area
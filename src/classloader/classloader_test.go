@@ -13,6 +13,7 @@ import (
 	"jacobin/log"
 	"jacobin/types"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -97,7 +98,7 @@ func TestLoadClassFromFileInvalidName(t *testing.T) {
 	_, wout, _ := os.Pipe()
 	os.Stdout = wout
 
-	name, err := LoadClassFromFile(Classloader{}, "noSuchFile")
+	name, err := LoadClassFromFile(&Classloader{}, "noSuchFile")
 
 	if name != "" {
 		t.Errorf("Expected empty filename due to error, got: %s", err.Error())
@@ -132,6 +133,44 @@ func TestNormalizingClassReference(t *testing.T) {
 	}
 }
 
+func TestLoadReferencedClasses(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	_ = Init()
+	defer InitMethodArea()
+
+	classDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(classDir, "Hello2.class"), Hello2Bytes, 0644)
+	globals.GetGlobalRef().Classpath = []string{classDir}
+	defer func() { globals.GetGlobalRef().Classpath = g.Classpath }()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	defer func() { _ = w.Close(); os.Stderr = normalStderr }()
+
+	// the main class refers to Hello2, to an array of it, and to a class that doesn't exist
+	mainClass := &Klass{Status: 'F', Loader: "app", Data: &ClData{Name: "Main", CP: CPool{
+		CpIndex:   []CpEntry{{}, {Type: UTF8, Slot: 0}, {Type: UTF8, Slot: 1}, {Type: UTF8, Slot: 2}},
+		Utf8Refs:  []string{"Hello2", "com/acme/Missing", "[LHello2;"},
+		ClassRefs: []uint16{1, 2, 3},
+	}}}
+	MethAreaInsert("Main", mainClass)
+	AppCL.recordClass("Main", mainClass)
+
+	// the program loads Hello2 while it's being preloaded
+	LoadReferencedClasses("Main")
+	k, err := AppCL.LoadClass("Hello2")
+	globals.LoaderWg.Wait()
+
+	if err != nil || MethAreaFetch("Hello2") != k || AppCL.FindLoadedClass("Hello2") != k {
+		t.Errorf("Expected Hello2 to be loaded once, got %v", err)
+	}
+	if MethAreaFetch("com/acme/Missing") != nil {
+		t.Errorf("Expected a missing class not to be loaded")
+	}
+}
+
 func TestConvertToPostableClassStringRefs(t *testing.T) {
 	// Testing the changes made as a result of JACOBIN-103
 	globals.InitGlobals("test")
@@ -178,7 +217,7 @@ func TestGetInvalidJar(t *testing.T) {
 	_, wout, _ := os.Pipe()
 	os.Stdout = wout
 
-	_, err := getJarFile(BootstrapCL, "")
+	_, err := getJarFile(&BootstrapCL, "")
 	if err == nil {
 		t.Errorf("expected err msg for fetching an invalid JAR, but got none")
 	}
@@ -212,7 +251,7 @@ func TestGetClassFromInvalidJar(t *testing.T) {
 	_, wout, _ := os.Pipe()
 	os.Stdout = wout
 
-	_, err := LoadClassFromJar(BootstrapCL, "pickle", "gherkin")
+	_, err := LoadClassFromJar(&BootstrapCL, "pickle", "gherkin")
 	if err == nil {
 		t.Errorf("expected err msg for loading invalid class from invalid JAR, but got none")
 	}
@@ -246,7 +285,7 @@ func TestMainClassFromInvalidJar(t *testing.T) {
 	_, wout, _ := os.Pipe()
 	os.Stdout = wout
 
-	_, err := GetMainClassFromJar(BootstrapCL, "gherkin")
+	_, err := GetMainClassFromJar(&BootstrapCL, "gherkin")
 	if err == nil {
 		t.Errorf("expected err msg for loading main class from invalid JAR, but got none")
 	}
@@ -0,0 +1,165 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The class path is the list of directories and jar files in which the
+// application's classes are looked for, in order. It's set by -cp (or
+// -classpath or --class-path) or, failing that, by the CLASSPATH environment
+// variable; its default is the current directory. When a program is run
+// with -jar, the class path is the jar file. The jars listed in the Class-Path
+// attribute of a jar's manifest are searched right after that jar.
+
+// ExpandClasspath splits a class path into its entries, which are separated
+// by the OS's path list separator (: or ;). As in the JDK, an empty entry is
+// the current directory, and an entry of the form dir/* stands for all the
+// jar files in the directory.
+func ExpandClasspath(classpath string) []string {
+	var entries []string
+	for _, entry := range filepath.SplitList(classpath) {
+		if entry == "" {
+			entries = append(entries, ".")
+		} else if entry == "*" || strings.HasSuffix(entry, "/*") || strings.HasSuffix(entry, `\*`) {
+			entries = append(entries, jarsInDirectory(entry[:len(entry)-1])...)
+		} else {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// AddManifestClasspaths adds the jars and directories in the Class-Path
+// attribute of the manifests of the jars in the class path. They're added
+// right after the jar whose manifest lists them, and the jars among them are
+// themselves checked for a Class-Path. An entry is only added once, so cycles
+// of jars that list each other are harmless.
+func AddManifestClasspaths(entries []string) []string {
+	var expanded []string
+	seen := make(map[string]bool)
+	var add func(entry string)
+	add = func(entry string) {
+		key := filepath.Clean(entry)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		expanded = append(expanded, entry)
+
+		info, err := os.Stat(entry)
+		if err != nil || info.IsDir() {
+			return
+		}
+		jar, err := NewJarFile(entry)
+		if err != nil {
+			return
+		}
+		for _, dependency := range jar.manifestClasspath() {
+			add(dependency)
+		}
+	}
+
+	for _, entry := range entries {
+		add(entry)
+	}
+	return expanded
+}
+
+// jarsInDirectory returns the jar files in the directory, in name order. The
+// JDK doesn't specify an order, but a fixed one makes runs repeatable.
+func jarsInDirectory(dir string) []string {
+	if dir == "" {
+		dir = "."
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		_ = log.Log("ExpandClasspath: cannot read directory "+dir+": "+err.Error(), log.WARNING)
+		return nil
+	}
+	var jars []string
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if !file.IsDir() && ext == ".jar" {
+			jars = append(jars, filepath.Join(dir, file.Name()))
+		}
+	}
+	sort.Strings(jars)
+	return jars
+}
+
+// classpathString returns the class path as it's given on the command line,
+// but with wildcards expanded. It's the value of the property java.class.path.
+func classpathString() string {
+	classpath := globals.GetGlobalRef().Classpath
+	if len(classpath) == 0 {
+		return "." // the JDK's default
+	}
+	return strings.Join(classpath, string(os.PathListSeparator))
+}
+
+// LoadClassFromClasspath loads the class, whose name is in the form
+// com/acme/Main or com.acme.Main, from the first entry of the class path that
+// contains it, and returns the name of the class as it was loaded.
+func LoadClassFromClasspath(cl *Classloader, className string) (string, error) {
+	filename, classBytes, err := classpathClassBytes(cl, className)
+	if err != nil {
+		return "", err
+	}
+	return ParseAndPostClass(cl, filename, classBytes)
+}
+
+// classpathClassBytes returns the name and the contents of the class file of
+// the class in the first entry of the class path that contains it.
+func classpathClassBytes(cl *Classloader, className string) (string, []byte, error) {
+	className = strings.ReplaceAll(className, ".", "/")
+	classpath := globals.GetGlobalRef().Classpath
+	if len(classpath) == 0 {
+		classpath = []string{"."}
+	}
+	for _, entry := range classpath {
+		info, err := os.Stat(entry)
+		if err != nil { // as in the JDK, entries that don't exist are ignored
+			continue
+		}
+
+		if info.IsDir() {
+			filename := filepath.Join(entry, filepath.FromSlash(className)+".class")
+			if _, err := os.Stat(filename); err == nil {
+				_ = log.Log("LoadClassFromClasspath: "+className+" found in directory "+entry, log.CLASS)
+				classBytes, err := os.ReadFile(filename)
+				return filename, classBytes, err
+			}
+			continue
+		}
+
+		jar, err := getJarFile(cl, entry)
+		if err != nil {
+			continue
+		}
+		jarName := strings.ReplaceAll(className, "/", ".") // the name under which jars record their classes
+		if jar.hasResource(jarName, ClassFile) {
+			_ = log.Log("LoadClassFromClasspath: "+className+" found in jar "+entry, log.CLASS)
+			result, err := jar.loadClass(jarName)
+			if err != nil {
+				return "", nil, err
+			}
+			if !result.Success {
+				return "", nil, fmt.Errorf("unable to find file %s in JAR file %s", jarName, entry)
+			}
+			return jarName, *result.Data, nil
+		}
+	}
+	return "", nil, classNotFound(className)
+}
@@ -0,0 +1,112 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"archive/zip"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandClasspath(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.jar", "a.JAR", "notes.txt"} {
+		_ = os.WriteFile(filepath.Join(dir, name), []byte{}, 0644)
+	}
+	_ = os.Mkdir(filepath.Join(dir, "classes.jar"), 0755) // a directory, so not a jar
+
+	sep := string(os.PathListSeparator)
+	entries := ExpandClasspath("classes" + sep + sep + dir + string(os.PathSeparator) + "*" + sep + "lib.jar")
+	expected := []string{"classes", ".", filepath.Join(dir, "a.JAR"), filepath.Join(dir, "b.jar"), "lib.jar"}
+	if strings.Join(entries, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected class path entries %v, got %v", expected, entries)
+	}
+}
+
+// writeJar creates a jar that contains the files, which are given as name, contents
+func writeJar(t *testing.T, jarName string, files map[string][]byte) {
+	jarFile, err := os.Create(jarName)
+	if err != nil {
+		t.Fatalf("Cannot create %s: %v", jarName, err)
+	}
+	defer jarFile.Close()
+	zw := zip.NewWriter(jarFile)
+	for name, contents := range files {
+		w, _ := zw.Create(name)
+		_, _ = w.Write(contents)
+	}
+	_ = zw.Close()
+}
+
+func TestLoadClassFromClasspath(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+	cl := &Classloader{Name: "app", Archives: make(map[string]*Archive)}
+
+	// the class is in the jar, which is after a directory that doesn't have it and one that doesn't exist
+	emptyDir := t.TempDir()
+	jarName := filepath.Join(t.TempDir(), "lib.jar")
+	writeJar(t, jarName, map[string][]byte{"Hello2.class": Hello2Bytes})
+	globals.GetGlobalRef().Classpath = []string{emptyDir, filepath.Join(emptyDir, "none"), jarName}
+	defer func() { globals.GetGlobalRef().Classpath = g.Classpath }()
+
+	name, err := LoadClassFromClasspath(cl, "Hello2")
+	if err != nil || name != "Hello2" {
+		t.Errorf("Expected Hello2 to be loaded from the jar, got %s, %v", name, err)
+	}
+
+	// and from a directory, in which packages are subdirectories
+	classDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(classDir, "Hello2.class"), Hello2Bytes, 0644)
+	_ = os.MkdirAll(filepath.Join(classDir, "java", "lang"), 0755)
+	_ = os.WriteFile(filepath.Join(classDir, "java", "lang", "Class.class"), ClassBytes, 0644)
+	globals.GetGlobalRef().Classpath = []string{classDir}
+	InitMethodArea()
+	if name, err = LoadClassFromClasspath(cl, "Hello2"); err != nil || name != "Hello2" {
+		t.Errorf("Expected Hello2 to be loaded from the directory, got %s, %v", name, err)
+	}
+
+	_, err = LoadClassFromClasspath(cl, "com.acme.Missing")
+	if err == nil || err.Error() != "ClassNotFoundException: com.acme.Missing" {
+		t.Errorf("Expected ClassNotFoundException for a class not on the class path, got %v", err)
+	}
+
+	// the application's classes can't be in java.*
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	_, err = LoadClassFromClasspath(cl, "java.lang.Class")
+	_ = w.Close()
+	os.Stderr = normalStderr
+	if err == nil || err.Error() != "SecurityException: Prohibited package name: java.lang" {
+		t.Errorf("Expected SecurityException for a java.lang class on the class path, got %v", err)
+	}
+}
+
+func TestAddManifestClasspaths(t *testing.T) {
+	dir := t.TempDir()
+	app := filepath.Join(dir, "app.jar")
+	lib := filepath.Join(dir, "lib", "lib.jar")
+	util := filepath.Join(dir, "lib", "util.jar")
+	_ = os.Mkdir(filepath.Join(dir, "lib"), 0755)
+
+	// app.jar lists lib.jar, which lists util.jar and (a cycle) app.jar
+	writeJar(t, app, map[string][]byte{"META-INF/MANIFEST.MF": []byte("Class-Path: lib/lib.jar\r\n")})
+	writeJar(t, lib, map[string][]byte{"META-INF/MANIFEST.MF": []byte("Class-Path: util.jar ../app.jar\r\n")})
+	writeJar(t, util, map[string][]byte{"data.txt": []byte("no manifest")})
+
+	entries := AddManifestClasspaths([]string{"classes", app, "other.jar"})
+	expected := []string{"classes", app, lib, util, "other.jar"}
+	if strings.Join(entries, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected the class path %v, got %v", expected, entries)
+	}
+}
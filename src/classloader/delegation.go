@@ -0,0 +1,290 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"jacobin/log"
+	"strings"
+	"sync"
+)
+
+// The classloaders form a chain: the application classloader's parent is the
+// extension classloader, whose parent is the bootstrap classloader. As in the
+// JDK, a classloader asked for a class first delegates to its parent and only
+// looks for the class itself if the parent can't find it. So the JDK's classes
+// are always loaded by the bootstrap classloader and an application can't
+// substitute its own versions of them.
+//
+// Each classloader has its own namespace: the classes it has loaded, whether
+// it defined them itself or got them from a parent. A class is identified by
+// its name together with its defining classloader, which is recorded in
+// Klass.Loader. Every classloader counts the classes it defined in ClassCount.
+// Reference: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-5.html#jvms-5.3
+//
+// So that classes with the same name defined by different classloaders don't
+// collide, the method area, the MTable, the static fields and the Class objects
+// hold classes under a key (see ClassKey), which is also the class name recorded
+// in objects and frames. Jacobin's own classloaders delegate parent-first and
+// can't define classes in each other's packages, so together they have one
+// namespace, in which the key of a class is its name. A class defined by a
+// classloader written in Java has a key that adds the name of the loader, and
+// the names its code refers to are resolved in that loader's namespace.
+
+// ErrClassNotFound is the error wrapped by the errors returned when no
+// classloader in the chain can find a class.
+var ErrClassNotFound = errors.New("ClassNotFoundException")
+
+// classNotFound returns the error for the class, whose name is in
+// java/lang/String format, not being found.
+func classNotFound(className string) error {
+	return fmt.Errorf("%w: %s", ErrClassNotFound, strings.ReplaceAll(className, "/", "."))
+}
+
+// javaClassloaders holds the classloaders written in Java, by name. Their
+// names are unique, so that Klass.Loader identifies the defining loader.
+var javaClassloaders = make(map[string]*Classloader)
+
+// ClassKey returns the key of the class defined by the named classloader: the
+// class's name, or for a classloader written in Java, the name followed by @ and
+// the loader's name, e.g. com/acme/Plugin@com.acme.PluginLoader@1
+func ClassKey(loader, className string) string {
+	if cl := getClassloader(loader); cl != nil && cl.javaLoader != nil {
+		return className + "@" + loader
+	}
+	return className
+}
+
+// ClassNameOfKey returns the name of the class with the key
+func ClassNameOfKey(key string) string {
+	name, _, _ := strings.Cut(key, "@")
+	return name
+}
+
+// Key returns the key of the class in the method area
+func (k *Klass) Key() string {
+	return ClassKey(k.Loader, k.Data.Name)
+}
+
+// RefKey returns the key of the class that the class k refers to by name, such
+// as its superclass. The name is resolved in the namespace of k's defining loader,
+// loading it through the loader if necessary. For Jacobin's own classloaders, the
+// key is the name, and the class is loaded when it's first used.
+func (k *Klass) RefKey(className string) string {
+	key, err := k.resolveRef(className)
+	if err != nil {
+		_ = log.Log("RefKey: "+k.Loader+" could not load "+className+": "+err.Error(), log.WARNING)
+		return className
+	}
+	return key
+}
+
+// resolveRef is RefKey, returning the error if the class can't be loaded
+func (k *Klass) resolveRef(className string) (string, error) {
+	if className == "" || strings.HasPrefix(className, "[") {
+		return className, nil
+	}
+	cl := getClassloader(k.Loader)
+	if cl == nil || cl.javaLoader == nil {
+		return className, nil
+	}
+	ref, err := cl.LoadClass(className)
+	if err != nil {
+		return "", err
+	}
+	return ref.Key(), nil
+}
+
+// getClassloader returns the classloader with the given name, or nil if there's none.
+func getClassloader(name string) *Classloader {
+	switch name {
+	case BootstrapCL.Name:
+		return &BootstrapCL
+	case ExtensionCL.Name:
+		return &ExtensionCL
+	case AppCL.Name:
+		return &AppCL
+	}
+	ClassesLock.RLock()
+	defer ClassesLock.RUnlock()
+	return javaClassloaders[name]
+}
+
+// LoadClass returns the class, whose name is in the form java/lang/String or
+// java.lang.String, loading it if it isn't yet in the classloader's namespace.
+// The parent classloader is asked for the class first. A classloader written
+// in Java is asked through its loadClass() method.
+func (cl *Classloader) LoadClass(className string) (*Klass, error) {
+	className = strings.ReplaceAll(className, ".", "/")
+	if cl.javaLoader != nil {
+		return cl.loadClassWithJava(className)
+	}
+	if k := cl.FindLoadedClass(className); k != nil {
+		return k, nil
+	}
+
+	if parent := getClassloader(cl.Parent); parent != nil && parent != cl {
+		k, err := parent.LoadClass(className)
+		if err == nil {
+			cl.recordClass(className, k)
+			return k, nil
+		}
+		if !errors.Is(err, ErrClassNotFound) {
+			return nil, err
+		}
+	}
+
+	k, err := cl.findClassOnce(className)
+	if err != nil {
+		return nil, err
+	}
+	_ = log.Log("LoadClass: "+className+" loaded by the "+cl.Name+" classloader", log.CLASS)
+	return k, nil
+}
+
+// bootstrapClassBytes returns the class file of a class in the JDK's modules,
+// which are in the jimage or in the jmod files.
+func bootstrapClassBytes(className string) ([]byte, error) {
+	if RuntimeImage != nil {
+		classBytes, err := RuntimeImage.ClassBytes(className)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, classNotFound(className)
+		}
+		_ = log.Log("findClass: Load "+className+" from jimage "+RuntimeImage.Filename, log.CLASS)
+		return classBytes, err
+	}
+
+	jmodFileName := JmodMapFetch(className)
+	if jmodFileName == "" {
+		return nil, classNotFound(className)
+	}
+	_ = log.Log("findClass: Load "+className+" from jmod "+jmodFileName, log.CLASS)
+	return GetClassBytes(jmodFileName, className)
+}
+
+// FindLoadedClass returns the class if it's in the classloader's namespace, else nil.
+func (cl *Classloader) FindLoadedClass(className string) *Klass {
+	ClassesLock.RLock()
+	defer ClassesLock.RUnlock()
+	return cl.classes[className]
+}
+
+// recordClass adds a class defined by another classloader to the classloader's namespace.
+func (cl *Classloader) recordClass(className string, k *Klass) {
+	ClassesLock.Lock()
+	if cl.classes == nil {
+		cl.classes = make(map[string]*Klass)
+	}
+	cl.classes[className] = k
+	ClassesLock.Unlock()
+}
+
+// A class can be loaded by several goroutines at once: by the one running the
+// program and by those preloading classes (see LoadReferencedClasses). So that
+// a class is only defined once, the first goroutine to look for a class that a
+// classloader doesn't have posts a classLoad, a future of the class, which the
+// others wait for, rather than loading the class again.
+type classLoad struct {
+	done chan struct{} // closed when the class is loaded or has failed to load
+	k    *Klass
+	err  error
+}
+
+type classLoadKey struct {
+	loader    string
+	className string
+}
+
+// the classes being loaded, by classloader and name
+var classLoads = make(map[classLoadKey]*classLoad)
+var classLoadsLock sync.Mutex
+
+// findClassOnce calls findClass, unless another goroutine is already loading
+// the class in the classloader, in which case it waits for its result.
+func (cl *Classloader) findClassOnce(className string) (*Klass, error) {
+	key := classLoadKey{cl.Name, className}
+	classLoadsLock.Lock()
+	if load, loading := classLoads[key]; loading {
+		classLoadsLock.Unlock()
+		<-load.done
+		return load.k, load.err
+	}
+	// another goroutine might have loaded the class since this one last looked
+	if k := cl.FindLoadedClass(className); k != nil {
+		classLoadsLock.Unlock()
+		return k, nil
+	}
+	load := &classLoad{done: make(chan struct{})}
+	classLoads[key] = load
+	classLoadsLock.Unlock()
+
+	defer func() {
+		classLoadsLock.Lock()
+		delete(classLoads, key)
+		classLoadsLock.Unlock()
+		close(load.done)
+	}()
+	load.k, load.err = cl.findClass(className)
+	return load.k, load.err
+}
+
+// waitForClassLoads waits for the loads of the class that are in progress, in any classloader.
+func waitForClassLoads(className string) {
+	var loads []*classLoad
+	classLoadsLock.Lock()
+	for key, load := range classLoads {
+		if key.className == className {
+			loads = append(loads, load)
+		}
+	}
+	classLoadsLock.Unlock()
+	for _, load := range loads {
+		<-load.done
+	}
+}
+
+// findClass looks for the class where the classloader itself finds classes and
+// defines it. The bootstrap classloader loads the classes in the JDK's modules
+// and the application classloader those on the class path. Since Java 9, the
+// extension classloader has no classes of its own.
+func (cl *Classloader) findClass(className string) (*Klass, error) {
+	var filename string
+	var classBytes []byte
+	var err error
+	switch cl.Name {
+	case "bootstrap":
+		filename = className
+		classBytes, err = bootstrapClassBytes(className)
+	case "app":
+		filename, classBytes, err = classpathClassBytes(cl, className)
+	default:
+		return nil, classNotFound(className)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cl.defineNamedClass(className, filename, classBytes)
+}
+
+// defineNamedClass defines the class in the class file, which must be the class
+// with the given name. A class file that holds a class with another name is an
+// error, and the class in it isn't defined.
+func (cl *Classloader) defineNamedClass(className, filename string, classBytes []byte) (*Klass, error) {
+	parsedClass, err := parseClass(filename, classBytes)
+	if err != nil {
+		return nil, err
+	}
+	if parsedClass.className != className {
+		return nil, fmt.Errorf("NoClassDefFoundError: %s (wrong name: %s)", className, parsedClass.className)
+	}
+	if _, err = postClass(cl, filename, parsedClass); err != nil {
+		return nil, err
+	}
+	return cl.FindLoadedClass(className), nil
+}
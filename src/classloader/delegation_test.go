@@ -0,0 +1,194 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParentFirstDelegation(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	_ = Init()
+
+	// the class path holds an application class and an attempt to replace a java.lang class
+	classDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(classDir, "Hello2.class"), Hello2Bytes, 0644)
+	_ = os.MkdirAll(filepath.Join(classDir, "java", "lang"), 0755)
+	_ = os.WriteFile(filepath.Join(classDir, "java", "lang", "Class.class"), ClassBytes, 0644)
+	globals.GetGlobalRef().Classpath = []string{classDir}
+	defer func() { globals.GetGlobalRef().Classpath = g.Classpath }()
+
+	// java/lang/Class is a JDK class, so it's defined by the bootstrap classloader
+	if _, err := ParseAndPostClass(&BootstrapCL, "Class.class", ClassBytes); err != nil {
+		t.Fatalf("Unexpected error defining java/lang/Class: %v", err)
+	}
+	bootstrapCount := BootstrapCL.GetCountOfLoadedClasses()
+
+	k, err := AppCL.LoadClass("java.lang.Class")
+	if err != nil {
+		t.Fatalf("Unexpected error loading java.lang.Class: %v", err)
+	}
+	if k.Loader != "bootstrap" || k != BootstrapCL.FindLoadedClass("java/lang/Class") {
+		t.Errorf("Expected the bootstrap classloader's java/lang/Class, got one defined by %s", k.Loader)
+	}
+	if BootstrapCL.GetCountOfLoadedClasses() != bootstrapCount || AppCL.GetCountOfLoadedClasses() != 0 {
+		t.Errorf("Expected the class not to be loaded again, got counts of %d and %d",
+			BootstrapCL.GetCountOfLoadedClasses(), AppCL.GetCountOfLoadedClasses())
+	}
+	if AppCL.FindLoadedClass("java/lang/Class") != k || ExtensionCL.FindLoadedClass("java/lang/Class") != k {
+		t.Errorf("Expected java/lang/Class to be in the namespaces of the classloaders that delegated its loading")
+	}
+
+	k, err = AppCL.LoadClass("Hello2")
+	if err != nil {
+		t.Fatalf("Unexpected error loading Hello2: %v", err)
+	}
+	if k.Loader != "app" || AppCL.GetCountOfLoadedClasses() != 1 {
+		t.Errorf("Expected Hello2 to be defined by the app classloader, got %s (count %d)",
+			k.Loader, AppCL.GetCountOfLoadedClasses())
+	}
+	if BootstrapCL.FindLoadedClass("Hello2") != nil {
+		t.Errorf("Expected Hello2 not to be in the bootstrap classloader's namespace")
+	}
+
+	_, err = AppCL.LoadClass("com.acme.Missing")
+	if !errors.Is(err, ErrClassNotFound) || err.Error() != "ClassNotFoundException: com.acme.Missing" {
+		t.Errorf("Expected ClassNotFoundException for a missing class, got %v", err)
+	}
+}
+
+func TestAppClassesCannotBeInJavaPackages(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	cl := &Classloader{Name: "app", Parent: "extension"}
+	_, err := ParseAndPostClass(cl, "Class.class", ClassBytes)
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if err == nil || err.Error() != "SecurityException: Prohibited package name: java.lang" {
+		t.Errorf("Expected SecurityException, got %v", err)
+	}
+	if cl.ClassCount != 0 || MethAreaFetch("java/lang/Class") != nil {
+		t.Errorf("Expected the class not to be defined")
+	}
+}
+
+func TestClassWithTheWrongNameIsNotDefined(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	_ = Init()
+
+	// the class file of com/acme/Hello2 holds Hello2
+	classDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(classDir, "com", "acme"), 0755)
+	_ = os.WriteFile(filepath.Join(classDir, "com", "acme", "Hello2.class"), Hello2Bytes, 0644)
+	globals.GetGlobalRef().Classpath = []string{classDir}
+	defer func() { globals.GetGlobalRef().Classpath = g.Classpath }()
+
+	_, err := AppCL.LoadClass("com.acme.Hello2")
+	if err == nil || err.Error() != "NoClassDefFoundError: com/acme/Hello2 (wrong name: Hello2)" {
+		t.Errorf("Expected NoClassDefFoundError for a class file with the wrong class, got %v", err)
+	}
+	if MethAreaFetch("Hello2") != nil || AppCL.FindLoadedClass("Hello2") != nil || AppCL.GetCountOfLoadedClasses() != 0 {
+		t.Errorf("Expected the class in the class file not to be defined")
+	}
+}
+
+func TestConcurrentLoadsDefineAClassOnce(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	_ = Init()
+
+	jarName := filepath.Join(t.TempDir(), "lib.jar")
+	writeJar(t, jarName, map[string][]byte{"Hello2.class": Hello2Bytes})
+	globals.GetGlobalRef().Classpath = []string{jarName}
+	defer func() { globals.GetGlobalRef().Classpath = g.Classpath }()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	defer func() { _ = w.Close(); os.Stderr = normalStderr }()
+
+	const goroutines = 16
+	var wg sync.WaitGroup
+	classes := make([]*Klass, goroutines)
+	errs := make([]error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%2 == 0 {
+				classes[i], errs[i] = AppCL.LoadClass("Hello2")
+			} else {
+				_, errs[i] = AppCL.LoadClass("com/acme/Missing")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < goroutines; i += 2 {
+		if errs[i] != nil || classes[i] != classes[0] {
+			t.Errorf("Expected every goroutine to get the same Hello2, got %p, %v", classes[i], errs[i])
+		}
+		if !errors.Is(errs[i+1], ErrClassNotFound) {
+			t.Errorf("Expected every goroutine to get ClassNotFoundException for a missing class, got %v", errs[i+1])
+		}
+	}
+	if AppCL.GetCountOfLoadedClasses() != 1 || MethAreaFetch("Hello2") != classes[0] {
+		t.Errorf("Expected Hello2 to be defined once, got a count of %d", AppCL.GetCountOfLoadedClasses())
+	}
+	if len(classLoads) != 0 {
+		t.Errorf("Expected no loads to be left in progress, got %v", classLoads)
+	}
+}
+
+func TestWaitForClassStatus(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+
+	if err := WaitForClassStatus("Hello2"); err == nil {
+		t.Errorf("Expected an error for a class that's neither loaded nor being loaded")
+	}
+
+	// a class being loaded by another goroutine is waited for
+	key := classLoadKey{"app", "Hello2"}
+	load := &classLoad{done: make(chan struct{})}
+	classLoadsLock.Lock()
+	classLoads[key] = load
+	classLoadsLock.Unlock()
+
+	result := make(chan error)
+	go func() { result <- WaitForClassStatus("Hello2") }()
+	select {
+	case err := <-result:
+		t.Fatalf("Expected WaitForClassStatus() to wait for the load in progress, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	MethAreaInsert("Hello2", &Klass{Status: 'F', Loader: "app", Data: &ClData{Name: "Hello2"}})
+	classLoadsLock.Lock()
+	delete(classLoads, key)
+	classLoadsLock.Unlock()
+	close(load.done)
+	if err := <-result; err != nil {
+		t.Errorf("Expected the class to be loaded once the load finished, got %v", err)
+	}
+}
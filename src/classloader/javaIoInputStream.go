@@ -0,0 +1,300 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"sync"
+)
+
+/*
+ System.in is implemented in Go. Its object holds (in its "in" field) an
+ inputStream struct that buffers the bytes read from stdin. The readers that
+ are built on System.in (InputStreamReader, BufferedReader, and Scanner; see
+ javaIoReader.go and javaUtilScanner.go) read through the same buffer, so a
+ program can mix them. (In the JDK, each reader has a buffer of its own, so
+ input that one reads ahead is lost to the others.) Other InputStreams, and the
+ readers built on them, run the JDK's code.
+*/
+
+const inputStreamInField = "in"
+const inputStreamBufSize = 8192
+
+type inputStream struct {
+	in     *bufio.Reader
+	closed bool
+	mutex  sync.Mutex
+}
+
+// stdin reads from os.Stdin, which is looked up on every read, rather than
+// stored, so that input follows any redirection of os.Stdin
+type stdin struct{}
+
+func (stdin) Read(b []byte) (int, error) {
+	return os.Stdin.Read(b)
+}
+
+// the Go state of System.in, created on first use
+var systemIn *inputStream
+var systemInOnce sync.Once
+
+// NewSystemIn creates the java/io/InputStream object for System.in
+func NewSystemIn() *object.Object {
+	systemInOnce.Do(func() {
+		systemIn = &inputStream{in: bufio.NewReaderSize(stdin{}, inputStreamBufSize)}
+	})
+	return newInputStreamObject(systemIn)
+}
+
+// newInputStreamObject creates an InputStream object for the Go state. Like
+// System.in in the JDK, its class is BufferedInputStream.
+func newInputStreamObject(is *inputStream) *object.Object {
+	obj := object.MakeEmptyObject()
+	className := "java/io/BufferedInputStream"
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		inputStreamInField: {Ftype: types.GoObject, Fvalue: is},
+	}
+	return obj
+}
+
+// getInputStream returns the Go state of an InputStream object, and false if
+// the object is not an InputStream implemented in Go
+func getInputStream(obj *object.Object) (*inputStream, bool) {
+	if obj == nil || obj.FieldTable == nil {
+		return nil, false
+	}
+	is, ok := obj.FieldTable[inputStreamInField].Fvalue.(*inputStream)
+	return is, ok
+}
+
+// hasInputStream is true if the InputStream in params[0] is implemented in Go,
+// as System.in is. The Go functions here handle only those streams; the JDK's
+// code handles the others, such as a FileInputStream.
+func hasInputStream(params []interface{}) bool {
+	obj, _ := params[0].(*object.Object)
+	_, ok := getInputStream(obj)
+	return ok
+}
+
+// wrapsInputStream is true if the InputStream in params[1], which a reader or
+// Scanner is being constructed on, is implemented in Go
+func wrapsInputStream(params []interface{}) bool {
+	return hasInputStream(params[1:])
+}
+
+// the exceptions thrown when a stream isn't usable
+func streamClosed() error {
+	return throwNativeException(exceptions.IOException, "java.io.IOException", "Stream closed")
+}
+
+func unsupportedStream(obj *object.Object) error {
+	name := "null"
+	if obj != nil {
+		name = javaClassName(obj)
+	}
+	return throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of the stream "+name)
+}
+
+// inputStreamThis returns the Go state of the InputStream in params[0], and
+// an exception if it's not usable
+func inputStreamThis(params []interface{}) (*inputStream, error) {
+	obj, _ := params[0].(*object.Object)
+	is, ok := getInputStream(obj)
+	if !ok {
+		return nil, unsupportedStream(obj)
+	}
+	if is.closed {
+		return nil, streamClosed()
+	}
+	return is, nil
+}
+
+func Load_Io_InputStream() map[string]GMeth {
+
+	MethodSignatures["java/io/InputStream.read()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamRead,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/InputStream.read([B)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  inputStreamReadBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/InputStream.read([BII)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  inputStreamReadBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/InputStream.readAllBytes()[B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamReadAllBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/InputStream.available()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamAvailable,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/InputStream.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamClose,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.read()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamRead,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.read([B)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  inputStreamReadBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.read([BII)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  inputStreamReadBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.readAllBytes()[B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamReadAllBytes,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.available()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamAvailable,
+			Accepts:    hasInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedInputStream.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  inputStreamClose,
+			Accepts:    hasInputStream,
+		}
+
+	return MethodSignatures
+}
+
+// read() returns the next byte, from 0 to 255, or -1 at the end of the stream
+func inputStreamRead(params []interface{}) interface{} {
+	is, err := inputStreamThis(params)
+	if err != nil {
+		return err
+	}
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	b, err := is.in.ReadByte()
+	if err != nil {
+		return int64(-1)
+	}
+	return int64(b)
+}
+
+// read(byte[]) and read(byte[], int off, int len) read at least one byte,
+// waiting for input if necessary, and no more than are available without
+// waiting. They return the number of bytes read, or -1 at the end of the stream.
+func inputStreamReadBytes(params []interface{}) interface{} {
+	arr, _ := params[1].(*object.Object)
+	if arr == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "InputStream.read(): null array")
+	}
+	bytes := *(arr.Fields[0].Fvalue.(*[]byte))
+	if len(params) > 2 {
+		offset, length := params[2].(int64), params[3].(int64)
+		if offset < 0 || length < 0 || offset > int64(len(bytes))-length {
+			return throwNativeException(exceptions.IndexOutOfBoundsException,
+				"java.lang.IndexOutOfBoundsException",
+				fmt.Sprintf("Range [%d, %d + %d) out of bounds for length %d", offset, offset, length, len(bytes)))
+		}
+		bytes = bytes[offset : offset+length]
+	}
+
+	is, err := inputStreamThis(params)
+	if err != nil {
+		return err
+	}
+	if len(bytes) == 0 {
+		return int64(0)
+	}
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	n, err := is.in.Read(bytes)
+	if n == 0 && err != nil {
+		return int64(-1)
+	}
+	return int64(n)
+}
+
+// readAllBytes() reads until the end of the stream
+func inputStreamReadAllBytes(params []interface{}) interface{} {
+	is, err := inputStreamThis(params)
+	if err != nil {
+		return err
+	}
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	bytes, _ := io.ReadAll(is.in)
+	arr := object.Make1DimArray(object.BYTE, int64(len(bytes)))
+	copy(*(arr.Fields[0].Fvalue.(*[]byte)), bytes)
+	return arr
+}
+
+// available() returns the number of bytes that can be read without waiting.
+// This is the number already buffered, which is 0 until the first read.
+func inputStreamAvailable(params []interface{}) interface{} {
+	is, err := inputStreamThis(params)
+	if err != nil {
+		return err
+	}
+	is.mutex.Lock()
+	defer is.mutex.Unlock()
+	return int64(is.in.Buffered())
+}
+
+// close() marks the stream closed; further reads throw an IOException. The
+// process's stdin itself is left open.
+func inputStreamClose(params []interface{}) interface{} {
+	obj, _ := params[0].(*object.Object)
+	if is, ok := getInputStream(obj); ok {
+		is.mutex.Lock()
+		is.closed = true
+		is.mutex.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bufio"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"os"
+	"strings"
+	"testing"
+)
+
+// inputStreamOf creates an InputStream object that reads the string
+func inputStreamOf(s string) *object.Object {
+	return newInputStreamObject(&inputStream{in: bufio.NewReader(strings.NewReader(s))})
+}
+
+func TestInputStreamRead(t *testing.T) {
+	in := inputStreamOf("ab\xffcdefg")
+
+	if got := inputStreamRead([]interface{}{in}); got != int64('a') {
+		t.Errorf("Expected read() to return 'a', got %v", got)
+	}
+	if got := inputStreamAvailable([]interface{}{in}); got != int64(7) {
+		t.Errorf("Expected available() to be 7 after the first read, got %v", got)
+	}
+
+	arr := object.Make1DimArray(object.BYTE, 5)
+	if got := inputStreamReadBytes([]interface{}{in, arr, int64(1), int64(3)}); got != int64(3) {
+		t.Errorf("Expected read(b, 1, 3) to return 3, got %v", got)
+	}
+	if got := string(*(arr.Fields[0].Fvalue.(*[]byte))); got != "\x00b\xffc\x00" {
+		t.Errorf("Expected read(b, 1, 3) to fill b[1..3], got %q", got)
+	}
+	if got := inputStreamRead([]interface{}{in}); got != int64('d') {
+		t.Errorf("Expected read() to return 'd', got %v", got)
+	}
+
+	rest := inputStreamReadAllBytes([]interface{}{in}).(*object.Object)
+	if got := string(*(rest.Fields[0].Fvalue.(*[]byte))); got != "efg" {
+		t.Errorf("Expected readAllBytes() to return \"efg\", got %q", got)
+	}
+	if got := inputStreamRead([]interface{}{in}); got != int64(-1) {
+		t.Errorf("Expected read() at the end of the stream to return -1, got %v", got)
+	}
+	if got := inputStreamReadBytes([]interface{}{in, arr}); got != int64(-1) {
+		t.Errorf("Expected read(b) at the end of the stream to return -1, got %v", got)
+	}
+}
+
+func TestInputStreamClosed(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	in := inputStreamOf("abc")
+	inputStreamClose([]interface{}{in})
+	if _, ok := inputStreamRead([]interface{}{in}).(error); !ok {
+		t.Errorf("Expected an IOException reading a closed stream")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+// System.in reads from os.Stdin, so input piped in by a test is read in full and in order
+func TestSystemInFromPipe(t *testing.T) {
+	normalStdin := os.Stdin
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	defer func() { os.Stdin = normalStdin }()
+
+	_, _ = w.WriteString("first line\r\nsecond \U0001F600\n\nlast")
+	_ = w.Close()
+
+	in := newInputStreamObject(&inputStream{in: bufio.NewReader(stdin{})})
+	isr := object.MakeEmptyObject()
+	inputStreamReaderInit([]interface{}{isr, in})
+	br := object.MakeEmptyObject()
+	bufferedReaderInit([]interface{}{br, isr})
+
+	expected := []string{"first line", "second \U0001F600", "", "last"}
+	for _, exp := range expected {
+		line, _ := readerReadLine([]interface{}{br}).(*object.Object)
+		if line == nil {
+			t.Fatalf("Expected readLine() to return %q, got null", exp)
+		}
+		if got := object.GetGoStringFromJavaStringPtr(line); got != exp {
+			t.Errorf("Expected readLine() to return %q, got %q", exp, got)
+		}
+	}
+	if line := readerReadLine([]interface{}{br}); line != object.Null {
+		t.Errorf("Expected readLine() at the end of the stream to return null, got %v", line)
+	}
+}
+
+func TestReaderReadChars(t *testing.T) {
+	isr := object.MakeEmptyObject()
+	inputStreamReaderInit([]interface{}{isr, inputStreamOf("hé\U0001F600!")})
+
+	if got := readerRead([]interface{}{isr}); got != int64('h') {
+		t.Errorf("Expected read() to return 'h', got %v", got)
+	}
+	arr := object.Make1DimArray(object.INT, 8)
+	if got := readerReadChars([]interface{}{isr, arr}); got != int64(4) {
+		t.Errorf("Expected read(char[]) to return 4, got %v", got)
+	}
+	chars := *(arr.Fields[0].Fvalue.(*[]int64))
+	expected := []int64{0xE9, 0xD83D, 0xDE00, '!'}
+	for i, c := range expected {
+		if chars[i] != c {
+			t.Errorf("Expected char[%d] to be %#x, got %#x", i, c, chars[i])
+		}
+	}
+	if got := readerRead([]interface{}{isr}); got != int64(-1) {
+		t.Errorf("Expected read() at the end of the stream to return -1, got %v", got)
+	}
+}
+
+// only the streams, readers and Scanners built on System.in (or another
+// InputStream implemented in Go) are handled in Go; the JDK's code handles the rest
+func TestStreamsWithoutGoStateAreNotAccepted(t *testing.T) {
+	fileStreamName := "java/io/FileInputStream"
+	fileStream := object.MakeEmptyObject()
+	fileStream.Klass = &fileStreamName
+	in := inputStreamOf("x")
+
+	if hasInputStream([]interface{}{fileStream}) || !hasInputStream([]interface{}{in}) {
+		t.Errorf("Expected only an InputStream with Go state to be accepted")
+	}
+
+	goReader, plainReader := object.MakeEmptyObject(), object.MakeEmptyObject()
+	if !wrapsInputStream([]interface{}{goReader, in}) || wrapsInputStream([]interface{}{plainReader, fileStream}) {
+		t.Errorf("Expected only a reader of an InputStream with Go state to be accepted")
+	}
+	inputStreamReaderInit([]interface{}{goReader, in})
+	if !hasReader([]interface{}{goReader}) || hasReader([]interface{}{plainReader}) {
+		t.Errorf("Expected only a reader with Go state to be accepted")
+	}
+	if !wrapsReader([]interface{}{object.MakeEmptyObject(), goReader}) ||
+		wrapsReader([]interface{}{object.MakeEmptyObject(), plainReader}) {
+		t.Errorf("Expected only a BufferedReader of a reader with Go state to be accepted")
+	}
+
+	goScanner := object.MakeEmptyObject()
+	scannerInitStream([]interface{}{goScanner, in})
+	if !hasScanner([]interface{}{goScanner}) || hasScanner([]interface{}{object.MakeEmptyObject()}) {
+		t.Errorf("Expected only a Scanner with Go state to be accepted")
+	}
+}
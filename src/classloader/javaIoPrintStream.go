@@ -8,7 +8,14 @@ package classloader
 
 import (
 	"fmt"
+	"jacobin/exceptions"
 	"jacobin/object"
+	"jacobin/shutdown"
+	"jacobin/types"
+	"os"
+	"strconv"
+	"sync"
+	"unicode/utf16"
 )
 
 /*
@@ -33,43 +40,225 @@ var MethodSignatures = make(map[string]GMeth)
 type GMeth struct {
 	ParamSlots int
 	GFunction  function
+	Accepts    func([]interface{}) bool // if set, the arguments GFunction handles (see GmEntry)
 }
 
 type function func([]interface{}) interface{}
 
+/*
+ java/io/PrintStream is implemented in Go. Each PrintStream object holds (in its
+ "out" field) a printStream struct that identifies the file the stream writes to
+ and buffers its output. System.out and System.err are the two PrintStreams
+ created at start-up; they write to stdout and stderr respectively.
+
+ As in the JDK, System.out and System.err are buffered and autoflushing: output
+ is written when a line is completed (by println() or a newline char), when one
+ of the write() methods is called, when flush() is called, when the buffer fills
+ up, and when the JVM exits.
+*/
+
+const printStreamOutField = "out"
+const printStreamBufSize = 8192
+
+// the line terminator that println() writes
+const printStreamNewline = "\n"
+
+type printStream struct {
+	fd        int // the file descriptor: 1 for stdout, 2 for stderr
+	buf       []byte
+	bufSize   int  // output is written when the buffer reaches this size; 0 means unbuffered
+	autoFlush bool // flush on newlines and write() calls
+	trouble   bool // set when an I/O error occurs; reported by checkError()
+	closed    bool
+	mutex     sync.Mutex
+}
+
+// the Go state of System.out and System.err
+var systemOut, systemErr *printStream
+var systemStreamsOnce sync.Once
+
+// initSystemStreams creates the Go state of System.out and System.err, and
+// arranges for their buffers to be flushed when the JVM exits
+func initSystemStreams() {
+	systemStreamsOnce.Do(func() {
+		systemOut = &printStream{fd: 1, bufSize: printStreamBufSize, autoFlush: true}
+		systemErr = &printStream{fd: 2, bufSize: printStreamBufSize, autoFlush: true}
+		shutdown.OnExit(func() {
+			systemOut.flush()
+			systemErr.flush()
+		})
+	})
+}
+
+// NewPrintStream creates a java/io/PrintStream object that writes to the
+// file descriptor: 1 (stdout) or 2 (stderr). The objects for the same
+// descriptor share their buffer.
+func NewPrintStream(fd int) *object.Object {
+	initSystemStreams()
+	ps := systemOut
+	if fd == 2 {
+		ps = systemErr
+	}
+
+	obj := object.MakeEmptyObject()
+	className := "java/io/PrintStream"
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		printStreamOutField: {Ftype: types.GoObject, Fvalue: ps},
+	}
+	return obj
+}
+
+// the file the stream writes to. It's looked up on every write, rather than
+// stored, so that output follows any redirection of os.Stdout or os.Stderr.
+func (ps *printStream) file() *os.File {
+	if ps.fd == 2 {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// write adds the bytes to the stream's buffer, writing the buffer out if it
+// fills up or if flushNow is true and the stream autoflushes
+func (ps *printStream) write(b []byte, flushNow bool) {
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	if ps.closed {
+		ps.trouble = true
+		return
+	}
+	ps.buf = append(ps.buf, b...)
+	if len(ps.buf) >= ps.bufSize || (flushNow && ps.autoFlush) {
+		ps.flushLocked()
+	}
+}
+
+// writeString writes the string, flushing if it contains a newline
+func (ps *printStream) writeString(s string) {
+	hasNewline := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			hasNewline = true
+			break
+		}
+	}
+	ps.write([]byte(s), hasNewline)
+}
+
+func (ps *printStream) flush() {
+	ps.mutex.Lock()
+	ps.flushLocked()
+	ps.mutex.Unlock()
+}
+
+// flushLocked writes out the buffer. The caller must hold the mutex.
+func (ps *printStream) flushLocked() {
+	if len(ps.buf) == 0 {
+		return
+	}
+	if _, err := ps.file().Write(ps.buf); err != nil {
+		ps.trouble = true
+	}
+	ps.buf = ps.buf[:0]
+}
+
+// printStreamOf returns the Go state of a PrintStream object, and false if the
+// object is not a PrintStream implemented in Go
+func printStreamOf(obj *object.Object) (*printStream, bool) {
+	if obj == nil || obj.FieldTable == nil {
+		return nil, false
+	}
+	ps, ok := obj.FieldTable[printStreamOutField].Fvalue.(*printStream)
+	return ps, ok
+}
+
+// hasPrintStream is true if the PrintStream in params[0] is implemented in Go.
+// The Go functions here handle only those PrintStreams; the JDK's code handles
+// the others, such as one created by new PrintStream(OutputStream).
+func hasPrintStream(params []interface{}) bool {
+	obj, _ := params[0].(*object.Object)
+	_, ok := printStreamOf(obj)
+	return ok
+}
+
+// printStreamThis returns the Go state of the PrintStream in params[0], and
+// an exception if it has none
+func printStreamThis(params []interface{}) (*printStream, error) {
+	obj, _ := params[0].(*object.Object)
+	ps, ok := printStreamOf(obj)
+	if !ok {
+		return nil, unsupportedStream(obj)
+	}
+	return ps, nil
+}
+
 func Load_Io_PrintStream() map[string]GMeth {
 	MethodSignatures["java/io/PrintStream.println()V"] = // println string
 		GMeth{
 			ParamSlots: 1, // [0] = PrintStream.out object,
 			GFunction:  PrintlnV,
+			Accepts:    hasPrintStream,
 		}
 	MethodSignatures["java/io/PrintStream.println(Ljava/lang/String;)V"] = // println string
 		GMeth{
 			ParamSlots: 2, // [0] = PrintStream.out object,
 			// [1] = index to StringConst to print
 			GFunction: Println,
+			Accepts:   hasPrintStream,
 		}
 	MethodSignatures["java/io/PrintStream.println(I)V"] = // println int
 		GMeth{
 			ParamSlots: 2,
 			GFunction:  PrintlnI,
+			Accepts:    hasPrintStream,
 		}
 	MethodSignatures["java/io/PrintStream.println(J)V"] = // println long
 		GMeth{
 			ParamSlots: 3, // PrintStream.out object + 2 slots for the long
 			GFunction:  PrintlnLong,
+			Accepts:    hasPrintStream,
 		}
 
 	MethodSignatures["java/io/PrintStream.println(D)V"] = // println double
 		GMeth{
 			ParamSlots: 3, // PrintStream.out object + 2 slots for the double
 			GFunction:  PrintlnDouble,
+			Accepts:    hasPrintStream,
 		}
 
 	MethodSignatures["java/io/PrintStream.println(F)V"] = // println float
 		GMeth{
 			ParamSlots: 2, // PrintStream.out object + 1 slot for the float
-			GFunction:  PrintlnDouble,
+			GFunction:  PrintlnFloat,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.println(Z)V"] = // println boolean
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintlnBoolean,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.println(C)V"] = // println char
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintlnChar,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.println([C)V"] = // println char array
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintlnCharArray,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.println(Ljava/lang/Object;)V"] = // println object
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintlnObject,
+			Accepts:    hasPrintStream,
 		}
 
 	MethodSignatures["java/io/PrintStream.print(Ljava/lang/String;)V"] = // print string
@@ -77,64 +266,194 @@ func Load_Io_PrintStream() map[string]GMeth {
 			ParamSlots: 2, // [0] = PrintStream.out object,
 			// [1] = index to StringConst to print
 			GFunction: PrintS,
+			Accepts:   hasPrintStream,
 		}
 	MethodSignatures["java/io/PrintStream.print(I)V"] = // print int
 		GMeth{
 			ParamSlots: 2,
 			GFunction:  PrintI,
+			Accepts:    hasPrintStream,
 		}
 	MethodSignatures["java/io/PrintStream.print(J)V"] = // print long
 		GMeth{
 			ParamSlots: 3, // PrintStream.out object + 2 slots for the long
 			GFunction:  PrintLong,
+			Accepts:    hasPrintStream,
 		}
 
 	MethodSignatures["java/io/PrintStream.print(D)V"] = // print double
 		GMeth{
 			ParamSlots: 3, // PrintStream.out object + 2 slots for the double
 			GFunction:  PrintDouble,
+			Accepts:    hasPrintStream,
 		}
 
 	MethodSignatures["java/io/PrintStream.print(F)V"] = // print float
 		GMeth{
 			ParamSlots: 2, // PrintStream.out object + 1 slot for the float
-			GFunction:  PrintDouble,
+			GFunction:  PrintFloat,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.print(Z)V"] = // print boolean
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintBoolean,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.print(C)V"] = // print char
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintChar,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.print([C)V"] = // print char array
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintCharArray,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.print(Ljava/lang/Object;)V"] = // print object
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintObject,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.printf(Ljava/lang/String;[Ljava/lang/Object;)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = PrintStream object, [1] = format string, [2] = array of args
+			GFunction:  Printf,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.printf(Ljava/util/Locale;Ljava/lang/String;[Ljava/lang/Object;)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 4, // [0] = PrintStream object, [1] = locale, [2] = format string, [3] = array of args
+			GFunction:  PrintfLocale,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.format(Ljava/lang/String;[Ljava/lang/Object;)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  Printf,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.format(Ljava/util/Locale;Ljava/lang/String;[Ljava/lang/Object;)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  PrintfLocale,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.write(I)V"] = // write a single byte
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintStreamWriteByte,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.write([B)V"] = // write an array of bytes
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintStreamWriteBytes,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.writeBytes([B)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintStreamWriteBytes,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.write([BII)V"] = // write part of an array of bytes
+		GMeth{
+			ParamSlots: 4, // [0] = PrintStream object, [1] = byte array, [2] = offset, [3] = length
+			GFunction:  PrintStreamWriteBytes,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.append(Ljava/lang/CharSequence;)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintStreamAppend,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.append(Ljava/lang/CharSequence;II)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 4, // [0] = PrintStream object, [1] = the CharSequence, [2] = start, [3] = end
+			GFunction:  PrintStreamAppend,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.append(C)Ljava/io/PrintStream;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  PrintStreamAppendChar,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.flush()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  PrintStreamFlush,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.checkError()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  PrintStreamCheckError,
+			Accepts:    hasPrintStream,
+		}
+
+	MethodSignatures["java/io/PrintStream.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  PrintStreamClose,
+			Accepts:    hasPrintStream,
 		}
 
 	return MethodSignatures
 }
 
-// Println is the go equivalent of System.out.println(). It accepts two args,
-// which are passed in a two-entry slice of type interface{}. The first arg is an
-// index in the CP to a StringConst entry; the second arg is an index into the
-// array of static fields, Statics. The entry there includes a pointer to the CP
-// for this class. The first arg then gets the StringConst ref, which is an index
-// into the UTF8 entries of the CP. This string is then printed to stdout. There
-// is no return value.
+// Println is the go equivalent of System.out.println(String). It accepts two args,
+// which are passed in a two-entry slice of type interface{}. The first arg is the
+// PrintStream object; the second is a pointer to the string to print.
 func Println(i []interface{}) interface{} {
-	strAddr := i[1].(*object.Object)
-	// t := (strAddr.Fields[0].Fvalue).(*[]types.JavaByte) // changed due to JAcOBIN-282
-	t := (strAddr.Fields[0].Fvalue).(*[]byte)
-
-	// goChars := make([]byte, len(*t), len(*t))
-	// for i, c := range *t {
-	// 	goChars[i] = byte(c)
-	// }
-
-	fmt.Println(string(*t))
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(stringOrNull(i[1]) + printStreamNewline)
 	return nil
 }
 
 // PrintlnV = java/io/Prinstream.println() -- println() prints a newline (V = void)
 func PrintlnV(i []interface{}) interface{} {
-	fmt.Println("")
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(printStreamNewline)
 	return nil
 }
 
-// PrintlnI = java/io/Prinstream.println(int) TODO: equivalent (verify that this grabs the right param to print)
+// PrintlnI = java/io/Prinstream.println(int)
 func PrintlnI(i []interface{}) interface{} {
 	intToPrint := i[1].(int64) // contains an int
-	fmt.Println(intToPrint)
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(strconv.FormatInt(intToPrint, 10) + printStreamNewline)
 	return nil
 }
 
@@ -142,22 +461,91 @@ func PrintlnI(i []interface{}) interface{} {
 // Long in Java are 64-bit ints, so we just duplicated the logic for println(int)
 func PrintlnLong(l []interface{}) interface{} {
 	longToPrint := l[1].(int64) // contains to an int64--the equivalent of a Java long
-	fmt.Println(longToPrint)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(strconv.FormatInt(longToPrint, 10) + printStreamNewline)
 	return nil
 }
 
 // PrintlnDouble = java/io/Prinstream.println(double)
-// Doubles in Java are 64-bit FP
+// Doubles in Java are 64-bit FP, which are printed as Double.toString() formats them
 func PrintlnDouble(l []interface{}) interface{} {
 	doubleToPrint := l[1].(float64) // contains to a float64--the equivalent of a Java double
-	fmt.Println(doubleToPrint)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(doubleToString(doubleToPrint) + printStreamNewline)
+	return nil
+}
+
+// PrintlnFloat = java/io/Prinstream.println(float)
+// Floats are held as float64, but are printed with the digits of the float
+func PrintlnFloat(l []interface{}) interface{} {
+	floatToPrint := l[1].(float64)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(floatToString(float32(floatToPrint)) + printStreamNewline)
+	return nil
+}
+
+// PrintlnBoolean = java/io/Prinstream.println(boolean)
+func PrintlnBoolean(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(booleanString(i[1].(int64)) + printStreamNewline)
 	return nil
 }
 
-// PrintI = java/io/Prinstream.print(int) TODO: equivalent (verify that this grabs the right param to print)
+// PrintlnChar = java/io/Prinstream.println(char)
+func PrintlnChar(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(charsToString([]uint16{uint16(i[1].(int64))}) + printStreamNewline)
+	return nil
+}
+
+// PrintlnCharArray = java/io/Prinstream.println(char[])
+func PrintlnCharArray(i []interface{}) interface{} {
+	chars, err := charArrayParam(i[1:])
+	if err != nil {
+		return err
+	}
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(charsToString(chars) + printStreamNewline)
+	return nil
+}
+
+// PrintlnObject = java/io/Prinstream.println(Object), which prints String.valueOf(obj)
+func PrintlnObject(i []interface{}) interface{} {
+	obj, _ := i[1].(*object.Object)
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(javaToString(obj) + printStreamNewline)
+	return nil
+}
+
+// PrintI = java/io/Prinstream.print(int)
 func PrintI(i []interface{}) interface{} {
 	intToPrint := i[1].(int64) // contains an int
-	fmt.Print(intToPrint)
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(strconv.FormatInt(intToPrint, 10))
 	return nil
 }
 
@@ -165,30 +553,235 @@ func PrintI(i []interface{}) interface{} {
 // Long in Java are 64-bit ints, so we just duplicated the logic for println(int)
 func PrintLong(l []interface{}) interface{} {
 	longToPrint := l[1].(int64) // contains to an int64--the equivalent of a Java long
-	fmt.Print(longToPrint)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(strconv.FormatInt(longToPrint, 10))
 	return nil
 }
 
 // PrintDouble = java/io/Prinstream.print(double)
-// Doubles in Java are 64-bit FP
+// Doubles in Java are 64-bit FP, which are printed as Double.toString() formats them
 func PrintDouble(l []interface{}) interface{} {
 	doubleToPrint := l[1].(float64) // contains to a float64--the equivalent of a Java double
-	fmt.Print(doubleToPrint)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(doubleToString(doubleToPrint))
+	return nil
+}
+
+// PrintFloat = java/io/Prinstream.print(float)
+func PrintFloat(l []interface{}) interface{} {
+	floatToPrint := l[1].(float64)
+	ps, err := printStreamThis(l)
+	if err != nil {
+		return err
+	}
+	ps.writeString(floatToString(float32(floatToPrint)))
+	return nil
+}
+
+// PrintBoolean = java/io/Prinstream.print(boolean)
+func PrintBoolean(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(booleanString(i[1].(int64)))
+	return nil
+}
+
+// PrintChar = java/io/Prinstream.print(char)
+func PrintChar(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(charsToString([]uint16{uint16(i[1].(int64))}))
+	return nil
+}
+
+// PrintCharArray = java/io/Prinstream.print(char[])
+func PrintCharArray(i []interface{}) interface{} {
+	chars, err := charArrayParam(i[1:])
+	if err != nil {
+		return err
+	}
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(charsToString(chars))
+	return nil
+}
+
+// PrintObject = java/io/Prinstream.print(Object)
+func PrintObject(i []interface{}) interface{} {
+	obj, _ := i[1].(*object.Object)
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(javaToString(obj))
 	return nil
 }
 
 // Print string
 func PrintS(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(stringOrNull(i[1]))
+	return nil
+}
 
-	strAddr := i[1].(*object.Object)
-	// t := (strAddr.Fields[0].Fvalue).(*[]types.JavaByte) // changed due to JACOBIN-282
-	t := (strAddr.Fields[0].Fvalue).(*[]byte)
-	//
-	// goChars := make([]byte, len(*t), len(*t))
-	// for i, c := range *t {
-	// 	goChars[i] = byte(c)
-	// }
+// Printf = java/io/PrintStream.printf(String, Object...) and format(String, Object...).
+// Both return the PrintStream.
+func Printf(i []interface{}) interface{} {
+	str := stringFormat(i[1:])
+	if err, ok := str.(error); ok {
+		return err
+	}
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(object.GetGoStringFromJavaStringPtr(str.(*object.Object)))
+	return i[0]
+}
 
-	fmt.Print(string(*t))
+// PrintfLocale = printf(Locale, String, Object...) and format(Locale, String, Object...)
+// The locale is not used: output is always formatted as for Locale.ROOT.
+func PrintfLocale(i []interface{}) interface{} {
+	return Printf([]interface{}{i[0], i[2], i[3]})
+}
+
+// PrintStreamWriteByte = java/io/PrintStream.write(int), which writes the low-order byte
+func PrintStreamWriteByte(i []interface{}) interface{} {
+	b := byte(i[1].(int64))
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.write([]byte{b}, b == '\n')
 	return nil
 }
+
+// PrintStreamWriteBytes = java/io/PrintStream.write(byte[]), write(byte[], int, int) and writeBytes(byte[])
+func PrintStreamWriteBytes(i []interface{}) interface{} {
+	arr, _ := i[1].(*object.Object)
+	if arr == nil {
+		return stringNPE("write")
+	}
+	bytes := *(arr.Fields[0].Fvalue.(*[]byte))
+
+	if len(i) > 2 {
+		offset, length := i[2].(int64), i[3].(int64)
+		if offset < 0 || length < 0 || offset > int64(len(bytes))-length {
+			return throwNativeException(exceptions.IndexOutOfBoundsException,
+				"java.lang.IndexOutOfBoundsException",
+				fmt.Sprintf("Range [%d, %d + %d) out of bounds for length %d", offset, offset, length, len(bytes)))
+		}
+		bytes = bytes[offset : offset+length]
+	}
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.write(bytes, true)
+	return nil
+}
+
+// PrintStreamAppend = java/io/PrintStream.append(CharSequence) and
+// append(CharSequence, int start, int end). A null CharSequence is appended as "null".
+func PrintStreamAppend(i []interface{}) interface{} {
+	csq, _ := i[1].(*object.Object)
+	chars, ok := charSequenceChars(csq)
+	if !ok {
+		chars = utf16.Encode([]rune(javaToString(csq)))
+	}
+	if len(i) > 2 {
+		start, end := i[2].(int64), i[3].(int64)
+		if start < 0 || end < start || end > int64(len(chars)) {
+			return throwNativeException(exceptions.StringIndexOutOfBoundsException,
+				"java.lang.StringIndexOutOfBoundsException",
+				fmt.Sprintf("begin %d, end %d, length %d", start, end, len(chars)))
+		}
+		chars = chars[start:end]
+	}
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.writeString(charsToString(chars))
+	return i[0]
+}
+
+// PrintStreamAppendChar = java/io/PrintStream.append(char)
+func PrintStreamAppendChar(i []interface{}) interface{} {
+	PrintChar(i)
+	return i[0]
+}
+
+// PrintStreamFlush = java/io/PrintStream.flush()
+func PrintStreamFlush(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.flush()
+	return nil
+}
+
+// PrintStreamCheckError = java/io/PrintStream.checkError(), which flushes the
+// stream and returns whether an error has ever occurred on it
+func PrintStreamCheckError(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.flush()
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	return types.ConvertGoBoolToJavaBool(ps.trouble)
+}
+
+// PrintStreamClose = java/io/PrintStream.close(). The stream is flushed and
+// further output is discarded, but the underlying file is left open, since
+// the JVM itself still writes to stdout and stderr.
+func PrintStreamClose(i []interface{}) interface{} {
+	ps, err := printStreamThis(i)
+	if err != nil {
+		return err
+	}
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	ps.flushLocked()
+	ps.closed = true
+	return nil
+}
+
+// the string to print for a String param, which might be null
+func stringOrNull(param interface{}) string {
+	str, _ := param.(*object.Object)
+	if str == nil {
+		return "null"
+	}
+	return object.GetGoStringFromJavaStringPtr(str)
+}
+
+func booleanString(b int64) string {
+	if b == types.JavaBoolTrue {
+		return "true"
+	}
+	return "false"
+}
+
+// charsToString converts Java chars to a Go (UTF-8) string
+func charsToString(chars []uint16) string {
+	return string(utf16.Decode(chars))
+}
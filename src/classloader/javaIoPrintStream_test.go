@@ -0,0 +1,141 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"io"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func TestPrintlnFloatingPoint(t *testing.T) {
+	normalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	ps := NewPrintStream(1)
+	PrintlnDouble([]interface{}{ps, 1e10, 1e10})
+	PrintlnFloat([]interface{}{ps, float64(float32(0.1))})
+	PrintDouble([]interface{}{ps, 2.0, 2.0})
+	PrintFloat([]interface{}{ps, float64(float32(1.0 / 3))})
+	PrintStreamFlush([]interface{}{ps})
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = normalStdout
+
+	expected := "1.0E10\n0.1\n2.00.33333334"
+	if string(out) != expected {
+		t.Errorf("Expected %q, got %q", expected, string(out))
+	}
+}
+
+func TestPrintStreamOutAndErrAreSeparate(t *testing.T) {
+	normalStdout, normalStderr := os.Stdout, os.Stderr
+	rOut, wOut, _ := os.Pipe()
+	rErr, wErr, _ := os.Pipe()
+	os.Stdout, os.Stderr = wOut, wErr
+
+	out, err := NewPrintStream(1), NewPrintStream(2)
+	PrintS([]interface{}{out, jstr("to out")})
+	PrintlnV([]interface{}{out})
+	PrintlnBoolean([]interface{}{err, types.JavaBoolTrue})
+	PrintChar([]interface{}{err, int64('é')})
+	PrintStreamFlush([]interface{}{err})
+
+	_ = wOut.Close()
+	_ = wErr.Close()
+	stdout, _ := io.ReadAll(rOut)
+	stderr, _ := io.ReadAll(rErr)
+	os.Stdout, os.Stderr = normalStdout, normalStderr
+
+	if string(stdout) != "to out\n" {
+		t.Errorf("Expected stdout to be %q, got %q", "to out\n", string(stdout))
+	}
+	if string(stderr) != "true\né" {
+		t.Errorf("Expected stderr to be %q, got %q", "true\né", string(stderr))
+	}
+}
+
+func TestPrintStreamBuffering(t *testing.T) {
+	ps := &printStream{fd: 1, bufSize: printStreamBufSize, autoFlush: true}
+	obj := NewPrintStream(1)
+	obj.FieldTable[printStreamOutField] = object.Field{Ftype: types.GoObject, Fvalue: ps}
+
+	PrintS([]interface{}{obj, jstr("partial")})
+	if string(ps.buf) != "partial" {
+		t.Errorf("Expected print() without a newline to be buffered, buffer holds %q", string(ps.buf))
+	}
+
+	normalStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	PrintStreamAppendChar([]interface{}{obj, int64('\n')})
+	PrintStreamWriteBytes([]interface{}{obj, byteArray("xyz"), int64(1), int64(2)})
+	if ret := Printf([]interface{}{obj, jstr("%d-%s%n"), refArray(box("java/lang/Integer", 7), jstr("x"))}); ret != obj {
+		t.Errorf("Expected printf() to return the PrintStream")
+	}
+	PrintStreamAppend([]interface{}{obj, object.Null})
+	if string(ps.buf) != "null" {
+		t.Errorf("Expected append(null) to buffer \"null\", buffer holds %q", string(ps.buf))
+	}
+	if PrintStreamCheckError([]interface{}{obj}) != types.JavaBoolFalse { // flushes the buffer
+		t.Errorf("Expected checkError() to be false")
+	}
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stdout = normalStdout
+
+	if string(out) != "partial\nyz7-x\nnull" {
+		t.Errorf("Expected %q, got %q", "partial\nyz7-x\nnull", string(out))
+	}
+}
+
+// a PrintStream created by Java code, rather than by the JVM, has no Go state,
+// so it's left to the JDK's code
+func TestPrintStreamWithoutGoState(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	className := "java/io/PrintStream"
+	javaStream := object.MakeEmptyObject()
+	javaStream.Klass = &className
+	if hasPrintStream([]interface{}{javaStream}) {
+		t.Errorf("Expected a PrintStream without Go state not to be accepted")
+	}
+	if !hasPrintStream([]interface{}{NewPrintStream(1)}) {
+		t.Errorf("Expected System.out to be accepted")
+	}
+	if _, ok := Println([]interface{}{javaStream, jstr("x")}).(error); !ok {
+		t.Errorf("Expected println() on a PrintStream without Go state to throw an exception")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func byteArray(s string) *object.Object {
+	arr := object.Make1DimArray(object.BYTE, int64(len(s)))
+	copy(*(arr.Fields[0].Fvalue.(*[]byte)), s)
+	return arr
+}
+
+func refArray(elems ...*object.Object) *object.Object {
+	arr := object.Make1DimArray(object.REF, int64(len(elems)))
+	copy(*(arr.Fields[0].Fvalue.(*[]*object.Object)), elems)
+	return arr
+}
@@ -0,0 +1,371 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"unicode/utf16"
+)
+
+// Implementation of java/io/InputStreamReader and java/io/BufferedReader over
+// an InputStream implemented in Go, such as System.in. Input is always decoded
+// as UTF-8; a charset passed to the constructor is accepted but not used. The
+// readers of other streams run the JDK's code.
+//
+// A BufferedReader shares the Go state of the reader it wraps, so
+// new BufferedReader(new InputStreamReader(System.in)).readLine() reads
+// directly from the buffer of System.in.
+
+// the fields of InputStreamReader and BufferedReader that hold the Go state
+const inputStreamReaderField = "sd"
+const bufferedReaderField = "in"
+
+// javaReader decodes the bytes of an input stream into Java chars
+type javaReader struct {
+	src          *inputStream
+	lowSurrogate uint16 // the second char of a surrogate pair, still to be read; 0 if none
+}
+
+func Load_Io_Reader() map[string]GMeth {
+
+	MethodSignatures["java/io/InputStreamReader.<init>(Ljava/io/InputStream;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  inputStreamReaderInit,
+			Accepts:    wrapsInputStream,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.<init>(Ljava/io/InputStream;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  inputStreamReaderInit,
+			Accepts:    wrapsInputStream,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.<init>(Ljava/io/InputStream;Ljava/nio/charset/Charset;)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  inputStreamReaderInit,
+			Accepts:    wrapsInputStream,
+		}
+
+	MethodSignatures["java/io/BufferedReader.<init>(Ljava/io/Reader;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  bufferedReaderInit,
+			Accepts:    wrapsReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.<init>(Ljava/io/Reader;I)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  bufferedReaderInit,
+			Accepts:    wrapsReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.readLine()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerReadLine,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.read()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerRead,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.read([C)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  readerReadChars,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.read([CII)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  readerReadChars,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.ready()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerReady,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/InputStreamReader.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerClose,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.read()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerRead,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.read([C)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  readerReadChars,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.read([CII)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  readerReadChars,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.ready()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerReady,
+			Accepts:    hasReader,
+		}
+
+	MethodSignatures["java/io/BufferedReader.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  readerClose,
+			Accepts:    hasReader,
+		}
+
+	return MethodSignatures
+}
+
+// getReader returns the Go state of an InputStreamReader or BufferedReader
+func getReader(obj *object.Object) (*javaReader, bool) {
+	if obj == nil || obj.FieldTable == nil {
+		return nil, false
+	}
+	for _, field := range []string{inputStreamReaderField, bufferedReaderField} {
+		if r, ok := obj.FieldTable[field].Fvalue.(*javaReader); ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// hasReader is true if the reader in params[0] reads an InputStream implemented
+// in Go. The Go functions here handle only those readers; the JDK's code handles
+// the others.
+func hasReader(params []interface{}) bool {
+	obj, _ := params[0].(*object.Object)
+	_, ok := getReader(obj)
+	return ok
+}
+
+// wrapsReader is true if the Reader in params[1], which a BufferedReader is
+// being constructed on, reads an InputStream implemented in Go
+func wrapsReader(params []interface{}) bool {
+	return hasReader(params[1:])
+}
+
+// readerThis returns the Go state of the reader in params[0], and an
+// exception if it's not usable
+func readerThis(params []interface{}) (*javaReader, error) {
+	obj, _ := params[0].(*object.Object)
+	r, ok := getReader(obj)
+	if !ok {
+		return nil, unsupportedStream(obj)
+	}
+	if r.src.closed {
+		return nil, streamClosed()
+	}
+	return r, nil
+}
+
+func setReader(this *object.Object, field string, r *javaReader) {
+	if this.FieldTable == nil {
+		this.FieldTable = make(map[string]object.Field)
+	}
+	this.FieldTable[field] = object.Field{Ftype: types.GoObject, Fvalue: r}
+}
+
+// readChar returns the next Java char, or -1 at the end of the stream. A
+// character outside the BMP is returned as two chars, a surrogate pair.
+// The caller must hold the source's mutex.
+func (r *javaReader) readChar() int64 {
+	if r.lowSurrogate != 0 {
+		c := r.lowSurrogate
+		r.lowSurrogate = 0
+		return int64(c)
+	}
+	ch, _, err := r.src.in.ReadRune()
+	if err != nil {
+		return -1
+	}
+	if ch >= 0x10000 {
+		high, low := utf16.EncodeRune(ch)
+		r.lowSurrogate = uint16(low)
+		return int64(high)
+	}
+	return int64(ch)
+}
+
+// readLine returns the chars up to the next line terminator ("\n", "\r", or
+// "\r\n"), which is consumed but not returned, and false at the end of the
+// stream if there are no chars to return. The caller must hold the source's mutex.
+func (r *javaReader) readLine() ([]uint16, bool) {
+	line := []uint16{}
+	for {
+		c := r.readChar()
+		switch c {
+		case -1:
+			return line, len(line) > 0
+		case '\n':
+			return line, true
+		case '\r':
+			if next, err := r.src.in.Peek(1); err == nil && next[0] == '\n' {
+				_, _ = r.src.in.ReadByte()
+			}
+			return line, true
+		}
+		line = append(line, uint16(c))
+	}
+}
+
+// InputStreamReader(InputStream), (InputStream, String charsetName) and
+// (InputStream, Charset)
+func inputStreamReaderInit(params []interface{}) interface{} {
+	in, _ := params[1].(*object.Object)
+	if in == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "InputStreamReader.<init>(): null InputStream")
+	}
+	is, ok := getInputStream(in)
+	if !ok {
+		return unsupportedStream(in)
+	}
+	setReader(params[0].(*object.Object), inputStreamReaderField, &javaReader{src: is})
+	return nil
+}
+
+// BufferedReader(Reader) and BufferedReader(Reader, int size)
+func bufferedReaderInit(params []interface{}) interface{} {
+	in, _ := params[1].(*object.Object)
+	if in == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "BufferedReader.<init>(): null Reader")
+	}
+	if len(params) > 2 && params[2].(int64) <= 0 {
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", "Buffer size <= 0")
+	}
+	r, ok := getReader(in)
+	if !ok {
+		return unsupportedStream(in)
+	}
+	setReader(params[0].(*object.Object), bufferedReaderField, r)
+	return nil
+}
+
+// read() returns the next char, or -1 at the end of the stream
+func readerRead(params []interface{}) interface{} {
+	r, err := readerThis(params)
+	if err != nil {
+		return err
+	}
+	r.src.mutex.Lock()
+	defer r.src.mutex.Unlock()
+	return r.readChar()
+}
+
+// read(char[]) and read(char[], int off, int len) read at least one char,
+// waiting for input if necessary, and then as many more as are buffered.
+// They return the number of chars read, or -1 at the end of the stream.
+func readerReadChars(params []interface{}) interface{} {
+	arr, _ := params[1].(*object.Object)
+	if arr == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Reader.read(): null array")
+	}
+	chars := *(arr.Fields[0].Fvalue.(*[]int64))
+	if len(params) > 2 {
+		offset, length := params[2].(int64), params[3].(int64)
+		if offset < 0 || length < 0 || offset > int64(len(chars))-length {
+			return throwNativeException(exceptions.IndexOutOfBoundsException,
+				"java.lang.IndexOutOfBoundsException",
+				fmt.Sprintf("Range [%d, %d + %d) out of bounds for length %d", offset, offset, length, len(chars)))
+		}
+		chars = chars[offset : offset+length]
+	}
+
+	r, err := readerThis(params)
+	if err != nil {
+		return err
+	}
+	if len(chars) == 0 {
+		return int64(0)
+	}
+	r.src.mutex.Lock()
+	defer r.src.mutex.Unlock()
+	n := 0
+	for n < len(chars) && (n == 0 || r.lowSurrogate != 0 || r.src.in.Buffered() > 0) {
+		c := r.readChar()
+		if c == -1 {
+			break
+		}
+		chars[n] = c
+		n++
+	}
+	if n == 0 {
+		return int64(-1)
+	}
+	return int64(n)
+}
+
+// BufferedReader.readLine() returns the next line, without its terminator,
+// or null at the end of the stream
+func readerReadLine(params []interface{}) interface{} {
+	r, err := readerThis(params)
+	if err != nil {
+		return err
+	}
+	r.src.mutex.Lock()
+	defer r.src.mutex.Unlock()
+	line, ok := r.readLine()
+	if !ok {
+		return object.Null
+	}
+	return newJavaString(line)
+}
+
+// ready() is true if a char can be read without waiting
+func readerReady(params []interface{}) interface{} {
+	r, err := readerThis(params)
+	if err != nil {
+		return err
+	}
+	r.src.mutex.Lock()
+	defer r.src.mutex.Unlock()
+	return types.ConvertGoBoolToJavaBool(r.lowSurrogate != 0 || r.src.in.Buffered() > 0)
+}
+
+// close() closes the underlying stream
+func readerClose(params []interface{}) interface{} {
+	obj, _ := params[0].(*object.Object)
+	if r, ok := getReader(obj); ok {
+		r.src.mutex.Lock()
+		r.src.closed = true
+		r.src.mutex.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/object"
+	"jacobin/types"
+	"strings"
+	"sync"
+)
+
+// Implementation of java/lang/Boolean. As in the JDK, valueOf() always returns
+// one of the two canonical instances, Boolean.TRUE and Boolean.FALSE.
+
+func Load_Lang_Boolean() map[string]GMeth {
+
+	MethodSignatures["java/lang/Boolean.<init>(Z)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Boolean.valueOf(Z)Ljava/lang/Boolean;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanValueOf,
+		}
+
+	MethodSignatures["java/lang/Boolean.valueOf(Ljava/lang/String;)Ljava/lang/Boolean;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanValueOfString,
+		}
+
+	MethodSignatures["java/lang/Boolean.parseBoolean(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanParseBoolean,
+		}
+
+	MethodSignatures["java/lang/Boolean.getBoolean(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanGetBoolean,
+		}
+
+	MethodSignatures["java/lang/Boolean.booleanValue()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Boolean.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Boolean.toString(Z)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanToString,
+		}
+
+	MethodSignatures["java/lang/Boolean.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanHashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Boolean.hashCode(Z)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  booleanHashCode,
+		}
+
+	MethodSignatures["java/lang/Boolean.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Boolean.compareTo(Ljava/lang/Boolean;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanCompareTo,
+		}
+
+	MethodSignatures["java/lang/Boolean.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanCompareTo,
+		}
+
+	MethodSignatures["java/lang/Boolean.compare(ZZ)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanCompare,
+		}
+
+	MethodSignatures["java/lang/Boolean.logicalAnd(ZZ)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanLogicalAnd,
+		}
+
+	MethodSignatures["java/lang/Boolean.logicalOr(ZZ)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanLogicalOr,
+		}
+
+	MethodSignatures["java/lang/Boolean.logicalXor(ZZ)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  booleanLogicalXor,
+		}
+
+	return MethodSignatures
+}
+
+// the canonical Boolean.TRUE and Boolean.FALSE, created on first use
+var booleanTrue, booleanFalse *object.Object
+var booleanOnce sync.Once
+
+// boxBoolean returns Boolean.TRUE or Boolean.FALSE
+func boxBoolean(b bool) *object.Object {
+	booleanOnce.Do(func() {
+		booleanTrue = newBox("java/lang/Boolean", types.JavaBoolTrue)
+		booleanFalse = newBox("java/lang/Boolean", types.JavaBoolFalse)
+	})
+	if b {
+		return booleanTrue
+	}
+	return booleanFalse
+}
+
+// parseBoolean returns true only if the string is "true", ignoring case. A
+// null string is false.
+func parseBoolean(obj *object.Object) bool {
+	if !object.IsJavaString(obj) {
+		return false
+	}
+	return strings.EqualFold(object.GetGoStringFromJavaStringPtr(obj), "true")
+}
+
+func booleanValueOf(params []interface{}) interface{} {
+	return boxBoolean(params[0].(int64) == types.JavaBoolTrue)
+}
+
+func booleanValueOfString(params []interface{}) interface{} {
+	str, _ := params[0].(*object.Object)
+	return boxBoolean(parseBoolean(str))
+}
+
+func booleanParseBoolean(params []interface{}) interface{} {
+	str, _ := params[0].(*object.Object)
+	return types.ConvertGoBoolToJavaBool(parseBoolean(str))
+}
+
+// getBoolean(String name) is true only if the system property is "true",
+// ignoring case
+func booleanGetBoolean(params []interface{}) interface{} {
+	nameObj, _ := params[0].(*object.Object)
+	if nameObj == nil {
+		return types.JavaBoolFalse
+	}
+	value, _ := getSystemProperties().get(object.GetGoStringFromJavaStringPtr(nameObj))
+	return types.ConvertGoBoolToJavaBool(strings.EqualFold(value, "true"))
+}
+
+func booleanToString(params []interface{}) interface{} {
+	if params[0].(int64) == types.JavaBoolTrue {
+		return goStringToJava("true")
+	}
+	return goStringToJava("false")
+}
+
+// hashCode(boolean) is 1231 for true and 1237 for false, as in the JDK
+func booleanHashCode(params []interface{}) interface{} {
+	if params[0].(int64) == types.JavaBoolTrue {
+		return int64(1231)
+	}
+	return int64(1237)
+}
+
+func booleanHashCodeMethod(params []interface{}) interface{} {
+	return booleanHashCode([]interface{}{unboxThis(params)})
+}
+
+// compare(boolean, boolean) orders false before true
+func booleanCompare(params []interface{}) interface{} {
+	return compareInt64(params[0].(int64), params[1].(int64))
+}
+
+func booleanCompareTo(params []interface{}) interface{} {
+	that, _ := params[1].(*object.Object)
+	thatVal, ok := unboxValue(that)
+	if !ok {
+		return stringNPE("compareTo")
+	}
+	return compareInt64(unboxThis(params).(int64), thatVal.(int64))
+}
+
+func booleanLogicalAnd(params []interface{}) interface{} {
+	return params[0].(int64) & params[1].(int64)
+}
+
+func booleanLogicalOr(params []interface{}) interface{} {
+	return params[0].(int64) | params[1].(int64)
+}
+
+func booleanLogicalXor(params []interface{}) interface{} {
+	return params[0].(int64) ^ params[1].(int64)
+}
@@ -0,0 +1,451 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Implementation of java/lang/Character. Most of the static methods come in two
+// versions: one that takes a char and one that takes an int code point. Since
+// both arrive as int64 values, they share the same functions.
+
+func Load_Lang_Character() map[string]GMeth {
+
+	MethodSignatures["java/lang/Character.<init>(C)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Character.valueOf(C)Ljava/lang/Character;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterValueOf,
+		}
+
+	MethodSignatures["java/lang/Character.charValue()C"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Character.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Character.toString(C)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToString,
+		}
+
+	MethodSignatures["java/lang/Character.toString(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToString,
+		}
+
+	MethodSignatures["java/lang/Character.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Character.hashCode(C)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterHashCode,
+		}
+
+	MethodSignatures["java/lang/Character.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Character.compareTo(Ljava/lang/Character;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterCompareTo,
+		}
+
+	MethodSignatures["java/lang/Character.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterCompareTo,
+		}
+
+	MethodSignatures["java/lang/Character.compare(CC)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterCompare,
+		}
+
+	MethodSignatures["java/lang/Character.isDigit(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsDigit,
+		}
+
+	MethodSignatures["java/lang/Character.isLetter(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLetter,
+		}
+
+	MethodSignatures["java/lang/Character.isLetterOrDigit(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLetterOrDigit,
+		}
+
+	MethodSignatures["java/lang/Character.isUpperCase(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsUpperCase,
+		}
+
+	MethodSignatures["java/lang/Character.isLowerCase(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLowerCase,
+		}
+
+	MethodSignatures["java/lang/Character.isWhitespace(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsWhitespace,
+		}
+
+	MethodSignatures["java/lang/Character.isSpaceChar(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsSpaceChar,
+		}
+
+	MethodSignatures["java/lang/Character.toUpperCase(C)C"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToUpperCase,
+		}
+
+	MethodSignatures["java/lang/Character.toLowerCase(C)C"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToLowerCase,
+		}
+
+	MethodSignatures["java/lang/Character.digit(CI)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterDigit,
+		}
+
+	MethodSignatures["java/lang/Character.getNumericValue(C)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterGetNumericValue,
+		}
+
+	MethodSignatures["java/lang/Character.isDigit(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsDigit,
+		}
+
+	MethodSignatures["java/lang/Character.isLetter(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLetter,
+		}
+
+	MethodSignatures["java/lang/Character.isLetterOrDigit(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLetterOrDigit,
+		}
+
+	MethodSignatures["java/lang/Character.isUpperCase(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsUpperCase,
+		}
+
+	MethodSignatures["java/lang/Character.isLowerCase(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLowerCase,
+		}
+
+	MethodSignatures["java/lang/Character.isWhitespace(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsWhitespace,
+		}
+
+	MethodSignatures["java/lang/Character.isSpaceChar(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsSpaceChar,
+		}
+
+	MethodSignatures["java/lang/Character.toUpperCase(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToUpperCase,
+		}
+
+	MethodSignatures["java/lang/Character.toLowerCase(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToLowerCase,
+		}
+
+	MethodSignatures["java/lang/Character.digit(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterDigit,
+		}
+
+	MethodSignatures["java/lang/Character.getNumericValue(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterGetNumericValue,
+		}
+
+	MethodSignatures["java/lang/Character.isAlphabetic(I)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsAlphabetic,
+		}
+
+	MethodSignatures["java/lang/Character.forDigit(II)C"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  characterForDigit,
+		}
+
+	MethodSignatures["java/lang/Character.isSurrogate(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsSurrogate,
+		}
+
+	MethodSignatures["java/lang/Character.isHighSurrogate(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsHighSurrogate,
+		}
+
+	MethodSignatures["java/lang/Character.isLowSurrogate(C)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterIsLowSurrogate,
+		}
+
+	MethodSignatures["java/lang/Character.charCount(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterCharCount,
+		}
+
+	MethodSignatures["java/lang/Character.toChars(I)[C"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  characterToChars,
+		}
+
+	return MethodSignatures
+}
+
+// valueOf(char) returns the boxed char, cached for chars 0 to 127
+func characterValueOf(params []interface{}) interface{} {
+	return box("java/lang/Character", params[0].(int64))
+}
+
+// toString(char) and toString(int codePoint)
+func characterToString(params []interface{}) interface{} {
+	return newJavaString(codePointToChars(params[0].(int64)))
+}
+
+func characterHashCode(params []interface{}) interface{} {
+	return params[0].(int64)
+}
+
+// compare(char, char) returns the difference between the chars
+func characterCompare(params []interface{}) interface{} {
+	return params[0].(int64) - params[1].(int64)
+}
+
+func characterCompareTo(params []interface{}) interface{} {
+	that, _ := params[1].(*object.Object)
+	thatVal, ok := unboxValue(that)
+	if !ok {
+		return stringNPE("compareTo")
+	}
+	return unboxThis(params).(int64) - thatVal.(int64)
+}
+
+// the char or code point passed to a static method
+func charParam(params []interface{}) rune {
+	return rune(params[0].(int64))
+}
+
+func characterIsDigit(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(unicode.IsDigit(charParam(params)))
+}
+
+func characterIsLetter(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(unicode.IsLetter(charParam(params)))
+}
+
+func characterIsLetterOrDigit(params []interface{}) interface{} {
+	r := charParam(params)
+	return types.ConvertGoBoolToJavaBool(unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func characterIsAlphabetic(params []interface{}) interface{} {
+	r := charParam(params)
+	return types.ConvertGoBoolToJavaBool(unicode.IsLetter(r) ||
+		unicode.In(r, unicode.Nl, unicode.Other_Alphabetic))
+}
+
+func characterIsUpperCase(params []interface{}) interface{} {
+	r := charParam(params)
+	return types.ConvertGoBoolToJavaBool(unicode.IsUpper(r) || unicode.Is(unicode.Other_Uppercase, r))
+}
+
+func characterIsLowerCase(params []interface{}) interface{} {
+	r := charParam(params)
+	return types.ConvertGoBoolToJavaBool(unicode.IsLower(r) || unicode.Is(unicode.Other_Lowercase, r))
+}
+
+func characterIsWhitespace(params []interface{}) interface{} {
+	r := charParam(params)
+	return types.ConvertGoBoolToJavaBool(r <= 0xFFFF && isJavaWhitespace(uint16(r)))
+}
+
+func characterIsSpaceChar(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(unicode.In(charParam(params), unicode.Zs, unicode.Zl, unicode.Zp))
+}
+
+// toUpperCase() and toLowerCase() use the one-to-one case mappings, so, as in
+// the JDK, Character.toUpperCase('ß') is 'ß'.
+func characterToUpperCase(params []interface{}) interface{} {
+	return int64(unicode.ToUpper(charParam(params)))
+}
+
+func characterToLowerCase(params []interface{}) interface{} {
+	return int64(unicode.ToLower(charParam(params)))
+}
+
+// digitValue returns the value of a char as a digit in radix 36: decimal
+// digits (in any script) are 0-9 and Latin letters, including the full-width
+// forms, are 10-35. It returns -1 for all other chars.
+func digitValue(r rune) int64 {
+	switch {
+	case r >= '0' && r <= '9':
+		return int64(r - '0')
+	case r >= 'a' && r <= 'z':
+		return int64(r-'a') + 10
+	case r >= 'A' && r <= 'Z':
+		return int64(r-'A') + 10
+	case r >= 0xFF21 && r <= 0xFF3A: // full-width A-Z
+		return int64(r-0xFF21) + 10
+	case r >= 0xFF41 && r <= 0xFF5A: // full-width a-z
+		return int64(r-0xFF41) + 10
+	case unicode.IsDigit(r):
+		// the decimal digits of every script are in runs of 10, from 0 to 9
+		for _, rng := range unicode.Nd.R16 {
+			if r >= rune(rng.Lo) && r <= rune(rng.Hi) {
+				return int64(r-rune(rng.Lo)) % 10
+			}
+		}
+		for _, rng := range unicode.Nd.R32 {
+			if r >= rune(rng.Lo) && r <= rune(rng.Hi) {
+				return int64(r-rune(rng.Lo)) % 10
+			}
+		}
+	}
+	return -1
+}
+
+// digit(char, int radix) returns the value of the char as a digit in the
+// radix, or -1 if it's not a valid digit in that radix
+func characterDigit(params []interface{}) interface{} {
+	radix := params[1].(int64)
+	if radix < 2 || radix > 36 {
+		return int64(-1)
+	}
+	value := digitValue(charParam(params))
+	if value >= radix {
+		return int64(-1)
+	}
+	return value
+}
+
+func characterGetNumericValue(params []interface{}) interface{} {
+	return digitValue(charParam(params))
+}
+
+// forDigit(int digit, int radix) returns the char for the digit, using
+// lowercase letters for digits > 9, or '\u0000' if the digit is invalid
+func characterForDigit(params []interface{}) interface{} {
+	digit, radix := params[0].(int64), params[1].(int64)
+	if radix < 2 || radix > 36 || digit < 0 || digit >= radix {
+		return int64(0)
+	}
+	if digit < 10 {
+		return '0' + digit
+	}
+	return 'a' + digit - 10
+}
+
+func characterIsSurrogate(params []interface{}) interface{} {
+	c := uint16(params[0].(int64))
+	return types.ConvertGoBoolToJavaBool(isHighSurrogate(c) || isLowSurrogate(c))
+}
+
+func characterIsHighSurrogate(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(isHighSurrogate(uint16(params[0].(int64))))
+}
+
+func characterIsLowSurrogate(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(isLowSurrogate(uint16(params[0].(int64))))
+}
+
+// charCount(int codePoint) is 2 for supplementary chars, otherwise 1
+func characterCharCount(params []interface{}) interface{} {
+	if params[0].(int64) >= 0x10000 {
+		return int64(2)
+	}
+	return int64(1)
+}
+
+// toChars(int codePoint) returns the one or two chars for the code point
+func characterToChars(params []interface{}) interface{} {
+	cp := params[0].(int64)
+	if cp < 0 || cp > unicode.MaxRune {
+		return throwNativeException(exceptions.IllegalArgumentException, "java.lang.IllegalArgumentException",
+			"Not a valid Unicode code point: 0x"+strings.ToUpper(strconv.FormatUint(uint64(uint32(cp)), 16)))
+	}
+	chars := codePointToChars(cp)
+	arr := object.Make1DimArray(object.INT, int64(len(chars)))
+	values := *(arr.Fields[0].Fvalue.(*[]int64))
+	for i, c := range chars {
+		values[i] = int64(c)
+	}
+	return arr
+}
@@ -0,0 +1,106 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/object"
+	"jacobin/types"
+	"testing"
+)
+
+func TestCharacterValueOfCache(t *testing.T) {
+	if characterValueOf([]interface{}{int64('a')}) != characterValueOf([]interface{}{int64('a')}) {
+		t.Errorf("Expected Character.valueOf('a') to return the cached instance")
+	}
+	if characterValueOf([]interface{}{int64('é')}) == characterValueOf([]interface{}{int64('é')}) {
+		t.Errorf("Expected Character.valueOf('é') to return a new instance")
+	}
+	if got := javaToString(characterValueOf([]interface{}{int64('é')}).(*object.Object)); got != "é" {
+		t.Errorf("Expected toString() to be 'é', got '%s'", got)
+	}
+}
+
+func TestCharacterClassification(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func([]interface{}) interface{}
+		c        rune
+		expected int64
+	}{
+		{"isDigit", characterIsDigit, '7', types.JavaBoolTrue},
+		{"isDigit", characterIsDigit, '٣', types.JavaBoolTrue}, // Arabic-Indic 3
+		{"isDigit", characterIsDigit, 'x', types.JavaBoolFalse},
+		{"isLetter", characterIsLetter, 'ж', types.JavaBoolTrue},
+		{"isLetterOrDigit", characterIsLetterOrDigit, '_', types.JavaBoolFalse},
+		{"isUpperCase", characterIsUpperCase, 'Q', types.JavaBoolTrue},
+		{"isLowerCase", characterIsLowerCase, 'Q', types.JavaBoolFalse},
+		{"isWhitespace", characterIsWhitespace, '\t', types.JavaBoolTrue},
+		{"isWhitespace", characterIsWhitespace, 0xA0, types.JavaBoolFalse}, // non-breaking space
+		{"isSpaceChar", characterIsSpaceChar, 0xA0, types.JavaBoolTrue},
+		{"isHighSurrogate", characterIsHighSurrogate, 0xD83D, types.JavaBoolTrue},
+		{"isSurrogate", characterIsSurrogate, 'a', types.JavaBoolFalse},
+	}
+	for _, tc := range tests {
+		if got := tc.fn([]interface{}{int64(tc.c)}); got != tc.expected {
+			t.Errorf("%s(%q): expected %d, got %v", tc.name, tc.c, tc.expected, got)
+		}
+	}
+}
+
+func TestCharacterDigits(t *testing.T) {
+	if got := characterDigit([]interface{}{int64('f'), int64(16)}); got != int64(15) {
+		t.Errorf("Expected digit('f', 16) to be 15, got %v", got)
+	}
+	if got := characterDigit([]interface{}{int64('g'), int64(16)}); got != int64(-1) {
+		t.Errorf("Expected digit('g', 16) to be -1, got %v", got)
+	}
+	if got := characterDigit([]interface{}{int64('٣'), int64(10)}); got != int64(3) {
+		t.Errorf("Expected digit('٣', 10) to be 3, got %v", got)
+	}
+	if got := characterForDigit([]interface{}{int64(11), int64(16)}); got != int64('b') {
+		t.Errorf("Expected forDigit(11, 16) to be 'b', got %v", got)
+	}
+	if got := characterForDigit([]interface{}{int64(11), int64(10)}); got != int64(0) {
+		t.Errorf("Expected forDigit(11, 10) to be 0, got %v", got)
+	}
+}
+
+func TestCharacterCaseAndCodePoints(t *testing.T) {
+	if got := characterToUpperCase([]interface{}{int64('ß')}); got != int64('ß') {
+		t.Errorf("Expected toUpperCase('ß') to be unchanged, got %v", got)
+	}
+	if got := characterToLowerCase([]interface{}{int64('Ä')}); got != int64('ä') {
+		t.Errorf("Expected toLowerCase('Ä') to be 'ä', got %v", got)
+	}
+	if got := characterCharCount([]interface{}{int64(0x1F600)}); got != int64(2) {
+		t.Errorf("Expected charCount(U+1F600) to be 2, got %v", got)
+	}
+	if got := gostr(t, characterToString([]interface{}{int64(0x1F600)})); got != "😀" {
+		t.Errorf("Expected Character.toString(0x1F600) to be '😀', got '%s'", got)
+	}
+}
+
+func TestBoolean(t *testing.T) {
+	if booleanValueOfString([]interface{}{jstr("TRUE")}) != boxBoolean(true) {
+		t.Errorf("Expected Boolean.valueOf(\"TRUE\") to be Boolean.TRUE")
+	}
+	if booleanValueOfString([]interface{}{jstr("yes")}) != boxBoolean(false) {
+		t.Errorf("Expected Boolean.valueOf(\"yes\") to be Boolean.FALSE")
+	}
+	if booleanParseBoolean([]interface{}{nil}) != types.JavaBoolFalse {
+		t.Errorf("Expected Boolean.parseBoolean(null) to be false")
+	}
+	if got := booleanHashCode([]interface{}{types.JavaBoolTrue}); got != int64(1231) {
+		t.Errorf("Expected Boolean.hashCode(true) to be 1231, got %v", got)
+	}
+	if got := booleanCompare([]interface{}{types.JavaBoolFalse, types.JavaBoolTrue}); got != int64(-1) {
+		t.Errorf("Expected Boolean.compare(false, true) to be -1, got %v", got)
+	}
+	if got := javaToString(boxBoolean(true)); got != "true" {
+		t.Errorf("Expected Boolean.TRUE.toString() to be 'true', got '%s'", got)
+	}
+}
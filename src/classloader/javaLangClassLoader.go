@@ -0,0 +1,824 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Implementation of java/lang/ClassLoader, so that applications can write their
+// own classloaders. Each java/lang/ClassLoader object is backed by a Classloader,
+// which holds the loader's namespace and which is the defining loader recorded in
+// Klass.Loader for the classes the Java code defines with defineClass(). Its parent
+// is the Classloader of the Java parent, or the bootstrap classloader if that's null.
+//
+// getSystemClassLoader() returns the object that stands for the application
+// classloader, whose parent is the object that stands for the extension
+// classloader, which is the JDK's platform classloader. Its parent, like that
+// of all the classloaders whose parent is the bootstrap classloader, is null.
+//
+// When a class defined by a classloader written in Java refers to another class,
+// the class is loaded by calling the loadClass() method of that classloader, as
+// the JVM specification requires. Native functions can't run Java methods, so
+// the jvm package registers a function for this with SetJavaMethodRunner().
+
+const classLoaderDesc = "Ljava/lang/ClassLoader;"
+const loadClassDesc = "(Ljava/lang/String;)Ljava/lang/Class;"
+const findResourceDesc = "(Ljava/lang/String;)Ljava/net/URL;"
+
+// the fields of java/lang/ClassLoader objects
+const classLoaderGoField = "classloader" // the Classloader behind the object
+const classLoaderParentField = "parent"
+const classLoaderNameField = "name"
+
+// JavaMethodRunner runs the Java method of the object's class with the given name
+// and type, passing it the arguments, and returns the value the method returns.
+type JavaMethodRunner func(obj *object.Object, methName, methType string, args ...interface{}) (interface{}, error)
+
+var javaMethodRunner JavaMethodRunner
+
+// SetJavaMethodRunner registers the function that runs Java methods on behalf of
+// the classloaders written in Java. It's called by the jvm package at start-up.
+func SetJavaMethodRunner(runner JavaMethodRunner) {
+	javaMethodRunner = runner
+}
+
+// the number of classloaders written in Java, which is used to make their names unique
+var javaClassloaderCount atomic.Int64
+
+var systemLoaderObj, platformLoaderObj *object.Object
+var systemLoaderMutex sync.Mutex
+
+func Load_Lang_ClassLoader() map[string]GMeth {
+
+	MethodSignatures["java/lang/ClassLoader.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classLoaderInit,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.<init>(Ljava/lang/ClassLoader;)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the parent
+			GFunction:  classLoaderInitParent,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.<init>(Ljava/lang/String;Ljava/lang/ClassLoader;)V"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = the name, [2] = the parent
+			GFunction:  classLoaderInitNameParent,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getSystemClassLoader()Ljava/lang/ClassLoader;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getSystemClassLoader,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getPlatformClassLoader()Ljava/lang/ClassLoader;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getPlatformClassLoader,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getParent()Ljava/lang/ClassLoader;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classLoaderGetParent,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classLoaderGetName,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.defineClass(Ljava/lang/String;[BII)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 5, // [0] = this, [1] = the name, [2] = the bytes, [3] = offset, [4] = length
+			GFunction:  classLoaderDefineClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.defineClass([BII)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 4, // the deprecated form without the name
+			GFunction:  classLoaderDefineClassNoName,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.findLoadedClass(Ljava/lang/String;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderFindLoadedClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.loadClass(Ljava/lang/String;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderLoadClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.loadClass(Ljava/lang/String;Z)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 3, // linking is done when the class is used, so resolve is ignored
+			GFunction:  classLoaderLoadClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.findClass(Ljava/lang/String;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderFindClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.findSystemClass(Ljava/lang/String;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderFindSystemClass,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.resolveClass(Ljava/lang/Class;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderResolveClass,
+		}
+
+	MethodSignatures["java/lang/Class.getClassLoader()Ljava/lang/ClassLoader;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classGetClassLoader,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getResource(Ljava/lang/String;)Ljava/net/URL;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderGetResource,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getResources(Ljava/lang/String;)Ljava/util/Enumeration;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderGetResources,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getResourceAsStream(Ljava/lang/String;)Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderGetResourceAsStream,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.findResource(Ljava/lang/String;)Ljava/net/URL;"] =
+		GMeth{
+			ParamSlots: 2, // the JDK's finds nothing; classloaders override it
+			GFunction:  classLoaderFindResource,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.findResources(Ljava/lang/String;)Ljava/util/Enumeration;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classLoaderFindResources,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getSystemResource(Ljava/lang/String;)Ljava/net/URL;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  getSystemResource,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getSystemResources(Ljava/lang/String;)Ljava/util/Enumeration;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  getSystemResources,
+		}
+
+	MethodSignatures["java/lang/ClassLoader.getSystemResourceAsStream(Ljava/lang/String;)Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  getSystemResourceAsStream,
+		}
+
+	MethodSignatures["java/lang/Class.getResource(Ljava/lang/String;)Ljava/net/URL;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classGetResource,
+		}
+
+	MethodSignatures["java/lang/Class.getResourceAsStream(Ljava/lang/String;)Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  classGetResourceAsStream,
+		}
+
+	return MethodSignatures
+}
+
+// ---- creating classloaders ----
+
+func classLoaderInit(params []interface{}) interface{} {
+	initJavaClassloader(params[0].(*object.Object), nil, systemClassLoader())
+	return nil
+}
+
+func classLoaderInitParent(params []interface{}) interface{} {
+	parent, _ := params[1].(*object.Object)
+	initJavaClassloader(params[0].(*object.Object), nil, parent)
+	return nil
+}
+
+func classLoaderInitNameParent(params []interface{}) interface{} {
+	parent, _ := params[2].(*object.Object)
+	name, _ := params[1].(*object.Object)
+	if name != nil && javaToString(name) == "" {
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", "name must be non-empty or null")
+	}
+	initJavaClassloader(params[0].(*object.Object), name, parent)
+	return nil
+}
+
+// initJavaClassloader creates the Classloader behind a classloader written in
+// Java. Its name is the name of the Java class followed by a number, which makes
+// it unique, as the loader's Java name needn't be.
+func initJavaClassloader(loader, name, parent *object.Object) {
+	parentName := BootstrapCL.Name
+	if parentCL := goClassloader(parent); parentCL != nil {
+		parentName = parentCL.Name
+	}
+	cl := &Classloader{
+		Name:       strings.ReplaceAll(ClassNameOfKey(*loader.Klass), "/", ".") + "@" + strconv.FormatInt(javaClassloaderCount.Add(1), 10),
+		Parent:     parentName,
+		Archives:   make(map[string]*Archive),
+		classes:    make(map[string]*Klass),
+		javaLoader: loader,
+	}
+	ClassesLock.Lock()
+	javaClassloaders[cl.Name] = cl
+	ClassesLock.Unlock()
+
+	if loader.FieldTable == nil {
+		loader.FieldTable = make(map[string]object.Field)
+	}
+	loader.FieldTable[classLoaderGoField] = object.Field{Ftype: types.GoObject, Fvalue: cl}
+	loader.FieldTable[classLoaderParentField] = object.Field{Ftype: classLoaderDesc, Fvalue: parent}
+	loader.FieldTable[classLoaderNameField] = object.Field{Ftype: "Ljava/lang/String;", Fvalue: name}
+}
+
+// goClassloader returns the Classloader behind a java/lang/ClassLoader object,
+// or nil if the object is null or hasn't been initialized.
+func goClassloader(loader *object.Object) *Classloader {
+	if loader == nil || loader.FieldTable == nil {
+		return nil
+	}
+	cl, _ := loader.FieldTable[classLoaderGoField].Fvalue.(*Classloader)
+	return cl
+}
+
+// makeLoaderObject returns a java/lang/ClassLoader object that stands for one of
+// Jacobin's own classloaders. The class names are those of the JDK.
+func makeLoaderObject(className string, cl *Classloader, parent *object.Object, name string) *object.Object {
+	obj := object.MakeEmptyObject()
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		classLoaderGoField:     {Ftype: types.GoObject, Fvalue: cl},
+		classLoaderParentField: {Ftype: classLoaderDesc, Fvalue: parent},
+		classLoaderNameField:   {Ftype: "Ljava/lang/String;", Fvalue: goStringToJava(name)},
+	}
+	return obj
+}
+
+// systemClassLoader returns the object for the application classloader, creating
+// it, and the object for its parent, on first use.
+func systemClassLoader() *object.Object {
+	systemLoaderMutex.Lock()
+	defer systemLoaderMutex.Unlock()
+	if systemLoaderObj == nil {
+		platformLoaderObj = makeLoaderObject("jdk/internal/loader/ClassLoaders$PlatformClassLoader",
+			&ExtensionCL, object.Null, "platform")
+		systemLoaderObj = makeLoaderObject("jdk/internal/loader/ClassLoaders$AppClassLoader",
+			&AppCL, platformLoaderObj, "app")
+	}
+	return systemLoaderObj
+}
+
+func getSystemClassLoader([]interface{}) interface{} {
+	return systemClassLoader()
+}
+
+func getPlatformClassLoader([]interface{}) interface{} {
+	systemClassLoader()
+	return platformLoaderObj
+}
+
+func classLoaderGetParent(params []interface{}) interface{} {
+	loader := params[0].(*object.Object)
+	if parent, ok := loader.FieldTable[classLoaderParentField].Fvalue.(*object.Object); ok {
+		return parent
+	}
+	return object.Null
+}
+
+func classLoaderGetName(params []interface{}) interface{} {
+	loader := params[0].(*object.Object)
+	if name, ok := loader.FieldTable[classLoaderNameField].Fvalue.(*object.Object); ok {
+		return name
+	}
+	return object.Null
+}
+
+// classGetClassLoader returns the defining loader of the class. It's null for
+// classes loaded by the bootstrap classloader.
+func classGetClassLoader(params []interface{}) interface{} {
+	k := classObjectKlass(params[0].(*object.Object))
+	if k == nil {
+		return object.Null
+	}
+	switch k.Loader {
+	case AppCL.Name:
+		return systemClassLoader()
+	case ExtensionCL.Name:
+		systemClassLoader()
+		return platformLoaderObj
+	}
+	if cl := getClassloader(k.Loader); cl != nil && cl.javaLoader != nil {
+		return cl.javaLoader
+	}
+	return object.Null
+}
+
+// ---- defining classes ----
+
+func classLoaderDefineClass(params []interface{}) interface{} {
+	name, _ := params[1].(*object.Object)
+	return defineClass(params[0].(*object.Object), name, params[2:])
+}
+
+func classLoaderDefineClassNoName(params []interface{}) interface{} {
+	return defineClass(params[0].(*object.Object), nil, params[1:])
+}
+
+// defineClass defines the class in bytes[offset:offset+length], where the last
+// three parameters are the array, the offset and the length, and returns its
+// Class object. The name, if it's not null, must be the class's name.
+func defineClass(loader, name *object.Object, params []interface{}) interface{} {
+	cl := goClassloader(loader)
+	if cl == nil {
+		return throwNativeException(exceptions.SecurityException,
+			"java.lang.SecurityException", "ClassLoader object not initialized")
+	}
+
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ClassLoader.defineClass(): null array")
+	}
+	bytes := *(arr.Fields[0].Fvalue.(*[]byte))
+	offset, length := params[1].(int64), params[2].(int64)
+	if offset < 0 || length < 0 || offset > int64(len(bytes))-length {
+		return throwNativeException(exceptions.IndexOutOfBoundsException,
+			"java.lang.IndexOutOfBoundsException",
+			fmt.Sprintf("Range [%d, %d + %d) out of bounds for length %d", offset, offset, length, len(bytes)))
+	}
+
+	var className string
+	if name != nil {
+		className = strings.ReplaceAll(javaToString(name), ".", "/")
+		if err := checkProhibitedPackage(cl, className); err != nil {
+			return throwNativeException(exceptions.SecurityException,
+				"java.lang.SecurityException", strings.TrimPrefix(err.Error(), "SecurityException: "))
+		}
+	}
+
+	parsedClass, err := parseClass(className+".class", bytes[offset:offset+length])
+	if err != nil {
+		return throwNativeException(exceptions.LinkageError,
+			"java.lang.ClassFormatError", "Incompatible magic value or malformed class in "+className)
+	}
+	if className != "" && parsedClass.className != className {
+		return throwNativeException(exceptions.LinkageError, "java.lang.NoClassDefFoundError",
+			fmt.Sprintf("%s (wrong name: %s)", className, parsedClass.className))
+	}
+	className = parsedClass.className
+	if cl.FindLoadedClass(className) != nil {
+		return throwNativeException(exceptions.LinkageError, "java.lang.LinkageError",
+			fmt.Sprintf("loader %s attempted duplicate class definition for %s.",
+				cl.Name, strings.ReplaceAll(className, "/", ".")))
+	}
+
+	if _, err = postClass(cl, className+".class", parsedClass); err != nil {
+		return throwNativeException(exceptions.SecurityException,
+			"java.lang.SecurityException", strings.TrimPrefix(err.Error(), "SecurityException: "))
+	}
+	return getClassObject(ClassKey(cl.Name, className))
+}
+
+// ---- finding and loading classes ----
+
+func classLoaderFindLoadedClass(params []interface{}) interface{} {
+	cl := goClassloader(params[0].(*object.Object))
+	name, _ := params[1].(*object.Object)
+	if cl == nil || name == nil {
+		return object.Null
+	}
+	className := strings.ReplaceAll(javaToString(name), ".", "/")
+	k := cl.FindLoadedClass(className)
+	if k == nil {
+		return object.Null
+	}
+	return getClassObject(k.Key())
+}
+
+// loadClass() is parent-first, as the JDK's is: the class is looked for by the
+// parent and if it doesn't have it, by findClass(), which is what classloaders
+// written in Java normally override. A classloader that overrides loadClass()
+// itself doesn't get here.
+func classLoaderLoadClass(params []interface{}) interface{} {
+	cl := goClassloader(params[0].(*object.Object))
+	name, _ := params[1].(*object.Object)
+	if name == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ClassLoader.loadClass(): null name")
+	}
+	if cl == nil {
+		return throwNativeException(exceptions.SecurityException,
+			"java.lang.SecurityException", "ClassLoader object not initialized")
+	}
+
+	className := strings.ReplaceAll(javaToString(name), ".", "/")
+	var k *Klass
+	var err error
+	if cl.javaLoader == nil { // one of Jacobin's own classloaders
+		k, err = cl.LoadClass(className)
+	} else {
+		k, err = cl.loadClassParentFirst(className)
+	}
+	if err != nil {
+		return classLoadingException(err)
+	}
+	return getClassObject(k.Key())
+}
+
+// the JDK's findClass() throws ClassNotFoundException; classloaders override it.
+func classLoaderFindClass(params []interface{}) interface{} {
+	name, _ := params[1].(*object.Object)
+	return classLoadingException(classNotFound(javaToString(name)))
+}
+
+func classLoaderFindSystemClass(params []interface{}) interface{} {
+	name, _ := params[1].(*object.Object)
+	if name == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ClassLoader.findSystemClass(): null name")
+	}
+	k, err := AppCL.LoadClass(javaToString(name))
+	if err != nil {
+		return classLoadingException(err)
+	}
+	return getClassObject(k.Key())
+}
+
+// classes are linked when they're first used, so there's nothing to do
+func classLoaderResolveClass([]interface{}) interface{} {
+	return nil
+}
+
+// loadClassParentFirst is the loadClass() of the classloaders written in Java.
+// The class is recorded in the loader's namespace, whichever loader defined it.
+func (cl *Classloader) loadClassParentFirst(className string) (*Klass, error) {
+	if k := cl.FindLoadedClass(className); k != nil {
+		return k, nil
+	}
+
+	parent := getClassloader(cl.Parent)
+	if parent == nil {
+		parent = &BootstrapCL
+	}
+	k, err := parent.LoadClass(className)
+	if err != nil && !errors.Is(err, ErrClassNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		k, err = cl.findClassInJava(className)
+		if err != nil {
+			return nil, err
+		}
+	}
+	cl.recordClass(className, k)
+	return k, nil
+}
+
+// findClassInJava calls the findClass() of a classloader written in Java. If the
+// classloader doesn't override it, the class isn't found, as in the JDK.
+func (cl *Classloader) findClassInJava(className string) (*Klass, error) {
+	if !cl.overrides("findClass", loadClassDesc) {
+		return nil, classNotFound(className)
+	}
+	return cl.callJavaLoader("findClass", className)
+}
+
+// loadClassWithJava loads the class through the loadClass() method of a
+// classloader written in Java, if it overrides it.
+func (cl *Classloader) loadClassWithJava(className string) (*Klass, error) {
+	if k := cl.FindLoadedClass(className); k != nil {
+		return k, nil
+	}
+	if !cl.overrides("loadClass", loadClassDesc) {
+		return cl.loadClassParentFirst(className)
+	}
+	k, err := cl.callJavaLoader("loadClass", className)
+	if err != nil {
+		return nil, err
+	}
+	cl.recordClass(className, k)
+	return k, nil
+}
+
+// overrides returns whether the class of a classloader written in Java has its
+// own Java version of the method.
+func (cl *Classloader) overrides(methName, methType string) bool {
+	me, err := FetchMethodAndCP(*cl.javaLoader.Klass, methName, methType)
+	return err == nil && me.MType == 'J'
+}
+
+// callJavaLoader calls findClass() or loadClass() of the Java object of the
+// classloader and returns the class of the Class object it returns.
+func (cl *Classloader) callJavaLoader(methName, className string) (*Klass, error) {
+	if javaMethodRunner == nil {
+		return nil, errors.New("no runner for the Java methods of classloaders")
+	}
+	dottedName := strings.ReplaceAll(className, "/", ".")
+	ret, err := javaMethodRunner(cl.javaLoader, methName, loadClassDesc, goStringToJava(dottedName))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "java.lang.ClassNotFoundException") {
+			return nil, classNotFound(className)
+		}
+		return nil, err
+	}
+	clazz, _ := ret.(*object.Object)
+	k := classObjectKlass(clazz)
+	if k == nil {
+		return nil, classNotFound(className)
+	}
+	return k, nil
+}
+
+// classObjectKlass returns the class that a java/lang/Class object stands for, if it's loaded.
+func classObjectKlass(clazz *object.Object) *Klass {
+	if clazz == nil || clazz.FieldTable == nil {
+		return nil
+	}
+	key, ok := clazz.FieldTable[classKeyField].Fvalue.(string)
+	if !ok {
+		name, ok := clazz.FieldTable["name"].Fvalue.(*object.Object)
+		if !ok {
+			return nil
+		}
+		key = strings.ReplaceAll(javaToString(name), ".", "/")
+	}
+	k := MethAreaFetch(key)
+	if k == nil || k.Data == nil {
+		return nil
+	}
+	return k
+}
+
+// classLoadingException throws the Java exception for an error in loading a class.
+func classLoadingException(err error) error {
+	if errors.Is(err, ErrClassNotFound) {
+		return throwNativeException(exceptions.ReflectiveOperationException, "java.lang.ClassNotFoundException",
+			strings.TrimPrefix(err.Error(), ErrClassNotFound.Error()+": "))
+	}
+	if msg, found := strings.CutPrefix(err.Error(), "SecurityException: "); found {
+		return throwNativeException(exceptions.SecurityException, "java.lang.SecurityException", msg)
+	}
+	return throwNativeException(exceptions.LinkageError, "java.lang.NoClassDefFoundError", err.Error())
+}
+
+// ---- resources ----
+
+// resourceURL returns the URL of the resource, or null if there's none
+func resourceURL(cl *Classloader, name string) interface{} {
+	if r := cl.getResource(name); r != nil {
+		return newURLObject(r)
+	}
+	return object.Null
+}
+
+// resourceStream returns an InputStream of the resource, or null if there's
+// none or it can't be read
+func resourceStream(cl *Classloader, name string) interface{} {
+	r := cl.getResource(name)
+	if r == nil {
+		return object.Null
+	}
+	data, err := r.read()
+	if err != nil {
+		return object.Null
+	}
+	return newResourceStream(data)
+}
+
+// resourceParams returns the Classloader in params[0] and the resource name in
+// params[1], and an exception if either isn't usable
+func resourceParams(params []interface{}, methName string) (*Classloader, string, error) {
+	name, _ := params[1].(*object.Object)
+	if name == nil {
+		return nil, "", throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ClassLoader."+methName+"(): null name")
+	}
+	cl := goClassloader(params[0].(*object.Object))
+	if cl == nil {
+		return nil, "", throwNativeException(exceptions.SecurityException,
+			"java.lang.SecurityException", "ClassLoader object not initialized")
+	}
+	return cl, javaToString(name), nil
+}
+
+func classLoaderGetResource(params []interface{}) interface{} {
+	cl, name, err := resourceParams(params, "getResource")
+	if err != nil {
+		return err
+	}
+	return resourceURL(cl, name)
+}
+
+func classLoaderGetResources(params []interface{}) interface{} {
+	cl, name, err := resourceParams(params, "getResources")
+	if err != nil {
+		return err
+	}
+	return newURLEnumeration(cl.getResources(name))
+}
+
+func classLoaderGetResourceAsStream(params []interface{}) interface{} {
+	cl, name, err := resourceParams(params, "getResourceAsStream")
+	if err != nil {
+		return err
+	}
+	return resourceStream(cl, name)
+}
+
+func classLoaderFindResource([]interface{}) interface{} {
+	return object.Null
+}
+
+func classLoaderFindResources([]interface{}) interface{} {
+	return newURLEnumeration(nil)
+}
+
+// the static getSystemResource() methods look for the resource with the application classloader
+func systemResourceName(params []interface{}) (string, error) {
+	name, _ := params[0].(*object.Object)
+	if name == nil {
+		return "", throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ClassLoader.getSystemResource(): null name")
+	}
+	return javaToString(name), nil
+}
+
+func getSystemResource(params []interface{}) interface{} {
+	name, err := systemResourceName(params)
+	if err != nil {
+		return err
+	}
+	return resourceURL(&AppCL, name)
+}
+
+func getSystemResources(params []interface{}) interface{} {
+	name, err := systemResourceName(params)
+	if err != nil {
+		return err
+	}
+	return newURLEnumeration(AppCL.getResources(name))
+}
+
+func getSystemResourceAsStream(params []interface{}) interface{} {
+	name, err := systemResourceName(params)
+	if err != nil {
+		return err
+	}
+	return resourceStream(&AppCL, name)
+}
+
+// classResource returns the defining loader of the class in params[0] and the
+// full name of the resource in params[1]. As in the JDK, a name that starts with
+// / is absolute, and other names are relative to the package of the class.
+func classResource(params []interface{}, methName string) (*Classloader, string, error) {
+	name, _ := params[1].(*object.Object)
+	if name == nil {
+		return nil, "", throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Class."+methName+"(): null name")
+	}
+	resourceName := javaToString(name)
+	clazz := params[0].(*object.Object)
+
+	cl := &AppCL
+	if k := classObjectKlass(clazz); k != nil {
+		if loader := getClassloader(k.Loader); loader != nil {
+			cl = loader
+		}
+	}
+
+	if absName, ok := strings.CutPrefix(resourceName, "/"); ok {
+		return cl, absName, nil
+	}
+	if className, ok := clazz.FieldTable["name"].Fvalue.(*object.Object); ok {
+		binaryName := strings.ReplaceAll(javaToString(className), ".", "/")
+		if i := strings.LastIndex(binaryName, "/"); i > 0 {
+			resourceName = binaryName[:i+1] + resourceName
+		}
+	}
+	return cl, resourceName, nil
+}
+
+func classGetResource(params []interface{}) interface{} {
+	cl, name, err := classResource(params, "getResource")
+	if err != nil {
+		return err
+	}
+	return resourceURL(cl, name)
+}
+
+func classGetResourceAsStream(params []interface{}) interface{} {
+	cl, name, err := classResource(params, "getResourceAsStream")
+	if err != nil {
+		return err
+	}
+	return resourceStream(cl, name)
+}
+
+// findResourceInJava calls the findResource() of a classloader written in Java,
+// if it overrides it. The URL it returns must be one that Jacobin created.
+func (cl *Classloader) findResourceInJava(name string) []*resource {
+	if javaMethodRunner == nil || !cl.overrides("findResource", findResourceDesc) {
+		return nil
+	}
+	ret, err := javaMethodRunner(cl.javaLoader, "findResource", findResourceDesc, goStringToJava(name))
+	if err != nil {
+		_ = log.Log("findResource: "+cl.Name+" could not look for "+name+": "+err.Error(), log.WARNING)
+		return nil
+	}
+	url, _ := ret.(*object.Object)
+	if r := urlResource(url); r != nil {
+		return []*resource{r}
+	}
+	return nil
+}
+
+// ResolveClassRef returns the key of the class that the code of the class with
+// the key referrer refers to by name. The name is resolved in the namespace of
+// the referrer's defining loader. If that's a classloader written in Java, the
+// class is loaded, as the JVM specification requires, by calling its loadClass()
+// unless the loader already has the class. Other references are resolved when
+// the class is used.
+func ResolveClassRef(className, referrer string) (string, error) {
+	k := MethAreaFetch(referrer)
+	if k == nil || k.Data == nil {
+		return className, nil
+	}
+	return k.resolveRef(className)
+}
+
+// cpClassKeysMutex protects the class keys cached in the CPs of all classes
+var cpClassKeysMutex sync.Mutex
+
+// ResolveCPClassRef returns the key of the class named className that the CP
+// entry, a class, field or method reference in the class with the key referrer,
+// refers to (see ResolveClassRef). The reference is resolved only once per CP
+// entry, and the key is then cached in the CP.
+func ResolveCPClassRef(cp *CPool, entry int, className, referrer string) (string, error) {
+	cpClassKeysMutex.Lock()
+	if entry >= 0 && entry < len(cp.ClassKeys) && cp.ClassKeys[entry] != "" {
+		key := cp.ClassKeys[entry]
+		cpClassKeysMutex.Unlock()
+		return key, nil
+	}
+	cpClassKeysMutex.Unlock()
+
+	// the lock isn't held while the class is loaded, as the classloader's Java
+	// code can resolve references of its own
+	key, err := ResolveClassRef(className, referrer)
+	if err != nil || entry < 0 || entry >= len(cp.CpIndex) {
+		return key, err
+	}
+
+	cpClassKeysMutex.Lock()
+	defer cpClassKeysMutex.Unlock()
+	if len(cp.ClassKeys) < len(cp.CpIndex) {
+		keys := make([]string, len(cp.CpIndex))
+		copy(keys, cp.ClassKeys)
+		cp.ClassKeys = keys
+	}
+	cp.ClassKeys[entry] = key
+	return key, nil
+}
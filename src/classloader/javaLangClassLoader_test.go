@@ -0,0 +1,262 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"os"
+	"testing"
+)
+
+// newClassLoaderObject returns a classloader written in Java, whose parent is the system classloader
+func newClassLoaderObject(className string) *object.Object {
+	loader := object.MakeEmptyObject()
+	loader.Klass = &className
+	classLoaderInit([]interface{}{loader})
+	return loader
+}
+
+func javaBytes(b []byte) *object.Object {
+	arr := object.Make1DimArray(object.BYTE, int64(len(b)))
+	copy(*(arr.Fields[0].Fvalue.(*[]byte)), b)
+	return arr
+}
+
+func initClassLoaderTest(t *testing.T) func() {
+	g := globals.InitGlobals("test")
+	log.Init()
+	_ = Init()
+	MTable = make(map[string]MTentry)
+	globals.GetGlobalRef().Classpath = []string{t.TempDir()}
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	return func() {
+		_ = w.Close()
+		os.Stderr = normalStderr
+		globals.GetGlobalRef().Classpath = g.Classpath
+		SetJavaMethodRunner(nil)
+	}
+}
+
+func TestSystemClassLoader(t *testing.T) {
+	defer initClassLoaderTest(t)()
+
+	system := getSystemClassLoader(nil).(*object.Object)
+	if goClassloader(system) != &AppCL || getSystemClassLoader(nil) != system {
+		t.Errorf("Expected the system classloader to be a single object for the app classloader")
+	}
+	platform := classLoaderGetParent([]interface{}{system}).(*object.Object)
+	if platform != getPlatformClassLoader(nil) || goClassloader(platform) != &ExtensionCL {
+		t.Errorf("Expected the parent of the system classloader to be the platform classloader")
+	}
+	if classLoaderGetParent([]interface{}{platform}) != object.Null {
+		t.Errorf("Expected the parent of the platform classloader to be null (the bootstrap classloader)")
+	}
+
+	loader := newClassLoaderObject("com/acme/PluginLoader")
+	if classLoaderGetParent([]interface{}{loader}) != system || goClassloader(loader).Parent != "app" {
+		t.Errorf("Expected a new classloader's default parent to be the system classloader")
+	}
+	if other := newClassLoaderObject("com/acme/PluginLoader"); goClassloader(other).Name == goClassloader(loader).Name {
+		t.Errorf("Expected classloaders of the same class to have different names, got %s",
+			goClassloader(other).Name)
+	}
+
+	orphan := object.MakeEmptyObject()
+	orphanClass := "com/acme/Orphan"
+	orphan.Klass = &orphanClass
+	classLoaderInitParent([]interface{}{orphan, object.Null})
+	if goClassloader(orphan).Parent != "bootstrap" {
+		t.Errorf("Expected a classloader with a null parent to delegate to the bootstrap classloader")
+	}
+}
+
+func TestDefineClass(t *testing.T) {
+	defer initClassLoaderTest(t)()
+
+	loader := newClassLoaderObject("com/acme/PluginLoader")
+	cl := goClassloader(loader)
+	bytes := javaBytes(append([]byte{0, 0}, Hello2Bytes...))
+	length := int64(len(Hello2Bytes))
+
+	ret := classLoaderDefineClass([]interface{}{loader, jstr("Hello2"), bytes, int64(2), length})
+	clazz, ok := ret.(*object.Object)
+	if !ok {
+		t.Fatalf("Expected a Class object from defineClass(), got %v", ret)
+	}
+	if k := MethAreaFetch(ClassKey(cl.Name, "Hello2")); k == nil || k.Loader != cl.Name ||
+		cl.GetCountOfLoadedClasses() != 1 {
+		t.Errorf("Expected Hello2 to be defined by %s", cl.Name)
+	}
+	if classGetClassLoader([]interface{}{clazz}) != loader {
+		t.Errorf("Expected getClassLoader() of the class to return the classloader that defined it")
+	}
+	if classLoaderFindLoadedClass([]interface{}{loader, jstr("Hello2")}) != clazz {
+		t.Errorf("Expected findLoadedClass() to find the class")
+	}
+	system := systemClassLoader()
+	if classLoaderFindLoadedClass([]interface{}{system, jstr("Hello2")}) != object.Null {
+		t.Errorf("Expected findLoadedClass() of another classloader not to find the class")
+	}
+
+	// defining the class a second time is a LinkageError
+	ret = classLoaderDefineClass([]interface{}{loader, jstr("Hello2"), bytes, int64(2), length})
+	if err, ok := ret.(error); !ok || err.Error() != "java.lang.LinkageError: loader "+cl.Name+
+		" attempted duplicate class definition for Hello2." {
+		t.Errorf("Expected a LinkageError for a duplicate definition, got %v", ret)
+	}
+
+	other := newClassLoaderObject("com/acme/PluginLoader")
+	ret = classLoaderDefineClass([]interface{}{other, jstr("com.acme.Hello2"), bytes, int64(2), length})
+	if err, ok := ret.(error); !ok ||
+		err.Error() != "java.lang.NoClassDefFoundError: com/acme/Hello2 (wrong name: Hello2)" {
+		t.Errorf("Expected a NoClassDefFoundError for the wrong name, got %v", ret)
+	}
+	ret = classLoaderDefineClass([]interface{}{other, jstr("java.lang.Hello2"), bytes, int64(2), length})
+	if err, ok := ret.(error); !ok || err.Error() != "java.lang.SecurityException: Prohibited package name: java.lang" {
+		t.Errorf("Expected a SecurityException for a class in java.lang, got %v", ret)
+	}
+	ret = classLoaderDefineClass([]interface{}{other, object.Null, bytes, int64(2), length + 1})
+	if err, ok := ret.(error); !ok || err.Error() != fmt.Sprintf(
+		"java.lang.IndexOutOfBoundsException: Range [2, 2 + %d) out of bounds for length %d", length+1, length+2) {
+		t.Errorf("Expected an IndexOutOfBoundsException for a range beyond the array, got %v", ret)
+	}
+
+	// the other classloader can define the class, without a name. It's another
+	// class, with its own Class object.
+	otherClazz, ok := classLoaderDefineClassNoName([]interface{}{other, bytes, int64(2), length}).(*object.Object)
+	if k := MethAreaFetch(ClassKey(goClassloader(other).Name, "Hello2")); !ok || k == nil ||
+		k.Loader != goClassloader(other).Name {
+		t.Errorf("Expected another classloader to be able to define the class")
+	}
+	if otherClazz == clazz || classGetClassLoader([]interface{}{otherClazz}) != other {
+		t.Errorf("Expected the class defined by another classloader to have its own Class object")
+	}
+	if k := MethAreaFetch(ClassKey(cl.Name, "Hello2")); k == nil || k.Loader != cl.Name {
+		t.Errorf("Expected the class defined by the first classloader to be kept")
+	}
+}
+
+func TestLoadClassThroughJavaClassLoader(t *testing.T) {
+	defer initClassLoaderTest(t)()
+
+	// the classloader's class overrides loadClass() and findClass(); the runner stands in for the JVM
+	CP := CPool{Utf8Refs: []string{"findClass", loadClassDesc, "loadClass"}}
+	code := CodeAttrib{MaxStack: 1, MaxLocals: 2, Code: []byte{0xB1}}
+	MethAreaInsert("com/acme/PluginLoader", &Klass{
+		Status: 'X',
+		Loader: "app",
+		Data: &ClData{Name: "com/acme/PluginLoader", Superclass: "java/lang/ClassLoader", CP: CP,
+			Methods: []Method{{Name: 0, Desc: 1, CodeAttr: code}, {Name: 2, Desc: 1, CodeAttr: code}}},
+	})
+	MethAreaInsert("java/lang/ClassLoader", &Klass{Status: 'X', Loader: "bootstrap", Data: &ClData{Name: "java/lang/ClassLoader"}})
+	loader := newClassLoaderObject("com/acme/PluginLoader")
+	cl := goClassloader(loader)
+
+	var calls []string
+	SetJavaMethodRunner(func(obj *object.Object, methName, methType string, args ...interface{}) (interface{}, error) {
+		name := args[0].(*object.Object)
+		calls = append(calls, methName+" "+javaToString(name))
+		var ret interface{}
+		if methName == "loadClass" { // calls super.loadClass()
+			ret = classLoaderLoadClass([]interface{}{obj, name})
+		} else if javaToString(name) != "Hello2" {
+			ret = classLoaderFindClass([]interface{}{obj, name})
+		} else {
+			ret = classLoaderDefineClass([]interface{}{obj, name, javaBytes(Hello2Bytes), int64(0), int64(len(Hello2Bytes))})
+		}
+		if err, ok := ret.(error); ok {
+			return nil, err
+		}
+		return ret, nil
+	})
+
+	// a class defined by the classloader refers to Hello2, which its parents don't have
+	pluginKey := ClassKey(cl.Name, "com/acme/Plugin")
+	MethAreaInsert(pluginKey, &Klass{Status: 'X', Loader: cl.Name, Data: &ClData{Name: "com/acme/Plugin"}})
+	key, err := ResolveClassRef("Hello2", pluginKey)
+	if err != nil {
+		t.Fatalf("Unexpected error loading the referenced class: %v", err)
+	}
+	if key != "Hello2@"+cl.Name || ClassNameOfKey(key) != "Hello2" {
+		t.Errorf("Expected the key of the class to name its classloader, got %s", key)
+	}
+	if len(calls) != 2 || calls[0] != "loadClass Hello2" || calls[1] != "findClass Hello2" {
+		t.Errorf("Expected the classloader's loadClass() and then its findClass() to be called, got %v", calls)
+	}
+	if k := cl.FindLoadedClass("Hello2"); k == nil || k.Loader != cl.Name {
+		t.Errorf("Expected Hello2 to be defined by the classloader")
+	}
+
+	// the classes referred to by the application's classes are loaded as usual
+	calls = nil
+	if key, _ = ResolveClassRef("com/acme/Other", "com/acme/PluginLoader"); key != "com/acme/Other" || len(calls) != 0 {
+		t.Errorf("Expected no calls to a Java classloader for a class of the app classloader, got %v", calls)
+	}
+
+	// a class that no classloader has is a ClassNotFoundException
+	_, err = cl.loadClassParentFirst("com/acme/Missing")
+	if !errors.Is(err, ErrClassNotFound) {
+		t.Errorf("Expected ClassNotFoundException, got %v", err)
+	}
+}
+
+func TestClassRefsResolveThroughTheReferrersLoader(t *testing.T) {
+	defer initClassLoaderTest(t)()
+
+	// the application classloader has a Hello2 of its own
+	appHello2 := &Klass{Status: 'X', Loader: "app", Data: &ClData{Name: "Hello2"}}
+	MethAreaInsert("Hello2", appHello2)
+	AppCL.recordClass("Hello2", appHello2)
+
+	// the classloader's loadClass() defines the classes itself, without asking its parent
+	CP := CPool{Utf8Refs: []string{"loadClass", loadClassDesc}}
+	code := CodeAttrib{MaxStack: 1, MaxLocals: 2, Code: []byte{0xB1}}
+	MethAreaInsert("com/acme/ChildFirstLoader", &Klass{
+		Status: 'X',
+		Loader: "app",
+		Data: &ClData{Name: "com/acme/ChildFirstLoader", Superclass: "java/lang/ClassLoader", CP: CP,
+			Methods: []Method{{Name: 0, Desc: 1, CodeAttr: code}}},
+	})
+	loader := newClassLoaderObject("com/acme/ChildFirstLoader")
+	cl := goClassloader(loader)
+
+	calls := 0
+	SetJavaMethodRunner(func(obj *object.Object, methName, methType string, args ...interface{}) (interface{}, error) {
+		calls++
+		ret := classLoaderDefineClass([]interface{}{obj, args[0], javaBytes(Hello2Bytes), int64(0), int64(len(Hello2Bytes))})
+		if err, ok := ret.(error); ok {
+			return nil, err
+		}
+		return ret, nil
+	})
+
+	pluginKey := ClassKey(cl.Name, "com/acme/Plugin")
+	MethAreaInsert(pluginKey, &Klass{Status: 'X', Loader: cl.Name, Data: &ClData{Name: "com/acme/Plugin"}})
+	pluginCP := &CPool{CpIndex: make([]CpEntry, 4)}
+	for i := 0; i < 2; i++ {
+		key, err := ResolveCPClassRef(pluginCP, 3, "Hello2", pluginKey)
+		if err != nil || key != ClassKey(cl.Name, "Hello2") {
+			t.Fatalf("Expected the reference to resolve to the classloader's Hello2, got %s, %v", key, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the classloader's loadClass() to be called once for the CP entry, got %d calls", calls)
+	}
+	if MethAreaFetch("Hello2") != appHello2 || MethAreaFetch(ClassKey(cl.Name, "Hello2")) == appHello2 {
+		t.Errorf("Expected the two classes named Hello2 to be kept apart")
+	}
+	if getClassObject(ClassKey(cl.Name, "Hello2")) == getClassObject("Hello2") {
+		t.Errorf("Expected the two classes named Hello2 to have different Class objects")
+	}
+}
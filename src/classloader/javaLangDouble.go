@@ -0,0 +1,729 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Implementation of the wrapper classes for the floating-point primitives:
+// Double and Float. Floats are held as float64 values, so the functions for
+// Float round their results to float32 precision. See javaLangNumber.go for
+// the boxing details.
+
+type floatingWrapper struct {
+	className string
+	prim      string // the type of the primitive: D or F
+	bits      int    // the width of the primitive
+	parseName string // the name of the parse method: parseDouble or parseFloat
+}
+
+var (
+	doubleWrapper = floatingWrapper{className: "java/lang/Double", prim: types.Double, bits: 64, parseName: "parseDouble"}
+	floatWrapper  = floatingWrapper{className: "java/lang/Float", prim: types.Float, bits: 32, parseName: "parseFloat"}
+)
+
+func Load_Lang_Double() map[string]GMeth {
+
+	MethodSignatures["java/lang/Double.<init>(D)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Double.valueOf(D)Ljava/lang/Double;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Double.valueOf(Ljava/lang/String;)Ljava/lang/Double;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  doubleWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Double.parseDouble(Ljava/lang/String;)D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  doubleWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Double.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Double.toString(D)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleWrapper.toString,
+		}
+
+	MethodSignatures["java/lang/Double.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  doubleWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Double.hashCode(D)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Double.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Double.compareTo(Ljava/lang/Double;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleWrapper.compareTo,
+		}
+
+	MethodSignatures["java/lang/Double.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleWrapper.compareTo,
+		}
+
+	MethodSignatures["java/lang/Double.compare(DD)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  doubleWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Double.isNaN()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsNaNMethod,
+		}
+
+	MethodSignatures["java/lang/Double.isNaN(D)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatingIsNaN,
+		}
+
+	MethodSignatures["java/lang/Double.isInfinite()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsInfiniteMethod,
+		}
+
+	MethodSignatures["java/lang/Double.isInfinite(D)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatingIsInfinite,
+		}
+
+	MethodSignatures["java/lang/Double.isFinite(D)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatingIsFinite,
+		}
+
+	MethodSignatures["java/lang/Double.max(DD)D"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  doubleWrapper.max,
+		}
+
+	MethodSignatures["java/lang/Double.min(DD)D"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  doubleWrapper.min,
+		}
+
+	MethodSignatures["java/lang/Double.sum(DD)D"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  doubleWrapper.sum,
+		}
+
+	MethodSignatures["java/lang/Double.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingByteValue,
+		}
+
+	MethodSignatures["java/lang/Double.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingShortValue,
+		}
+
+	MethodSignatures["java/lang/Double.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIntValue,
+		}
+
+	MethodSignatures["java/lang/Double.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingLongValue,
+		}
+
+	MethodSignatures["java/lang/Double.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingFloatValue,
+		}
+
+	MethodSignatures["java/lang/Double.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Float.<init>(F)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Float.valueOf(F)Ljava/lang/Float;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Float.valueOf(Ljava/lang/String;)Ljava/lang/Float;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Float.parseFloat(Ljava/lang/String;)F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Float.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Float.toString(F)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.toString,
+		}
+
+	MethodSignatures["java/lang/Float.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Float.hashCode(F)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Float.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Float.compareTo(Ljava/lang/Float;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.compareTo,
+		}
+
+	MethodSignatures["java/lang/Float.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.compareTo,
+		}
+
+	MethodSignatures["java/lang/Float.compare(FF)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Float.isNaN()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsNaNMethod,
+		}
+
+	MethodSignatures["java/lang/Float.isNaN(F)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsNaN,
+		}
+
+	MethodSignatures["java/lang/Float.isInfinite()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsInfiniteMethod,
+		}
+
+	MethodSignatures["java/lang/Float.isInfinite(F)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsInfinite,
+		}
+
+	MethodSignatures["java/lang/Float.isFinite(F)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIsFinite,
+		}
+
+	MethodSignatures["java/lang/Float.max(FF)F"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.max,
+		}
+
+	MethodSignatures["java/lang/Float.min(FF)F"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.min,
+		}
+
+	MethodSignatures["java/lang/Float.sum(FF)F"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  floatWrapper.sum,
+		}
+
+	MethodSignatures["java/lang/Float.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingByteValue,
+		}
+
+	MethodSignatures["java/lang/Float.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingShortValue,
+		}
+
+	MethodSignatures["java/lang/Float.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingIntValue,
+		}
+
+	MethodSignatures["java/lang/Float.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingLongValue,
+		}
+
+	MethodSignatures["java/lang/Float.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatingFloatValue,
+		}
+
+	MethodSignatures["java/lang/Float.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Double.doubleToLongBits(D)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleToLongBitsMethod,
+		}
+
+	MethodSignatures["java/lang/Double.doubleToRawLongBits(D)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleToRawLongBits,
+		}
+
+	MethodSignatures["java/lang/Double.longBitsToDouble(J)D"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longBitsToDouble,
+		}
+
+	MethodSignatures["java/lang/Float.floatToIntBits(F)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatToIntBitsMethod,
+		}
+
+	MethodSignatures["java/lang/Float.floatToRawIntBits(F)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatToRawIntBits,
+		}
+
+	MethodSignatures["java/lang/Float.intBitsToFloat(I)F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  intBitsToFloat,
+		}
+
+	return MethodSignatures
+}
+
+// slots returns the number of slots the primitive occupies on the op stack
+func (w floatingWrapper) slots() int {
+	if w.prim == types.Double {
+		return 2
+	}
+	return 1
+}
+
+// round rounds a value to the precision of the primitive
+func (w floatingWrapper) round(f float64) float64 {
+	if w.bits == 32 {
+		return float64(float32(f))
+	}
+	return f
+}
+
+// the second param of a static method whose first param is a primitive
+func (w floatingWrapper) secondParam(params []interface{}) float64 {
+	return params[w.slots()].(float64)
+}
+
+// valueOf(primitive). Unlike the integral wrappers, no values are cached.
+func (w floatingWrapper) valueOf(params []interface{}) interface{} {
+	return newBox(w.className, params[0].(float64))
+}
+
+// valueOf(String)
+func (w floatingWrapper) valueOfString(params []interface{}) interface{} {
+	val := w.parseMethod(params)
+	if err, ok := val.(error); ok {
+		return err
+	}
+	return newBox(w.className, val.(float64))
+}
+
+// parseDouble(String) and parseFloat(String)
+func (w floatingWrapper) parseMethod(params []interface{}) interface{} {
+	strObj, _ := params[0].(*object.Object)
+	if strObj == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "")
+	}
+	val, err := parseFloating(object.GetGoStringFromJavaStringPtr(strObj), w.bits)
+	if err != nil {
+		return err
+	}
+	return val
+}
+
+// the formats Java accepts for floating-point numbers: decimal, hexadecimal
+// (which requires a binary exponent), and the special values. Decimal and
+// hex numbers can have an f, F, d or D suffix.
+var (
+	decimalFloatingPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?[fFdD]?$`)
+	hexFloatingPattern     = regexp.MustCompile(`^[+-]?0[xX]([0-9a-fA-F]+\.?[0-9a-fA-F]*|\.[0-9a-fA-F]+)[pP][+-]?\d+[fFdD]?$`)
+)
+
+// parseFloating parses a string as Double.parseDouble() and Float.parseFloat()
+// do. Leading and trailing chars <= space are ignored. Go's parser accepts
+// some strings Java does not (e.g., "inf" or "1_000"), so the format is
+// checked first.
+func parseFloating(str string, bitSize int) (float64, error) {
+	trimmed := strings.TrimFunc(str, func(r rune) bool { return r <= ' ' })
+
+	switch strings.TrimPrefix(trimmed, "+") {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	}
+	if trimmed == "-Infinity" {
+		return math.Inf(-1), nil
+	}
+
+	if trimmed == "" {
+		return 0, numberFormatException("empty String")
+	}
+	if !decimalFloatingPattern.MatchString(trimmed) && !hexFloatingPattern.MatchString(trimmed) {
+		return 0, forInputString(str, 10)
+	}
+
+	trimmed = strings.TrimRight(trimmed, "fFdD")
+	val, err := strconv.ParseFloat(trimmed, bitSize)
+	if err != nil && !strings.Contains(err.Error(), "range") { // out of range is +/-Infinity or 0, as in Java
+		return 0, forInputString(str, 10)
+	}
+	return val, nil
+}
+
+// toString(primitive)
+func (w floatingWrapper) toString(params []interface{}) interface{} {
+	f := params[0].(float64)
+	if w.bits == 32 {
+		return goStringToJava(floatToString(float32(f)))
+	}
+	return goStringToJava(doubleToString(f))
+}
+
+// === Double.toString() and Float.toString() ===
+
+// doubleToString formats a double exactly as Double.toString() does. It's used
+// wherever Java converts a double to a string: String.valueOf(), string
+// concatenation (which compiles to StringBuilder.append()), and PrintStream.
+func doubleToString(d float64) string {
+	return formatJavaFloatingPoint(d, 64)
+}
+
+// floatToString formats a float exactly as Float.toString() does. The digits
+// are the shortest that identify the float, not the double it's held in, so
+// 0.1f is "0.1" rather than "0.10000000149011612".
+func floatToString(f float32) string {
+	return formatJavaFloatingPoint(float64(f), 32)
+}
+
+// formatJavaFloatingPoint implements the algorithm the JDK specifies for
+// Double.toString() and Float.toString(). Values from 10^-3 up to (but not
+// including) 10^7 are shown as decimals with at least one digit after the
+// decimal point, e.g., 100.0 and 0.001; all others use computerized
+// scientific notation, e.g., 1.0E10 and 1.0E-4.
+func formatJavaFloatingPoint(d float64, bitSize int) string {
+	switch {
+	case math.IsNaN(d):
+		return "NaN"
+	case math.IsInf(d, 1):
+		return "Infinity"
+	case math.IsInf(d, -1):
+		return "-Infinity"
+	case d == 0:
+		if math.Signbit(d) {
+			return "-0.0"
+		}
+		return "0.0"
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+	}
+	abs := math.Abs(d)
+	digits, exp := shortestDigits(abs, bitSize)
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	if abs >= 1e-3 && abs < 1e7 {
+		if exp >= 0 {
+			// the digits before the decimal point, padded with zeros if needed
+			for i := 0; i <= exp; i++ {
+				if i < len(digits) {
+					sb.WriteByte(digits[i])
+				} else {
+					sb.WriteByte('0')
+				}
+			}
+			sb.WriteByte('.')
+			if exp+1 < len(digits) {
+				sb.WriteString(digits[exp+1:])
+			} else {
+				sb.WriteByte('0')
+			}
+		} else {
+			sb.WriteString("0.")
+			sb.WriteString(strings.Repeat("0", -exp-1))
+			sb.WriteString(digits)
+		}
+		return sb.String()
+	}
+
+	sb.WriteByte(digits[0])
+	sb.WriteByte('.')
+	if len(digits) > 1 {
+		sb.WriteString(digits[1:])
+	} else {
+		sb.WriteByte('0')
+	}
+	sb.WriteByte('E')
+	sb.WriteString(strconv.Itoa(exp))
+	return sb.String()
+}
+
+// shortestDigits returns the significant digits of the shortest decimal that
+// rounds to the (positive, finite) value, along with the decimal exponent of
+// its first digit, so that 1234.5 is ("12345", 3). Among decimals of the same
+// length, Go picks the one closest to the value, as the JDK does.
+//
+// The JDK adds one rule: when a single digit would do, it considers two-digit
+// decimals as well and uses the one closest to the value. So Double.MIN_VALUE
+// is 4.9E-324, not 5.0E-324, and Float.MIN_VALUE is 1.4E-45.
+func shortestDigits(abs float64, bitSize int) (string, int) {
+	str := strconv.FormatFloat(abs, 'e', -1, bitSize)
+	mantissa, exponent, _ := strings.Cut(str, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+
+	if len(digits) == 1 {
+		twoDigits := strconv.FormatFloat(abs, 'e', 1, bitSize)
+		if val, err := strconv.ParseFloat(twoDigits, bitSize); err == nil && val == abs {
+			mantissa, exponent, _ = strings.Cut(twoDigits, "e")
+			digits = strings.TrimRight(strings.Replace(mantissa, ".", "", 1), "0")
+		}
+	}
+
+	exp, _ := strconv.Atoi(exponent)
+	return digits, exp
+}
+
+// hashCode() is the bits of the value; for Double, the two halves of the bits XORed together
+func (w floatingWrapper) hashOf(f float64) int64 {
+	if w.bits == 32 {
+		return floatToIntBits(f)
+	}
+	bits := doubleToLongBits(f)
+	return int64(int32(bits ^ int64(uint64(bits)>>32)))
+}
+
+func (w floatingWrapper) hashCodeMethod(params []interface{}) interface{} {
+	return w.hashOf(unboxThis(params).(float64))
+}
+
+func (w floatingWrapper) hashCodeStatic(params []interface{}) interface{} {
+	return w.hashOf(params[0].(float64))
+}
+
+// compareValues orders values as Double.compare() does: -0.0 is less than 0.0,
+// and NaN is greater than all other values, including +Infinity, and equal to itself
+func (w floatingWrapper) compareValues(x, y float64) int64 {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	if w.bits == 32 {
+		return compareInt64(floatToIntBits(x), floatToIntBits(y))
+	}
+	return compareInt64(doubleToLongBits(x), doubleToLongBits(y))
+}
+
+// compare(x, y)
+func (w floatingWrapper) compare(params []interface{}) interface{} {
+	return w.compareValues(params[0].(float64), w.secondParam(params))
+}
+
+// compareTo()
+func (w floatingWrapper) compareTo(params []interface{}) interface{} {
+	that, _ := params[1].(*object.Object)
+	thatVal, ok := unboxValue(that)
+	if !ok {
+		return stringNPE("compareTo")
+	}
+	return w.compareValues(unboxThis(params).(float64), thatVal.(float64))
+}
+
+func floatingIsNaN(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(math.IsNaN(params[0].(float64)))
+}
+
+func floatingIsNaNMethod(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(math.IsNaN(unboxThis(params).(float64)))
+}
+
+func floatingIsInfinite(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(math.IsInf(params[0].(float64), 0))
+}
+
+func floatingIsInfiniteMethod(params []interface{}) interface{} {
+	return types.ConvertGoBoolToJavaBool(math.IsInf(unboxThis(params).(float64), 0))
+}
+
+func floatingIsFinite(params []interface{}) interface{} {
+	f := params[0].(float64)
+	return types.ConvertGoBoolToJavaBool(!math.IsInf(f, 0) && !math.IsNaN(f))
+}
+
+// max() and min() follow Math.max() and Math.min(): NaN if either value is NaN,
+// and 0.0 is greater than -0.0
+func (w floatingWrapper) max(params []interface{}) interface{} {
+	x, y := params[0].(float64), w.secondParam(params)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.NaN()
+	}
+	return math.Max(x, y)
+}
+
+func (w floatingWrapper) min(params []interface{}) interface{} {
+	x, y := params[0].(float64), w.secondParam(params)
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.NaN()
+	}
+	return math.Min(x, y)
+}
+
+func (w floatingWrapper) sum(params []interface{}) interface{} {
+	return w.round(params[0].(float64) + w.secondParam(params))
+}
+
+// === Number methods ===
+
+func floatingByteValue(params []interface{}) interface{} {
+	return int64(int8(floatingToIntegral(unboxThis(params).(float64), 32)))
+}
+
+func floatingShortValue(params []interface{}) interface{} {
+	return int64(int16(floatingToIntegral(unboxThis(params).(float64), 32)))
+}
+
+func floatingIntValue(params []interface{}) interface{} {
+	return floatingToIntegral(unboxThis(params).(float64), 32)
+}
+
+func floatingLongValue(params []interface{}) interface{} {
+	return floatingToIntegral(unboxThis(params).(float64), 64)
+}
+
+func floatingFloatValue(params []interface{}) interface{} {
+	return float64(float32(unboxThis(params).(float64)))
+}
+
+// === conversions to and from bits ===
+
+func doubleToLongBitsMethod(params []interface{}) interface{} {
+	return doubleToLongBits(params[0].(float64))
+}
+
+func doubleToRawLongBits(params []interface{}) interface{} {
+	return int64(math.Float64bits(params[0].(float64)))
+}
+
+func longBitsToDouble(params []interface{}) interface{} {
+	return math.Float64frombits(uint64(params[0].(int64)))
+}
+
+func floatToIntBitsMethod(params []interface{}) interface{} {
+	return floatToIntBits(params[0].(float64))
+}
+
+func floatToRawIntBits(params []interface{}) interface{} {
+	return int64(int32(math.Float32bits(float32(params[0].(float64)))))
+}
+
+func intBitsToFloat(params []interface{}) interface{} {
+	return float64(math.Float32frombits(uint32(params[0].(int64))))
+}
@@ -0,0 +1,168 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/types"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDoubleParse(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"  -2.5e3  ", -2500},
+		{".5", 0.5},
+		{"3.", 3},
+		{"1.0d", 1},
+		{"0x1p4", 16},
+		{"-Infinity", math.Inf(-1)},
+		{"1e400", math.Inf(1)},
+	}
+	for _, tc := range tests {
+		got := doubleWrapper.parseMethod([]interface{}{jstr(tc.str)})
+		if got != tc.expected {
+			t.Errorf("parseDouble(\"%s\"): expected %v, got %v", tc.str, tc.expected, got)
+		}
+	}
+
+	if got := doubleWrapper.parseMethod([]interface{}{jstr("NaN")}); !math.IsNaN(got.(float64)) {
+		t.Errorf("Expected parseDouble(\"NaN\") to be NaN, got %v", got)
+	}
+	if got := floatWrapper.parseMethod([]interface{}{jstr("0.1f")}); got != float64(float32(0.1)) {
+		t.Errorf("Expected parseFloat(\"0.1f\") to be rounded to a float, got %v", got)
+	}
+}
+
+func TestDoubleParseErrors(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	tests := map[string]string{
+		"abc":   "java.lang.NumberFormatException: For input string: \"abc\"",
+		"1_000": "java.lang.NumberFormatException: For input string: \"1_000\"",
+		"inf":   "java.lang.NumberFormatException: For input string: \"inf\"",
+		"  ":    "java.lang.NumberFormatException: empty String",
+	}
+	for str, msg := range tests {
+		err, ok := doubleWrapper.parseMethod([]interface{}{jstr(str)}).(error)
+		if !ok {
+			t.Errorf("Expected parseDouble(\"%s\") to throw an exception", str)
+			continue
+		}
+		if err.Error() != msg {
+			t.Errorf("Expected '%s', got '%s'", msg, err.Error())
+		}
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func TestDoubleCompare(t *testing.T) {
+	nan, negZero := math.NaN(), math.Copysign(0, -1)
+	tests := []struct {
+		x, y     float64
+		expected int64
+	}{
+		{1, 2, -1},
+		{negZero, 0, -1},
+		{0, negZero, 1},
+		{nan, math.Inf(1), 1},
+		{nan, nan, 0},
+	}
+	for _, tc := range tests {
+		if got := doubleWrapper.compare([]interface{}{tc.x, tc.x, tc.y, tc.y}); got != tc.expected {
+			t.Errorf("Double.compare(%v, %v): expected %d, got %v", tc.x, tc.y, tc.expected, got)
+		}
+		if got := floatWrapper.compare([]interface{}{tc.x, tc.y}); got != tc.expected {
+			t.Errorf("Float.compare(%v, %v): expected %d, got %v", tc.x, tc.y, tc.expected, got)
+		}
+	}
+
+	// equals() compares bits, unlike ==
+	if boxedEquals([]interface{}{newBox("java/lang/Double", nan), newBox("java/lang/Double", nan)}) != types.JavaBoolTrue {
+		t.Errorf("Expected Double.NaN.equals(Double.NaN)")
+	}
+	if boxedEquals([]interface{}{newBox("java/lang/Double", 0.0), newBox("java/lang/Double", negZero)}) != types.JavaBoolFalse {
+		t.Errorf("Expected 0.0.equals(-0.0) to be false")
+	}
+}
+
+func TestDoubleBits(t *testing.T) {
+	if got := doubleToLongBits(math.Float64frombits(0x7ff0000000000123)); got != 0x7ff8000000000000 {
+		t.Errorf("Expected doubleToLongBits() to return the canonical NaN, got %x", got)
+	}
+	if got := floatToIntBits(-1); got != int64(int32(-0x40800000)) {
+		t.Errorf("Expected floatToIntBits(-1.0f) to be 0xbf800000, got %x", got)
+	}
+	if got := doubleWrapper.hashCodeStatic([]interface{}{1.0, 1.0}); got != int64(1072693248) {
+		t.Errorf("Expected Double.hashCode(1.0) to be 1072693248, got %v", got)
+	}
+	if got := floatingIntValue([]interface{}{newBox("java/lang/Double", 1e20)}); got != int64(math.MaxInt32) {
+		t.Errorf("Expected intValue() of 1e20 to be Integer.MAX_VALUE, got %v", got)
+	}
+}
+
+func TestDoubleToString(t *testing.T) {
+	tests := []struct {
+		d        float64
+		expected string
+	}{
+		{1, "1.0"},
+		{100, "100.0"},
+		{-2.5, "-2.5"},
+		{0.001, "0.001"},
+		{0.0001, "1.0E-4"},
+		{1234567, "1234567.0"},
+		{1e7, "1.0E7"},
+		{1e10, "1.0E10"},
+		{1.5e-10, "1.5E-10"},
+		{0.30000000000000004, "0.30000000000000004"},
+		{2e23, "2.0E23"},
+		{math.MaxFloat64, "1.7976931348623157E308"},
+		{math.SmallestNonzeroFloat64, "4.9E-324"},
+		{math.Copysign(0, -1), "-0.0"},
+		{math.Inf(-1), "-Infinity"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tc := range tests {
+		if got := doubleToString(tc.d); got != tc.expected {
+			t.Errorf("Double.toString(%v): expected '%s', got '%s'", tc.d, tc.expected, got)
+		}
+	}
+}
+
+func TestFloatToString(t *testing.T) {
+	tests := []struct {
+		f        float32
+		expected string
+	}{
+		{0.1, "0.1"},
+		{1.0 / 3, "0.33333334"},
+		{16777216, "1.6777216E7"},
+		{3.14159, "3.14159"},
+		{math.MaxFloat32, "3.4028235E38"},
+		{math.SmallestNonzeroFloat32, "1.4E-45"},
+		{1e-5, "1.0E-5"},
+	}
+	for _, tc := range tests {
+		if got := floatToString(tc.f); got != tc.expected {
+			t.Errorf("Float.toString(%v): expected '%s', got '%s'", tc.f, tc.expected, got)
+		}
+	}
+}
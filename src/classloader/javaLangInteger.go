@@ -0,0 +1,1159 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// Implementation of the wrapper classes for the integral primitives: Integer,
+// Long, Short and Byte. They share all their functions, which differ only in
+// the width of the primitive. See javaLangNumber.go for the boxing details.
+
+type integralWrapper struct {
+	className string
+	prim      string // the type of the primitive: I, J, S, or B
+	bits      int    // the width of the primitive
+	parseName string // the name of the parse method, e.g., parseInt
+}
+
+var (
+	integerWrapper = integralWrapper{className: "java/lang/Integer", prim: types.Int, bits: 32, parseName: "parseInt"}
+	longWrapper    = integralWrapper{className: "java/lang/Long", prim: types.Long, bits: 64, parseName: "parseLong"}
+	shortWrapper   = integralWrapper{className: "java/lang/Short", prim: types.Short, bits: 16, parseName: "parseShort"}
+	byteWrapper    = integralWrapper{className: "java/lang/Byte", prim: types.Byte, bits: 8, parseName: "parseByte"}
+)
+
+func Load_Lang_Integer() map[string]GMeth {
+
+	MethodSignatures["java/lang/Integer.<init>(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Integer.valueOf(I)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Integer.valueOf(Ljava/lang/String;)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Integer.valueOf(Ljava/lang/String;I)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Integer.parseInt(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Integer.parseInt(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Integer.decode(Ljava/lang/String;)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.decode,
+		}
+
+	MethodSignatures["java/lang/Integer.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Integer.toString(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralToString,
+		}
+
+	MethodSignatures["java/lang/Integer.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Integer.hashCode(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Integer.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Integer.compareTo(Ljava/lang/Integer;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Integer.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Integer.compare(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Integer.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralByteValue,
+		}
+
+	MethodSignatures["java/lang/Integer.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralShortValue,
+		}
+
+	MethodSignatures["java/lang/Integer.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralIntValue,
+		}
+
+	MethodSignatures["java/lang/Integer.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Integer.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralFloatValue,
+		}
+
+	MethodSignatures["java/lang/Integer.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralDoubleValue,
+		}
+
+	MethodSignatures["java/lang/Integer.getInteger(Ljava/lang/String;)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Integer.getInteger(Ljava/lang/String;I)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Integer.getInteger(Ljava/lang/String;Ljava/lang/Integer;)Ljava/lang/Integer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Integer.toString(II)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.toStringRadix,
+		}
+
+	MethodSignatures["java/lang/Integer.toHexString(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.toHexString,
+		}
+
+	MethodSignatures["java/lang/Integer.toOctalString(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.toOctalString,
+		}
+
+	MethodSignatures["java/lang/Integer.toBinaryString(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.toBinaryString,
+		}
+
+	MethodSignatures["java/lang/Integer.bitCount(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.bitCount,
+		}
+
+	MethodSignatures["java/lang/Integer.reverse(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.reverse,
+		}
+
+	MethodSignatures["java/lang/Integer.reverseBytes(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.reverseBytes,
+		}
+
+	MethodSignatures["java/lang/Integer.numberOfLeadingZeros(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.numberOfLeadingZeros,
+		}
+
+	MethodSignatures["java/lang/Integer.numberOfTrailingZeros(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.numberOfTrailingZeros,
+		}
+
+	MethodSignatures["java/lang/Integer.highestOneBit(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.highestOneBit,
+		}
+
+	MethodSignatures["java/lang/Integer.lowestOneBit(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integerWrapper.lowestOneBit,
+		}
+
+	MethodSignatures["java/lang/Integer.rotateLeft(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.rotateLeft,
+		}
+
+	MethodSignatures["java/lang/Integer.rotateRight(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.rotateRight,
+		}
+
+	MethodSignatures["java/lang/Integer.signum(I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralSignum,
+		}
+
+	MethodSignatures["java/lang/Integer.max(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.max,
+		}
+
+	MethodSignatures["java/lang/Integer.min(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.min,
+		}
+
+	MethodSignatures["java/lang/Integer.sum(II)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integerWrapper.sum,
+		}
+
+	MethodSignatures["java/lang/Long.<init>(J)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Long.valueOf(J)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Long.valueOf(Ljava/lang/String;)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  longWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Long.valueOf(Ljava/lang/String;I)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Long.parseLong(Ljava/lang/String;)J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  longWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Long.parseLong(Ljava/lang/String;I)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Long.decode(Ljava/lang/String;)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  longWrapper.decode,
+		}
+
+	MethodSignatures["java/lang/Long.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Long.toString(J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralToString,
+		}
+
+	MethodSignatures["java/lang/Long.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  longWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Long.hashCode(J)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Long.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Long.compareTo(Ljava/lang/Long;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Long.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Long.compare(JJ)I"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  longWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Long.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralByteValue,
+		}
+
+	MethodSignatures["java/lang/Long.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralShortValue,
+		}
+
+	MethodSignatures["java/lang/Long.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralIntValue,
+		}
+
+	MethodSignatures["java/lang/Long.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Long.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralFloatValue,
+		}
+
+	MethodSignatures["java/lang/Long.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralDoubleValue,
+		}
+
+	MethodSignatures["java/lang/Long.getLong(Ljava/lang/String;)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  longWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Long.getLong(Ljava/lang/String;J)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  longWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Long.getLong(Ljava/lang/String;Ljava/lang/Long;)Ljava/lang/Long;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.getProperty,
+		}
+
+	MethodSignatures["java/lang/Long.toString(JI)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  longWrapper.toStringRadix,
+		}
+
+	MethodSignatures["java/lang/Long.toHexString(J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.toHexString,
+		}
+
+	MethodSignatures["java/lang/Long.toOctalString(J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.toOctalString,
+		}
+
+	MethodSignatures["java/lang/Long.toBinaryString(J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.toBinaryString,
+		}
+
+	MethodSignatures["java/lang/Long.bitCount(J)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.bitCount,
+		}
+
+	MethodSignatures["java/lang/Long.reverse(J)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.reverse,
+		}
+
+	MethodSignatures["java/lang/Long.reverseBytes(J)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.reverseBytes,
+		}
+
+	MethodSignatures["java/lang/Long.numberOfLeadingZeros(J)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.numberOfLeadingZeros,
+		}
+
+	MethodSignatures["java/lang/Long.numberOfTrailingZeros(J)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.numberOfTrailingZeros,
+		}
+
+	MethodSignatures["java/lang/Long.highestOneBit(J)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.highestOneBit,
+		}
+
+	MethodSignatures["java/lang/Long.lowestOneBit(J)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  longWrapper.lowestOneBit,
+		}
+
+	MethodSignatures["java/lang/Long.rotateLeft(JI)J"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  longWrapper.rotateLeft,
+		}
+
+	MethodSignatures["java/lang/Long.rotateRight(JI)J"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  longWrapper.rotateRight,
+		}
+
+	MethodSignatures["java/lang/Long.signum(J)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralSignum,
+		}
+
+	MethodSignatures["java/lang/Long.max(JJ)J"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  longWrapper.max,
+		}
+
+	MethodSignatures["java/lang/Long.min(JJ)J"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  longWrapper.min,
+		}
+
+	MethodSignatures["java/lang/Long.sum(JJ)J"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  longWrapper.sum,
+		}
+
+	MethodSignatures["java/lang/Short.<init>(S)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Short.valueOf(S)Ljava/lang/Short;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Short.valueOf(Ljava/lang/String;)Ljava/lang/Short;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Short.valueOf(Ljava/lang/String;I)Ljava/lang/Short;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  shortWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Short.parseShort(Ljava/lang/String;)S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Short.parseShort(Ljava/lang/String;I)S"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  shortWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Short.decode(Ljava/lang/String;)Ljava/lang/Short;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.decode,
+		}
+
+	MethodSignatures["java/lang/Short.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Short.toString(S)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralToString,
+		}
+
+	MethodSignatures["java/lang/Short.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Short.hashCode(S)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Short.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Short.compareTo(Ljava/lang/Short;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Short.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Short.compare(SS)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  shortWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Short.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralByteValue,
+		}
+
+	MethodSignatures["java/lang/Short.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralShortValue,
+		}
+
+	MethodSignatures["java/lang/Short.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralIntValue,
+		}
+
+	MethodSignatures["java/lang/Short.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Short.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralFloatValue,
+		}
+
+	MethodSignatures["java/lang/Short.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralDoubleValue,
+		}
+
+	MethodSignatures["java/lang/Short.toUnsignedInt(S)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.toUnsigned,
+		}
+
+	MethodSignatures["java/lang/Short.toUnsignedLong(S)J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  shortWrapper.toUnsigned,
+		}
+
+	MethodSignatures["java/lang/Byte.<init>(B)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxInit,
+		}
+
+	MethodSignatures["java/lang/Byte.valueOf(B)Ljava/lang/Byte;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.valueOf,
+		}
+
+	MethodSignatures["java/lang/Byte.valueOf(Ljava/lang/String;)Ljava/lang/Byte;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Byte.valueOf(Ljava/lang/String;I)Ljava/lang/Byte;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  byteWrapper.valueOfString,
+		}
+
+	MethodSignatures["java/lang/Byte.parseByte(Ljava/lang/String;)B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Byte.parseByte(Ljava/lang/String;I)B"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  byteWrapper.parseMethod,
+		}
+
+	MethodSignatures["java/lang/Byte.decode(Ljava/lang/String;)Ljava/lang/Byte;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.decode,
+		}
+
+	MethodSignatures["java/lang/Byte.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  boxedToStringMethod,
+		}
+
+	MethodSignatures["java/lang/Byte.toString(B)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralToString,
+		}
+
+	MethodSignatures["java/lang/Byte.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.hashCodeMethod,
+		}
+
+	MethodSignatures["java/lang/Byte.hashCode(B)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.hashCodeStatic,
+		}
+
+	MethodSignatures["java/lang/Byte.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  boxedEquals,
+		}
+
+	MethodSignatures["java/lang/Byte.compareTo(Ljava/lang/Byte;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Byte.compareTo(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  integralCompareTo,
+		}
+
+	MethodSignatures["java/lang/Byte.compare(BB)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  byteWrapper.compare,
+		}
+
+	MethodSignatures["java/lang/Byte.byteValue()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralByteValue,
+		}
+
+	MethodSignatures["java/lang/Byte.shortValue()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralShortValue,
+		}
+
+	MethodSignatures["java/lang/Byte.intValue()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralIntValue,
+		}
+
+	MethodSignatures["java/lang/Byte.longValue()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  unboxThis,
+		}
+
+	MethodSignatures["java/lang/Byte.floatValue()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralFloatValue,
+		}
+
+	MethodSignatures["java/lang/Byte.doubleValue()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  integralDoubleValue,
+		}
+
+	MethodSignatures["java/lang/Byte.toUnsignedInt(B)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.toUnsigned,
+		}
+
+	MethodSignatures["java/lang/Byte.toUnsignedLong(B)J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  byteWrapper.toUnsigned,
+		}
+
+	return MethodSignatures
+}
+
+// slots returns the number of slots the primitive occupies on the op stack
+func (w integralWrapper) slots() int {
+	if w.prim == types.Long {
+		return 2
+	}
+	return 1
+}
+
+// wrap truncates a value to the width of the primitive, as Java arithmetic does
+func (w integralWrapper) wrap(v int64) int64 {
+	switch w.bits {
+	case 32:
+		return int64(int32(v))
+	case 16:
+		return int64(int16(v))
+	case 8:
+		return int64(int8(v))
+	default:
+		return v
+	}
+}
+
+// unsigned returns the bits of the value as an unsigned number of the primitive's width
+func (w integralWrapper) unsigned(v int64) uint64 {
+	if w.bits == 64 {
+		return uint64(v)
+	}
+	return uint64(v) & (1<<w.bits - 1)
+}
+
+// the second param of a static method whose first param is a primitive
+func (w integralWrapper) secondParam(params []interface{}) int64 {
+	return params[w.slots()].(int64)
+}
+
+// valueOf(primitive) returns the boxed value, cached for -128 to 127
+func (w integralWrapper) valueOf(params []interface{}) interface{} {
+	return box(w.className, params[0].(int64))
+}
+
+// valueOf(String) and valueOf(String, int radix)
+func (w integralWrapper) valueOfString(params []interface{}) interface{} {
+	val := w.parseMethod(params)
+	if err, ok := val.(error); ok {
+		return err
+	}
+	return box(w.className, val.(int64))
+}
+
+// parseInt(String), parseInt(String, int radix) and the equivalents for Long,
+// Short and Byte
+func (w integralWrapper) parseMethod(params []interface{}) interface{} {
+	strObj, _ := params[0].(*object.Object)
+	radix := int64(10)
+	if len(params) > 1 {
+		radix = params[1].(int64)
+	}
+	if strObj == nil {
+		return numberFormatException("Cannot parse null string: null")
+	}
+	val, err := w.parse(object.GetGoStringFromJavaStringPtr(strObj), radix)
+	if err != nil {
+		return err
+	}
+	return val
+}
+
+// parse parses a string as an integral value in the radix. As in the JDK, Short
+// and Byte values are parsed as ints and then checked for range.
+func (w integralWrapper) parse(str string, radix int64) (int64, error) {
+	if radix < 2 {
+		return 0, numberFormatException(fmt.Sprintf("radix %d less than Character.MIN_RADIX", radix))
+	}
+	if radix > 36 {
+		return 0, numberFormatException(fmt.Sprintf("radix %d greater than Character.MAX_RADIX", radix))
+	}
+
+	parseBits := w.bits
+	if parseBits < 32 {
+		parseBits = 32
+	}
+	val, err := strconv.ParseInt(str, int(radix), parseBits)
+	if err != nil {
+		return 0, forInputString(str, radix)
+	}
+	if w.wrap(val) != val {
+		return 0, numberFormatException(fmt.Sprintf("Value out of range. Value:\"%s\" Radix:%d", str, radix))
+	}
+	return val, nil
+}
+
+// decodeParts splits a string in the format decode() accepts, which is an
+// optional sign followed by a decimal number, a hex number prefixed with 0x,
+// 0X or #, or an octal number prefixed with 0, into the signed digits and
+// their radix. If the format is wrong, it returns the exception message.
+func decodeParts(str string) (digits string, radix int64, errMsg string) {
+	if str == "" {
+		return "", 0, "Zero length string"
+	}
+	sign, rest := "", str
+	if str[0] == '-' || str[0] == '+' {
+		sign, rest = str[:1], str[1:]
+	}
+	radix = 10
+	switch {
+	case strings.HasPrefix(rest, "0x"), strings.HasPrefix(rest, "0X"):
+		rest, radix = rest[2:], 16
+	case strings.HasPrefix(rest, "#"):
+		rest, radix = rest[1:], 16
+	case strings.HasPrefix(rest, "0") && len(rest) > 1:
+		rest, radix = rest[1:], 8
+	}
+	if strings.HasPrefix(rest, "-") || strings.HasPrefix(rest, "+") {
+		return "", 0, "Sign character in wrong position"
+	}
+	return sign + rest, radix, ""
+}
+
+// decode(String) returns the boxed value of a decimal, hex or octal number
+func (w integralWrapper) decode(params []interface{}) interface{} {
+	strObj, _ := params[0].(*object.Object)
+	if strObj == nil {
+		return throwNativeException(exceptions.NullPointerException, "java.lang.NullPointerException", "")
+	}
+	digits, radix, errMsg := decodeParts(object.GetGoStringFromJavaStringPtr(strObj))
+	if errMsg != "" {
+		return numberFormatException(errMsg)
+	}
+	val, err := w.parse(digits, radix)
+	if err != nil {
+		return err
+	}
+	return box(w.className, val)
+}
+
+// getInteger(String name) and getLong(String name), with an optional default
+// value that is a primitive or boxed, return the value of the system property
+// as decode() would, or the default (or null) if the property isn't set or
+// can't be decoded
+func (w integralWrapper) getProperty(params []interface{}) interface{} {
+	var def interface{} = object.Null
+	if len(params) > 1 {
+		def = params[1]
+		if v, ok := def.(int64); ok {
+			def = box(w.className, v)
+		}
+	}
+
+	nameObj, _ := params[0].(*object.Object)
+	if nameObj == nil {
+		return def
+	}
+	value, ok := getSystemProperties().get(object.GetGoStringFromJavaStringPtr(nameObj))
+	if !ok {
+		return def
+	}
+	digits, radix, errMsg := decodeParts(value)
+	if errMsg != "" {
+		return def
+	}
+	val, err := strconv.ParseInt(digits, int(radix), w.bits)
+	if err != nil {
+		return def
+	}
+	return box(w.className, val)
+}
+
+// toString(primitive) for all the integral wrappers
+func integralToString(params []interface{}) interface{} {
+	return goStringToJava(strconv.FormatInt(params[0].(int64), 10))
+}
+
+// toString(primitive, int radix). An invalid radix is treated as 10.
+func (w integralWrapper) toStringRadix(params []interface{}) interface{} {
+	radix := w.secondParam(params)
+	if radix < 2 || radix > 36 {
+		radix = 10
+	}
+	return goStringToJava(strconv.FormatInt(params[0].(int64), int(radix)))
+}
+
+// toHexString(), toOctalString() and toBinaryString() format the value as unsigned
+func (w integralWrapper) toHexString(params []interface{}) interface{} {
+	return goStringToJava(strconv.FormatUint(w.unsigned(params[0].(int64)), 16))
+}
+
+func (w integralWrapper) toOctalString(params []interface{}) interface{} {
+	return goStringToJava(strconv.FormatUint(w.unsigned(params[0].(int64)), 8))
+}
+
+func (w integralWrapper) toBinaryString(params []interface{}) interface{} {
+	return goStringToJava(strconv.FormatUint(w.unsigned(params[0].(int64)), 2))
+}
+
+// Short.toUnsignedInt(), Byte.toUnsignedLong() and the like
+func (w integralWrapper) toUnsigned(params []interface{}) interface{} {
+	return int64(w.unsigned(params[0].(int64)))
+}
+
+// hashCode() for the integral wrappers is the value itself, except for Long,
+// where it's the two halves of the value XORed together
+func (w integralWrapper) hashOf(v int64) int64 {
+	if w.bits == 64 {
+		return int64(int32(v ^ int64(uint64(v)>>32)))
+	}
+	return v
+}
+
+func (w integralWrapper) hashCodeMethod(params []interface{}) interface{} {
+	return w.hashOf(unboxThis(params).(int64))
+}
+
+func (w integralWrapper) hashCodeStatic(params []interface{}) interface{} {
+	return w.hashOf(params[0].(int64))
+}
+
+// compare(x, y) returns -1, 0, or 1
+func (w integralWrapper) compare(params []interface{}) interface{} {
+	return compareInt64(params[0].(int64), w.secondParam(params))
+}
+
+// compareTo() for the integral wrappers
+func integralCompareTo(params []interface{}) interface{} {
+	that, _ := params[1].(*object.Object)
+	thatVal, ok := unboxValue(that)
+	if !ok {
+		return stringNPE("compareTo")
+	}
+	return compareInt64(unboxThis(params).(int64), thatVal.(int64))
+}
+
+func compareInt64(x, y int64) int64 {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// === Number methods ===
+
+func integralByteValue(params []interface{}) interface{} {
+	return int64(int8(unboxThis(params).(int64)))
+}
+
+func integralShortValue(params []interface{}) interface{} {
+	return int64(int16(unboxThis(params).(int64)))
+}
+
+func integralIntValue(params []interface{}) interface{} {
+	return int64(int32(unboxThis(params).(int64)))
+}
+
+func integralFloatValue(params []interface{}) interface{} {
+	return float64(float32(unboxThis(params).(int64)))
+}
+
+func integralDoubleValue(params []interface{}) interface{} {
+	return float64(unboxThis(params).(int64))
+}
+
+// === bit manipulation (Integer and Long only) ===
+
+func (w integralWrapper) bitCount(params []interface{}) interface{} {
+	return int64(bits.OnesCount64(w.unsigned(params[0].(int64))))
+}
+
+func (w integralWrapper) reverse(params []interface{}) interface{} {
+	v := params[0].(int64)
+	if w.bits == 32 {
+		return int64(int32(bits.Reverse32(uint32(v))))
+	}
+	return int64(bits.Reverse64(uint64(v)))
+}
+
+func (w integralWrapper) reverseBytes(params []interface{}) interface{} {
+	v := params[0].(int64)
+	if w.bits == 32 {
+		return int64(int32(bits.ReverseBytes32(uint32(v))))
+	}
+	return int64(bits.ReverseBytes64(uint64(v)))
+}
+
+func (w integralWrapper) numberOfLeadingZeros(params []interface{}) interface{} {
+	return int64(bits.LeadingZeros64(w.unsigned(params[0].(int64))) - (64 - w.bits))
+}
+
+func (w integralWrapper) numberOfTrailingZeros(params []interface{}) interface{} {
+	v := w.unsigned(params[0].(int64))
+	if v == 0 {
+		return int64(w.bits)
+	}
+	return int64(bits.TrailingZeros64(v))
+}
+
+func (w integralWrapper) highestOneBit(params []interface{}) interface{} {
+	v := w.unsigned(params[0].(int64))
+	if v == 0 {
+		return int64(0)
+	}
+	return w.wrap(int64(uint64(1) << (63 - bits.LeadingZeros64(v))))
+}
+
+func (w integralWrapper) lowestOneBit(params []interface{}) interface{} {
+	v := params[0].(int64)
+	return w.wrap(v & -v)
+}
+
+func (w integralWrapper) rotateLeft(params []interface{}) interface{} {
+	v, distance := params[0].(int64), int(w.secondParam(params))
+	if w.bits == 32 {
+		return int64(int32(bits.RotateLeft32(uint32(v), distance)))
+	}
+	return int64(bits.RotateLeft64(uint64(v), distance))
+}
+
+func (w integralWrapper) rotateRight(params []interface{}) interface{} {
+	v, distance := params[0].(int64), int(w.secondParam(params))
+	if w.bits == 32 {
+		return int64(int32(bits.RotateLeft32(uint32(v), -distance)))
+	}
+	return int64(bits.RotateLeft64(uint64(v), -distance))
+}
+
+func integralSignum(params []interface{}) interface{} {
+	return compareInt64(params[0].(int64), 0)
+}
+
+func (w integralWrapper) max(params []interface{}) interface{} {
+	x, y := params[0].(int64), w.secondParam(params)
+	if x > y {
+		return x
+	}
+	return y
+}
+
+func (w integralWrapper) min(params []interface{}) interface{} {
+	x, y := params[0].(int64), w.secondParam(params)
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func (w integralWrapper) sum(params []interface{}) interface{} {
+	return w.wrap(params[0].(int64) + w.secondParam(params))
+}
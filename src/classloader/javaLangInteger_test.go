@@ -0,0 +1,148 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func TestIntegerValueOfCache(t *testing.T) {
+	if integerWrapper.valueOf([]interface{}{int64(127)}) != integerWrapper.valueOf([]interface{}{int64(127)}) {
+		t.Errorf("Expected Integer.valueOf(127) to return the cached instance")
+	}
+	if integerWrapper.valueOf([]interface{}{int64(-128)}) != integerWrapper.valueOf([]interface{}{int64(-128)}) {
+		t.Errorf("Expected Integer.valueOf(-128) to return the cached instance")
+	}
+	if integerWrapper.valueOf([]interface{}{int64(128)}) == integerWrapper.valueOf([]interface{}{int64(128)}) {
+		t.Errorf("Expected Integer.valueOf(128) to return a new instance")
+	}
+	if integerWrapper.valueOf([]interface{}{int64(5)}) == longWrapper.valueOf([]interface{}{int64(5)}) {
+		t.Errorf("Expected Integer and Long to have separate caches")
+	}
+
+	five := integerWrapper.valueOf([]interface{}{int64(5)})
+	if boxedEquals([]interface{}{five, box("java/lang/Integer", 5)}) != types.JavaBoolTrue {
+		t.Errorf("Expected Integer(5).equals(Integer(5))")
+	}
+	if boxedEquals([]interface{}{five, longWrapper.valueOf([]interface{}{int64(5)})}) != types.JavaBoolFalse {
+		t.Errorf("Expected Integer(5).equals(Long(5)) to be false")
+	}
+	if got := gostr(t, boxedToStringMethod([]interface{}{five})); got != "5" {
+		t.Errorf("Expected toString() to be '5', got '%s'", got)
+	}
+}
+
+func TestIntegerParse(t *testing.T) {
+	tests := []struct {
+		str      string
+		radix    int64
+		expected int64
+	}{
+		{"123", 10, 123},
+		{"-2147483648", 10, -2147483648},
+		{"+7", 10, 7},
+		{"ff", 16, 255},
+		{"-1010", 2, -10},
+		{"zz", 36, 1295},
+	}
+	for _, tc := range tests {
+		got := integerWrapper.parseMethod([]interface{}{jstr(tc.str), tc.radix})
+		if got != tc.expected {
+			t.Errorf("parseInt(\"%s\", %d): expected %d, got %v", tc.str, tc.radix, tc.expected, got)
+		}
+	}
+}
+
+func TestIntegerParseErrors(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	tests := []struct {
+		wrapper integralWrapper
+		str     string
+		radix   int64
+		msg     string
+	}{
+		{integerWrapper, "12a", 10, "java.lang.NumberFormatException: For input string: \"12a\""},
+		{integerWrapper, "2147483648", 10, "java.lang.NumberFormatException: For input string: \"2147483648\""},
+		{integerWrapper, "12", 2, "java.lang.NumberFormatException: For input string: \"12\" under radix 2"},
+		{integerWrapper, "", 10, "java.lang.NumberFormatException: For input string: \"\""},
+		{integerWrapper, "1", 37, "java.lang.NumberFormatException: radix 37 greater than Character.MAX_RADIX"},
+		{byteWrapper, "128", 10, "java.lang.NumberFormatException: Value out of range. Value:\"128\" Radix:10"},
+	}
+	for _, tc := range tests {
+		err, ok := tc.wrapper.parseMethod([]interface{}{jstr(tc.str), tc.radix}).(error)
+		if !ok {
+			t.Errorf("Expected parsing \"%s\" to throw an exception", tc.str)
+			continue
+		}
+		if err.Error() != tc.msg {
+			t.Errorf("Expected '%s', got '%s'", tc.msg, err.Error())
+		}
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func TestIntegerFormatting(t *testing.T) {
+	if got := gostr(t, integerWrapper.toHexString([]interface{}{int64(-1)})); got != "ffffffff" {
+		t.Errorf("Expected Integer.toHexString(-1) to be 'ffffffff', got '%s'", got)
+	}
+	if got := gostr(t, longWrapper.toHexString([]interface{}{int64(-1), int64(-1)})); got != "ffffffffffffffff" {
+		t.Errorf("Expected Long.toHexString(-1) to be 'ffffffffffffffff', got '%s'", got)
+	}
+	if got := gostr(t, integerWrapper.toBinaryString([]interface{}{int64(10)})); got != "1010" {
+		t.Errorf("Expected Integer.toBinaryString(10) to be '1010', got '%s'", got)
+	}
+	if got := gostr(t, integerWrapper.toStringRadix([]interface{}{int64(-255), int64(16)})); got != "-ff" {
+		t.Errorf("Expected Integer.toString(-255, 16) to be '-ff', got '%s'", got)
+	}
+}
+
+func TestIntegerBitOperations(t *testing.T) {
+	if got := integerWrapper.bitCount([]interface{}{int64(-1)}); got != int64(32) {
+		t.Errorf("Expected Integer.bitCount(-1) to be 32, got %v", got)
+	}
+	if got := longWrapper.bitCount([]interface{}{int64(-1), int64(-1)}); got != int64(64) {
+		t.Errorf("Expected Long.bitCount(-1) to be 64, got %v", got)
+	}
+	if got := integerWrapper.reverse([]interface{}{int64(1)}); got != int64(-2147483648) {
+		t.Errorf("Expected Integer.reverse(1) to be MIN_VALUE, got %v", got)
+	}
+	if got := integerWrapper.compare([]interface{}{int64(-5), int64(3)}); got != int64(-1) {
+		t.Errorf("Expected Integer.compare(-5, 3) to be -1, got %v", got)
+	}
+	if got := integerWrapper.hashCodeStatic([]interface{}{int64(-7)}); got != int64(-7) {
+		t.Errorf("Expected Integer.hashCode(-7) to be -7, got %v", got)
+	}
+	// Long.hashCode() is the xor of the high and low 32 bits
+	if got := longWrapper.hashCodeStatic([]interface{}{int64(1) << 32, int64(1) << 32}); got != int64(1) {
+		t.Errorf("Expected Long.hashCode(1<<32) to be 1, got %v", got)
+	}
+}
+
+func TestWrapperStatics(t *testing.T) {
+	LoadWrapperStatics()
+	if Statics["java/lang/Integer.MAX_VALUE"].Value != int64(2147483647) {
+		t.Errorf("Expected Integer.MAX_VALUE to be 2147483647, got %v", Statics["java/lang/Integer.MAX_VALUE"].Value)
+	}
+	if Statics["java/lang/Long.MIN_VALUE"].Value != int64(-9223372036854775808) {
+		t.Errorf("Expected Long.MIN_VALUE to be -9223372036854775808, got %v", Statics["java/lang/Long.MIN_VALUE"].Value)
+	}
+	if Statics["java/lang/Boolean.TRUE"].Value != boxBoolean(true) {
+		t.Errorf("Expected Boolean.TRUE to be the canonical instance")
+	}
+}
@@ -0,0 +1,248 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"math"
+	"strconv"
+	"sync"
+)
+
+/*
+ Support for the classes that box primitives: Integer, Long, Short, Byte,
+ Character, Boolean, Float and Double. A boxed value is an object of the
+ wrapper class whose field table holds the primitive in a field named "value",
+ just as the JDK's wrapper classes do. As elsewhere in Jacobin, all integral
+ values are held as int64 and float and double values as float64.
+
+ The natives for the individual classes are in javaLangInteger.go (which also
+ handles Long, Short and Byte), javaLangDouble.go (Double and Float),
+ javaLangCharacter.go and javaLangBoolean.go.
+*/
+
+const boxedValueField = "value"
+
+// wrapperPrimitives maps each wrapper class to the type of the primitive it boxes
+var wrapperPrimitives = map[string]string{
+	"java/lang/Integer":   types.Int,
+	"java/lang/Long":      types.Long,
+	"java/lang/Short":     types.Short,
+	"java/lang/Byte":      types.Byte,
+	"java/lang/Character": types.Char,
+	"java/lang/Boolean":   types.Bool,
+	"java/lang/Float":     types.Float,
+	"java/lang/Double":    types.Double,
+}
+
+// newBox creates a new instance of a wrapper class holding the value. Most code
+// should call box(), which returns the cached instance when the JDK would.
+func newBox(className string, value interface{}) *object.Object {
+	obj := object.MakeEmptyObject()
+	name := className
+	obj.Klass = &name
+	obj.FieldTable = map[string]object.Field{
+		boxedValueField: {Ftype: wrapperPrimitives[className], Fvalue: value},
+	}
+	return obj
+}
+
+// the caches of boxed values that valueOf() returns, so that, as in the JDK,
+// Integer.valueOf(127) == Integer.valueOf(127). For each class, the entry for
+// a value v is at index v+128.
+var boxCache = make(map[string]*[256]*object.Object)
+var boxCacheMutex sync.Mutex
+
+// box returns a boxed value, as the valueOf() methods do. Integers, Longs,
+// Shorts and Bytes from -128 to 127 and Characters from 0 to 127 are cached;
+// other values are always boxed in a new instance.
+func box(className string, value int64) *object.Object {
+	low := int64(-128)
+	if className == "java/lang/Character" {
+		low = 0
+	}
+	if value < low || value > 127 {
+		return newBox(className, value)
+	}
+
+	boxCacheMutex.Lock()
+	defer boxCacheMutex.Unlock()
+	cache, ok := boxCache[className]
+	if !ok {
+		cache = new([256]*object.Object)
+		boxCache[className] = cache
+	}
+	if cache[value+128] == nil {
+		cache[value+128] = newBox(className, value)
+	}
+	return cache[value+128]
+}
+
+// unboxValue returns the primitive value held by a boxed value
+func unboxValue(obj *object.Object) (interface{}, bool) {
+	if obj == nil || obj.FieldTable == nil || obj.Klass == nil {
+		return nil, false
+	}
+	if _, ok := wrapperPrimitives[*obj.Klass]; !ok {
+		return nil, false
+	}
+	fld, ok := obj.FieldTable[boxedValueField]
+	if !ok {
+		return nil, false
+	}
+	return fld.Fvalue, true
+}
+
+// unboxThis returns the value in the boxed object in params[0], the 'this' of
+// the wrappers' instance methods
+func unboxThis(params []interface{}) interface{} {
+	val, _ := unboxValue(params[0].(*object.Object))
+	return val
+}
+
+// boxedToString returns the string that toString() returns for a boxed value,
+// and false if the object is not a boxed value
+func boxedToString(obj *object.Object) (string, bool) {
+	val, ok := unboxValue(obj)
+	if !ok {
+		return "", false
+	}
+	switch *obj.Klass {
+	case "java/lang/Character":
+		return string(rune(val.(int64))), true
+	case "java/lang/Boolean":
+		return strconv.FormatBool(val.(int64) == types.JavaBoolTrue), true
+	case "java/lang/Float":
+		return floatToString(float32(val.(float64))), true
+	case "java/lang/Double":
+		return doubleToString(val.(float64)), true
+	default:
+		return strconv.FormatInt(val.(int64), 10), true
+	}
+}
+
+// boxedEquals implements equals() for all the wrapper classes: the other
+// object must be of the same class and hold the same value. (For Float and
+// Double, the same value means the same bits, so NaN equals NaN and 0.0
+// does not equal -0.0.)
+func boxedEquals(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	that, _ := params[1].(*object.Object)
+	thatVal, ok := unboxValue(that)
+	if !ok || *that.Klass != *this.Klass {
+		return types.JavaBoolFalse
+	}
+	thisVal, _ := unboxValue(this)
+
+	switch *this.Klass {
+	case "java/lang/Float":
+		return types.ConvertGoBoolToJavaBool(
+			floatToIntBits(thisVal.(float64)) == floatToIntBits(thatVal.(float64)))
+	case "java/lang/Double":
+		return types.ConvertGoBoolToJavaBool(
+			doubleToLongBits(thisVal.(float64)) == doubleToLongBits(thatVal.(float64)))
+	default:
+		return types.ConvertGoBoolToJavaBool(thisVal == thatVal)
+	}
+}
+
+// boxedToStringMethod implements the instance toString() of all the wrapper classes
+func boxedToStringMethod(params []interface{}) interface{} {
+	str, _ := boxedToString(params[0].(*object.Object))
+	return goStringToJava(str)
+}
+
+// boxedHashCode returns the value that hashCode() returns for a boxed value,
+// and false if the object is not a boxed value
+func boxedHashCode(obj *object.Object) (int64, bool) {
+	val, ok := unboxValue(obj)
+	if !ok {
+		return 0, false
+	}
+	switch *obj.Klass {
+	case "java/lang/Boolean":
+		return booleanHashCode([]interface{}{val}).(int64), true
+	case "java/lang/Long":
+		v := val.(int64)
+		return int64(int32(v ^ int64(uint64(v)>>32))), true
+	case "java/lang/Float":
+		return floatToIntBits(val.(float64)), true
+	case "java/lang/Double":
+		bits := doubleToLongBits(val.(float64))
+		return int64(int32(bits ^ int64(uint64(bits)>>32))), true
+	default:
+		return val.(int64), true
+	}
+}
+
+// boxInit implements the (deprecated) constructors that take a primitive,
+// such as Integer(int). The value is in params[1].
+func boxInit(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	if this.FieldTable == nil {
+		this.FieldTable = make(map[string]object.Field)
+	}
+	this.FieldTable[boxedValueField] = object.Field{Ftype: wrapperPrimitives[*this.Klass], Fvalue: params[1]}
+	return nil
+}
+
+// the exception thrown when a string cannot be parsed as a number
+func numberFormatException(msg string) error {
+	return throwNativeException(exceptions.NumberFormatException,
+		"java.lang.NumberFormatException", msg)
+}
+
+// forInputString duplicates the JDK's message for an unparseable number
+func forInputString(str string, radix int64) error {
+	msg := "For input string: \"" + str + "\""
+	if radix != 10 {
+		msg += " under radix " + strconv.FormatInt(radix, 10)
+	}
+	return numberFormatException(msg)
+}
+
+// === conversions between floating-point values and integers and bits ===
+
+// floatingToIntegral converts a float or double to an int or long as Java does:
+// NaN becomes 0 and values out of range become the min or max value
+func floatingToIntegral(f float64, bits int) int64 {
+	if math.IsNaN(f) {
+		return 0
+	}
+	min, max := int64(math.MinInt64), int64(math.MaxInt64)
+	if bits == 32 {
+		min, max = math.MinInt32, math.MaxInt32
+	}
+	switch {
+	case f <= float64(min):
+		return min
+	case f >= float64(max):
+		return max
+	default:
+		return int64(f)
+	}
+}
+
+// doubleToLongBits returns the bits of a double, with all NaNs collapsed
+// into the canonical NaN, as Double.doubleToLongBits() does
+func doubleToLongBits(d float64) int64 {
+	if math.IsNaN(d) {
+		return 0x7ff8000000000000
+	}
+	return int64(math.Float64bits(d))
+}
+
+// floatToIntBits returns the bits of a float, with all NaNs collapsed into the
+// canonical NaN, as Float.floatToIntBits() does
+func floatToIntBits(f float64) int64 {
+	if math.IsNaN(f) {
+		return 0x7fc00000
+	}
+	return int64(int32(math.Float32bits(float32(f))))
+}
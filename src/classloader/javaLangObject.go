@@ -0,0 +1,202 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"strings"
+)
+
+// Implementation of the identity-related methods of java/lang/Object. These
+// are the methods that are native in the JDK and which every class inherits
+// unless it overrides them.
+
+func Load_Lang_Object() map[string]GMeth {
+
+	MethodSignatures["java/lang/Object.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1, // [0] = this
+			GFunction:  objectHashCode,
+		}
+
+	MethodSignatures["java/lang/Object.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the object to compare to
+			GFunction:  objectEquals,
+		}
+
+	MethodSignatures["java/lang/Object.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  objectToString,
+		}
+
+	MethodSignatures["java/lang/Object.getClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  objectGetClass,
+		}
+
+	MethodSignatures["java/lang/Object.clone()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  objectClone,
+		}
+
+	return MethodSignatures
+}
+
+// java/lang/Object.hashCode() returns the identity hash code, which is
+// generated once and then stored in the object's mark word.
+func objectHashCode(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	return int64(object.IdentityHash(this))
+}
+
+// java/lang/Object.equals() is true only if the two references point
+// to the same object
+func objectEquals(params []interface{}) interface{} {
+	this, _ := params[0].(*object.Object)
+	that, _ := params[1].(*object.Object)
+	return types.ConvertGoBoolToJavaBool(this == that)
+}
+
+// java/lang/Object.toString() returns the class name (in java.lang.Object
+// format), followed by @ and the hash code in hex, e.g. java.lang.Object@1b6d3586
+func objectToString(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	str := fmt.Sprintf("%s@%x", javaClassName(this), object.IdentityHash(this))
+	return object.CreateCompactStringFromGoString(&str)
+}
+
+// java/lang/Object.getClass() returns the java/lang/Class instance for the
+// object's class. The same instance is returned for all objects of a class.
+func objectGetClass(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	return getClassObject(*this.Klass)
+}
+
+// java/lang/Object.clone() makes a shallow copy of an object: the fields
+// are copied, but objects the fields point to are not. Arrays of all types
+// can be cloned. Other objects can be cloned only if their class implements
+// java/lang/Cloneable; otherwise CloneNotSupportedException is thrown.
+func objectClone(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	if this == nil {
+		errMsg := "Object.clone(): null object"
+		exceptions.Throw(exceptions.NullPointerException, errMsg)
+		return errors.New(errMsg)
+	}
+
+	if this.Klass == nil { // an object that has no class can't be shown to be Cloneable
+		errMsg := "java.lang.CloneNotSupportedException: object has no class"
+		exceptions.Throw(exceptions.CloneNotSupportedException, errMsg)
+		return errors.New(errMsg)
+	}
+
+	className := *this.Klass
+	if !strings.HasPrefix(className, types.Array) && !isCloneable(className) {
+		errMsg := "java.lang.CloneNotSupportedException: " + strings.ReplaceAll(className, "/", ".")
+		exceptions.Throw(exceptions.CloneNotSupportedException, errMsg)
+		return errors.New(errMsg)
+	}
+
+	clone := object.MakeEmptyObject() // note: the clone gets its own identity hash
+	clone.Klass = this.Klass
+
+	for _, fld := range this.Fields {
+		clone.Fields = append(clone.Fields, object.Field{Ftype: fld.Ftype, Fvalue: cloneArrayValue(fld.Fvalue)})
+	}
+
+	if this.FieldTable != nil {
+		clone.FieldTable = make(map[string]object.Field, len(this.FieldTable))
+		for name, fld := range this.FieldTable {
+			clone.FieldTable[name] = fld
+		}
+	}
+	return clone
+}
+
+// arrays hold a pointer to the raw Go slice in their field. A clone of an array
+// needs a copy of that slice, otherwise the two arrays would share the same
+// elements. For all other field values, a plain copy is what's needed.
+func cloneArrayValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *[]byte:
+		c := make([]byte, len(*v))
+		copy(c, *v)
+		return &c
+	case *[]int64:
+		c := make([]int64, len(*v))
+		copy(c, *v)
+		return &c
+	case *[]float64:
+		c := make([]float64, len(*v))
+		copy(c, *v)
+		return &c
+	case *[]*object.Object:
+		c := make([]*object.Object, len(*v))
+		copy(c, *v)
+		return &c
+	default:
+		return value
+	}
+}
+
+// isCloneable determines whether the named class, one of its superclasses,
+// or any of their superinterfaces is java/lang/Cloneable.
+func isCloneable(className string) bool {
+	return implementsInterface(className, "java/lang/Cloneable")
+}
+
+// implementsInterface walks the class hierarchy of the named class, checking
+// whether the class (or its superclasses) implement the named interface,
+// either directly or via a superinterface.
+func implementsInterface(className string, interfaceName string) bool {
+	checked := make(map[string]bool)
+	toCheck := []string{className}
+
+	for len(toCheck) > 0 {
+		name := toCheck[0]
+		toCheck = toCheck[1:]
+		if name == interfaceName {
+			return true
+		}
+		if name == "" || checked[name] {
+			continue
+		}
+		checked[name] = true
+
+		k := MethAreaFetch(name)
+		if k == nil || k.Data == nil {
+			continue
+		}
+
+		for _, idx := range k.Data.Interfaces {
+			if int(idx) < len(k.Data.CP.Utf8Refs) {
+				toCheck = append(toCheck, k.RefKey(k.Data.CP.Utf8Refs[idx]))
+			}
+		}
+		if name != "java/lang/Object" {
+			toCheck = append(toCheck, k.RefKey(k.Data.Superclass))
+		}
+	}
+	return false
+}
+
+// javaClassName returns the class name of an object in the format Java
+// uses in Class.getName(), e.g. java.lang.String rather than java/lang/String
+func javaClassName(obj *object.Object) string {
+	if obj.Klass == nil {
+		return "java.lang.Object"
+	}
+	return strings.ReplaceAll(ClassNameOfKey(*obj.Klass), "/", ".")
+}
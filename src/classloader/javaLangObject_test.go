@@ -0,0 +1,161 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"io"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func TestObjectHashCodeIsStable(t *testing.T) {
+	obj := object.MakeEmptyObject()
+	h1 := objectHashCode([]interface{}{obj}).(int64)
+	h2 := objectHashCode([]interface{}{obj}).(int64)
+	if h1 != h2 {
+		t.Errorf("Expected hashCode() to return the same value twice, got %d and %d", h1, h2)
+	}
+	if h1 <= 0 {
+		t.Errorf("Expected a positive hash code, got %d", h1)
+	}
+
+	ih := identityHashCode([]interface{}{obj}).(int64)
+	if ih != h1 {
+		t.Errorf("Expected identityHashCode() to equal hashCode(), got %d and %d", ih, h1)
+	}
+
+	if identityHashCode([]interface{}{object.Null}).(int64) != 0 {
+		t.Errorf("Expected identityHashCode(null) to be 0")
+	}
+}
+
+func TestObjectEquals(t *testing.T) {
+	obj1 := object.MakeEmptyObject()
+	obj2 := object.MakeEmptyObject()
+
+	if objectEquals([]interface{}{obj1, obj1}).(int64) != types.JavaBoolTrue {
+		t.Errorf("Expected an object to equal itself")
+	}
+	if objectEquals([]interface{}{obj1, obj2}).(int64) != types.JavaBoolFalse {
+		t.Errorf("Expected two distinct objects not to be equal")
+	}
+	if objectEquals([]interface{}{obj1, object.Null}).(int64) != types.JavaBoolFalse {
+		t.Errorf("Expected an object not to equal null")
+	}
+}
+
+func TestObjectToString(t *testing.T) {
+	obj := object.MakeEmptyObject()
+	className := "com/example/Widget"
+	obj.Klass = &className
+
+	str := objectToString([]interface{}{obj}).(*object.Object)
+	expected := fmt.Sprintf("com.example.Widget@%x", object.IdentityHash(obj))
+	if object.GetGoStringFromJavaStringPtr(str) != expected {
+		t.Errorf("Expected toString() to return %s, got %s",
+			expected, object.GetGoStringFromJavaStringPtr(str))
+	}
+}
+
+func TestObjectGetClassReturnsSameInstance(t *testing.T) {
+	className := "com/example/Widget"
+	obj1 := object.MakeEmptyObject()
+	obj1.Klass = &className
+	obj2 := object.MakeEmptyObject()
+	obj2.Klass = &className
+
+	c1 := objectGetClass([]interface{}{obj1}).(*object.Object)
+	c2 := objectGetClass([]interface{}{obj2}).(*object.Object)
+	if c1 != c2 {
+		t.Errorf("Expected getClass() to return the same Class object for the same class")
+	}
+
+	name := c1.FieldTable["name"].Fvalue.(*object.Object)
+	if object.GetGoStringFromJavaStringPtr(name) != "com.example.Widget" {
+		t.Errorf("Expected class name com.example.Widget, got %s",
+			object.GetGoStringFromJavaStringPtr(name))
+	}
+}
+
+func TestObjectCloneArray(t *testing.T) {
+	arr := object.Make1DimArray(object.INT, 3)
+	values := arr.Fields[0].Fvalue.(*[]int64)
+	(*values)[0] = 42
+
+	clone := objectClone([]interface{}{arr}).(*object.Object)
+	cloneValues := clone.Fields[0].Fvalue.(*[]int64)
+	if (*cloneValues)[0] != 42 {
+		t.Errorf("Expected cloned array element to be 42, got %d", (*cloneValues)[0])
+	}
+
+	(*cloneValues)[0] = 7
+	if (*values)[0] != 42 {
+		t.Errorf("Expected clone to have its own copy of the array elements")
+	}
+	if object.IdentityHash(clone) == object.IdentityHash(arr) {
+		t.Errorf("Expected clone to have its own identity hash")
+	}
+}
+
+func TestObjectCloneCloneableAndNot(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+
+	// a class that implements Cloneable via its superclass
+	MethAreaInsert("Base", &Klass{Status: 'F', Loader: "app", Data: &ClData{
+		Name: "Base", Superclass: "java/lang/Object",
+		Interfaces: []uint16{0},
+		CP:         CPool{Utf8Refs: []string{"java/lang/Cloneable"}},
+	}})
+	MethAreaInsert("Derived", &Klass{Status: 'F', Loader: "app", Data: &ClData{
+		Name: "Derived", Superclass: "Base",
+	}})
+	MethAreaInsert("Plain", &Klass{Status: 'F', Loader: "app", Data: &ClData{
+		Name: "Plain", Superclass: "java/lang/Object",
+	}})
+
+	derivedName := "Derived"
+	derived := object.MakeEmptyObject()
+	derived.Klass = &derivedName
+	derived.Fields = append(derived.Fields, object.Field{Ftype: types.Int, Fvalue: int64(5)})
+
+	clone, ok := objectClone([]interface{}{derived}).(*object.Object)
+	if !ok {
+		t.Fatalf("Expected clone of a Cloneable object to succeed")
+	}
+	if clone == derived || clone.Fields[0].Fvalue.(int64) != 5 {
+		t.Errorf("Expected a distinct shallow copy of the object")
+	}
+
+	// redirect stderr, so as not to clutter the output with the exception message
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	plainName := "Plain"
+	plain := object.MakeEmptyObject()
+	plain.Klass = &plainName
+	ret := objectClone([]interface{}{plain})
+	noClass := objectClone([]interface{}{object.MakeEmptyObject()})
+
+	_ = w.Close()
+	_, _ = io.ReadAll(r)
+	os.Stderr = normalStderr
+
+	if _, isErr := ret.(error); !isErr {
+		t.Errorf("Expected CloneNotSupportedException for a non-Cloneable class, got %v", ret)
+	}
+	if _, isErr := noClass.(error); !isErr {
+		t.Errorf("Expected CloneNotSupportedException for an object with no class, got %v", noClass)
+	}
+}
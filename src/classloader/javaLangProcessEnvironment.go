@@ -0,0 +1,1233 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"jacobin/exceptions"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Access to the environment of the process, via System.getenv() and
+// java/lang/ProcessEnvironment, which is where the JDK's System.getenv() and
+// ProcessBuilder get it. As in the JDK, the environment is read once, the
+// first time it's needed; later changes to it are not seen. The -setenv and
+// -unsetenv options override and mask variables, so that tests can run with
+// the same environment everywhere.
+//
+// The maps of environment variables are implemented in Go, as instances of
+// ProcessEnvironment$StringEnvironment that hold their Go state in the field
+// "m", which is the field that holds the underlying map in the JDK. The map
+// that System.getenv() returns can't be modified. (In the JDK, it's a
+// Collections$UnmodifiableMap that wraps a StringEnvironment, but that class
+// is used for other maps, which must not run these Go functions.) The map that
+// ProcessEnvironment.environment() returns, for use by ProcessBuilder, is a
+// copy that can be modified.
+//
+// The views of a map that keySet(), values() and entrySet() return, their
+// iterators and the entries of entrySet() are implemented in Go as well, as
+// instances of the classes the JDK uses for them. They're live: changes to
+// the map are seen in the views, and removing from a view (or setting the
+// value of an entry) changes the map, if it can be modified. An iterator
+// returns the variables that were in the map when it was created, in name order.
+
+const envMapField = "m"
+const stringEnvironmentClass = "java/lang/ProcessEnvironment$StringEnvironment"
+
+// the fields that hold the Go state of the views, iterators and entries
+const envViewField = "s"
+const envIteratorField = "i"
+const envEntryField = "e"
+
+// the kinds of view of a map, and the classes of the views and of their iterators
+const (
+	envKeys = iota
+	envValues
+	envEntries
+)
+
+var envViewClasses = [...]string{
+	envKeys:    "java/lang/ProcessEnvironment$StringKeySet",
+	envValues:  "java/lang/ProcessEnvironment$StringValues",
+	envEntries: "java/lang/ProcessEnvironment$StringEntrySet",
+}
+
+var envIteratorClasses = [...]string{
+	envKeys:    "java/lang/ProcessEnvironment$StringKeySet$1",
+	envValues:  "java/lang/ProcessEnvironment$StringValues$1",
+	envEntries: "java/lang/ProcessEnvironment$StringEntrySet$1",
+}
+
+const envEntryClass = "java/lang/ProcessEnvironment$StringEntry"
+
+// envMap is the Go state of a map of environment variables
+type envMap struct {
+	vars     *properties // see javaUtilProperties.go
+	readOnly bool
+}
+
+// envView is the Go state of a view of a map: its keys, values or entries
+type envView struct {
+	env  *envMap
+	kind int
+}
+
+// envIterator is the Go state of an iterator over a view
+type envIterator struct {
+	view  envView
+	names []string // the names of the variables to return, in order
+	next  int
+}
+
+// envEntry is the Go state of an entry of a map. Its value is the value of the
+// variable when the entry was returned, or that set by setValue().
+type envEntry struct {
+	env   *envMap
+	name  string
+	value string
+}
+
+// the environment, created on first use
+var environment *properties
+var environmentObj *object.Object
+var environmentOnce sync.Once
+
+// getEnvironment returns the environment, with the overrides of the command line applied
+func getEnvironment() *properties {
+	environmentOnce.Do(func() {
+		environment = newProperties()
+		for _, entry := range os.Environ() {
+			// on Windows, there are entries such as "=C:=C:\dir" that aren't variables
+			name, value, found := strings.Cut(entry, "=")
+			if found && name != "" {
+				environment.values[name] = value
+			}
+		}
+		for name, value := range globals.GetGlobalRef().EnvOverrides {
+			name = envVariableName(name)
+			if value == nil {
+				delete(environment.values, name)
+			} else {
+				environment.values[name] = *value
+			}
+		}
+		environmentObj = newEnvMapObject(&envMap{vars: environment, readOnly: true})
+	})
+	return environment
+}
+
+// envVariableName returns the name under which a variable is stored. On
+// Windows, the names of variables are not case-sensitive, so an override of
+// "Path" must replace "PATH".
+func envVariableName(name string) string {
+	if runtime.GOOS != "windows" {
+		return name
+	}
+	for existing := range environment.values {
+		if strings.EqualFold(existing, name) {
+			return existing
+		}
+	}
+	return name
+}
+
+// newEnvMapObject creates a map object for the Go state
+func newEnvMapObject(env *envMap) *object.Object {
+	obj := object.MakeEmptyObject()
+	className := stringEnvironmentClass
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		envMapField: {Ftype: types.GoObject, Fvalue: env},
+	}
+	return obj
+}
+
+// getEnvMap returns the Go state of the map in params[0], and whether the
+// map can be modified
+func getEnvMap(params []interface{}) (*properties, bool, error) {
+	env, err := envMapThis(params)
+	if err != nil {
+		return nil, false, err
+	}
+	return env.vars, !env.readOnly, nil
+}
+
+func envMapThis(params []interface{}) (*envMap, error) {
+	if env, ok := envMapOf(params[0]); ok {
+		return env, nil
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of this map")
+}
+
+// envMapOf returns the Go state of a map of environment variables
+func envMapOf(param interface{}) (*envMap, bool) {
+	env, ok := goStateField(param, envMapField).(*envMap)
+	return env, ok
+}
+
+// hasEnvMap is true if the map in params[0] is implemented in Go. The Go
+// functions here handle only those maps; the JDK's code handles the others.
+func hasEnvMap(params []interface{}) bool {
+	_, ok := envMapOf(params[0])
+	return ok
+}
+
+// hasEnvView, hasEnvIterator and hasEnvEntry are the same for views, iterators and entries
+func hasEnvView(params []interface{}) bool {
+	_, ok := envViewOf(params[0])
+	return ok
+}
+
+func hasEnvIterator(params []interface{}) bool {
+	_, ok := envIteratorOf(params[0])
+	return ok
+}
+
+func hasEnvEntry(params []interface{}) bool {
+	_, ok := envEntryOf(params[0])
+	return ok
+}
+
+// goStateField returns the Go state held in the field of an object, or nil
+func goStateField(param interface{}, field string) interface{} {
+	obj, _ := param.(*object.Object)
+	if obj == nil || obj.FieldTable == nil {
+		return nil
+	}
+	return obj.FieldTable[field].Fvalue
+}
+
+func envViewOf(param interface{}) (*envView, bool) {
+	view, ok := goStateField(param, envViewField).(*envView)
+	return view, ok
+}
+
+func envIteratorOf(param interface{}) (*envIterator, bool) {
+	it, ok := goStateField(param, envIteratorField).(*envIterator)
+	return it, ok
+}
+
+func envEntryOf(param interface{}) (*envEntry, bool) {
+	entry, ok := goStateField(param, envEntryField).(*envEntry)
+	return entry, ok
+}
+
+// newGoStateObject creates an object of the class that holds the Go state in the field
+func newGoStateObject(className, field string, state interface{}) *object.Object {
+	obj := object.MakeEmptyObject()
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		field: {Ftype: types.GoObject, Fvalue: state},
+	}
+	return obj
+}
+
+func Load_Lang_ProcessEnvironment() map[string]GMeth {
+
+	MethodSignatures["java/lang/ProcessEnvironment.getenv(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  getenv,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment.getenv()Ljava/util/Map;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getenvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment.environment()Ljava/util/Map;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  environmentCopy,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment.environ()[[B"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  environ,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.get(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapGet,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.getOrDefault(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  envMapGet,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.containsKey(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapContainsKey,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.containsValue(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapContainsValue,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.size()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapSize,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapIsEmpty,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapToString,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.put(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  envMapPut,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.remove(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapRemove,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.clear()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapClear,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.keySet()Ljava/util/Set;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapKeySet,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.values()Ljava/util/Collection;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapValues,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.entrySet()Ljava/util/Set;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapEntrySet,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.forEach(Ljava/util/function/BiConsumer;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapForEach,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envMapEquals,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEnvironment.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envMapHashCode,
+			Accepts:    hasEnvMap,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.iterator()Ljava/util/Iterator;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIterator,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.size()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewSize,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIsEmpty,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.contains(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewContains,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.remove(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewRemove,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.clear()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewClear,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewToString,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.iterator()Ljava/util/Iterator;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIterator,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.size()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewSize,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIsEmpty,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.contains(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewContains,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.remove(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewRemove,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.clear()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewClear,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewToString,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.iterator()Ljava/util/Iterator;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIterator,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.size()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewSize,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewIsEmpty,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.contains(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewContains,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.remove(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envViewRemove,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.clear()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewClear,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envViewToString,
+			Accepts:    hasEnvView,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet$1.hasNext()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorHasNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet$1.next()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringKeySet$1.remove()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorRemove,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues$1.hasNext()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorHasNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues$1.next()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringValues$1.remove()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorRemove,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet$1.hasNext()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorHasNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet$1.next()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorNext,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntrySet$1.remove()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envIteratorRemove,
+			Accepts:    hasEnvIterator,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.getKey()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envEntryGetKey,
+			Accepts:    hasEnvEntry,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.getValue()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envEntryGetValue,
+			Accepts:    hasEnvEntry,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.setValue(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envEntrySetValue,
+			Accepts:    hasEnvEntry,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  envEntryEquals,
+			Accepts:    hasEnvEntry,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envEntryHashCode,
+			Accepts:    hasEnvEntry,
+		}
+
+	MethodSignatures["java/lang/ProcessEnvironment$StringEntry.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  envEntryToString,
+			Accepts:    hasEnvEntry,
+		}
+
+	return MethodSignatures
+}
+
+// System.getenv(String name) returns the value of the variable, or null if
+// it isn't set
+func getenv(params []interface{}) interface{} {
+	name, err := propertyString(params[0])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(getEnvironment().get(name))
+}
+
+// System.getenv() returns the environment as an unmodifiable Map<String,String>.
+// It's always the same map.
+func getenvMap([]interface{}) interface{} {
+	getEnvironment()
+	return environmentObj
+}
+
+// ProcessEnvironment.environment() returns a modifiable copy of the environment
+func environmentCopy([]interface{}) interface{} {
+	env := getEnvironment()
+	env.mutex.RLock()
+	defer env.mutex.RUnlock()
+	envCopy := newProperties()
+	for name, value := range env.values {
+		envCopy.values[name] = value
+	}
+	return newEnvMapObject(&envMap{vars: envCopy})
+}
+
+// ProcessEnvironment.environ() returns the environment as the JDK's native
+// method does: an array of byte arrays that alternate between the name and
+// the value of each variable
+func environ([]interface{}) interface{} {
+	env := getEnvironment()
+	names := env.sortedKeys()
+	arr := object.Make1DimArray(object.REF, int64(2*len(names)))
+	arr.Fields[0].Ftype = "[[B"
+	entries := *(arr.Fields[0].Fvalue.(*[]*object.Object))
+	for i, name := range names {
+		value, _ := env.get(name)
+		for j, str := range []string{name, value} {
+			entry := object.Make1DimArray(object.BYTE, int64(len(str)))
+			copy(*(entry.Fields[0].Fvalue.(*[]byte)), str)
+			entries[2*i+j] = entry
+		}
+	}
+	return arr
+}
+
+// get(name) and getOrDefault(name, defaultValue) return the value of the
+// variable, or the default (or null) if it isn't set
+func envMapGet(params []interface{}) interface{} {
+	env, _, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	name, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	if value, ok := env.get(name); ok {
+		return goStringToJava(value)
+	}
+	if len(params) > 2 {
+		return params[2]
+	}
+	return object.Null
+}
+
+func envMapContainsKey(params []interface{}) interface{} {
+	env, _, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	name, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	_, ok := env.get(name)
+	return types.ConvertGoBoolToJavaBool(ok)
+}
+
+func envMapContainsValue(params []interface{}) interface{} {
+	env, _, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	value, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	env.mutex.RLock()
+	defer env.mutex.RUnlock()
+	for _, v := range env.values {
+		if v == value {
+			return types.JavaBoolTrue
+		}
+	}
+	return types.JavaBoolFalse
+}
+
+func envMapSize(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	return int64(env.size())
+}
+
+func envMapIsEmpty(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(env.size() == 0)
+}
+
+func (env *envMap) size() int {
+	env.vars.mutex.RLock()
+	defer env.vars.mutex.RUnlock()
+	return len(env.vars.values)
+}
+
+// toString() returns the variables as {NAME1=value1, NAME2=value2}, in name order
+func envMapToString(params []interface{}) interface{} {
+	env, _, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	return propertiesToString([]interface{}{newPropertiesObject(env)})
+}
+
+// unsupportedModification is the exception for an attempt to change the map
+// that System.getenv() returns
+func unsupportedModification() error {
+	return throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "")
+}
+
+// put(name, value) returns the previous value, or null
+func envMapPut(params []interface{}) interface{} {
+	env, modifiable, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	if !modifiable {
+		return unsupportedModification()
+	}
+	name, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	value, err := propertyString(params[2])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(env.set(name, value))
+}
+
+// remove(name) returns the value that was removed, or null
+func envMapRemove(params []interface{}) interface{} {
+	env, modifiable, err := getEnvMap(params)
+	if err != nil {
+		return err
+	}
+	if !modifiable {
+		return unsupportedModification()
+	}
+	name, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(env.remove(name))
+}
+
+func envMapClear(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	return env.clear()
+}
+
+// clear removes all the variables, or returns an exception if the map can't be modified
+func (env *envMap) clear() error {
+	if env.readOnly {
+		return unsupportedModification()
+	}
+	env.vars.mutex.Lock()
+	defer env.vars.mutex.Unlock()
+	env.vars.values = make(map[string]string)
+	return nil
+}
+
+// ---- the views of a map ----
+
+func envMapKeySet(params []interface{}) interface{} {
+	return newEnvView(params, envKeys)
+}
+
+func envMapValues(params []interface{}) interface{} {
+	return newEnvView(params, envValues)
+}
+
+func envMapEntrySet(params []interface{}) interface{} {
+	return newEnvView(params, envEntries)
+}
+
+func newEnvView(params []interface{}, kind int) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	return newGoStateObject(envViewClasses[kind], envViewField, &envView{env: env, kind: kind})
+}
+
+func envViewThis(params []interface{}) (*envView, error) {
+	if view, ok := envViewOf(params[0]); ok {
+		return view, nil
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of this collection")
+}
+
+// element returns the element of the view for a variable: its name, its value or an entry
+func (view *envView) element(name, value string) *object.Object {
+	switch view.kind {
+	case envKeys:
+		return goStringToJava(name)
+	case envValues:
+		return goStringToJava(value)
+	default:
+		return newGoStateObject(envEntryClass, envEntryField, &envEntry{env: view.env, name: name, value: value})
+	}
+}
+
+// find returns the name of a variable whose element of the view equals the object
+func (view *envView) find(obj *object.Object) (string, bool) {
+	switch view.kind {
+	case envKeys:
+		if !object.IsJavaString(obj) {
+			return "", false
+		}
+		name := javaToString(obj)
+		_, ok := view.env.vars.get(name)
+		return name, ok
+	case envValues:
+		if !object.IsJavaString(obj) {
+			return "", false
+		}
+		value := javaToString(obj)
+		for _, name := range view.env.vars.sortedKeys() {
+			if v, ok := view.env.vars.get(name); ok && v == value {
+				return name, true
+			}
+		}
+		return "", false
+	default:
+		name, value, ok := mapEntryStrings(obj)
+		if !ok {
+			return "", false
+		}
+		v, ok := view.env.vars.get(name)
+		return name, ok && v == value
+	}
+}
+
+// mapEntryStrings returns the key and value of an entry of a map of environment
+// variables, or of any other Map.Entry whose key and value are strings
+func mapEntryStrings(obj *object.Object) (string, string, bool) {
+	if entry, ok := envEntryOf(obj); ok {
+		return entry.name, entry.value, true
+	}
+	if obj == nil || obj.Klass == nil || javaMethodRunner == nil ||
+		!implementsInterface(*obj.Klass, "java/util/Map$Entry") {
+		return "", "", false
+	}
+	key, err := javaMethodRunner(obj, "getKey", "()Ljava/lang/Object;")
+	if err != nil {
+		return "", "", false
+	}
+	value, err := javaMethodRunner(obj, "getValue", "()Ljava/lang/Object;")
+	if err != nil {
+		return "", "", false
+	}
+	keyObj, _ := key.(*object.Object)
+	valueObj, _ := value.(*object.Object)
+	if !object.IsJavaString(keyObj) || !object.IsJavaString(valueObj) {
+		return "", "", false
+	}
+	return javaToString(keyObj), javaToString(valueObj), true
+}
+
+// iterator() returns an iterator over the variables in the map now
+func envViewIterator(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	it := &envIterator{view: *view, names: view.env.vars.sortedKeys()}
+	return newGoStateObject(envIteratorClasses[view.kind], envIteratorField, it)
+}
+
+func envViewSize(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	return int64(view.env.size())
+}
+
+func envViewIsEmpty(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(view.env.size() == 0)
+}
+
+func envViewContains(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	obj, _ := params[1].(*object.Object)
+	_, ok := view.find(obj)
+	return types.ConvertGoBoolToJavaBool(ok)
+}
+
+// remove(o) removes the variable whose element is o, and returns whether there was one
+func envViewRemove(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	if view.env.readOnly {
+		return unsupportedModification()
+	}
+	obj, _ := params[1].(*object.Object)
+	name, ok := view.find(obj)
+	if ok {
+		view.env.vars.remove(name)
+	}
+	return types.ConvertGoBoolToJavaBool(ok)
+}
+
+func envViewClear(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	return view.env.clear()
+}
+
+// toString() returns the elements as [e1, e2], in name order, as AbstractCollection does
+func envViewToString(params []interface{}) interface{} {
+	view, err := envViewThis(params)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, name := range view.env.vars.sortedKeys() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		value, _ := view.env.vars.get(name)
+		switch view.kind {
+		case envKeys:
+			sb.WriteString(name)
+		case envValues:
+			sb.WriteString(value)
+		default:
+			sb.WriteString(name + "=" + value)
+		}
+	}
+	sb.WriteByte(']')
+	return goStringToJava(sb.String())
+}
+
+// ---- iterating ----
+
+func envIteratorThis(params []interface{}) (*envIterator, error) {
+	if it, ok := envIteratorOf(params[0]); ok {
+		return it, nil
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of the Iterator")
+}
+
+func envIteratorHasNext(params []interface{}) interface{} {
+	it, err := envIteratorThis(params)
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(it.next < len(it.names))
+}
+
+// next() returns the element for the next variable. A variable that has been
+// removed from the map other than by this iterator is a ConcurrentModificationException.
+func envIteratorNext(params []interface{}) interface{} {
+	it, err := envIteratorThis(params)
+	if err != nil {
+		return err
+	}
+	if it.next >= len(it.names) {
+		return throwNativeException(exceptions.NoSuchElementException, "java.util.NoSuchElementException", "")
+	}
+	name := it.names[it.next]
+	value, ok := it.view.env.vars.get(name)
+	if !ok {
+		return throwNativeException(exceptions.ConcurrentModificationException,
+			"java.util.ConcurrentModificationException", "")
+	}
+	it.next++
+	return it.view.element(name, value)
+}
+
+// remove() removes the variable that next() returned last
+func envIteratorRemove(params []interface{}) interface{} {
+	it, err := envIteratorThis(params)
+	if err != nil {
+		return err
+	}
+	if it.view.env.readOnly {
+		return unsupportedModification()
+	}
+	if it.next == 0 || it.names[it.next-1] == "" {
+		return throwNativeException(exceptions.IllegalStateException, "java.lang.IllegalStateException", "")
+	}
+	it.view.env.vars.remove(it.names[it.next-1])
+	it.names[it.next-1] = "" // it can't be removed twice
+	return nil
+}
+
+// ---- the entries of a map ----
+
+func envEntryThis(params []interface{}) (*envEntry, error) {
+	if entry, ok := envEntryOf(params[0]); ok {
+		return entry, nil
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of this Map.Entry")
+}
+
+func envEntryGetKey(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(entry.name)
+}
+
+func envEntryGetValue(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(entry.value)
+}
+
+// setValue(value) sets the value of the variable in the map and returns the
+// previous value of the entry
+func envEntrySetValue(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	if entry.env.readOnly {
+		return unsupportedModification()
+	}
+	value, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	prev := entry.value
+	entry.env.vars.set(entry.name, value)
+	entry.value = value
+	return goStringToJava(prev)
+}
+
+// equals(o) is true if o is a Map.Entry with the same key and value
+func envEntryEquals(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	obj, _ := params[1].(*object.Object)
+	name, value, ok := mapEntryStrings(obj)
+	return types.ConvertGoBoolToJavaBool(ok && name == entry.name && value == entry.value)
+}
+
+// hashCode() is the hash code of the key XOR that of the value, as Map.Entry specifies
+func envEntryHashCode(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	return envEntryHash(entry.name, entry.value)
+}
+
+func envEntryHash(name, value string) int64 {
+	return javaHashCode(goStringToJava(name)) ^ javaHashCode(goStringToJava(value))
+}
+
+func envEntryToString(params []interface{}) interface{} {
+	entry, err := envEntryThis(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(entry.name + "=" + entry.value)
+}
+
+// ---- the map as a whole ----
+
+// forEach(action) calls action.accept(name, value) for each variable, in name order
+func envMapForEach(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	action, _ := params[1].(*object.Object)
+	if action == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Map.forEach(): null action")
+	}
+	if javaMethodRunner == nil {
+		return errors.New("no runner for the Java method of the action")
+	}
+	for _, name := range env.vars.sortedKeys() {
+		value, ok := env.vars.get(name)
+		if !ok {
+			continue
+		}
+		_, err = javaMethodRunner(action, "accept", "(Ljava/lang/Object;Ljava/lang/Object;)V",
+			goStringToJava(name), goStringToJava(value))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equals(o) is true if o is a map with the same variables, as Map specifies.
+// The variables are compared directly if o is also implemented in Go, and
+// through the Java methods size() and get() of o if it's another Map.
+func envMapEquals(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	obj, _ := params[1].(*object.Object)
+	if other, ok := envMapOf(obj); ok {
+		return types.ConvertGoBoolToJavaBool(other == env || sameVariables(env.vars, other.vars))
+	}
+	if obj == nil || obj.Klass == nil || javaMethodRunner == nil ||
+		!implementsInterface(*obj.Klass, "java/util/Map") {
+		return types.JavaBoolFalse
+	}
+
+	names := env.vars.sortedKeys()
+	size, err := javaMethodRunner(obj, "size", "()I")
+	if err != nil || size != int64(len(names)) {
+		return types.JavaBoolFalse
+	}
+	for _, name := range names {
+		value, _ := env.vars.get(name)
+		ret, err := javaMethodRunner(obj, "get", "(Ljava/lang/Object;)Ljava/lang/Object;", goStringToJava(name))
+		otherValue, _ := ret.(*object.Object)
+		if err != nil || !object.IsJavaString(otherValue) || javaToString(otherValue) != value {
+			return types.JavaBoolFalse
+		}
+	}
+	return types.JavaBoolTrue
+}
+
+func sameVariables(a, b *properties) bool {
+	namesA, namesB := a.sortedKeys(), b.sortedKeys()
+	if len(namesA) != len(namesB) {
+		return false
+	}
+	for i, name := range namesA {
+		valueA, _ := a.get(name)
+		valueB, okB := b.get(name)
+		if namesB[i] != name || !okB || valueA != valueB {
+			return false
+		}
+	}
+	return true
+}
+
+// hashCode() is the sum of the hash codes of the entries, as Map specifies
+func envMapHashCode(params []interface{}) interface{} {
+	env, err := envMapThis(params)
+	if err != nil {
+		return err
+	}
+	var hash int32
+	env.vars.mutex.RLock()
+	defer env.vars.mutex.RUnlock()
+	for name, value := range env.vars.values {
+		hash += int32(envEntryHash(name, value))
+	}
+	return int64(hash)
+}
@@ -0,0 +1,201 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+// The environment is read once, so this is the only test that sets it up
+func TestGetenvWithOverrides(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	_ = os.Setenv("JACOBIN_TEST_KEPT", "kept")
+	_ = os.Setenv("JACOBIN_TEST_MASKED", "masked")
+	_ = os.Setenv("JACOBIN_TEST_OVERRIDDEN", "actual")
+	defer func() {
+		_ = os.Unsetenv("JACOBIN_TEST_KEPT")
+		_ = os.Unsetenv("JACOBIN_TEST_MASKED")
+		_ = os.Unsetenv("JACOBIN_TEST_OVERRIDDEN")
+	}()
+	overridden := "override"
+	overrides := globals.GetGlobalRef().EnvOverrides
+	overrides["JACOBIN_TEST_MASKED"] = nil
+	overrides["JACOBIN_TEST_OVERRIDDEN"] = &overridden
+
+	expected := map[string]string{
+		"JACOBIN_TEST_KEPT":       "kept",
+		"JACOBIN_TEST_OVERRIDDEN": "override",
+	}
+	for name, value := range expected {
+		if got := javaToString(getenv([]interface{}{jstr(name)}).(*object.Object)); got != value {
+			t.Errorf("Expected getenv(%q) to return %q, got %q", name, value, got)
+		}
+	}
+	if got := getenv([]interface{}{jstr("JACOBIN_TEST_MASKED")}); got != object.Null {
+		t.Errorf("Expected a masked variable to be null, got %v", got)
+	}
+
+	env := getenvMap(nil).(*object.Object)
+	if got := envMapContainsKey([]interface{}{env, jstr("JACOBIN_TEST_MASKED")}); got != types.JavaBoolFalse {
+		t.Errorf("Expected the map not to contain a masked variable")
+	}
+	got := envMapGet([]interface{}{env, jstr("JACOBIN_TEST_NONE"), jstr("default")}).(*object.Object)
+	if javaToString(got) != "default" {
+		t.Errorf("Expected getOrDefault() of an unset variable to return the default, got %s", javaToString(got))
+	}
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	err, ok := envMapPut([]interface{}{env, jstr("A"), jstr("B")}).(error)
+	_ = w.Close()
+	os.Stderr = normalStderr
+	if !ok || err.Error() != "java.lang.UnsupportedOperationException" {
+		t.Errorf("Expected UnsupportedOperationException from put() on the map of getenv(), got %v", err)
+	}
+
+	// the copy for ProcessBuilder can be modified without changing the environment
+	envCopy := environmentCopy(nil).(*object.Object)
+	envMapRemove([]interface{}{envCopy, jstr("JACOBIN_TEST_KEPT")})
+	if envMapContainsKey([]interface{}{envCopy, jstr("JACOBIN_TEST_KEPT")}) != types.JavaBoolFalse ||
+		envMapContainsKey([]interface{}{env, jstr("JACOBIN_TEST_KEPT")}) != types.JavaBoolTrue {
+		t.Errorf("Expected remove() to change only the copy of the environment")
+	}
+}
+
+func newTestEnvMap(readOnly bool, vars map[string]string) *object.Object {
+	p := newProperties()
+	for name, value := range vars {
+		p.values[name] = value
+	}
+	return newEnvMapObject(&envMap{vars: p, readOnly: readOnly})
+}
+
+// iterateEnvView returns the elements of a view of a map, as strings
+func iterateEnvView(view *object.Object) []string {
+	var elements []string
+	it := envViewIterator([]interface{}{view}).(*object.Object)
+	for envIteratorHasNext([]interface{}{it}) == types.JavaBoolTrue {
+		elem := envIteratorNext([]interface{}{it}).(*object.Object)
+		if object.IsJavaString(elem) {
+			elements = append(elements, javaToString(elem))
+		} else {
+			elements = append(elements, javaToString(envEntryToString([]interface{}{elem}).(*object.Object)))
+		}
+	}
+	return elements
+}
+
+func TestEnvMapViews(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	env := newTestEnvMap(false, map[string]string{"B": "2", "A": "1"})
+	keys := envMapKeySet([]interface{}{env}).(*object.Object)
+	values := envMapValues([]interface{}{env}).(*object.Object)
+	entries := envMapEntrySet([]interface{}{env}).(*object.Object)
+
+	for _, test := range []struct {
+		view     *object.Object
+		expected string
+	}{{keys, "[A B]"}, {values, "[1 2]"}, {entries, "[A=1 B=2]"}} {
+		if got := fmt.Sprint(iterateEnvView(test.view)); got != test.expected {
+			t.Errorf("Expected the view to iterate over %s, got %s", test.expected, got)
+		}
+	}
+	if got := javaToString(envViewToString([]interface{}{entries}).(*object.Object)); got != "[A=1, B=2]" {
+		t.Errorf("Expected toString() of the entries to be [A=1, B=2], got %s", got)
+	}
+	if envViewContains([]interface{}{values, jstr("2")}) != types.JavaBoolTrue ||
+		envViewContains([]interface{}{keys, jstr("2")}) != types.JavaBoolFalse {
+		t.Errorf("Expected contains() to look for values in values() and for names in keySet()")
+	}
+
+	// setting the value of an entry changes the map
+	it := envViewIterator([]interface{}{entries}).(*object.Object)
+	entry := envIteratorNext([]interface{}{it}).(*object.Object)
+	prev := envEntrySetValue([]interface{}{entry, jstr("one")}).(*object.Object)
+	if javaToString(prev) != "1" || javaToString(envMapGet([]interface{}{env, jstr("A")}).(*object.Object)) != "one" {
+		t.Errorf("Expected setValue() to change the map and return the previous value, got %s", javaToString(prev))
+	}
+	if envViewContains([]interface{}{entries, entry}) != types.JavaBoolTrue {
+		t.Errorf("Expected the entry set to contain the entry after setValue()")
+	}
+
+	// removing from an iterator or a view changes the map
+	envIteratorRemove([]interface{}{it})
+	envViewRemove([]interface{}{values, jstr("2")})
+	if envMapSize([]interface{}{env}) != int64(0) || envViewIsEmpty([]interface{}{keys}) != types.JavaBoolTrue {
+		t.Errorf("Expected the map to be empty after removing its variables via the views")
+	}
+}
+
+func TestEnvMapEqualsAndHashCode(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	vars := map[string]string{"HOME": "/home/jacobin", "SHELL": "/bin/sh"}
+	env1 := newTestEnvMap(true, vars)
+	env2 := newTestEnvMap(false, vars)
+	if envMapEquals([]interface{}{env1, env2}) != types.JavaBoolTrue {
+		t.Errorf("Expected maps with the same variables to be equal")
+	}
+	if envMapEquals([]interface{}{env1, newTestEnvMap(false, map[string]string{"HOME": "/"})}) != types.JavaBoolFalse {
+		t.Errorf("Expected maps with different variables not to be equal")
+	}
+
+	// the sum of the hash codes of the entries, each of which is key.hashCode() ^ value.hashCode()
+	var expected int32
+	for name, value := range vars {
+		expected += int32(stringHashCode([]interface{}{jstr(name)}).(int64) ^
+			stringHashCode([]interface{}{jstr(value)}).(int64))
+	}
+	if got := envMapHashCode([]interface{}{env1}); got != int64(expected) {
+		t.Errorf("Expected hashCode() to be %d, got %v", expected, got)
+	}
+
+	// the map of getenv() can't be modified via its views
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+	ret := envViewRemove([]interface{}{envMapKeySet([]interface{}{env1}), jstr("HOME")})
+	_ = w.Close()
+	os.Stderr = normalStderr
+	if _, ok := ret.(error); !ok {
+		t.Errorf("Expected UnsupportedOperationException from removing a variable of a read-only map, got %v", ret)
+	}
+}
+
+func TestEnvMapForEach(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	var accepted []string
+	prevRunner := javaMethodRunner
+	SetJavaMethodRunner(func(obj *object.Object, methName, methType string, args ...interface{}) (interface{}, error) {
+		accepted = append(accepted, methName+":"+javaToString(args[0].(*object.Object))+"="+
+			javaToString(args[1].(*object.Object)))
+		return nil, nil
+	})
+	defer SetJavaMethodRunner(prevRunner)
+
+	env := newTestEnvMap(true, map[string]string{"B": "2", "A": "1"})
+	if ret := envMapForEach([]interface{}{env, object.MakeEmptyObject()}); ret != nil {
+		t.Errorf("Expected forEach() to succeed, got %v", ret)
+	}
+	if got := fmt.Sprint(accepted); got != "[accept:A=1 accept:B=2]" {
+		t.Errorf("Expected accept() to be called for each variable in name order, got %s", got)
+	}
+}
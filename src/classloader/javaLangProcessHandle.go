@@ -0,0 +1,249 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Implementation of ProcessHandle.current() and of the methods of the handle
+// it returns, which is a java/lang/ProcessHandleImpl, as in the JDK. Its info()
+// returns a java/lang/ProcessHandleImpl$Info, whose values are Optionals.
+// Jacobin creates these Optionals itself, with the value in the field "value",
+// which is where the Java methods of Optional look for it.
+//
+// The CPU time of the process is not available, so totalCpuDuration() is
+// always empty, which the JDK allows for.
+
+const processHandleClass = "java/lang/ProcessHandleImpl"
+const processInfoClass = "java/lang/ProcessHandleImpl$Info"
+
+// the time the JVM started, for ProcessHandle.Info.startInstant()
+var processStartTime = time.Now()
+
+var processHandleObj *object.Object
+var processHandleOnce sync.Once
+
+func Load_Lang_ProcessHandle() map[string]GMeth {
+
+	MethodSignatures["java/lang/ProcessHandle.current()Ljava/lang/ProcessHandle;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processHandleCurrent,
+		}
+
+	MethodSignatures[processHandleClass+".current()Ljava/lang/ProcessHandleImpl;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processHandleCurrent,
+		}
+
+	MethodSignatures[processHandleClass+".pid()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processHandlePid,
+		}
+
+	MethodSignatures[processHandleClass+".isAlive()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processHandleIsAlive,
+		}
+
+	MethodSignatures[processHandleClass+".info()Ljava/lang/ProcessHandle$Info;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processHandleInfo,
+		}
+
+	MethodSignatures[processInfoClass+".command()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoCommand,
+		}
+
+	MethodSignatures[processInfoClass+".arguments()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoArguments,
+		}
+
+	MethodSignatures[processInfoClass+".commandLine()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoCommandLine,
+		}
+
+	MethodSignatures[processInfoClass+".user()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoUser,
+		}
+
+	MethodSignatures[processInfoClass+".startInstant()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoStartInstant,
+		}
+
+	MethodSignatures[processInfoClass+".totalCpuDuration()Ljava/util/Optional;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoTotalCpuDuration,
+		}
+
+	MethodSignatures[processInfoClass+".toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processInfoToString,
+		}
+
+	return MethodSignatures
+}
+
+// ProcessHandle.current() returns the handle of the JVM's process. It's always the same object.
+func processHandleCurrent([]interface{}) interface{} {
+	processHandleOnce.Do(func() {
+		processHandleObj = object.MakeEmptyObject()
+		className := processHandleClass
+		processHandleObj.Klass = &className
+	})
+	return processHandleObj
+}
+
+func processHandlePid([]interface{}) interface{} {
+	return int64(os.Getpid())
+}
+
+// isAlive() is true, as the only handle is that of the running JVM
+func processHandleIsAlive([]interface{}) interface{} {
+	return types.JavaBoolTrue
+}
+
+func processHandleInfo([]interface{}) interface{} {
+	info := object.MakeEmptyObject()
+	className := processInfoClass
+	info.Klass = &className
+	return info
+}
+
+// newOptional creates a java/util/Optional that holds the value, or an empty
+// one if the value is null
+func newOptional(value *object.Object) *object.Object {
+	opt := object.MakeEmptyObject()
+	className := "java/util/Optional"
+	opt.Klass = &className
+	opt.FieldTable = map[string]object.Field{
+		"value": {Ftype: "Ljava/lang/Object;", Fvalue: value},
+	}
+	return opt
+}
+
+// processCommand returns the path of the executable, if it's known
+func processCommand() (string, bool) {
+	exe, err := os.Executable()
+	return exe, err == nil
+}
+
+// processUser returns the name of the user the process runs as, if it's known
+func processUser() (string, bool) {
+	u, err := user.Current()
+	if err != nil {
+		return "", false
+	}
+	return u.Username, true
+}
+
+// command() returns the path of the executable
+func processInfoCommand([]interface{}) interface{} {
+	return newOptional(stringOrJavaNull(processCommand()))
+}
+
+// arguments() returns the arguments on the command line, as a String[]
+func processInfoArguments([]interface{}) interface{} {
+	args := os.Args[1:]
+	arr := object.Make1DimArray(object.REF, int64(len(args)))
+	elements := *(arr.Fields[0].Fvalue.(*[]*object.Object))
+	for i, arg := range args {
+		elements[i] = goStringToJava(arg)
+	}
+	return newOptional(arr)
+}
+
+// commandLine() returns the executable and the arguments, separated by spaces
+func processInfoCommandLine([]interface{}) interface{} {
+	exe, ok := processCommand()
+	if !ok {
+		return newOptional(object.Null)
+	}
+	return newOptional(goStringToJava(strings.Join(append([]string{exe}, os.Args[1:]...), " ")))
+}
+
+func processInfoUser([]interface{}) interface{} {
+	return newOptional(stringOrJavaNull(processUser()))
+}
+
+// startInstant() returns the time the JVM started, as a java/time/Instant,
+// which holds the time in the fields "seconds" and "nanos"
+func processInfoStartInstant([]interface{}) interface{} {
+	instant := object.MakeEmptyObject()
+	className := "java/time/Instant"
+	instant.Klass = &className
+	instant.FieldTable = map[string]object.Field{
+		"seconds": {Ftype: types.Long, Fvalue: processStartTime.Unix()},
+		"nanos":   {Ftype: types.Int, Fvalue: int64(processStartTime.Nanosecond())},
+	}
+	return newOptional(instant)
+}
+
+func processInfoTotalCpuDuration([]interface{}) interface{} {
+	return newOptional(object.Null)
+}
+
+// toString() lists the values that are known, in the format of the JDK:
+// [user: Optional[name], cmd: /path/to/jacobin, args: [a, b], cmdLine: ..., startTime: Optional[...]]
+func processInfoToString([]interface{}) interface{} {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	if name, ok := processUser(); ok {
+		sb.WriteString("user: Optional[" + name + "]")
+	}
+	if exe, ok := processCommand(); ok {
+		sb.WriteString(", cmd: " + exe)
+		if len(os.Args) > 1 {
+			sb.WriteString(", args: [" + strings.Join(os.Args[1:], ", ") + "]")
+		}
+		sb.WriteString(", cmdLine: " + strings.Join(append([]string{exe}, os.Args[1:]...), " "))
+	}
+	sb.WriteString(", startTime: Optional[" + instantString(processStartTime) + "]")
+	sb.WriteByte(']')
+	return goStringToJava(sb.String())
+}
+
+// instantString formats the time as Instant.toString() does: in UTC, with the
+// fraction of a second in groups of three digits, as many as are needed
+func instantString(t time.Time) string {
+	t = t.UTC()
+	str := t.Format("2006-01-02T15:04:05")
+	if nanos := t.Nanosecond(); nanos != 0 {
+		switch {
+		case nanos%1_000_000 == 0:
+			str += t.Format(".000")
+		case nanos%1_000 == 0:
+			str += t.Format(".000000")
+		default:
+			str += t.Format(".000000000")
+		}
+	}
+	return str + "Z"
+}
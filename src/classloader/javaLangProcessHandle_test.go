@@ -0,0 +1,69 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/object"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// optionalValue returns the value of an Optional created by newOptional()
+func optionalValue(opt interface{}) *object.Object {
+	value, _ := opt.(*object.Object).FieldTable["value"].Fvalue.(*object.Object)
+	return value
+}
+
+func TestProcessHandleCurrent(t *testing.T) {
+	handle := processHandleCurrent(nil).(*object.Object)
+	if processHandleCurrent(nil) != handle || *handle.Klass != "java/lang/ProcessHandleImpl" {
+		t.Errorf("Expected ProcessHandle.current() to always return the same ProcessHandleImpl")
+	}
+	if pid := processHandlePid([]interface{}{handle}).(int64); pid != int64(os.Getpid()) {
+		t.Errorf("Expected pid() to return %d, got %d", os.Getpid(), pid)
+	}
+
+	info := processHandleInfo([]interface{}{handle})
+	exe, _ := os.Executable()
+	if cmd := optionalValue(processInfoCommand([]interface{}{info})); javaToString(cmd) != exe {
+		t.Errorf("Expected command() to return %s, got %s", exe, javaToString(cmd))
+	}
+	args := optionalValue(processInfoArguments([]interface{}{info}))
+	if n := len(*(args.Fields[0].Fvalue.(*[]*object.Object))); n != len(os.Args)-1 {
+		t.Errorf("Expected arguments() to return %d arguments, got %d", len(os.Args)-1, n)
+	}
+	cmdLine := javaToString(optionalValue(processInfoCommandLine([]interface{}{info})))
+	if !strings.HasPrefix(cmdLine, exe) {
+		t.Errorf("Expected commandLine() to start with %s, got %s", exe, cmdLine)
+	}
+	if value := optionalValue(processInfoTotalCpuDuration([]interface{}{info})); value != nil {
+		t.Errorf("Expected totalCpuDuration() to be empty")
+	}
+
+	start := optionalValue(processInfoStartInstant([]interface{}{info}))
+	seconds := start.FieldTable["seconds"].Fvalue.(int64)
+	if now := time.Now().Unix(); seconds > now || seconds < now-3600 {
+		t.Errorf("Expected startInstant() to be the time the process started, got %d", seconds)
+	}
+}
+
+func TestInstantString(t *testing.T) {
+	tests := map[string]string{
+		"2023-05-01T10:20:30Z":             "2023-05-01T10:20:30Z",
+		"2023-05-01T10:20:30.5Z":           "2023-05-01T10:20:30.500Z",
+		"2023-05-01T12:20:30.000123+02:00": "2023-05-01T10:20:30.000123Z",
+		"2023-05-01T10:20:30.000000001Z":   "2023-05-01T10:20:30.000000001Z",
+	}
+	for in, expected := range tests {
+		tm, _ := time.Parse(time.RFC3339Nano, in)
+		if got := instantString(tm); got != expected {
+			t.Errorf("Expected %s to be formatted as %s, got %s", in, expected, got)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/shutdown"
+	"jacobin/types"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Implementation of java/lang/Runtime. There is one Runtime object, which
+// Runtime.getRuntime() returns. Its methods for shutting down the JVM are
+// handled by the shutdown package. Jacobin's objects are on the Go heap, so
+// the methods that report memory report on the Go heap.
+
+var runtimeObj *object.Object
+var runtimeOnce sync.Once
+
+func Load_Lang_Runtime() map[string]GMeth {
+
+	MethodSignatures["java/lang/Runtime.getRuntime()Ljava/lang/Runtime;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getRuntime,
+		}
+
+	MethodSignatures["java/lang/Runtime.exit(I)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the exit status
+			GFunction:  runtimeExit,
+		}
+
+	MethodSignatures["java/lang/Runtime.halt(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  runtimeHalt,
+		}
+
+	MethodSignatures["java/lang/Runtime.addShutdownHook(Ljava/lang/Thread;)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the hook
+			GFunction:  addShutdownHook,
+		}
+
+	MethodSignatures["java/lang/Runtime.removeShutdownHook(Ljava/lang/Thread;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  removeShutdownHook,
+		}
+
+	MethodSignatures["java/lang/Runtime.availableProcessors()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  availableProcessors,
+		}
+
+	MethodSignatures["java/lang/Runtime.totalMemory()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  totalMemory,
+		}
+
+	MethodSignatures["java/lang/Runtime.freeMemory()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  freeMemory,
+		}
+
+	MethodSignatures["java/lang/Runtime.maxMemory()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  maxMemory,
+		}
+
+	MethodSignatures["java/lang/Runtime.gc()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  forceGC, // see javaLangSystem.go
+		}
+
+	return MethodSignatures
+}
+
+// Runtime.getRuntime() returns the one Runtime object
+func getRuntime([]interface{}) interface{} {
+	runtimeOnce.Do(func() {
+		runtimeObj = object.MakeEmptyObject()
+		className := "java/lang/Runtime"
+		runtimeObj.Klass = &className
+	})
+	return runtimeObj
+}
+
+// Runtime.exit(status) runs the shutdown hooks and then exits, as System.exit() does
+func runtimeExit(params []interface{}) interface{} {
+	shutdown.Exit(int(params[1].(int64)))
+	return nil
+}
+
+// Runtime.halt(status) exits without running the shutdown hooks
+func runtimeHalt(params []interface{}) interface{} {
+	shutdown.Halt(int(params[1].(int64)))
+	return nil
+}
+
+// Runtime.addShutdownHook(Thread hook) registers a thread that's started when the JVM shuts down
+func addShutdownHook(params []interface{}) interface{} {
+	hook, err := shutdownHook(params[1])
+	if err != nil {
+		return err
+	}
+	return shutdownHookException(shutdown.AddHook(hook))
+}
+
+// Runtime.removeShutdownHook(Thread hook) returns whether the hook had been registered
+func removeShutdownHook(params []interface{}) interface{} {
+	hook, err := shutdownHook(params[1])
+	if err != nil {
+		return err
+	}
+	removed, err := shutdown.RemoveHook(hook)
+	if err != nil {
+		return shutdownHookException(err)
+	}
+	return types.ConvertGoBoolToJavaBool(removed)
+}
+
+// shutdownHook returns the hook in the param, and a NullPointerException if it's null
+func shutdownHook(param interface{}) (*object.Object, error) {
+	hook, _ := param.(*object.Object)
+	if hook == nil {
+		return nil, throwNativeException(exceptions.NullPointerException, "java.lang.NullPointerException", "")
+	}
+	return hook, nil
+}
+
+// shutdownHookException converts an error from the shutdown package into the JDK's exception
+func shutdownHookException(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case shutdown.ErrShutdownInProgress:
+		return throwNativeException(exceptions.IllegalStateException,
+			"java.lang.IllegalStateException", err.Error())
+	default:
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", err.Error())
+	}
+}
+
+// Runtime.availableProcessors() returns the number of CPUs the JVM can use
+func availableProcessors([]interface{}) interface{} {
+	return int64(runtime.NumCPU())
+}
+
+// Runtime.totalMemory() returns the size of the heap, that is, the memory the
+// Go runtime has obtained for the heap and not returned to the OS
+func totalMemory([]interface{}) interface{} {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.HeapSys - stats.HeapReleased)
+}
+
+// Runtime.freeMemory() returns the part of the heap that's not in use
+func freeMemory([]interface{}) interface{} {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	free := int64(stats.HeapSys-stats.HeapReleased) - int64(stats.HeapAlloc)
+	return max(free, 0)
+}
+
+// Runtime.maxMemory() returns the memory limit of the Go runtime, which is set
+// by the GOMEMLIMIT environment variable. As in the JDK, if there is no limit,
+// it returns Long.MAX_VALUE.
+func maxMemory([]interface{}) interface{} {
+	return debug.SetMemoryLimit(-1) // a negative limit doesn't change it
+}
@@ -0,0 +1,99 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"math"
+	"os"
+	"runtime/debug"
+	"testing"
+)
+
+func TestShutdownHookRegistration(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	rt := getRuntime(nil).(*object.Object)
+	if getRuntime(nil) != rt || *rt.Klass != "java/lang/Runtime" {
+		t.Errorf("Expected getRuntime() to always return the same java/lang/Runtime object")
+	}
+
+	hook := object.MakeEmptyObject()
+	threadInitName([]interface{}{hook, jstr("flush-metrics")})
+	if javaToString(threadGetName([]interface{}{hook}).(*object.Object)) != "flush-metrics" {
+		t.Errorf("Expected the hook to be named flush-metrics")
+	}
+
+	if ret := addShutdownHook([]interface{}{rt, hook}); ret != nil {
+		t.Errorf("Expected the hook to be added, got %v", ret)
+	}
+	if err, ok := addShutdownHook([]interface{}{rt, hook}).(error); !ok ||
+		err.Error() != "java.lang.IllegalArgumentException: Hook previously registered" {
+		t.Errorf("Expected IllegalArgumentException for a hook added twice, got %v", err)
+	}
+	if err, ok := addShutdownHook([]interface{}{rt, object.Null}).(error); !ok ||
+		err.Error() != "java.lang.NullPointerException" {
+		t.Errorf("Expected NullPointerException for a null hook, got %v", err)
+	}
+	if ret := removeShutdownHook([]interface{}{rt, hook}); ret != types.JavaBoolTrue {
+		t.Errorf("Expected removeShutdownHook() of a registered hook to return true, got %v", ret)
+	}
+	if ret := removeShutdownHook([]interface{}{rt, hook}); ret != types.JavaBoolFalse {
+		t.Errorf("Expected removeShutdownHook() of a removed hook to return false, got %v", ret)
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func TestThreadDefaultNames(t *testing.T) {
+	first, second := object.MakeEmptyObject(), object.MakeEmptyObject()
+	threadInit([]interface{}{first})
+	threadInitRunnable([]interface{}{second, object.Null})
+
+	var n1, n2 int
+	name1 := javaToString(threadGetName([]interface{}{first}).(*object.Object))
+	name2 := javaToString(threadGetName([]interface{}{second}).(*object.Object))
+	if _, err := fmt.Sscanf(name1, "Thread-%d", &n1); err != nil {
+		t.Fatalf("Expected a name such as Thread-0, got %s", name1)
+	}
+	if _, err := fmt.Sscanf(name2, "Thread-%d", &n2); err != nil || n2 != n1+1 {
+		t.Errorf("Expected the next thread to be named Thread-%d, got %s", n1+1, name2)
+	}
+}
+
+func TestRuntimeMemoryAndProcessors(t *testing.T) {
+	rt := getRuntime(nil)
+	if n := availableProcessors([]interface{}{rt}).(int64); n < 1 {
+		t.Errorf("Expected at least 1 available processor, got %d", n)
+	}
+
+	total := totalMemory([]interface{}{rt}).(int64)
+	free := freeMemory([]interface{}{rt}).(int64)
+	if total <= 0 || free < 0 || free > total {
+		t.Errorf("Expected 0 <= freeMemory() <= totalMemory(), got free %d, total %d", free, total)
+	}
+
+	prevLimit := debug.SetMemoryLimit(1 << 40)
+	defer debug.SetMemoryLimit(prevLimit)
+	if limit := maxMemory([]interface{}{rt}).(int64); limit != 1<<40 {
+		t.Errorf("Expected maxMemory() to return the Go memory limit, got %d", limit)
+	}
+	debug.SetMemoryLimit(math.MaxInt64)
+	if limit := maxMemory([]interface{}{rt}).(int64); limit != math.MaxInt64 {
+		t.Errorf("Expected maxMemory() to return Long.MAX_VALUE when there's no limit, got %d", limit)
+	}
+}
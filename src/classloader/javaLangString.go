@@ -0,0 +1,1351 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Implementation of the core methods of java/lang/String. All of these work on
+// the String's chars as Java chars (that is, UTF-16 code units), so that indexes
+// and lengths are the same as in the JDK, regardless of whether the string is
+// stored as a compact (LATIN1) string or as UTF16.
+
+func Load_Lang_String() map[string]GMeth {
+
+	// === constructors ===
+	MethodSignatures["java/lang/String.<init>()V"] =
+		GMeth{
+			ParamSlots: 1, // [0] = this
+			GFunction:  stringInitEmpty,
+		}
+
+	MethodSignatures["java/lang/String.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the original string
+			GFunction:  stringInitString,
+		}
+
+	MethodSignatures["java/lang/String.<init>([C)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the array of chars
+			GFunction:  stringInitChars,
+		}
+
+	MethodSignatures["java/lang/String.<init>([CII)V"] =
+		GMeth{
+			ParamSlots: 4, // [0] = this, [1] = the array of chars, [2] = offset, [3] = count
+			GFunction:  stringInitChars,
+		}
+
+	MethodSignatures["java/lang/String.<init>([B)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = array of UTF-8 bytes
+			GFunction:  stringInitBytes,
+		}
+
+	// === accessors and comparisons ===
+	MethodSignatures["java/lang/String.length()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringLength,
+		}
+
+	MethodSignatures["java/lang/String.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringIsEmpty,
+		}
+
+	MethodSignatures["java/lang/String.charAt(I)C"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = index
+			GFunction:  stringCharAt,
+		}
+
+	MethodSignatures["java/lang/String.codePointAt(I)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringCodePointAt,
+		}
+
+	MethodSignatures["java/lang/String.equals(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringEquals,
+		}
+
+	MethodSignatures["java/lang/String.equalsIgnoreCase(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringEqualsIgnoreCase,
+		}
+
+	MethodSignatures["java/lang/String.hashCode()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringHashCode,
+		}
+
+	MethodSignatures["java/lang/String.compareTo(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringCompareTo,
+		}
+
+	MethodSignatures["java/lang/String.compareTo(Ljava/lang/Object;)I"] = // the bridge method for Comparable
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringCompareTo,
+		}
+
+	MethodSignatures["java/lang/String.compareToIgnoreCase(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringCompareToIgnoreCase,
+		}
+
+	MethodSignatures["java/lang/String.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringToString,
+		}
+
+	MethodSignatures["java/lang/String.intern()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringIntern,
+		}
+
+	// === searching ===
+	MethodSignatures["java/lang/String.indexOf(I)I"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the char (as a code point)
+			GFunction:  stringIndexOfChar,
+		}
+
+	MethodSignatures["java/lang/String.indexOf(II)I"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = the char, [2] = index to start from
+			GFunction:  stringIndexOfChar,
+		}
+
+	MethodSignatures["java/lang/String.indexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringIndexOfString,
+		}
+
+	MethodSignatures["java/lang/String.indexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringIndexOfString,
+		}
+
+	MethodSignatures["java/lang/String.lastIndexOf(I)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringLastIndexOfChar,
+		}
+
+	MethodSignatures["java/lang/String.lastIndexOf(II)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringLastIndexOfChar,
+		}
+
+	MethodSignatures["java/lang/String.lastIndexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringLastIndexOfString,
+		}
+
+	MethodSignatures["java/lang/String.lastIndexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringLastIndexOfString,
+		}
+
+	MethodSignatures["java/lang/String.contains(Ljava/lang/CharSequence;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringContains,
+		}
+
+	MethodSignatures["java/lang/String.startsWith(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringStartsWith,
+		}
+
+	MethodSignatures["java/lang/String.startsWith(Ljava/lang/String;I)Z"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = the prefix, [2] = offset in this
+			GFunction:  stringStartsWith,
+		}
+
+	MethodSignatures["java/lang/String.endsWith(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringEndsWith,
+		}
+
+	MethodSignatures["java/lang/String.matches(Ljava/lang/String;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringMatches,
+		}
+
+	// === methods that create new strings ===
+	MethodSignatures["java/lang/String.substring(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = begin index
+			GFunction:  stringSubstring,
+		}
+
+	MethodSignatures["java/lang/String.substring(II)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = begin index, [2] = end index
+			GFunction:  stringSubstring,
+		}
+
+	MethodSignatures["java/lang/String.subSequence(II)Ljava/lang/CharSequence;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringSubstring,
+		}
+
+	MethodSignatures["java/lang/String.concat(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringConcat,
+		}
+
+	MethodSignatures["java/lang/String.replace(CC)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = old char, [2] = new char
+			GFunction:  stringReplaceChar,
+		}
+
+	MethodSignatures["java/lang/String.replace(Ljava/lang/CharSequence;Ljava/lang/CharSequence;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = target, [2] = replacement
+			GFunction:  stringReplace,
+		}
+
+	MethodSignatures["java/lang/String.replaceAll(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = regex, [2] = replacement
+			GFunction:  stringReplaceAll,
+		}
+
+	MethodSignatures["java/lang/String.replaceFirst(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringReplaceFirst,
+		}
+
+	MethodSignatures["java/lang/String.split(Ljava/lang/String;)[Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = regex
+			GFunction:  stringSplit,
+		}
+
+	MethodSignatures["java/lang/String.split(Ljava/lang/String;I)[Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = this, [1] = regex, [2] = limit
+			GFunction:  stringSplit,
+		}
+
+	MethodSignatures["java/lang/String.trim()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringTrim,
+		}
+
+	MethodSignatures["java/lang/String.strip()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringStrip,
+		}
+
+	MethodSignatures["java/lang/String.stripLeading()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringStripLeading,
+		}
+
+	MethodSignatures["java/lang/String.stripTrailing()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringStripTrailing,
+		}
+
+	MethodSignatures["java/lang/String.isBlank()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringIsBlank,
+		}
+
+	MethodSignatures["java/lang/String.toUpperCase()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringToUpperCase,
+		}
+
+	MethodSignatures["java/lang/String.toLowerCase()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringToLowerCase,
+		}
+
+	MethodSignatures["java/lang/String.repeat(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  stringRepeat,
+		}
+
+	MethodSignatures["java/lang/String.toCharArray()[C"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringToCharArray,
+		}
+
+	MethodSignatures["java/lang/String.getBytes()[B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringGetBytes,
+		}
+
+	// === static methods ===
+	MethodSignatures["java/lang/String.format(Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // [0] = format string, [1] = array of args
+			GFunction:  stringFormat,
+		}
+
+	MethodSignatures["java/lang/String.format(Ljava/util/Locale;Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = locale, [1] = format string, [2] = array of args
+			GFunction:  stringFormatLocale,
+		}
+
+	MethodSignatures["java/lang/String.formatted([Ljava/lang/Object;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, used as the format string, [1] = array of args
+			GFunction:  stringFormat,
+		}
+
+	MethodSignatures["java/lang/String.join(Ljava/lang/CharSequence;[Ljava/lang/CharSequence;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // [0] = delimiter, [1] = array of elements
+			GFunction:  stringJoin,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfInt,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // longs take two slots
+			GFunction:  stringValueOfInt,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(F)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfFloat,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(D)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2, // doubles take two slots
+			GFunction:  stringValueOfDouble,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(Z)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfBoolean,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(C)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfChar,
+		}
+
+	MethodSignatures["java/lang/String.valueOf(Ljava/lang/Object;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfObject,
+		}
+
+	MethodSignatures["java/lang/String.valueOf([C)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfChars,
+		}
+
+	MethodSignatures["java/lang/String.valueOf([CII)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3, // [0] = array of chars, [1] = offset, [2] = count
+			GFunction:  stringValueOfChars,
+		}
+
+	MethodSignatures["java/lang/String.copyValueOf([C)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  stringValueOfChars,
+		}
+
+	MethodSignatures["java/lang/String.copyValueOf([CII)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  stringValueOfChars,
+		}
+
+	MethodSignatures["java/lang/StringUTF16.isBigEndian()Z"] = // see object.CoderUTF16
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  returnTrue,
+		}
+
+	return MethodSignatures
+}
+
+// throwNativeException reports an exception thrown by a Go function and
+// returns the error that tells the interpreter the function did not complete
+func throwNativeException(excType int, excName string, msg string) error {
+	errMsg := excName
+	if msg != "" {
+		errMsg += ": " + msg
+	}
+	exceptions.Throw(excType, errMsg)
+	return errors.New(errMsg)
+}
+
+// the exception thrown when a method is called on a null string or passed one
+func stringNPE(methName string) error {
+	return throwNativeException(exceptions.NullPointerException,
+		"java.lang.NullPointerException", "String."+methName+"(): null string")
+}
+
+// newJavaString is a shorthand for creating a Java string from Java chars
+func newJavaString(chars []uint16) *object.Object {
+	return object.NewStringFromUTF16(chars)
+}
+
+// goStringToJava is a shorthand for creating a Java string from a Go string
+func goStringToJava(str string) *object.Object {
+	return object.NewStringFromGoString(str)
+}
+
+// charSequenceChars returns the chars of a CharSequence. The implementations
+// currently supported are String, StringBuilder and StringBuffer.
+func charSequenceChars(obj *object.Object) ([]uint16, bool) {
+	if object.IsJavaString(obj) {
+		return object.GetStringUTF16(obj), true
+	}
+	return builderChars(obj)
+}
+
+// getStringParams returns the string in params[0] and the string (or other
+// CharSequence) in params[1]. If either is null, an NPE is thrown.
+func getStringParams(params []interface{}, methName string) ([]uint16, []uint16, error) {
+	this, _ := params[0].(*object.Object)
+	that, _ := params[1].(*object.Object)
+	if this == nil || that == nil {
+		return nil, nil, stringNPE(methName)
+	}
+	thatChars, ok := charSequenceChars(that)
+	if !ok {
+		return nil, nil, stringNPE(methName)
+	}
+	return object.GetStringUTF16(this), thatChars, nil
+}
+
+// === constructors ===
+
+// java/lang/String.<init>() creates an empty string
+func stringInitEmpty(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	object.SetStringValue(this, []uint16{})
+	return nil
+}
+
+// java/lang/String.<init>(String) copies the chars of the passed-in string
+func stringInitString(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	orig, _ := params[1].(*object.Object)
+	if orig == nil {
+		return stringNPE("<init>")
+	}
+	object.SetStringValue(this, object.GetStringUTF16(orig))
+	return nil
+}
+
+// java/lang/String.<init>(char[]) and <init>(char[], int offset, int count)
+func stringInitChars(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	chars, err := charArrayParam(params[1:])
+	if err != nil {
+		return err
+	}
+	object.SetStringValue(this, chars)
+	return nil
+}
+
+// java/lang/String.<init>(byte[]) decodes the bytes as UTF-8, which is
+// the default charset in Jacobin
+func stringInitBytes(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	arr, _ := params[1].(*object.Object)
+	if arr == nil {
+		return stringNPE("<init>")
+	}
+	bytes := *(arr.Fields[0].Fvalue.(*[]byte))
+	object.SetStringValue(this, utf16.Encode([]rune(string(bytes))))
+	return nil
+}
+
+// charArrayParam converts a Java char array, optionally followed by an offset
+// and count, into Java chars. Used by the constructors and by valueOf(char[]).
+func charArrayParam(params []interface{}) ([]uint16, error) {
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return nil, stringNPE("valueOf")
+	}
+	values := *(arr.Fields[0].Fvalue.(*[]int64))
+
+	offset, count := 0, len(values)
+	if len(params) > 1 {
+		offset = int(params[1].(int64))
+		count = int(params[2].(int64))
+		if offset < 0 || count < 0 || offset > len(values)-count {
+			return nil, throwNativeException(exceptions.StringIndexOutOfBoundsException,
+				"java.lang.StringIndexOutOfBoundsException",
+				fmt.Sprintf("offset %d, count %d, length %d", offset, count, len(values)))
+		}
+	}
+
+	chars := make([]uint16, count)
+	for i := range chars {
+		chars[i] = uint16(values[offset+i])
+	}
+	return chars, nil
+}
+
+// === accessors and comparisons ===
+
+// java/lang/String.length()
+func stringLength(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	return int64(object.GetStringLength(this))
+}
+
+// java/lang/String.isEmpty()
+func stringIsEmpty(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	return types.ConvertGoBoolToJavaBool(object.GetStringLength(this) == 0)
+}
+
+// java/lang/String.charAt() returns the Java char (a UTF-16 code unit) at the index
+func stringCharAt(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	index := params[1].(int64)
+	chars := object.GetStringUTF16(this)
+	if index < 0 || index >= int64(len(chars)) {
+		return indexOutOfBounds(index, len(chars))
+	}
+	return int64(chars[index])
+}
+
+// java/lang/String.codePointAt() returns the Unicode code point at the index,
+// combining a surrogate pair into a single code point
+func stringCodePointAt(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	index := params[1].(int64)
+	chars := object.GetStringUTF16(this)
+	if index < 0 || index >= int64(len(chars)) {
+		return indexOutOfBounds(index, len(chars))
+	}
+	c := chars[index]
+	if utf16.IsSurrogate(rune(c)) && index+1 < int64(len(chars)) {
+		if r := utf16.DecodeRune(rune(c), rune(chars[index+1])); r != unicode.ReplacementChar {
+			return int64(r)
+		}
+	}
+	return int64(c)
+}
+
+// the exception thrown for an invalid index into a string
+func indexOutOfBounds(index int64, length int) error {
+	return throwNativeException(exceptions.StringIndexOutOfBoundsException,
+		"java.lang.StringIndexOutOfBoundsException",
+		fmt.Sprintf("index %d, length %d", index, length))
+}
+
+// java/lang/String.equals() is true if the other object is a string with the same chars
+func stringEquals(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	that, _ := params[1].(*object.Object)
+	if this == that {
+		return types.JavaBoolTrue
+	}
+	if !object.IsJavaString(that) {
+		return types.JavaBoolFalse
+	}
+	return types.ConvertGoBoolToJavaBool(charsEqual(object.GetStringUTF16(this), object.GetStringUTF16(that)))
+}
+
+// java/lang/String.equalsIgnoreCase() compares the chars as Java does: two chars
+// are equal if they're the same, or if they're the same after conversion to
+// upper case, or to lower case.
+func stringEqualsIgnoreCase(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	that, _ := params[1].(*object.Object)
+	if that == nil {
+		return types.JavaBoolFalse
+	}
+	a, b := object.GetStringUTF16(this), object.GetStringUTF16(that)
+	if len(a) != len(b) {
+		return types.JavaBoolFalse
+	}
+	for i := range a {
+		if foldChar(a[i]) != foldChar(b[i]) {
+			return types.JavaBoolFalse
+		}
+	}
+	return types.JavaBoolTrue
+}
+
+// foldChar converts a char to upper and then to lower case, which is how Java
+// compares chars in case-insensitive comparisons
+func foldChar(c uint16) uint16 {
+	return uint16(unicode.ToLower(unicode.ToUpper(rune(c))))
+}
+
+func charsEqual(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// java/lang/String.hashCode() computes s[0]*31^(n-1) + s[1]*31^(n-2) + ... + s[n-1]
+// using 32-bit int arithmetic. As in the JDK, the hash is cached in the hash field
+// and the hashIsZero field records that a computed hash of 0 need not be recomputed.
+func stringHashCode(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	if hash, ok := this.Fields[2].Fvalue.(int64); ok && hash != 0 {
+		return hash
+	}
+	if this.Fields[9].Fvalue == types.JavaBoolTrue {
+		return int64(0)
+	}
+
+	var hash int32
+	for _, c := range object.GetStringUTF16(this) {
+		hash = 31*hash + int32(c)
+	}
+
+	if hash == 0 {
+		this.Fields[9].Fvalue = types.JavaBoolTrue
+	} else {
+		this.Fields[2].Fvalue = int64(hash)
+	}
+	return int64(hash)
+}
+
+// java/lang/String.compareTo() compares strings lexicographically by Java char.
+// The result is the difference between the first pair of unequal chars or,
+// if one string is a prefix of the other, the difference in lengths.
+func stringCompareTo(params []interface{}) interface{} {
+	a, b, err := getStringParams(params, "compareTo")
+	if err != nil {
+		return err
+	}
+	return int64(compareChars(a, b, false))
+}
+
+// java/lang/String.compareToIgnoreCase()
+func stringCompareToIgnoreCase(params []interface{}) interface{} {
+	a, b, err := getStringParams(params, "compareToIgnoreCase")
+	if err != nil {
+		return err
+	}
+	return int64(compareChars(a, b, true))
+}
+
+func compareChars(a, b []uint16, ignoreCase bool) int {
+	minLen := len(a)
+	if len(b) < minLen {
+		minLen = len(b)
+	}
+	for i := 0; i < minLen; i++ {
+		c1, c2 := a[i], b[i]
+		if ignoreCase {
+			c1, c2 = foldChar(c1), foldChar(c2)
+		}
+		if c1 != c2 {
+			return int(c1) - int(c2)
+		}
+	}
+	return len(a) - len(b)
+}
+
+// java/lang/String.toString() returns the string itself
+func stringToString(params []interface{}) interface{} {
+	return params[0].(*object.Object)
+}
+
+// java/lang/String.intern() returns the canonical instance of the string
+func stringIntern(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	return object.InternString(this)
+}
+
+// === searching ===
+
+// java/lang/String.indexOf(int ch) and indexOf(int ch, int fromIndex). The char
+// is passed as a code point, so supplementary chars are searched for as a
+// surrogate pair.
+func stringIndexOfChar(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	target := codePointToChars(params[1].(int64))
+	from := int64(0)
+	if len(params) > 2 {
+		from = params[2].(int64)
+	}
+	return int64(indexOfChars(object.GetStringUTF16(this), target, from))
+}
+
+// java/lang/String.indexOf(String) and indexOf(String, int fromIndex)
+func stringIndexOfString(params []interface{}) interface{} {
+	chars, target, err := getStringParams(params, "indexOf")
+	if err != nil {
+		return err
+	}
+	from := int64(0)
+	if len(params) > 2 {
+		from = params[2].(int64)
+	}
+	return int64(indexOfChars(chars, target, from))
+}
+
+// java/lang/String.lastIndexOf(int ch) and lastIndexOf(int ch, int fromIndex)
+func stringLastIndexOfChar(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	chars := object.GetStringUTF16(this)
+	target := codePointToChars(params[1].(int64))
+	from := int64(len(chars))
+	if len(params) > 2 {
+		from = params[2].(int64)
+	}
+	return int64(lastIndexOfChars(chars, target, from))
+}
+
+// java/lang/String.lastIndexOf(String) and lastIndexOf(String, int fromIndex)
+func stringLastIndexOfString(params []interface{}) interface{} {
+	chars, target, err := getStringParams(params, "lastIndexOf")
+	if err != nil {
+		return err
+	}
+	from := int64(len(chars))
+	if len(params) > 2 {
+		from = params[2].(int64)
+	}
+	return int64(lastIndexOfChars(chars, target, from))
+}
+
+// codePointToChars converts a code point to one or two (for supplementary
+// chars) Java chars
+func codePointToChars(cp int64) []uint16 {
+	if cp < 0 || cp > unicode.MaxRune {
+		return []uint16{0xFFFF, 0xFFFF} // can never match
+	}
+	if cp >= 0x10000 {
+		r1, r2 := utf16.EncodeRune(rune(cp))
+		return []uint16{uint16(r1), uint16(r2)}
+	}
+	return []uint16{uint16(cp)}
+}
+
+// indexOfChars returns the index of the first occurrence of target in chars,
+// starting the search at from, or -1 if not found
+func indexOfChars(chars, target []uint16, from int64) int {
+	if from < 0 {
+		from = 0
+	}
+	for i := int(from); i+len(target) <= len(chars); i++ {
+		if charsEqual(chars[i:i+len(target)], target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndexOfChars returns the index of the last occurrence of target in chars
+// that begins at or before from, or -1 if not found
+func lastIndexOfChars(chars, target []uint16, from int64) int {
+	start := len(chars) - len(target)
+	if from < int64(start) {
+		start = int(from)
+	}
+	for i := start; i >= 0; i-- {
+		if charsEqual(chars[i:i+len(target)], target) {
+			return i
+		}
+	}
+	return -1
+}
+
+// java/lang/String.contains()
+func stringContains(params []interface{}) interface{} {
+	chars, target, err := getStringParams(params, "contains")
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(indexOfChars(chars, target, 0) >= 0)
+}
+
+// java/lang/String.startsWith(String) and startsWith(String, int offset)
+func stringStartsWith(params []interface{}) interface{} {
+	chars, prefix, err := getStringParams(params, "startsWith")
+	if err != nil {
+		return err
+	}
+	offset := int64(0)
+	if len(params) > 2 {
+		offset = params[2].(int64)
+	}
+	if offset < 0 || offset > int64(len(chars)-len(prefix)) {
+		return types.JavaBoolFalse
+	}
+	return types.ConvertGoBoolToJavaBool(charsEqual(chars[offset:int(offset)+len(prefix)], prefix))
+}
+
+// java/lang/String.endsWith()
+func stringEndsWith(params []interface{}) interface{} {
+	chars, suffix, err := getStringParams(params, "endsWith")
+	if err != nil {
+		return err
+	}
+	if len(suffix) > len(chars) {
+		return types.JavaBoolFalse
+	}
+	return types.ConvertGoBoolToJavaBool(charsEqual(chars[len(chars)-len(suffix):], suffix))
+}
+
+// java/lang/String.matches() is true if the entire string matches the regex
+func stringMatches(params []interface{}) interface{} {
+	this, regex, err := getRegexParams(params, "matches")
+	if err != nil {
+		return err
+	}
+	re, err := compileJavaRegex("^(?:" + regex + ")$")
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(re.MatchString(this))
+}
+
+// === methods that create new strings ===
+
+// java/lang/String.substring(int begin) and substring(int begin, int end)
+func stringSubstring(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	chars := object.GetStringUTF16(this)
+	begin := params[1].(int64)
+	end := int64(len(chars))
+	if len(params) > 2 {
+		end = params[2].(int64)
+	}
+
+	if begin < 0 || begin > end || end > int64(len(chars)) {
+		return throwNativeException(exceptions.StringIndexOutOfBoundsException,
+			"java.lang.StringIndexOutOfBoundsException",
+			fmt.Sprintf("begin %d, end %d, length %d", begin, end, len(chars)))
+	}
+	if begin == 0 && end == int64(len(chars)) {
+		return this
+	}
+	return newJavaString(chars[begin:end])
+}
+
+// java/lang/String.concat()
+func stringConcat(params []interface{}) interface{} {
+	a, b, err := getStringParams(params, "concat")
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return params[0].(*object.Object)
+	}
+	return newJavaString(append(a, b...))
+}
+
+// java/lang/String.replace(char, char) replaces all occurrences of a char
+func stringReplaceChar(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	oldChar := uint16(params[1].(int64))
+	newChar := uint16(params[2].(int64))
+
+	chars := object.GetStringUTF16(this)
+	replaced := false
+	for i, c := range chars {
+		if c == oldChar {
+			chars[i] = newChar
+			replaced = true
+		}
+	}
+	if !replaced {
+		return this
+	}
+	return newJavaString(chars)
+}
+
+// java/lang/String.replace(CharSequence, CharSequence) replaces all occurrences
+// of the literal target. An empty target matches before every char and at the end.
+func stringReplace(params []interface{}) interface{} {
+	chars, target, err := getStringParams(params, "replace")
+	if err != nil {
+		return err
+	}
+	replacementObj, _ := params[2].(*object.Object)
+	replacement, ok := charSequenceChars(replacementObj)
+	if !ok {
+		return stringNPE("replace")
+	}
+
+	var result []uint16
+	if len(target) == 0 {
+		for _, c := range chars {
+			result = append(result, replacement...)
+			result = append(result, c)
+		}
+		return newJavaString(append(result, replacement...))
+	}
+
+	i := 0
+	for {
+		j := indexOfChars(chars, target, int64(i))
+		if j < 0 {
+			break
+		}
+		result = append(result, chars[i:j]...)
+		result = append(result, replacement...)
+		i = j + len(target)
+	}
+	return newJavaString(append(result, chars[i:]...))
+}
+
+// java/lang/String.replaceAll()
+func stringReplaceAll(params []interface{}) interface{} {
+	return replaceRegex(params, "replaceAll", -1)
+}
+
+// java/lang/String.replaceFirst()
+func stringReplaceFirst(params []interface{}) interface{} {
+	return replaceRegex(params, "replaceFirst", 1)
+}
+
+// replaceRegex replaces up to count (-1 = all) matches of a regex
+func replaceRegex(params []interface{}, methName string, count int) interface{} {
+	this, regex, err := getRegexParams(params, methName)
+	if err != nil {
+		return err
+	}
+	replacementObj, _ := params[2].(*object.Object)
+	if replacementObj == nil {
+		return stringNPE(methName)
+	}
+	replacement := javaReplacementToGo(object.GetGoStringFromJavaStringPtr(replacementObj))
+
+	re, err := compileJavaRegex(regex)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(this, count) {
+		sb.WriteString(this[last:m[0]])
+		sb.Write(re.ExpandString(nil, replacement, this, m))
+		last = m[1]
+	}
+	sb.WriteString(this[last:])
+	return goStringToJava(sb.String())
+}
+
+// javaReplacementToGo converts a Java regex replacement string, in which
+// groups are referenced as $1 or ${name} and \ escapes the next char, into
+// the template syntax of Go's regexp package
+func javaReplacementToGo(repl string) string {
+	var sb strings.Builder
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		switch {
+		case c == '\\' && i+1 < len(repl):
+			i++
+			if repl[i] == '$' {
+				sb.WriteString("$$")
+			} else {
+				sb.WriteByte(repl[i])
+			}
+		case c == '$' && i+1 < len(repl) && repl[i+1] >= '0' && repl[i+1] <= '9':
+			j := i + 1
+			for j < len(repl) && repl[j] >= '0' && repl[j] <= '9' {
+				j++
+			}
+			sb.WriteString("${" + repl[i+1:j] + "}")
+			i = j - 1
+		case c == '$' && i+1 < len(repl) && repl[i+1] == '{':
+			sb.WriteByte('$') // named groups have the same syntax in Go
+		case c == '$':
+			sb.WriteString("$$")
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// java/lang/String.split(String regex) and split(String regex, int limit). As in
+// the JDK, a zero-width match at the start never produces a leading empty string,
+// and when the limit is 0, trailing empty strings are discarded.
+func stringSplit(params []interface{}) interface{} {
+	this, regex, err := getRegexParams(params, "split")
+	if err != nil {
+		return err
+	}
+	limit := 0
+	if len(params) > 2 {
+		limit = int(params[2].(int64))
+	}
+
+	re, err := compileJavaRegex(regex)
+	if err != nil {
+		return err
+	}
+
+	var parts []string
+	index := 0
+	for _, m := range re.FindAllStringIndex(this, -1) {
+		if limit > 0 && len(parts) >= limit-1 {
+			break
+		}
+		if index == 0 && m[0] == 0 && m[1] == 0 {
+			continue // no leading empty string for a zero-width match at the start
+		}
+		parts = append(parts, this[index:m[0]])
+		index = m[1]
+	}
+
+	if index == 0 { // no match was found, so the result is the string itself
+		parts = []string{this}
+	} else {
+		parts = append(parts, this[index:])
+		if limit == 0 {
+			for len(parts) > 0 && parts[len(parts)-1] == "" {
+				parts = parts[:len(parts)-1]
+			}
+		}
+	}
+
+	arr := object.Make1DimArray(object.REF, int64(len(parts)))
+	elements := *(arr.Fields[0].Fvalue.(*[]*object.Object))
+	for i, part := range parts {
+		elements[i] = goStringToJava(part)
+	}
+	return arr
+}
+
+// getRegexParams returns the string in params[0] and the regex in params[1]
+// as Go strings, which is what Go's regexp package works on
+func getRegexParams(params []interface{}, methName string) (string, string, error) {
+	this, _ := params[0].(*object.Object)
+	regex, _ := params[1].(*object.Object)
+	if this == nil || regex == nil {
+		return "", "", stringNPE(methName)
+	}
+	return object.GetGoStringFromJavaStringPtr(this), object.GetGoStringFromJavaStringPtr(regex), nil
+}
+
+// compileJavaRegex compiles a Java regex. Go's regular expressions support most
+// of Java's syntax, but not all (e.g., not backreferences or lookaround). If the
+// regex can't be compiled, a PatternSyntaxException is thrown.
+func compileJavaRegex(regex string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, throwNativeException(exceptions.PatternSyntaxException,
+			"java.util.regex.PatternSyntaxException", err.Error())
+	}
+	return re, nil
+}
+
+// java/lang/String.trim() removes all leading and trailing chars <= space
+func stringTrim(params []interface{}) interface{} {
+	return stripChars(params[0].(*object.Object), true, true,
+		func(c uint16) bool { return c <= ' ' })
+}
+
+// java/lang/String.strip() removes leading and trailing white space,
+// as defined by Character.isWhitespace()
+func stringStrip(params []interface{}) interface{} {
+	return stripChars(params[0].(*object.Object), true, true, isJavaWhitespace)
+}
+
+// java/lang/String.stripLeading()
+func stringStripLeading(params []interface{}) interface{} {
+	return stripChars(params[0].(*object.Object), true, false, isJavaWhitespace)
+}
+
+// java/lang/String.stripTrailing()
+func stringStripTrailing(params []interface{}) interface{} {
+	return stripChars(params[0].(*object.Object), false, true, isJavaWhitespace)
+}
+
+// java/lang/String.isBlank() is true if the string is empty or all white space
+func stringIsBlank(params []interface{}) interface{} {
+	for _, c := range object.GetStringUTF16(params[0].(*object.Object)) {
+		if !isJavaWhitespace(c) {
+			return types.JavaBoolFalse
+		}
+	}
+	return types.JavaBoolTrue
+}
+
+// stripChars removes the chars that satisfy isStripped from the start and/or
+// the end of the string. If nothing is removed, the original string is returned.
+func stripChars(this *object.Object, leading, trailing bool, isStripped func(uint16) bool) interface{} {
+	chars := object.GetStringUTF16(this)
+	begin, end := 0, len(chars)
+	for leading && begin < end && isStripped(chars[begin]) {
+		begin++
+	}
+	for trailing && end > begin && isStripped(chars[end-1]) {
+		end--
+	}
+	if begin == 0 && end == len(chars) {
+		return this
+	}
+	return newJavaString(chars[begin:end])
+}
+
+// isJavaWhitespace duplicates Character.isWhitespace(): Unicode space separators
+// other than the non-breaking spaces, plus the ASCII control chars for white space
+func isJavaWhitespace(c uint16) bool {
+	switch c {
+	case '\t', '\n', 0x0B, '\f', '\r', 0x1C, 0x1D, 0x1E, 0x1F:
+		return true
+	case 0x00A0, 0x2007, 0x202F: // non-breaking spaces
+		return false
+	}
+	r := rune(c)
+	return unicode.In(r, unicode.Zs, unicode.Zl, unicode.Zp)
+}
+
+// java/lang/String.toUpperCase() converts using the Unicode case mappings,
+// including the one-to-many mapping of the German sharp s to SS
+func stringToUpperCase(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	str := object.GetGoStringFromJavaStringPtr(this)
+	upper := javaToUpperCase(str)
+	if upper == str {
+		return this
+	}
+	return goStringToJava(upper)
+}
+
+// javaToUpperCase converts a string to upper case as String.toUpperCase() does
+func javaToUpperCase(str string) string {
+	return strings.ReplaceAll(strings.Map(unicode.ToUpper, str), "ß", "SS")
+}
+
+// java/lang/String.toLowerCase()
+func stringToLowerCase(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	str := object.GetGoStringFromJavaStringPtr(this)
+	lower := strings.Map(unicode.ToLower, str)
+	if lower == str {
+		return this
+	}
+	return goStringToJava(lower)
+}
+
+// java/lang/String.repeat()
+func stringRepeat(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	count := params[1].(int64)
+	if count < 0 {
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", "count is negative: "+strconv.FormatInt(count, 10))
+	}
+	if count == 1 {
+		return this
+	}
+	chars := object.GetStringUTF16(this)
+	result := make([]uint16, 0, len(chars)*int(count))
+	for i := int64(0); i < count; i++ {
+		result = append(result, chars...)
+	}
+	return newJavaString(result)
+}
+
+// java/lang/String.toCharArray()
+func stringToCharArray(params []interface{}) interface{} {
+	chars := object.GetStringUTF16(params[0].(*object.Object))
+	arr := object.Make1DimArray(object.INT, int64(len(chars)))
+	values := *(arr.Fields[0].Fvalue.(*[]int64))
+	for i, c := range chars {
+		values[i] = int64(c)
+	}
+	return arr
+}
+
+// java/lang/String.getBytes() encodes the string in UTF-8, the default charset
+func stringGetBytes(params []interface{}) interface{} {
+	str := object.GetGoStringFromJavaStringPtr(params[0].(*object.Object))
+	arr := object.Make1DimArray(object.BYTE, int64(len(str)))
+	copy(*arr.Fields[0].Fvalue.(*[]byte), str)
+	return arr
+}
+
+// === static methods ===
+
+// java/lang/String.format(String, Object...) and formatted(Object...). See javaUtilFormatter.go.
+func stringFormat(params []interface{}) interface{} {
+	formatObj, _ := params[0].(*object.Object)
+	if formatObj == nil {
+		return stringNPE("format")
+	}
+
+	var args []*object.Object
+	if argArray, ok := params[1].(*object.Object); ok && argArray != nil {
+		args = *(argArray.Fields[0].Fvalue.(*[]*object.Object))
+	}
+
+	str, err := javaFormat(object.GetGoStringFromJavaStringPtr(formatObj), args)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(str)
+}
+
+// java/lang/String.format(Locale, String, Object...). Formatting is always
+// locale-neutral, so the locale is not used.
+func stringFormatLocale(params []interface{}) interface{} {
+	return stringFormat(params[1:])
+}
+
+// java/lang/String.join(CharSequence delimiter, CharSequence... elements)
+func stringJoin(params []interface{}) interface{} {
+	delimObj, _ := params[0].(*object.Object)
+	arrObj, _ := params[1].(*object.Object)
+	delim, ok := charSequenceChars(delimObj)
+	if !ok || arrObj == nil {
+		return stringNPE("join")
+	}
+
+	var result []uint16
+	for i, elem := range *(arrObj.Fields[0].Fvalue.(*[]*object.Object)) {
+		if i > 0 {
+			result = append(result, delim...)
+		}
+		result = append(result, utf16.Encode([]rune(javaToString(elem)))...)
+	}
+	return newJavaString(result)
+}
+
+// java/lang/String.valueOf(int) and valueOf(long)
+func stringValueOfInt(params []interface{}) interface{} {
+	return goStringToJava(strconv.FormatInt(params[0].(int64), 10))
+}
+
+// java/lang/String.valueOf(float)
+func stringValueOfFloat(params []interface{}) interface{} {
+	return goStringToJava(floatToString(float32(params[0].(float64))))
+}
+
+// java/lang/String.valueOf(double)
+func stringValueOfDouble(params []interface{}) interface{} {
+	return goStringToJava(doubleToString(params[0].(float64)))
+}
+
+// java/lang/String.valueOf(boolean)
+func stringValueOfBoolean(params []interface{}) interface{} {
+	if params[0].(int64) == types.JavaBoolTrue {
+		return goStringToJava("true")
+	}
+	return goStringToJava("false")
+}
+
+// java/lang/String.valueOf(char)
+func stringValueOfChar(params []interface{}) interface{} {
+	return newJavaString([]uint16{uint16(params[0].(int64))})
+}
+
+// java/lang/String.valueOf(Object) returns "null" for a null object
+func stringValueOfObject(params []interface{}) interface{} {
+	obj, _ := params[0].(*object.Object)
+	if object.IsJavaString(obj) {
+		return obj
+	}
+	return goStringToJava(javaToString(obj))
+}
+
+// java/lang/String.valueOf(char[]) and valueOf(char[], int offset, int count),
+// as well as the equivalent copyValueOf() methods
+func stringValueOfChars(params []interface{}) interface{} {
+	chars, err := charArrayParam(params)
+	if err != nil {
+		return err
+	}
+	return newJavaString(chars)
+}
+
+// javaToString returns the string that String.valueOf(Object) would produce. For
+// objects other than strings, this is the output of Object.toString().
+func javaToString(obj *object.Object) string {
+	if obj == nil {
+		return "null"
+	}
+	if object.IsJavaString(obj) {
+		return object.GetGoStringFromJavaStringPtr(obj)
+	}
+	if chars, ok := builderChars(obj); ok {
+		return string(utf16.Decode(chars))
+	}
+	if str, ok := boxedToString(obj); ok {
+		return str
+	}
+	return fmt.Sprintf("%s@%x", javaClassName(obj), object.IdentityHash(obj))
+}
+
+// returnTrue is a do-nothing function that always returns the Java true
+func returnTrue([]interface{}) interface{} {
+	return types.JavaBoolTrue
+}
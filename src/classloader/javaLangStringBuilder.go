@@ -0,0 +1,1015 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"strconv"
+	"sync"
+	"unicode/utf16"
+)
+
+/*
+ StringBuilder and StringBuffer are implemented as Go-backed objects: the chars are
+ held in a Go struct (a stringBuilder) stored in the object's "value" field, rather
+ than in the byte array of the JDK's AbstractStringBuilder. The two classes share
+ all their functions. The only difference is that the methods of StringBuffer are
+ synchronized, which here is done by locking the stringBuilder's mutex.
+*/
+
+const builderValueField = "value"
+
+type stringBuilder struct {
+	chars        []uint16 // the Java chars in the builder
+	synchronized bool     // true for StringBuffer
+	mutex        sync.Mutex
+}
+
+func Load_Lang_StringBuilder() map[string]GMeth {
+
+	MethodSignatures["java/lang/StringBuilder.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbInit,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.<init>(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitCapacity,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitString,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.<init>(Ljava/lang/CharSequence;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitString,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Ljava/lang/String;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Ljava/lang/Object;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Ljava/lang/CharSequence;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Ljava/lang/StringBuffer;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Ljava/lang/CharSequence;II)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbAppendCharSequenceRange,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append([C)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendChars,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append([CII)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbAppendChars,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(Z)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendBoolean,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(C)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendChar,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(I)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendLong,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(J)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbAppendLong,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(F)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendFloat,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.append(D)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbAppendDouble,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.appendCodePoint(I)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendCodePoint,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(ILjava/lang/String;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(ILjava/lang/Object;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(ILjava/lang/CharSequence;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(I[C)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertChars,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(IZ)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertBoolean,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(IC)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertChar,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(II)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertLong,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(IJ)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbInsertLong,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(IF)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertFloat,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.insert(ID)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbInsertDouble,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.delete(II)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbDelete,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.deleteCharAt(I)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbDeleteCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.replace(IILjava/lang/String;)Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbReplace,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.reverse()Ljava/lang/StringBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbReverse,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.length()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbLength,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbIsEmpty,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.setLength(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbSetLength,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.charAt(I)C"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.setCharAt(IC)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSetCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.indexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.indexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.lastIndexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbLastIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.lastIndexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbLastIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.substring(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.substring(II)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.subSequence(II)Ljava/lang/CharSequence;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuilder.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbToString,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbInit,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.<init>(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitCapacity,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitString,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.<init>(Ljava/lang/CharSequence;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbInitString,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Ljava/lang/String;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Ljava/lang/Object;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Ljava/lang/CharSequence;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Ljava/lang/StringBuffer;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Ljava/lang/CharSequence;II)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbAppendCharSequenceRange,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append([C)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendChars,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append([CII)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbAppendChars,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(Z)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendBoolean,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(C)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendChar,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(I)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendLong,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(J)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbAppendLong,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(F)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendFloat,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.append(D)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbAppendDouble,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.appendCodePoint(I)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbAppendCodePoint,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(ILjava/lang/String;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(ILjava/lang/Object;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(ILjava/lang/CharSequence;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertObject,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(I[C)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertChars,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(IZ)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertBoolean,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(IC)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertChar,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(II)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertLong,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(IJ)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbInsertLong,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(IF)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbInsertFloat,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.insert(ID)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbInsertDouble,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.delete(II)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbDelete,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.deleteCharAt(I)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbDeleteCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.replace(IILjava/lang/String;)Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  sbReplace,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.reverse()Ljava/lang/StringBuffer;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbReverse,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.length()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbLength,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbIsEmpty,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.setLength(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbSetLength,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.charAt(I)C"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.setCharAt(IC)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSetCharAt,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.indexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.indexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.lastIndexOf(Ljava/lang/String;)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbLastIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.lastIndexOf(Ljava/lang/String;I)I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbLastIndexOf,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.substring(I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.substring(II)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.subSequence(II)Ljava/lang/CharSequence;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  sbSubstring,
+		}
+
+	MethodSignatures["java/lang/StringBuffer.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  sbToString,
+		}
+
+	return MethodSignatures
+}
+
+// getBuilder returns the Go-side stringBuilder of a StringBuilder or StringBuffer,
+// or nil if the object is not one
+func getBuilder(obj *object.Object) *stringBuilder {
+	if obj == nil || obj.FieldTable == nil {
+		return nil
+	}
+	if sb, ok := obj.FieldTable[builderValueField].Fvalue.(*stringBuilder); ok {
+		return sb
+	}
+	return nil
+}
+
+// withBuilder runs an operation on the builder in params[0], holding the lock if
+// it's a StringBuffer. A builder that has not been initialized is an empty one.
+func withBuilder(params []interface{}, op func(sb *stringBuilder) interface{}) interface{} {
+	this := params[0].(*object.Object)
+	sb := getBuilder(this)
+	if sb == nil {
+		sb = initBuilder(this, nil)
+	}
+	if sb.synchronized {
+		sb.mutex.Lock()
+		defer sb.mutex.Unlock()
+	}
+	return op(sb)
+}
+
+// initBuilder creates the Go-side stringBuilder for the object, with the given
+// initial chars. Whether it's synchronized depends on the object's class.
+func initBuilder(this *object.Object, chars []uint16) *stringBuilder {
+	sb := &stringBuilder{
+		chars:        chars,
+		synchronized: this.Klass != nil && *this.Klass == "java/lang/StringBuffer",
+	}
+	if this.FieldTable == nil {
+		this.FieldTable = make(map[string]object.Field)
+	}
+	this.FieldTable[builderValueField] = object.Field{Ftype: types.GoObject, Fvalue: sb}
+	return sb
+}
+
+// the exception thrown for an invalid index into a builder
+func sbIndexOutOfBounds(msg string) error {
+	return throwNativeException(exceptions.StringIndexOutOfBoundsException,
+		"java.lang.StringIndexOutOfBoundsException", msg)
+}
+
+// === constructors ===
+
+// StringBuilder.<init>()
+func sbInit(params []interface{}) interface{} {
+	initBuilder(params[0].(*object.Object), make([]uint16, 0, 16))
+	return nil
+}
+
+// StringBuilder.<init>(int capacity)
+func sbInitCapacity(params []interface{}) interface{} {
+	capacity := params[1].(int64)
+	if capacity < 0 {
+		return throwNativeException(exceptions.NegativeArraySizeException,
+			"java.lang.NegativeArraySizeException", strconv.FormatInt(capacity, 10))
+	}
+	initBuilder(params[0].(*object.Object), make([]uint16, 0, capacity))
+	return nil
+}
+
+// StringBuilder.<init>(String) and <init>(CharSequence)
+func sbInitString(params []interface{}) interface{} {
+	str, _ := params[1].(*object.Object)
+	chars, ok := charSequenceChars(str)
+	if !ok {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "StringBuilder.<init>(): null string")
+	}
+	initBuilder(params[0].(*object.Object), chars)
+	return nil
+}
+
+// === appending ===
+
+// appendChars appends the chars to the builder in params[0] and returns it, as
+// all the append() methods do
+func appendChars(params []interface{}, chars []uint16) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		sb.chars = append(sb.chars, chars...)
+		return params[0]
+	})
+}
+
+// append(String), append(Object), and append(CharSequence). As in the JDK,
+// a null value is appended as "null".
+func sbAppendObject(params []interface{}) interface{} {
+	obj, _ := params[1].(*object.Object)
+	return appendChars(params, objectToChars(obj))
+}
+
+// append(CharSequence s, int start, int end)
+func sbAppendCharSequenceRange(params []interface{}) interface{} {
+	obj, _ := params[1].(*object.Object)
+	chars := objectToChars(obj)
+	start, end := params[2].(int64), params[3].(int64)
+	if start < 0 || start > end || end > int64(len(chars)) {
+		return throwNativeException(exceptions.IndexOutOfBoundsException,
+			"java.lang.IndexOutOfBoundsException",
+			fmt.Sprintf("start %d, end %d, length %d", start, end, len(chars)))
+	}
+	return appendChars(params, chars[start:end])
+}
+
+// append(char[]) and append(char[], int offset, int len)
+func sbAppendChars(params []interface{}) interface{} {
+	chars, err := charArrayParam(params[1:])
+	if err != nil {
+		return err
+	}
+	return appendChars(params, chars)
+}
+
+// append(boolean)
+func sbAppendBoolean(params []interface{}) interface{} {
+	return appendChars(params, booleanToChars(params[1].(int64)))
+}
+
+// append(char)
+func sbAppendChar(params []interface{}) interface{} {
+	return appendChars(params, []uint16{uint16(params[1].(int64))})
+}
+
+// append(int) and append(long)
+func sbAppendLong(params []interface{}) interface{} {
+	return appendChars(params, asciiToChars(strconv.FormatInt(params[1].(int64), 10)))
+}
+
+// append(float) formats the float as Float.toString() does
+func sbAppendFloat(params []interface{}) interface{} {
+	return appendChars(params, asciiToChars(floatToString(float32(params[1].(float64)))))
+}
+
+// append(double) formats the double as Double.toString() does
+func sbAppendDouble(params []interface{}) interface{} {
+	return appendChars(params, asciiToChars(doubleToString(params[1].(float64))))
+}
+
+// appendCodePoint(int) appends a surrogate pair for supplementary chars
+func sbAppendCodePoint(params []interface{}) interface{} {
+	cp := params[1].(int64)
+	if cp < 0 || cp > 0x10FFFF {
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException",
+			fmt.Sprintf("Not a valid Unicode code point: 0x%X", cp))
+	}
+	return appendChars(params, codePointToChars(cp))
+}
+
+// objectToChars returns the chars that String.valueOf(obj) would produce
+func objectToChars(obj *object.Object) []uint16 {
+	if chars, ok := charSequenceChars(obj); ok {
+		return chars
+	}
+	return utf16.Encode([]rune(javaToString(obj)))
+}
+
+func booleanToChars(b int64) []uint16 {
+	if b == types.JavaBoolTrue {
+		return asciiToChars("true")
+	}
+	return asciiToChars("false")
+}
+
+func asciiToChars(str string) []uint16 {
+	chars := make([]uint16, len(str))
+	for i := 0; i < len(str); i++ {
+		chars[i] = uint16(str[i])
+	}
+	return chars
+}
+
+// === inserting ===
+
+// insertChars inserts the chars at the offset in params[1] and returns the builder
+func insertChars(params []interface{}, chars []uint16) interface{} {
+	offset := params[1].(int64)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		if offset < 0 || offset > int64(len(sb.chars)) {
+			return sbIndexOutOfBounds(fmt.Sprintf("offset %d, length %d", offset, len(sb.chars)))
+		}
+		newChars := make([]uint16, 0, len(sb.chars)+len(chars))
+		newChars = append(newChars, sb.chars[:offset]...)
+		newChars = append(newChars, chars...)
+		sb.chars = append(newChars, sb.chars[offset:]...)
+		return params[0]
+	})
+}
+
+// insert(int, String), insert(int, Object), and insert(int, CharSequence)
+func sbInsertObject(params []interface{}) interface{} {
+	obj, _ := params[2].(*object.Object)
+	return insertChars(params, objectToChars(obj))
+}
+
+// insert(int, char[])
+func sbInsertChars(params []interface{}) interface{} {
+	chars, err := charArrayParam(params[2:])
+	if err != nil {
+		return err
+	}
+	return insertChars(params, chars)
+}
+
+// insert(int, boolean)
+func sbInsertBoolean(params []interface{}) interface{} {
+	return insertChars(params, booleanToChars(params[2].(int64)))
+}
+
+// insert(int, char)
+func sbInsertChar(params []interface{}) interface{} {
+	return insertChars(params, []uint16{uint16(params[2].(int64))})
+}
+
+// insert(int, int) and insert(int, long)
+func sbInsertLong(params []interface{}) interface{} {
+	return insertChars(params, asciiToChars(strconv.FormatInt(params[2].(int64), 10)))
+}
+
+// insert(int, float)
+func sbInsertFloat(params []interface{}) interface{} {
+	return insertChars(params, asciiToChars(floatToString(float32(params[2].(float64)))))
+}
+
+// insert(int, double)
+func sbInsertDouble(params []interface{}) interface{} {
+	return insertChars(params, asciiToChars(doubleToString(params[2].(float64))))
+}
+
+// === deleting and replacing ===
+
+// delete(int start, int end). An end past the end of the builder is
+// treated as the end of the builder.
+func sbDelete(params []interface{}) interface{} {
+	start, end := params[1].(int64), params[2].(int64)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		length := int64(len(sb.chars))
+		if end > length {
+			end = length
+		}
+		if start < 0 || start > end {
+			return sbIndexOutOfBounds(fmt.Sprintf("start %d, end %d, length %d", start, end, length))
+		}
+		sb.chars = append(sb.chars[:start], sb.chars[end:]...)
+		return params[0]
+	})
+}
+
+// deleteCharAt(int index)
+func sbDeleteCharAt(params []interface{}) interface{} {
+	index := params[1].(int64)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		if index < 0 || index >= int64(len(sb.chars)) {
+			return sbIndexOutOfBounds(fmt.Sprintf("index %d, length %d", index, len(sb.chars)))
+		}
+		sb.chars = append(sb.chars[:index], sb.chars[index+1:]...)
+		return params[0]
+	})
+}
+
+// replace(int start, int end, String str)
+func sbReplace(params []interface{}) interface{} {
+	start, end := params[1].(int64), params[2].(int64)
+	str, _ := params[3].(*object.Object)
+	if str == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "StringBuilder.replace(): null string")
+	}
+	replacement := object.GetStringUTF16(str)
+
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		length := int64(len(sb.chars))
+		if end > length {
+			end = length
+		}
+		if start < 0 || start > length || start > end {
+			return sbIndexOutOfBounds(fmt.Sprintf("start %d, end %d, length %d", start, end, length))
+		}
+		newChars := make([]uint16, 0, length-(end-start)+int64(len(replacement)))
+		newChars = append(newChars, sb.chars[:start]...)
+		newChars = append(newChars, replacement...)
+		sb.chars = append(newChars, sb.chars[end:]...)
+		return params[0]
+	})
+}
+
+// reverse() reverses the chars, except that surrogate pairs are kept in
+// order, so that supplementary chars survive the reversal
+func sbReverse(params []interface{}) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		chars := sb.chars
+		for i, j := 0, len(chars)-1; i < j; i, j = i+1, j-1 {
+			chars[i], chars[j] = chars[j], chars[i]
+		}
+		for i := 0; i < len(chars)-1; i++ {
+			if isLowSurrogate(chars[i]) && isHighSurrogate(chars[i+1]) {
+				chars[i], chars[i+1] = chars[i+1], chars[i]
+				i++
+			}
+		}
+		return params[0]
+	})
+}
+
+func isHighSurrogate(c uint16) bool { return c >= 0xD800 && c <= 0xDBFF }
+func isLowSurrogate(c uint16) bool  { return c >= 0xDC00 && c <= 0xDFFF }
+
+// === accessors ===
+
+// length()
+func sbLength(params []interface{}) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		return int64(len(sb.chars))
+	})
+}
+
+// isEmpty()
+func sbIsEmpty(params []interface{}) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		return types.ConvertGoBoolToJavaBool(len(sb.chars) == 0)
+	})
+}
+
+// setLength(int) truncates the builder or pads it with '\u0000' chars
+func sbSetLength(params []interface{}) interface{} {
+	newLength := params[1].(int64)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		if newLength < 0 {
+			return sbIndexOutOfBounds("String index out of range: " + strconv.FormatInt(newLength, 10))
+		}
+		if newLength <= int64(len(sb.chars)) {
+			sb.chars = sb.chars[:newLength]
+		} else {
+			sb.chars = append(sb.chars, make([]uint16, newLength-int64(len(sb.chars)))...)
+		}
+		return nil
+	})
+}
+
+// charAt(int)
+func sbCharAt(params []interface{}) interface{} {
+	index := params[1].(int64)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		if index < 0 || index >= int64(len(sb.chars)) {
+			return sbIndexOutOfBounds(fmt.Sprintf("index %d, length %d", index, len(sb.chars)))
+		}
+		return int64(sb.chars[index])
+	})
+}
+
+// setCharAt(int, char)
+func sbSetCharAt(params []interface{}) interface{} {
+	index := params[1].(int64)
+	c := uint16(params[2].(int64))
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		if index < 0 || index >= int64(len(sb.chars)) {
+			return sbIndexOutOfBounds(fmt.Sprintf("index %d, length %d", index, len(sb.chars)))
+		}
+		sb.chars[index] = c
+		return nil
+	})
+}
+
+// indexOf(String) and indexOf(String, int fromIndex)
+func sbIndexOf(params []interface{}) interface{} {
+	str, _ := params[1].(*object.Object)
+	if str == nil {
+		return stringNPE("indexOf")
+	}
+	target := object.GetStringUTF16(str)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		from := int64(0)
+		if len(params) > 2 {
+			from = params[2].(int64)
+		}
+		return int64(indexOfChars(sb.chars, target, from))
+	})
+}
+
+// lastIndexOf(String) and lastIndexOf(String, int fromIndex)
+func sbLastIndexOf(params []interface{}) interface{} {
+	str, _ := params[1].(*object.Object)
+	if str == nil {
+		return stringNPE("lastIndexOf")
+	}
+	target := object.GetStringUTF16(str)
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		from := int64(len(sb.chars))
+		if len(params) > 2 {
+			from = params[2].(int64)
+		}
+		return int64(lastIndexOfChars(sb.chars, target, from))
+	})
+}
+
+// substring(int start), substring(int start, int end) and subSequence()
+func sbSubstring(params []interface{}) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		start := params[1].(int64)
+		end := int64(len(sb.chars))
+		if len(params) > 2 {
+			end = params[2].(int64)
+		}
+		if start < 0 || start > end || end > int64(len(sb.chars)) {
+			return sbIndexOutOfBounds(fmt.Sprintf("start %d, end %d, length %d", start, end, len(sb.chars)))
+		}
+		return newJavaString(sb.chars[start:end])
+	})
+}
+
+// toString() returns a new string with the builder's current chars
+func sbToString(params []interface{}) interface{} {
+	return withBuilder(params, func(sb *stringBuilder) interface{} {
+		return newJavaString(sb.chars)
+	})
+}
+
+// builderChars returns a copy of the chars in a StringBuilder or StringBuffer
+func builderChars(obj *object.Object) ([]uint16, bool) {
+	sb := getBuilder(obj)
+	if sb == nil {
+		return nil, false
+	}
+	if sb.synchronized {
+		sb.mutex.Lock()
+		defer sb.mutex.Unlock()
+	}
+	chars := make([]uint16, len(sb.chars))
+	copy(chars, sb.chars)
+	return chars, true
+}
@@ -0,0 +1,155 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func newBuilder(className string) *object.Object {
+	obj := object.MakeEmptyObject()
+	obj.Klass = &className
+	sbInit([]interface{}{obj})
+	return obj
+}
+
+func builderString(t *testing.T, sb *object.Object) string {
+	t.Helper()
+	return gostr(t, sbToString([]interface{}{sb}))
+}
+
+func TestStringBuilderAppend(t *testing.T) {
+	sb := newBuilder("java/lang/StringBuilder")
+
+	if sbAppendObject([]interface{}{sb, jstr("x=")}) != sb {
+		t.Errorf("Expected append() to return the builder")
+	}
+	sbAppendLong([]interface{}{sb, int64(-12)})
+	sbAppendChar([]interface{}{sb, int64(',')})
+	sbAppendLong([]interface{}{sb, int64(9000000000), int64(9000000000)})
+	sbAppendChar([]interface{}{sb, int64(',')})
+	sbAppendDouble([]interface{}{sb, 2.5, 2.5})
+	sbAppendChar([]interface{}{sb, int64(',')})
+	sbAppendFloat([]interface{}{sb, float64(float32(0.1))})
+	sbAppendChar([]interface{}{sb, int64(',')})
+	sbAppendDouble([]interface{}{sb, 1e7, 1e7})
+	sbAppendChar([]interface{}{sb, int64(',')})
+	sbAppendBoolean([]interface{}{sb, types.JavaBoolTrue})
+	sbAppendObject([]interface{}{sb, object.Null})
+
+	expected := "x=-12,9000000000,2.5,0.1,1.0E7,truenull"
+	if got := builderString(t, sb); got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+	if sbLength([]interface{}{sb}).(int64) != int64(len(expected)) {
+		t.Errorf("Expected length %d, got %d", len(expected), sbLength([]interface{}{sb}).(int64))
+	}
+}
+
+func TestStringBuilderEditing(t *testing.T) {
+	sb := newBuilder("java/lang/StringBuilder")
+	sbAppendObject([]interface{}{sb, jstr("hello")})
+
+	sbInsertObject([]interface{}{sb, int64(0), jstr(">> ")})
+	sbInsertLong([]interface{}{sb, int64(3), int64(1)})
+	if got := builderString(t, sb); got != ">> 1hello" {
+		t.Errorf("insert() got '%s'", got)
+	}
+
+	sbDeleteCharAt([]interface{}{sb, int64(3)})
+	sbDelete([]interface{}{sb, int64(0), int64(3)})
+	if got := builderString(t, sb); got != "hello" {
+		t.Errorf("delete() got '%s'", got)
+	}
+
+	sbReplace([]interface{}{sb, int64(1), int64(4), jstr("ipp")})
+	if got := builderString(t, sb); got != "hippo" {
+		t.Errorf("replace() got '%s'", got)
+	}
+
+	sbSetCharAt([]interface{}{sb, int64(0), int64('H')})
+	sbSetLength([]interface{}{sb, int64(3)})
+	if got := builderString(t, sb); got != "Hip" {
+		t.Errorf("setLength() got '%s'", got)
+	}
+	sbSetLength([]interface{}{sb, int64(4)})
+	if sbCharAt([]interface{}{sb, int64(3)}).(int64) != 0 {
+		t.Errorf("Expected setLength() to pad with null chars")
+	}
+}
+
+func TestStringBuilderReverseKeepsSurrogatePairs(t *testing.T) {
+	sb := newBuilder("java/lang/StringBuilder")
+	sbAppendObject([]interface{}{sb, jstr("ab😀c")})
+	sbReverse([]interface{}{sb})
+	if got := builderString(t, sb); got != "c😀ba" {
+		t.Errorf("reverse() got '%s'", got)
+	}
+}
+
+func TestStringBufferIsSynchronized(t *testing.T) {
+	buf := newBuilder("java/lang/StringBuffer")
+	if !getBuilder(buf).synchronized {
+		t.Errorf("Expected StringBuffer to be synchronized")
+	}
+	if getBuilder(newBuilder("java/lang/StringBuilder")).synchronized {
+		t.Errorf("Expected StringBuilder not to be synchronized")
+	}
+
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for j := 0; j < 100; j++ {
+				sbAppendChar([]interface{}{buf, int64('x')})
+			}
+			done <- true
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if sbLength([]interface{}{buf}).(int64) != 400 {
+		t.Errorf("Expected 400 chars after concurrent appends, got %d", sbLength([]interface{}{buf}).(int64))
+	}
+}
+
+func TestStringBuilderAsCharSequence(t *testing.T) {
+	sb := newBuilder("java/lang/StringBuilder")
+	sbAppendObject([]interface{}{sb, jstr("lo w")})
+	if stringContains([]interface{}{jstr("hello world"), sb}) != types.JavaBoolTrue {
+		t.Errorf("Expected String.contains() to accept a StringBuilder")
+	}
+	if got := gostr(t, stringValueOfObject([]interface{}{sb})); got != "lo w" {
+		t.Errorf("Expected String.valueOf(StringBuilder) to be 'lo w', got '%s'", got)
+	}
+}
+
+func TestStringBuilderIndexOutOfBounds(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	sb := newBuilder("java/lang/StringBuilder")
+	sbAppendObject([]interface{}{sb, jstr("abc")})
+	if _, ok := sbDeleteCharAt([]interface{}{sb, int64(3)}).(error); !ok {
+		t.Errorf("Expected deleteCharAt(3) to throw an exception")
+	}
+	if _, ok := sbInsertObject([]interface{}{sb, int64(4), jstr("x")}).(error); !ok {
+		t.Errorf("Expected insert(4, ...) to throw an exception")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
@@ -0,0 +1,265 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func jstr(s string) *object.Object {
+	return object.NewStringFromGoString(s)
+}
+
+func gostr(t *testing.T, ret interface{}) string {
+	t.Helper()
+	obj, ok := ret.(*object.Object)
+	if !ok || !object.IsJavaString(obj) {
+		t.Fatalf("Expected a Java string, got %T %v", ret, ret)
+	}
+	return object.GetGoStringFromJavaStringPtr(obj)
+}
+
+// compact strings must be LATIN1 with one byte per char; others UTF16
+func TestStringCoders(t *testing.T) {
+	latin := jstr("café")
+	if object.GetStringCoder(latin) != object.CoderLatin1 {
+		t.Errorf("Expected LATIN1 coder for 'café'")
+	}
+	if len(*(latin.Fields[0].Fvalue.(*[]byte))) != 4 {
+		t.Errorf("Expected 4 bytes for 'café', got %d", len(*(latin.Fields[0].Fvalue.(*[]byte))))
+	}
+
+	wide := jstr("a€😀")
+	if object.GetStringCoder(wide) != object.CoderUTF16 {
+		t.Errorf("Expected UTF16 coder for 'a€😀'")
+	}
+	if stringLength([]interface{}{wide}).(int64) != 4 { // the emoji is a surrogate pair
+		t.Errorf("Expected length 4, got %d", stringLength([]interface{}{wide}).(int64))
+	}
+	if gostr(t, wide) != "a€😀" {
+		t.Errorf("Round trip of UTF16 string failed, got %s", gostr(t, wide))
+	}
+	if stringCharAt([]interface{}{wide, int64(1)}).(int64) != 0x20AC {
+		t.Errorf("Expected charAt(1) to be the euro sign")
+	}
+	if stringCodePointAt([]interface{}{wide, int64(2)}).(int64) != 0x1F600 {
+		t.Errorf("Expected codePointAt(2) to be the emoji")
+	}
+}
+
+func TestStringHashCodeAndEquals(t *testing.T) {
+	s := jstr("hello")
+	if h := stringHashCode([]interface{}{s}).(int64); h != 99162322 {
+		t.Errorf("Expected hash of 'hello' to be 99162322, got %d", h)
+	}
+	if s.Fields[2].Fvalue.(int64) != 99162322 {
+		t.Errorf("Expected hash to be cached in the hash field")
+	}
+
+	wide := jstr("€")
+	if h := stringHashCode([]interface{}{wide}).(int64); h != 8364 {
+		t.Errorf("Expected hash of '€' to be 8364, got %d", h)
+	}
+
+	empty := jstr("")
+	if stringHashCode([]interface{}{empty}).(int64) != 0 || empty.Fields[9].Fvalue != types.JavaBoolTrue {
+		t.Errorf("Expected hash of empty string to be 0 and hashIsZero to be set")
+	}
+
+	if stringEquals([]interface{}{s, jstr("hello")}) != types.JavaBoolTrue {
+		t.Errorf("Expected equal strings to be equal")
+	}
+	if stringEquals([]interface{}{s, jstr("Hello")}) != types.JavaBoolFalse {
+		t.Errorf("Expected different strings to be unequal")
+	}
+	if stringEquals([]interface{}{s, object.Null}) != types.JavaBoolFalse {
+		t.Errorf("Expected string not to equal null")
+	}
+	if stringEqualsIgnoreCase([]interface{}{s, jstr("HeLLo")}) != types.JavaBoolTrue {
+		t.Errorf("Expected equalsIgnoreCase to be true")
+	}
+
+	if stringCompareTo([]interface{}{jstr("apple"), jstr("banana")}).(int64) != -1 {
+		t.Errorf("Expected compareTo to return -1")
+	}
+	if stringCompareTo([]interface{}{jstr("abc"), jstr("ab")}).(int64) != 1 {
+		t.Errorf("Expected compareTo to return the length difference")
+	}
+}
+
+func TestStringSearching(t *testing.T) {
+	s := jstr("hello world")
+	if stringIndexOfChar([]interface{}{s, int64('o')}).(int64) != 4 {
+		t.Errorf("indexOf('o') failed")
+	}
+	if stringIndexOfChar([]interface{}{s, int64('o'), int64(5)}).(int64) != 7 {
+		t.Errorf("indexOf('o', 5) failed")
+	}
+	if stringIndexOfString([]interface{}{s, jstr("world")}).(int64) != 6 {
+		t.Errorf("indexOf(\"world\") failed")
+	}
+	if stringLastIndexOfChar([]interface{}{s, int64('o')}).(int64) != 7 {
+		t.Errorf("lastIndexOf('o') failed")
+	}
+	if stringLastIndexOfString([]interface{}{s, jstr("xyz")}).(int64) != -1 {
+		t.Errorf("lastIndexOf(\"xyz\") should not be found")
+	}
+	if stringContains([]interface{}{s, jstr("lo w")}) != types.JavaBoolTrue {
+		t.Errorf("contains() failed")
+	}
+	if stringStartsWith([]interface{}{s, jstr("world"), int64(6)}) != types.JavaBoolTrue {
+		t.Errorf("startsWith(\"world\", 6) failed")
+	}
+	if stringEndsWith([]interface{}{s, jstr("world")}) != types.JavaBoolTrue {
+		t.Errorf("endsWith() failed")
+	}
+}
+
+func TestStringTransformations(t *testing.T) {
+	s := jstr("  Hello World  ")
+	if got := gostr(t, stringTrim([]interface{}{s})); got != "Hello World" {
+		t.Errorf("trim() got '%s'", got)
+	}
+	if got := gostr(t, stringStrip([]interface{}{jstr(" x\t")})); got != "x" {
+		t.Errorf("strip() got '%s'", got)
+	}
+	if got := gostr(t, stringTrim([]interface{}{jstr(" x")})); got != " x" {
+		t.Errorf("trim() should not remove an em space, got '%s'", got)
+	}
+	if got := gostr(t, stringToUpperCase([]interface{}{jstr("straße")})); got != "STRASSE" {
+		t.Errorf("toUpperCase() got '%s'", got)
+	}
+	if got := gostr(t, stringToLowerCase([]interface{}{jstr("ÀBC")})); got != "àbc" {
+		t.Errorf("toLowerCase() got '%s'", got)
+	}
+	if got := gostr(t, stringSubstring([]interface{}{jstr("hello"), int64(1), int64(3)})); got != "el" {
+		t.Errorf("substring(1, 3) got '%s'", got)
+	}
+	if got := gostr(t, stringConcat([]interface{}{jstr("ab"), jstr("€")})); got != "ab€" {
+		t.Errorf("concat() got '%s'", got)
+	}
+	if got := gostr(t, stringReplaceChar([]interface{}{jstr("banana"), int64('a'), int64('o')})); got != "bonono" {
+		t.Errorf("replace(char, char) got '%s'", got)
+	}
+	if got := gostr(t, stringReplace([]interface{}{jstr("aaa"), jstr("aa"), jstr("b")})); got != "ba" {
+		t.Errorf("replace(CharSequence, CharSequence) got '%s'", got)
+	}
+	if got := gostr(t, stringReplace([]interface{}{jstr("ab"), jstr(""), jstr("-")})); got != "-a-b-" {
+		t.Errorf("replace with empty target got '%s'", got)
+	}
+	if got := gostr(t, stringReplaceAll([]interface{}{jstr("a1b22c"), jstr("(\\d+)"), jstr("<$1>")})); got != "a<1>b<22>c" {
+		t.Errorf("replaceAll() got '%s'", got)
+	}
+
+	arr := stringGetBytes([]interface{}{jstr("a€")}).(*object.Object)
+	if arr.Klass == nil || *arr.Klass != types.ByteArray || string(*arr.Fields[0].Fvalue.(*[]byte)) != "a€" {
+		t.Errorf("getBytes() should return a byte array of the UTF-8 bytes, got %v", arr)
+	}
+}
+
+func splitToGo(t *testing.T, ret interface{}) []string {
+	t.Helper()
+	arr := ret.(*object.Object)
+	var parts []string
+	for _, elem := range *(arr.Fields[0].Fvalue.(*[]*object.Object)) {
+		parts = append(parts, object.GetGoStringFromJavaStringPtr(elem))
+	}
+	return parts
+}
+
+func TestStringSplit(t *testing.T) {
+	tests := []struct {
+		str, regex string
+		limit      int64
+		expected   []string
+	}{
+		{"a,b,,c,,", ",", 0, []string{"a", "b", "", "c"}},
+		{"a,b,,c,,", ",", -1, []string{"a", "b", "", "c", "", ""}},
+		{"a,b,c", ",", 2, []string{"a", "b,c"}},
+		{"abc", "", 0, []string{"a", "b", "c"}},
+		{"", ",", 0, []string{""}},
+		{"one  two", "\\s+", 0, []string{"one", "two"}},
+	}
+
+	for _, test := range tests {
+		got := splitToGo(t, stringSplit([]interface{}{jstr(test.str), jstr(test.regex), test.limit}))
+		if len(got) != len(test.expected) {
+			t.Errorf("split(%q, %q, %d): expected %q, got %q", test.str, test.regex, test.limit, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("split(%q, %q, %d): expected %q, got %q", test.str, test.regex, test.limit, test.expected, got)
+				break
+			}
+		}
+	}
+}
+
+func TestStringValueOfAndFormat(t *testing.T) {
+	if got := gostr(t, stringValueOfInt([]interface{}{int64(-42)})); got != "-42" {
+		t.Errorf("valueOf(int) got '%s'", got)
+	}
+	if got := gostr(t, stringValueOfDouble([]interface{}{1.0, 1.0})); got != "1.0" {
+		t.Errorf("valueOf(1.0) got '%s'", got)
+	}
+	if got := gostr(t, stringValueOfDouble([]interface{}{1.5e10, 1.5e10})); got != "1.5E10" {
+		t.Errorf("valueOf(1.5e10) got '%s'", got)
+	}
+	if got := gostr(t, stringValueOfBoolean([]interface{}{types.JavaBoolTrue})); got != "true" {
+		t.Errorf("valueOf(true) got '%s'", got)
+	}
+	if got := gostr(t, stringValueOfObject([]interface{}{object.Null})); got != "null" {
+		t.Errorf("valueOf(null) got '%s'", got)
+	}
+
+	args := object.Make1DimArray(object.REF, 2)
+	elements := *(args.Fields[0].Fvalue.(*[]*object.Object))
+	elements[0] = jstr("x")
+	elements[1] = jstr("y")
+	got := gostr(t, stringFormat([]interface{}{jstr("[%-3s|%2$3s|%%]%n"), args}))
+	if got != "[x  |  y|%]\n" {
+		t.Errorf("format() got '%s'", got)
+	}
+}
+
+func TestStringIntern(t *testing.T) {
+	a := jstr("interned")
+	b := jstr("interned")
+	if stringIntern([]interface{}{a}) != a {
+		t.Errorf("Expected the first string interned to be the canonical instance")
+	}
+	if stringIntern([]interface{}{b}) != a {
+		t.Errorf("Expected an equal string to intern to the canonical instance")
+	}
+}
+
+func TestStringIndexOutOfBounds(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	ret := stringCharAt([]interface{}{jstr("abc"), int64(3)})
+	if _, ok := ret.(error); !ok {
+		t.Errorf("Expected charAt(3) on 'abc' to throw an exception")
+	}
+	ret = stringSubstring([]interface{}{jstr("abc"), int64(2), int64(1)})
+	if _, ok := ret.(error); !ok {
+		t.Errorf("Expected substring(2, 1) to throw an exception")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
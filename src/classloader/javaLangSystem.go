@@ -8,13 +8,17 @@ package classloader
 
 import (
 	"fmt"
+	"jacobin/exceptions"
 	"jacobin/globals"
 	"jacobin/object"
 	"jacobin/shutdown"
+	"jacobin/types"
 	"os"
 	"os/user"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -36,6 +40,12 @@ import (
 
 func Load_Lang_System() map[string]GMeth {
 
+	MethodSignatures["java/lang/System.arraycopy(Ljava/lang/Object;ILjava/lang/Object;II)V"] =
+		GMeth{
+			ParamSlots: 5,
+			GFunction:  arraycopy,
+		}
+
 	MethodSignatures["java/lang/System.currentTimeMillis()J"] = // get time in ms since Jan 1, 1970, returned as long
 		GMeth{
 			ParamSlots: 0,
@@ -60,12 +70,78 @@ func Load_Lang_System() map[string]GMeth {
 			GFunction:  forceGC,
 		}
 
+	MethodSignatures["java/lang/System.identityHashCode(Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  identityHashCode,
+		}
+
+	MethodSignatures["java/lang/System.setOut(Ljava/io/PrintStream;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  setOut,
+		}
+
+	MethodSignatures["java/lang/System.setErr(Ljava/io/PrintStream;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  setErr,
+		}
+
+	MethodSignatures["java/lang/System.setIn(Ljava/io/InputStream;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  setIn,
+		}
+
+	MethodSignatures["java/lang/System.getenv(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  getenv,
+		}
+
+	MethodSignatures["java/lang/System.getenv()Ljava/util/Map;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getenvMap,
+		}
+
 	MethodSignatures["java/lang/System.getProperty(Ljava/lang/String;)Ljava/lang/String;"] =
 		GMeth{
 			ParamSlots: 1,
 			GFunction:  getProperty,
 		}
 
+	MethodSignatures["java/lang/System.getProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  getProperty,
+		}
+
+	MethodSignatures["java/lang/System.setProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  setProperty,
+		}
+
+	MethodSignatures["java/lang/System.clearProperty(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  clearProperty,
+		}
+
+	MethodSignatures["java/lang/System.getProperties()Ljava/util/Properties;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getPropertiesObject,
+		}
+
+	MethodSignatures["java/lang/System.lineSeparator()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  lineSeparator,
+		}
+
 	// MethodSignatures["java/lang/System.<clinit>()V"] = // TODO: need to replace eventually
 	// 	GMeth{
 	// 		ParamSlots: 0,
@@ -75,6 +151,190 @@ func Load_Lang_System() map[string]GMeth {
 	return MethodSignatures
 }
 
+// System.arraycopy(src, srcPos, dest, destPos, length)
+func arraycopy(params []interface{}) interface{} {
+	src, _ := params[0].(*object.Object)
+	dest, _ := params[2].(*object.Object)
+	if err := arrayCopy(src, params[1].(int64), dest, params[3].(int64), params[4].(int64)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// arrayCopy copies length elements of the src array, starting at srcPos, into
+// the dest array at destPos. src and dest can be the same array, and the ranges
+// can overlap: the copy is made as though through a temporary array. The checks,
+// and the messages of the exceptions they throw, are the JDK's.
+//
+// Jacobin arrays record how their elements are held, not their Java type, so
+// an int[] can be copied into a long[]. The elements copied into an array of
+// references are checked against its component type, if the array records one.
+func arrayCopy(src *object.Object, srcPos int64, dest *object.Object, destPos int64, length int64) error {
+	if src == nil || dest == nil {
+		return throwNativeException(exceptions.NullPointerException, "java.lang.NullPointerException", "")
+	}
+	if !isArrayObject(src) {
+		return throwNativeException(exceptions.ArrayStoreException, "java.lang.ArrayStoreException",
+			fmt.Sprintf("arraycopy: source type %s is not an array", javaClassName(src)))
+	}
+	if !isArrayObject(dest) {
+		return throwNativeException(exceptions.ArrayStoreException, "java.lang.ArrayStoreException",
+			fmt.Sprintf("arraycopy: destination type %s is not an array", javaClassName(dest)))
+	}
+
+	srcName, destName := arrayElementName(src), arrayElementName(dest)
+	if srcName != destName {
+		return throwNativeException(exceptions.ArrayStoreException, "java.lang.ArrayStoreException",
+			fmt.Sprintf("arraycopy: type mismatch: can not copy %s[] into %s[]", srcName, destName))
+	}
+
+	srcLen, destLen := int64(arrayLength(src)), int64(arrayLength(dest))
+	var errMsg string
+	switch {
+	case srcPos < 0:
+		errMsg = fmt.Sprintf("arraycopy: source index %d out of bounds for %s[%d]", srcPos, srcName, srcLen)
+	case destPos < 0:
+		errMsg = fmt.Sprintf("arraycopy: destination index %d out of bounds for %s[%d]", destPos, destName, destLen)
+	case length < 0:
+		errMsg = fmt.Sprintf("arraycopy: length %d is negative", length)
+	case srcPos+length > srcLen:
+		errMsg = fmt.Sprintf("arraycopy: last source index %d out of bounds for %s[%d]", srcPos+length, srcName, srcLen)
+	case destPos+length > destLen:
+		errMsg = fmt.Sprintf("arraycopy: last destination index %d out of bounds for %s[%d]", destPos+length, destName, destLen)
+	}
+	if errMsg != "" {
+		return throwNativeException(exceptions.ArrayIndexOutOfBoundsException,
+			"java.lang.ArrayIndexOutOfBoundsException", errMsg)
+	}
+
+	// Go's copy() is safe for overlapping slices
+	switch s := src.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		copy((*dest.Fields[0].Fvalue.(*[]byte))[destPos:], (*s)[srcPos:srcPos+length])
+	case *[]int64:
+		copy((*dest.Fields[0].Fvalue.(*[]int64))[destPos:], (*s)[srcPos:srcPos+length])
+	case *[]float64:
+		copy((*dest.Fields[0].Fvalue.(*[]float64))[destPos:], (*s)[srcPos:srcPos+length])
+	case *[]*object.Object:
+		// as in the JDK, the elements before the first that can't be stored are copied
+		elements := (*s)[srcPos : srcPos+length]
+		component := arrayComponentType(dest)
+		for i, elem := range elements {
+			if !canStoreElement(elem, component) {
+				copy((*dest.Fields[0].Fvalue.(*[]*object.Object))[destPos:], elements[:i])
+				return throwNativeException(exceptions.ArrayStoreException, "java.lang.ArrayStoreException",
+					fmt.Sprintf("arraycopy: element type mismatch: can not cast one of the elements of %s[] "+
+						"to the type of the destination array, %s", javaComponentName(src), javaTypeName(component)))
+			}
+		}
+		copy((*dest.Fields[0].Fvalue.(*[]*object.Object))[destPos:], elements)
+	}
+	return nil
+}
+
+// arrayComponentType returns the type of the elements of an array of references,
+// e.g. java/lang/String for a String[] and [I for an int[][]. It's "" if the
+// array doesn't record the type, as an array created by ANEWARRAY doesn't,
+// in which case it's treated as an Object[].
+func arrayComponentType(arr *object.Object) string {
+	arrType := arr.Fields[0].Ftype
+	switch {
+	case strings.HasPrefix(arrType, "[["):
+		return arrType[1:]
+	case strings.HasPrefix(arrType, types.RefArray) && strings.HasSuffix(arrType, ";"):
+		return arrType[2 : len(arrType)-1]
+	default:
+		return ""
+	}
+}
+
+// canStoreElement is true if the object can be stored in an array whose
+// component type is the given one, that is, if an AASTORE of it into the
+// array would not throw an ArrayStoreException
+func canStoreElement(elem *object.Object, component string) bool {
+	if elem == nil || component == "" || component == "java/lang/Object" {
+		return true
+	}
+
+	if isArrayObject(elem) {
+		switch {
+		case component == "java/lang/Cloneable" || component == "java/io/Serializable":
+			return true
+		case !strings.HasPrefix(component, types.Array):
+			return false
+		case elem.Fields[0].Ftype == types.RefArray: // an array of references of unknown type
+			return strings.HasPrefix(component, types.RefArray) || strings.HasPrefix(component, "[[")
+		default:
+			return elem.Fields[0].Ftype == component
+		}
+	}
+
+	if strings.HasPrefix(component, types.Array) {
+		return false
+	}
+	// an object of a class that isn't in the method area can't be checked
+	if elem.Klass == nil || MethAreaFetch(*elem.Klass) == nil {
+		return true
+	}
+	return implementsInterface(*elem.Klass, component) // which also checks the superclasses
+}
+
+// javaComponentName returns the type of the elements of an array of
+// references as the JDK shows it in exception messages, e.g. java.lang.String
+func javaComponentName(arr *object.Object) string {
+	return javaTypeName(arrayComponentType(arr))
+}
+
+// javaTypeName converts a component type to the format of Class.getName()
+func javaTypeName(component string) string {
+	if component == "" {
+		return "java.lang.Object"
+	}
+	return strings.ReplaceAll(component, "/", ".")
+}
+
+// isArrayObject is true if the object is a Jacobin array
+func isArrayObject(obj *object.Object) bool {
+	if obj.Klass == nil || !strings.HasPrefix(*obj.Klass, types.Array) || len(obj.Fields) == 0 {
+		return false
+	}
+	return arrayElementName(obj) != ""
+}
+
+// arrayElementName returns the name the JDK uses in exception messages for
+// the elements of an array of this representation, and "" if the object is
+// not an array
+func arrayElementName(arr *object.Object) string {
+	switch arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		return "byte"
+	case *[]int64:
+		return "int"
+	case *[]float64:
+		return "double"
+	case *[]*object.Object:
+		return "object array"
+	default:
+		return ""
+	}
+}
+
+// arrayLength returns the number of elements in an array
+func arrayLength(arr *object.Object) int {
+	switch a := arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		return len(*a)
+	case *[]int64:
+		return len(*a)
+	case *[]float64:
+		return len(*a)
+	case *[]*object.Object:
+		return len(*a)
+	default:
+		return 0
+	}
+}
+
 // Return time in milliseconds, measured since midnight of Jan 1, 1970
 func currentTimeMillis([]interface{}) interface{} {
 	return time.Now().UnixMilli() // is int64
@@ -86,7 +346,8 @@ func nanoTime([]interface{}) interface{} {
 	return time.Now().UnixNano() // is int64
 }
 
-// Exits the program directly, returning the passed in value
+// Exits the program, returning the passed in value, after running the shutdown
+// hooks. It can be called from any thread.
 func exitI(params []interface{}) interface{} {
 	exitCode := params[0].(int64) // int64
 	var exitStatus = int(exitCode)
@@ -100,83 +361,163 @@ func forceGC([]interface{}) interface{} {
 	return nil
 }
 
-// Get a property
-func getProperty(params []interface{}) interface{} {
-	propObj := params[0].(*object.Object) // string
-	strPtr := propObj.Fields[0].Fvalue.(*[]byte)
-	str := *strPtr
-	prop := string(str)
+// Return the identity hash code of an object, which is the value Object.hashCode()
+// returns, even if the object's class overrides hashCode(). The hash of null is 0.
+func identityHashCode(params []interface{}) interface{} {
+	obj, _ := params[0].(*object.Object)
+	return int64(object.IdentityHash(obj))
+}
+
+// Reassign System.in, System.out, and System.err
+func setIn(params []interface{}) interface{} {
+	_ = AddStatic("java/lang/System.in", Static{Type: "Ljava/io/InputStream;", Value: params[0]})
+	return nil
+}
+
+func setOut(params []interface{}) interface{} {
+	_ = AddStatic("java/lang/System.out", Static{Type: "Ljava/io/PrintStream;", Value: params[0]})
+	return nil
+}
 
-	var value string
+func setErr(params []interface{}) interface{} {
+	_ = AddStatic("java/lang/System.err", Static{Type: "Ljava/io/PrintStream;", Value: params[0]})
+	return nil
+}
+
+// the system properties, created on first use
+var systemProperties *properties
+var systemPropertiesObj *object.Object
+var systemPropertiesOnce sync.Once
+
+// getSystemProperties returns the system properties. On first use, they're
+// set to the values the VM provides, overridden by any set on the command
+// line with -D.
+func getSystemProperties() *properties {
+	systemPropertiesOnce.Do(func() {
+		systemProperties = newProperties()
+		for key, value := range vmProperties() {
+			systemProperties.values[key] = value
+		}
+		for key, value := range globals.GetGlobalRef().Properties {
+			systemProperties.values[key] = value
+		}
+		systemPropertiesObj = newPropertiesObject(systemProperties)
+	})
+	return systemProperties
+}
+
+// vmProperties returns the values of the system properties that the VM provides
+func vmProperties() map[string]string {
 	g := globals.GetGlobalRef()
 	operSys := runtime.GOOS
 
-	switch prop {
-	case "file.encoding":
-		value = g.FileEncoding
-	case "file.separator":
-		value = string(os.PathSeparator)
-	case "java.class.path":
-		value = "." // OpenJDK JVM default value
-	case "java.compiler": // the name of the JIT compiler (we don't have a JIT)
-		value = "no JIT"
-	case "java.home":
-		value = g.JavaHome
-	case "java.library.path":
-		value = g.JavaHome
-	case "java.vendor":
-		value = "Jacobin"
-	case "java.vendor.url":
-		value = "http://jacobin.org"
-	case "java.vendor.version":
-		value = g.Version
-	case "java.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	// case "java.version.date":
-	// 	value = // need to get this
-	case "java.vm.name":
-		value = fmt.Sprintf(
-			"Jacobin VM v. %s (Java %d) 64-bit VM", g.Version, g.MaxJavaVersion)
-	case "java.vm.specification.name":
-		value = "Java Virtual Machine Specification"
-	case "java.vm.specification.vendor":
-		value = "Oracle and Jacobin"
-	case "java.vm.specification.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	case "java.vm.vendor":
-		value = "Jacobin"
-	case "java.vm.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	case "line.separator":
-		if operSys == "windows" {
-			value = "\\r\\n"
-		} else {
-			value = "\\n"
-		}
-	case "native.encoding": // hard to find out what this is, so hard-coding to UTF8
-		value = "UTF8"
-	case "os.arch":
-		value = runtime.GOARCH
-	case "os.name":
-		value = operSys
-	case "os.version":
-		value = "not yet available"
-	case "path.separator":
-		value = string(os.PathSeparator)
-	case "user.dir": // present working directory
-		value, _ = os.Getwd()
-	case "user.home":
-		currentUser, _ := user.Current()
-		value = currentUser.HomeDir
-	case "user.name":
-		currentUser, _ := user.Current()
-		value = currentUser.Name
-	default:
-		return object.Null
+	props := map[string]string{
+		"file.encoding":                 g.FileEncoding,
+		"file.separator":                string(os.PathSeparator),
+		"java.class.path":               classpathString(),
+		"java.compiler":                 "no JIT", // the name of the JIT compiler (we don't have a JIT)
+		"java.home":                     g.JavaHome,
+		"java.io.tmpdir":                os.TempDir(),
+		"java.library.path":             g.JavaHome,
+		"java.vendor":                   "Jacobin",
+		"java.vendor.url":               "http://jacobin.org",
+		"java.vendor.version":           g.Version,
+		"java.version":                  strconv.Itoa(g.MaxJavaVersion),
+		"java.vm.name":                  fmt.Sprintf("Jacobin VM v. %s (Java %d) 64-bit VM", g.Version, g.MaxJavaVersion),
+		"java.vm.specification.name":    "Java Virtual Machine Specification",
+		"java.vm.specification.vendor":  "Oracle and Jacobin",
+		"java.vm.specification.version": strconv.Itoa(g.MaxJavaVersion),
+		"java.vm.vendor":                "Jacobin",
+		"java.vm.version":               strconv.Itoa(g.MaxJavaVersion),
+		"line.separator":                "\n",
+		"native.encoding":               "UTF8", // hard to find out what this is, so hard-coding to UTF8
+		"os.arch":                       runtime.GOARCH,
+		"os.name":                       operSys,
+		"os.version":                    "not yet available",
+		"path.separator":                string(os.PathListSeparator),
 	}
+	if operSys == "windows" {
+		props["line.separator"] = "\r\n"
+	}
+
+	props["user.dir"], _ = os.Getwd() // present working directory
+	if currentUser, err := user.Current(); err == nil {
+		props["user.home"] = currentUser.HomeDir
+		// the login name, without the domain that precedes it on Windows
+		name := currentUser.Username
+		props["user.name"] = name[strings.LastIndex(name, "\\")+1:]
+	}
+	return props
+}
+
+// checkPropertyKey returns the JDK's exception for a null or empty key, and
+// otherwise the key as a Go string
+func checkPropertyKey(param interface{}) (string, error) {
+	keyObj, _ := param.(*object.Object)
+	if keyObj == nil {
+		return "", throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "key can't be null")
+	}
+	key := object.GetGoStringFromJavaStringPtr(keyObj)
+	if key == "" {
+		return "", throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", "key can't be empty")
+	}
+	return key, nil
+}
 
-	obj := object.CreateCompactStringFromGoString(&value)
-	return obj
+// getProperty(key) and getProperty(key, def) return the value of the system
+// property, or def (which defaults to null) if there is none
+func getProperty(params []interface{}) interface{} {
+	key, err := checkPropertyKey(params[0])
+	if err != nil {
+		return err
+	}
+	if value, ok := getSystemProperties().get(key); ok {
+		return goStringToJava(value)
+	}
+	if len(params) > 1 {
+		return params[1]
+	}
+	return object.Null
+}
+
+// setProperty(key, value) returns the previous value of the property, or null
+func setProperty(params []interface{}) interface{} {
+	key, err := checkPropertyKey(params[0])
+	if err != nil {
+		return err
+	}
+	value, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(getSystemProperties().set(key, value))
+}
+
+// clearProperty(key) removes the property and returns its value, or null
+func clearProperty(params []interface{}) interface{} {
+	key, err := checkPropertyKey(params[0])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(getSystemProperties().remove(key))
+}
+
+// getProperties() returns the system properties as a java/util/Properties.
+// It's always the same object, and changes made through it are changes to
+// the system properties.
+func getPropertiesObject([]interface{}) interface{} {
+	getSystemProperties()
+	return systemPropertiesObj
+}
+
+// lineSeparator() returns the value the line.separator property had at start-up
+func lineSeparator([]interface{}) interface{} {
+	if runtime.GOOS == "windows" {
+		return goStringToJava("\r\n")
+	}
+	return goStringToJava("\n")
 }
 
 // do-nothing function
@@ -0,0 +1,206 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+// intArrayOf creates an int[] holding the values
+func intArrayOf(values ...int64) *object.Object {
+	arr := object.Make1DimArray(object.INT, int64(len(values)))
+	copy(*(arr.Fields[0].Fvalue.(*[]int64)), values)
+	return arr
+}
+
+func TestArraycopyOverlapping(t *testing.T) {
+	arr := intArrayOf(1, 2, 3, 4, 5)
+	if ret := arraycopy([]interface{}{arr, int64(0), arr, int64(1), int64(4)}); ret != nil {
+		t.Fatalf("Expected arraycopy() to succeed, got %v", ret)
+	}
+	expected := []int64{1, 1, 2, 3, 4}
+	for i, v := range *(arr.Fields[0].Fvalue.(*[]int64)) {
+		if v != expected[i] {
+			t.Errorf("Expected element %d to be %d after an overlapping copy, got %d", i, expected[i], v)
+		}
+	}
+
+	refs := object.Make1DimArray(object.REF, 3)
+	str := jstr("x")
+	(*(refs.Fields[0].Fvalue.(*[]*object.Object)))[2] = str
+	arraycopy([]interface{}{refs, int64(2), refs, int64(0), int64(1)})
+	if got := (*(refs.Fields[0].Fvalue.(*[]*object.Object)))[0]; got != str {
+		t.Errorf("Expected the reference to be copied, got %v", got)
+	}
+}
+
+func TestArraycopyExceptions(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	ints := intArrayOf(1, 2, 3)
+	tests := []struct {
+		params   []interface{}
+		expected string
+	}{
+		{[]interface{}{object.Null, int64(0), ints, int64(0), int64(1)},
+			"java.lang.NullPointerException"},
+		{[]interface{}{jstr("abc"), int64(0), ints, int64(0), int64(1)},
+			"java.lang.ArrayStoreException: arraycopy: source type java.lang.String is not an array"},
+		{[]interface{}{ints, int64(0), object.Make1DimArray(object.REF, 3), int64(0), int64(1)},
+			"java.lang.ArrayStoreException: arraycopy: type mismatch: can not copy int[] into object array[]"},
+		{[]interface{}{ints, int64(-1), ints, int64(0), int64(1)},
+			"java.lang.ArrayIndexOutOfBoundsException: arraycopy: source index -1 out of bounds for int[3]"},
+		{[]interface{}{ints, int64(0), ints, int64(0), int64(-2)},
+			"java.lang.ArrayIndexOutOfBoundsException: arraycopy: length -2 is negative"},
+		{[]interface{}{ints, int64(0), ints, int64(2), int64(2)},
+			"java.lang.ArrayIndexOutOfBoundsException: arraycopy: last destination index 4 out of bounds for int[3]"},
+	}
+	for _, test := range tests {
+		err, ok := arraycopy(test.params).(error)
+		if !ok || err.Error() != test.expected {
+			t.Errorf("Expected %q, got %v", test.expected, err)
+		}
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+// refArrayOf creates an array of references of the given type, such as [Ljava/lang/String;
+func refArrayOf(arrType string, elements ...*object.Object) *object.Object {
+	arr := object.Make1DimArray(object.REF, int64(len(elements)))
+	arr.Fields[0].Ftype = arrType
+	copy(*(arr.Fields[0].Fvalue.(*[]*object.Object)), elements)
+	return arr
+}
+
+func TestArraycopyChecksComponentType(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	InitMethodArea()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	MethAreaInsert("Base", &Klass{Status: 'F', Loader: "app", Data: &ClData{
+		Name: "Base", Superclass: "java/lang/Object",
+	}})
+	MethAreaInsert("Derived", &Klass{Status: 'F', Loader: "app", Data: &ClData{
+		Name: "Derived", Superclass: "Base",
+	}})
+	derivedName, baseName := "Derived", "Base"
+	derived, base := object.MakeEmptyObject(), object.MakeEmptyObject()
+	derived.Klass, base.Klass = &derivedName, &baseName
+	derivedArr := refArrayOf("[LDerived;", nil)
+	if ret := arraycopy([]interface{}{refArrayOf("[LBase;", derived), int64(0), derivedArr, int64(0), int64(1)}); ret != nil {
+		t.Errorf("Expected a Derived to be copied into a Derived[], got %v", ret)
+	}
+	if _, ok := arraycopy([]interface{}{refArrayOf("[LBase;", base), int64(0), derivedArr, int64(0), int64(1)}).(error); !ok {
+		t.Errorf("Expected a Base not to be copied into a Derived[]")
+	}
+
+	a, b := jstr("a"), jstr("b")
+	objects := refArrayOf(types.RefArray, a, intArrayOf(1), b)
+	strings := refArrayOf("[Ljava/lang/String;", nil, nil, nil)
+	err, ok := arraycopy([]interface{}{objects, int64(0), strings, int64(0), int64(3)}).(error)
+	expected := "java.lang.ArrayStoreException: arraycopy: element type mismatch: can not cast one of the " +
+		"elements of java.lang.Object[] to the type of the destination array, java.lang.String"
+	if !ok || err.Error() != expected {
+		t.Errorf("Expected %q, got %v", expected, err)
+	}
+	copied := *(strings.Fields[0].Fvalue.(*[]*object.Object))
+	if copied[0] != a || copied[1] != nil || copied[2] != nil {
+		t.Errorf("Expected only the elements before the int[] to be copied, got %v", copied)
+	}
+
+	if ret := arraycopy([]interface{}{objects, int64(2), strings, int64(1), int64(1)}); ret != nil {
+		t.Errorf("Expected a String to be copied into a String[], got %v", ret)
+	}
+
+	intArrays := refArrayOf("[[I", nil)
+	if ret := arraycopy([]interface{}{objects, int64(1), intArrays, int64(0), int64(1)}); ret != nil {
+		t.Errorf("Expected an int[] to be copied into an int[][], got %v", ret)
+	}
+	if _, ok = arraycopy([]interface{}{objects, int64(0), intArrays, int64(0), int64(1)}).(error); !ok {
+		t.Errorf("Expected a String not to be copied into an int[][]")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func TestSystemProperties(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	if got := getProperty([]interface{}{jstr("line.separator")}); javaToString(got.(*object.Object)) != "\n" {
+		t.Errorf("Expected line.separator to be a newline, got %q", javaToString(got.(*object.Object)))
+	}
+
+	if prev := setProperty([]interface{}{jstr("test.size"), jstr("0x1F")}); prev != object.Null {
+		t.Errorf("Expected setProperty() of a new property to return null, got %v", prev)
+	}
+	if got := getInteger("test.size"); got != "31" {
+		t.Errorf("Expected Integer.getInteger() to decode 0x1F as 31, got %s", got)
+	}
+	if got := getInteger("test.missing", int64(5)); got != "5" {
+		t.Errorf("Expected Integer.getInteger() to return the default, got %s", got)
+	}
+
+	// the Properties object is a live view of the system properties
+	props := getPropertiesObject(nil).(*object.Object)
+	propertiesPut([]interface{}{props, jstr("test.flag"), jstr("TRUE")})
+	if booleanGetBoolean([]interface{}{jstr("test.flag")}) != types.JavaBoolTrue {
+		t.Errorf("Expected Boolean.getBoolean() to see a property set through getProperties()")
+	}
+
+	prev := clearProperty([]interface{}{jstr("test.size")}).(*object.Object)
+	if javaToString(prev) != "0x1F" {
+		t.Errorf("Expected clearProperty() to return the previous value, got %s", javaToString(prev))
+	}
+	if got := getProperty([]interface{}{jstr("test.size"), jstr("none")}); javaToString(got.(*object.Object)) != "none" {
+		t.Errorf("Expected getProperty() of a cleared property to return the default, got %v", got)
+	}
+}
+
+// getInteger calls Integer.getInteger() and returns the result as a string
+func getInteger(name string, def ...interface{}) string {
+	params := append([]interface{}{jstr(name)}, def...)
+	return javaToString(integerWrapper.getProperty(params).(*object.Object))
+}
+
+func TestSystemPropertyKeyChecks(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	if err, ok := getProperty([]interface{}{jstr("")}).(error); !ok ||
+		err.Error() != "java.lang.IllegalArgumentException: key can't be empty" {
+		t.Errorf("Expected IllegalArgumentException for an empty key, got %v", err)
+	}
+	if err, ok := setProperty([]interface{}{object.Null, jstr("x")}).(error); !ok ||
+		err.Error() != "java.lang.NullPointerException: key can't be null" {
+		t.Errorf("Expected NullPointerException for a null key, got %v", err)
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
@@ -0,0 +1,111 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/object"
+	"strconv"
+	"sync/atomic"
+)
+
+// Implementation of the constructors of java/lang/Thread, so that Thread
+// objects can be created for use as shutdown hooks. (The JDK's constructors
+// depend on parts of the JVM that Jacobin doesn't have yet.) The thread's
+// Runnable and name are held in the fields "target" and "name".
+
+const ThreadTargetField = "target"
+const threadNameField = "name"
+
+// the number in the name of the next unnamed thread: Thread-0, Thread-1, etc.
+var threadInitNumber atomic.Int64
+
+func Load_Lang_Thread() map[string]GMeth {
+
+	MethodSignatures["java/lang/Thread.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadInit,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/Runnable;)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the Runnable
+			GFunction:  threadInitRunnable,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2, // [0] = this, [1] = the name
+			GFunction:  threadInitName,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/Runnable;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  threadInitRunnableName,
+		}
+
+	MethodSignatures["java/lang/Thread.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadGetName,
+		}
+
+	return MethodSignatures
+}
+
+func threadInit(params []interface{}) interface{} {
+	setThreadFields(params[0].(*object.Object), nil, nil)
+	return nil
+}
+
+func threadInitRunnable(params []interface{}) interface{} {
+	target, _ := params[1].(*object.Object)
+	setThreadFields(params[0].(*object.Object), target, nil)
+	return nil
+}
+
+func threadInitName(params []interface{}) interface{} {
+	name, err := propertyString(params[1]) // a null name is a NullPointerException
+	if err != nil {
+		return err
+	}
+	setThreadFields(params[0].(*object.Object), nil, &name)
+	return nil
+}
+
+func threadInitRunnableName(params []interface{}) interface{} {
+	name, err := propertyString(params[2])
+	if err != nil {
+		return err
+	}
+	target, _ := params[1].(*object.Object)
+	setThreadFields(params[0].(*object.Object), target, &name)
+	return nil
+}
+
+// setThreadFields sets the Runnable and the name of the thread. A thread with
+// no name is named Thread-n, as in the JDK.
+func setThreadFields(thread, target *object.Object, name *string) {
+	if name == nil {
+		defaultName := "Thread-" + strconv.FormatInt(threadInitNumber.Add(1)-1, 10)
+		name = &defaultName
+	}
+	if thread.FieldTable == nil {
+		thread.FieldTable = make(map[string]object.Field)
+	}
+	thread.FieldTable[ThreadTargetField] = object.Field{Ftype: "Ljava/lang/Runnable;", Fvalue: target}
+	thread.FieldTable[threadNameField] = object.Field{Ftype: "Ljava/lang/String;", Fvalue: goStringToJava(*name)}
+}
+
+func threadGetName(params []interface{}) interface{} {
+	thread := params[0].(*object.Object)
+	if name, ok := thread.FieldTable[threadNameField].Fvalue.(*object.Object); ok {
+		return name
+	}
+	return object.Null
+}
@@ -0,0 +1,253 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bufio"
+	"bytes"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"strings"
+	"sync"
+)
+
+// Implementation of the java/net/URL objects that getResource() returns (see
+// resources.go) and of the Enumeration of them that getResources() returns.
+// The URL's object holds the resource it identifies in the field "resource",
+// and openStream() returns an InputStream of the resource's contents. Other
+// URLs and Enumerations, such as those created by Java code, run the JDK's code.
+
+const urlResourceField = "resource"
+const enumerationField = "elements"
+
+// urlEnumeration is the Go state of an Enumeration of URLs
+type urlEnumeration struct {
+	urls  []*object.Object
+	next  int
+	mutex sync.Mutex
+}
+
+func Load_Net_URL() map[string]GMeth {
+
+	MethodSignatures["java/net/URL.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  urlToString,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/net/URL.toExternalForm()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  urlToString,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/net/URL.getProtocol()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  urlGetProtocol,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/net/URL.getPath()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  urlGetPath,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/net/URL.getFile()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1, // the same as the path, as resource URLs have no query
+			GFunction:  urlGetPath,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/net/URL.openStream()Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  urlOpenStream,
+			Accepts:    hasURLResource,
+		}
+
+	MethodSignatures["java/lang/CompoundEnumeration.hasMoreElements()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  enumerationHasMoreElements,
+			Accepts:    hasURLEnumeration,
+		}
+
+	MethodSignatures["java/lang/CompoundEnumeration.nextElement()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  enumerationNextElement,
+			Accepts:    hasURLEnumeration,
+		}
+
+	return MethodSignatures
+}
+
+// newURLObject creates the java/net/URL object for the resource
+func newURLObject(r *resource) *object.Object {
+	obj := object.MakeEmptyObject()
+	className := "java/net/URL"
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		urlResourceField: {Ftype: types.GoObject, Fvalue: r},
+	}
+	return obj
+}
+
+// urlResource returns the resource of a URL object, or nil if it's not one
+// created by newURLObject
+func urlResource(obj *object.Object) *resource {
+	if obj == nil || obj.FieldTable == nil {
+		return nil
+	}
+	r, _ := obj.FieldTable[urlResourceField].Fvalue.(*resource)
+	return r
+}
+
+// hasURLResource is true if the URL in params[0] was created by newURLObject.
+// The Go functions here handle only those URLs; the JDK's code handles the others.
+func hasURLResource(params []interface{}) bool {
+	obj, _ := params[0].(*object.Object)
+	return urlResource(obj) != nil
+}
+
+// urlThis returns the resource of the URL in params[0], and an exception if
+// the URL isn't implemented in Go
+func urlThis(params []interface{}) (*resource, error) {
+	obj, _ := params[0].(*object.Object)
+	r := urlResource(obj)
+	if r == nil {
+		name := "null"
+		if obj != nil {
+			name = javaClassName(obj)
+		}
+		return nil, throwNativeException(exceptions.UnsupportedOperationException,
+			"java.lang.UnsupportedOperationException", "no Go implementation of the URL "+name)
+	}
+	return r, nil
+}
+
+func urlToString(params []interface{}) interface{} {
+	r, err := urlThis(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(r.url)
+}
+
+// the protocol is what precedes the first colon: file, jar or jrt
+func urlGetProtocol(params []interface{}) interface{} {
+	r, err := urlThis(params)
+	if err != nil {
+		return err
+	}
+	protocol, _, _ := strings.Cut(r.url, ":")
+	return goStringToJava(protocol)
+}
+
+// resource URLs have no host, so the path is all that follows the protocol
+func urlGetPath(params []interface{}) interface{} {
+	r, err := urlThis(params)
+	if err != nil {
+		return err
+	}
+	_, urlPath, _ := strings.Cut(r.url, ":")
+	return goStringToJava(urlPath)
+}
+
+// openStream() reads the resource and returns an InputStream of its contents
+func urlOpenStream(params []interface{}) interface{} {
+	r, err := urlThis(params)
+	if err != nil {
+		return err
+	}
+	data, err := r.read()
+	if err != nil {
+		return throwNativeException(exceptions.IOException, "java.io.IOException",
+			"cannot read "+r.url+": "+err.Error())
+	}
+	return newResourceStream(data)
+}
+
+// newResourceStream returns an InputStream object that reads the bytes
+func newResourceStream(data []byte) *object.Object {
+	return newInputStreamObject(&inputStream{in: bufio.NewReader(bytes.NewReader(data))})
+}
+
+// newURLEnumeration creates the Enumeration of the URLs of the resources. Its
+// class is the one that ClassLoader.getResources() returns in the JDK.
+func newURLEnumeration(resources []*resource) *object.Object {
+	e := &urlEnumeration{}
+	for _, r := range resources {
+		e.urls = append(e.urls, newURLObject(r))
+	}
+	obj := object.MakeEmptyObject()
+	className := "java/lang/CompoundEnumeration"
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		enumerationField: {Ftype: types.GoObject, Fvalue: e},
+	}
+	return obj
+}
+
+// urlEnumerationOf returns the Go state of an Enumeration created by
+// newURLEnumeration, and false if the object isn't one
+func urlEnumerationOf(obj *object.Object) (*urlEnumeration, bool) {
+	if obj == nil || obj.FieldTable == nil {
+		return nil, false
+	}
+	e, ok := obj.FieldTable[enumerationField].Fvalue.(*urlEnumeration)
+	return e, ok
+}
+
+// hasURLEnumeration is true if the Enumeration in params[0] was created by
+// newURLEnumeration. The JDK's code handles the other CompoundEnumerations.
+func hasURLEnumeration(params []interface{}) bool {
+	obj, _ := params[0].(*object.Object)
+	_, ok := urlEnumerationOf(obj)
+	return ok
+}
+
+func enumerationThis(params []interface{}) (*urlEnumeration, error) {
+	obj, _ := params[0].(*object.Object)
+	if e, ok := urlEnumerationOf(obj); ok {
+		return e, nil
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of the Enumeration")
+}
+
+func enumerationHasMoreElements(params []interface{}) interface{} {
+	e, err := enumerationThis(params)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return types.ConvertGoBoolToJavaBool(e.next < len(e.urls))
+}
+
+func enumerationNextElement(params []interface{}) interface{} {
+	e, err := enumerationThis(params)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.next >= len(e.urls) {
+		return throwNativeException(exceptions.NoSuchElementException,
+			"java.util.NoSuchElementException", "")
+	}
+	e.next++
+	return e.urls[e.next-1]
+}
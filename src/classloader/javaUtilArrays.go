@@ -0,0 +1,828 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"sort"
+	"strconv"
+	"unicode/utf16"
+)
+
+// Implementation of the methods of java/util/Arrays that work on arrays of
+// primitives and on Object[]. A Jacobin array records only how its elements
+// are held (see object/arrays.go), so the methods that must know the Java
+// type of the elements, such as toString(), are registered once per type
+// and passed the type's letter from the method descriptor.
+
+func Load_Util_Arrays() map[string]GMeth {
+
+	MethodSignatures["java/util/Arrays.fill([ZZ)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([ZIIZ)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([ZI)[Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([ZII)[Z"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([Z[Z)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([Z)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'Z') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([Z)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'Z') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([BB)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([BIIB)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([BI)[B"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([BII)[B"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([B[B)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([B)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'B') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([B)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'B') },
+		}
+
+	// there's no sort() for boolean[], and sorting an Object[] requires
+	// calling compareTo(), which Go functions can't do
+	MethodSignatures["java/util/Arrays.sort([B)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'B') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([BII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'B') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([CC)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([CIIC)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([CI)[C"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([CII)[C"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([C[C)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([C)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'C') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([C)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'C') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([C)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'C') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([CII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'C') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([SS)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([SIIS)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([SI)[S"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([SII)[S"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([S[S)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([S)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'S') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([S)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'S') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([S)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'S') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([SII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'S') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([II)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([IIII)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([II)[I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([III)[I"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([I[I)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([I)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'I') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([I)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'I') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([I)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'I') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([III)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'I') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([JJ)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([JIIJ)V"] =
+		GMeth{
+			ParamSlots: 5,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([JI)[J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([JII)[J"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([J[J)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([J)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'J') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([J)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'J') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([J)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'J') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([JII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'J') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([FF)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([FIIF)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([FI)[F"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([FII)[F"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([F[F)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([F)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'F') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([F)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'F') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([F)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'F') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([FII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'F') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([DD)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([DIID)V"] =
+		GMeth{
+			ParamSlots: 5,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([DI)[D"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([DII)[D"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([D[D)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([D)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'D') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([D)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'D') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([D)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'D') },
+		}
+
+	MethodSignatures["java/util/Arrays.sort([DII)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  func(params []interface{}) interface{} { return arraysSort(params, 'D') },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([Ljava/lang/Object;Ljava/lang/Object;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, false) },
+		}
+
+	MethodSignatures["java/util/Arrays.fill([Ljava/lang/Object;IILjava/lang/Object;)V"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  func(params []interface{}) interface{} { return arraysFill(params, true) },
+		}
+
+	MethodSignatures["java/util/Arrays.copyOf([Ljava/lang/Object;I)[Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysCopyOf,
+		}
+
+	MethodSignatures["java/util/Arrays.copyOfRange([Ljava/lang/Object;II)[Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  arraysCopyOfRange,
+		}
+
+	MethodSignatures["java/util/Arrays.equals([Ljava/lang/Object;[Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  arraysEquals,
+		}
+
+	MethodSignatures["java/util/Arrays.hashCode([Ljava/lang/Object;)I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysHashCode(params, 'L') },
+		}
+
+	MethodSignatures["java/util/Arrays.toString([Ljava/lang/Object;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return arraysToString(params, 'L') },
+		}
+
+	return MethodSignatures
+}
+
+// arraysNullArray throws the NullPointerException for a null array passed to
+// one of the methods
+func arraysNullArray(method string) error {
+	return throwNativeException(exceptions.NullPointerException,
+		"java.lang.NullPointerException", "Arrays."+method+"(): null array")
+}
+
+// arraysRangeCheck performs the JDK's check of fromIndex and toIndex against
+// the length of the array
+func arraysRangeCheck(length int, from, to int64) error {
+	switch {
+	case from > to:
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", fmt.Sprintf("fromIndex(%d) > toIndex(%d)", from, to))
+	case from < 0:
+		return throwNativeException(exceptions.ArrayIndexOutOfBoundsException,
+			"java.lang.ArrayIndexOutOfBoundsException", fmt.Sprintf("Array index out of range: %d", from))
+	case to > int64(length):
+		return throwNativeException(exceptions.ArrayIndexOutOfBoundsException,
+			"java.lang.ArrayIndexOutOfBoundsException", fmt.Sprintf("Array index out of range: %d", to))
+	}
+	return nil
+}
+
+// fill(a, val) and fill(a, fromIndex, toIndex, val). A long or double value
+// takes two slots, but it's always the first of them that holds it.
+func arraysFill(params []interface{}, ranged bool) interface{} {
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return arraysNullArray("fill")
+	}
+	from, to := int64(0), int64(arrayLength(arr))
+	value := params[1]
+	if ranged {
+		from, to = params[1].(int64), params[2].(int64)
+		if err := arraysRangeCheck(arrayLength(arr), from, to); err != nil {
+			return err
+		}
+		value = params[3]
+	}
+
+	switch a := arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		v := byte(value.(int64))
+		for i := from; i < to; i++ {
+			(*a)[i] = v
+		}
+	case *[]int64:
+		v := value.(int64)
+		for i := from; i < to; i++ {
+			(*a)[i] = v
+		}
+	case *[]float64:
+		v := value.(float64)
+		for i := from; i < to; i++ {
+			(*a)[i] = v
+		}
+	case *[]*object.Object:
+		v, _ := value.(*object.Object)
+		if !canStoreElement(v, arrayComponentType(arr)) {
+			return throwNativeException(exceptions.ArrayStoreException,
+				"java.lang.ArrayStoreException", javaClassName(v))
+		}
+		for i := from; i < to; i++ {
+			(*a)[i] = v
+		}
+	}
+	return nil
+}
+
+// makeArrayLike creates an array of the given length that holds the same
+// type of elements as arr
+func makeArrayLike(arr *object.Object, length int64) *object.Object {
+	var arrType uint8
+	switch arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		arrType = object.BYTE
+	case *[]float64:
+		arrType = object.FLOAT
+	case *[]*object.Object:
+		arrType = object.REF
+	default:
+		arrType = object.INT
+	}
+	newArr := object.Make1DimArray(arrType, length)
+	newArr.Fields[0].Ftype = arr.Fields[0].Ftype // e.g., [[I for an array of int arrays
+	return newArr
+}
+
+// copyOf(original, newLength) returns a copy of the array, truncated or padded
+// with zeros (or nulls) to the new length
+func arraysCopyOf(params []interface{}) interface{} {
+	original, _ := params[0].(*object.Object)
+	if original == nil {
+		return arraysNullArray("copyOf")
+	}
+	newLength := params[1].(int64)
+	if newLength < 0 {
+		return throwNativeException(exceptions.NegativeArraySizeException,
+			"java.lang.NegativeArraySizeException", strconv.FormatInt(newLength, 10))
+	}
+	copied := makeArrayLike(original, newLength)
+	if err := arrayCopy(original, 0, copied, 0, min(int64(arrayLength(original)), newLength)); err != nil {
+		return err
+	}
+	return copied
+}
+
+// copyOfRange(original, from, to) returns a copy of the elements from index
+// from up to (but not including) to, which can be past the end of the array;
+// the elements there are zeros (or nulls)
+func arraysCopyOfRange(params []interface{}) interface{} {
+	original, _ := params[0].(*object.Object)
+	if original == nil {
+		return arraysNullArray("copyOfRange")
+	}
+	from, to := params[1].(int64), params[2].(int64)
+	newLength := to - from
+	if newLength < 0 {
+		return throwNativeException(exceptions.IllegalArgumentException,
+			"java.lang.IllegalArgumentException", fmt.Sprintf("%d > %d", from, to))
+	}
+	copied := makeArrayLike(original, newLength)
+	if err := arrayCopy(original, from, copied, 0, min(int64(arrayLength(original))-from, newLength)); err != nil {
+		return err
+	}
+	return copied
+}
+
+// equals(a, a2) is true if both arrays are null, or if they have the same
+// elements in the same order. Floating-point elements are compared as
+// Float.equals() and Double.equals() compare them, so NaN equals NaN and
+// 0.0 does not equal -0.0.
+func arraysEquals(params []interface{}) interface{} {
+	a, _ := params[0].(*object.Object)
+	b, _ := params[1].(*object.Object)
+	if a == b {
+		return types.JavaBoolTrue
+	}
+	if a == nil || b == nil || arrayLength(a) != arrayLength(b) {
+		return types.JavaBoolFalse
+	}
+
+	equal := true
+	switch x := a.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		y := *b.Fields[0].Fvalue.(*[]byte)
+		for i := range *x {
+			equal = equal && (*x)[i] == y[i]
+		}
+	case *[]int64:
+		y := *b.Fields[0].Fvalue.(*[]int64)
+		for i := range *x {
+			equal = equal && (*x)[i] == y[i]
+		}
+	case *[]float64:
+		y := *b.Fields[0].Fvalue.(*[]float64)
+		for i := range *x {
+			equal = equal && doubleToLongBits((*x)[i]) == doubleToLongBits(y[i])
+		}
+	case *[]*object.Object:
+		y := *b.Fields[0].Fvalue.(*[]*object.Object)
+		for i := range *x {
+			equal = equal && javaEquals((*x)[i], y[i])
+		}
+	}
+	return types.ConvertGoBoolToJavaBool(equal)
+}
+
+// javaEquals returns what Objects.equals(a, b) returns for strings and boxed
+// values. Other objects are equal only if they're the same object.
+func javaEquals(a, b *object.Object) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	if object.IsJavaString(a) {
+		return stringEquals([]interface{}{a, b}) == types.JavaBoolTrue
+	}
+	if _, ok := unboxValue(a); ok {
+		return boxedEquals([]interface{}{a, b}) == types.JavaBoolTrue
+	}
+	return false
+}
+
+// hashCode(a) combines the hash codes of the elements, which are those of
+// their boxed values, as List.hashCode() does. The hash of a null array is 0.
+func arraysHashCode(params []interface{}, kind byte) interface{} {
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return int64(0)
+	}
+
+	hash := int32(1)
+	add := func(h int64) { hash = 31*hash + int32(h) }
+	switch a := arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		for _, b := range *a {
+			if kind == 'Z' {
+				add(booleanHashCode([]interface{}{int64(b)}).(int64))
+			} else {
+				add(int64(int8(b)))
+			}
+		}
+	case *[]int64:
+		for _, v := range *a {
+			if kind == 'J' {
+				add(v ^ int64(uint64(v)>>32))
+			} else {
+				add(v)
+			}
+		}
+	case *[]float64:
+		for _, v := range *a {
+			if kind == 'F' {
+				add(floatToIntBits(v))
+			} else {
+				bits := doubleToLongBits(v)
+				add(bits ^ int64(uint64(bits)>>32))
+			}
+		}
+	case *[]*object.Object:
+		for _, obj := range *a {
+			if obj == nil {
+				add(0)
+			} else {
+				add(javaHashCode(obj))
+			}
+		}
+	}
+	return int64(hash)
+}
+
+// toString(a) returns the elements, formatted as String.valueOf() formats them,
+// in brackets and separated by ", ". A null array is "null".
+func arraysToString(params []interface{}, kind byte) interface{} {
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return goStringToJava("null")
+	}
+
+	chars := []uint16{'['}
+	separate := func(i int) {
+		if i > 0 {
+			chars = append(chars, ',', ' ')
+		}
+	}
+	appendStr := func(i int, str string) {
+		separate(i)
+		chars = append(chars, utf16.Encode([]rune(str))...)
+	}
+	switch a := arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		for i, b := range *a {
+			if kind == 'Z' {
+				appendStr(i, strconv.FormatBool(b != 0))
+			} else {
+				appendStr(i, strconv.Itoa(int(int8(b))))
+			}
+		}
+	case *[]int64:
+		for i, v := range *a {
+			if kind == 'C' { // a char is appended as is, even if it's half of a surrogate pair
+				separate(i)
+				chars = append(chars, uint16(v))
+			} else {
+				appendStr(i, strconv.FormatInt(v, 10))
+			}
+		}
+	case *[]float64:
+		for i, v := range *a {
+			if kind == 'F' {
+				appendStr(i, floatToString(float32(v)))
+			} else {
+				appendStr(i, doubleToString(v))
+			}
+		}
+	case *[]*object.Object:
+		for i, obj := range *a {
+			appendStr(i, javaToString(obj))
+		}
+	}
+	chars = append(chars, ']')
+	return newJavaString(chars)
+}
+
+// sort(a) and sort(a, fromIndex, toIndex) sort the elements in ascending
+// numerical order. Floating-point values are ordered as Double.compare()
+// orders them: -0.0 comes before 0.0, and NaN comes after everything else.
+func arraysSort(params []interface{}, kind byte) interface{} {
+	arr, _ := params[0].(*object.Object)
+	if arr == nil {
+		return arraysNullArray("sort")
+	}
+	from, to := int64(0), int64(arrayLength(arr))
+	if len(params) > 1 {
+		from, to = params[1].(int64), params[2].(int64)
+		if err := arraysRangeCheck(arrayLength(arr), from, to); err != nil {
+			return err
+		}
+	}
+
+	switch a := arr.Fields[0].Fvalue.(type) {
+	case *[]byte:
+		s := (*a)[from:to]
+		sort.Slice(s, func(i, j int) bool { return int8(s[i]) < int8(s[j]) })
+	case *[]int64:
+		s := (*a)[from:to]
+		sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	case *[]float64:
+		s := (*a)[from:to]
+		sort.Slice(s, func(i, j int) bool { return compareDoubles(s[i], s[j]) < 0 })
+	}
+	return nil
+}
+
+// compareDoubles returns what Double.compare() returns
+func compareDoubles(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	xBits, yBits := doubleToLongBits(x), doubleToLongBits(y)
+	switch {
+	case xBits < yBits:
+		return -1
+	case xBits > yBits:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"math"
+	"os"
+	"testing"
+)
+
+// doubleArrayOf creates a double[] holding the values
+func doubleArrayOf(values ...float64) *object.Object {
+	arr := object.Make1DimArray(object.FLOAT, int64(len(values)))
+	copy(*(arr.Fields[0].Fvalue.(*[]float64)), values)
+	return arr
+}
+
+func arraysString(arr *object.Object, kind byte) string {
+	return object.GetGoStringFromJavaStringPtr(arraysToString([]interface{}{arr}, kind).(*object.Object))
+}
+
+func TestArraysSortAndToString(t *testing.T) {
+	ints := intArrayOf(5, -3, 9, 0, -3, 7)
+	arraysSort([]interface{}{ints, int64(1), int64(5)}, 'I')
+	if got := arraysString(ints, 'I'); got != "[5, -3, -3, 0, 9, 7]" {
+		t.Errorf("Expected sort(a, 1, 5) to sort only the range, got %s", got)
+	}
+
+	doubles := doubleArrayOf(math.NaN(), 1.5, 0.0, math.Copysign(0, -1), math.Inf(-1))
+	arraysSort([]interface{}{doubles}, 'D')
+	if got := arraysString(doubles, 'D'); got != "[-Infinity, -0.0, 0.0, 1.5, NaN]" {
+		t.Errorf("Expected doubles in Double.compare() order, got %s", got)
+	}
+
+	bytes := object.Make1DimArray(object.BYTE, 3)
+	copy(*(bytes.Fields[0].Fvalue.(*[]byte)), []byte{0x7F, 0x80, 0x00})
+	arraysSort([]interface{}{bytes}, 'B')
+	if got := arraysString(bytes, 'B'); got != "[-128, 0, 127]" {
+		t.Errorf("Expected signed bytes sorted, got %s", got)
+	}
+	if got := arraysString(bytes, 'Z'); got != "[true, false, true]" {
+		t.Errorf("Expected booleans formatted as true and false, got %s", got)
+	}
+	if got := arraysString(intArrayOf('h', 'i'), 'C'); got != "[h, i]" {
+		t.Errorf("Expected chars formatted as chars, got %s", got)
+	}
+	if got := arraysString(object.Null, 'I'); got != "null" {
+		t.Errorf("Expected a null array to be \"null\", got %s", got)
+	}
+}
+
+func TestArraysCopyFillEquals(t *testing.T) {
+	ints := intArrayOf(1, 2, 3)
+	longer := arraysCopyOf([]interface{}{ints, int64(5)}).(*object.Object)
+	if got := arraysString(longer, 'I'); got != "[1, 2, 3, 0, 0]" {
+		t.Errorf("Expected copyOf() to pad with zeros, got %s", got)
+	}
+	rng := arraysCopyOfRange([]interface{}{ints, int64(1), int64(4)}).(*object.Object)
+	if got := arraysString(rng, 'I'); got != "[2, 3, 0]" {
+		t.Errorf("Expected copyOfRange(a, 1, 4) to be [2, 3, 0], got %s", got)
+	}
+
+	arraysFill([]interface{}{longer, int64(3), int64(5), int64(3)}, true)
+	if arraysEquals([]interface{}{longer, intArrayOf(1, 2, 3, 3, 3)}) != types.JavaBoolTrue {
+		t.Errorf("Expected fill(a, 3, 5, 3) to fill the range, got %s", arraysString(longer, 'I'))
+	}
+	if arraysEquals([]interface{}{doubleArrayOf(math.NaN()), doubleArrayOf(math.NaN())}) != types.JavaBoolTrue {
+		t.Errorf("Expected arrays holding NaN to be equal")
+	}
+	if arraysEquals([]interface{}{doubleArrayOf(0.0), doubleArrayOf(math.Copysign(0, -1))}) != types.JavaBoolFalse {
+		t.Errorf("Expected 0.0 and -0.0 to be unequal")
+	}
+}
+
+func TestArraysHashCode(t *testing.T) {
+	// values from the JDK
+	if got := arraysHashCode([]interface{}{intArrayOf(1, -1, 100)}, 'I'); got != int64(30821) {
+		t.Errorf("Expected hashCode(new int[]{1, -1, 100}) to be 30821, got %v", got)
+	}
+	if got := arraysHashCode([]interface{}{intArrayOf(-1, 1<<40)}, 'J'); got != int64(1217) {
+		t.Errorf("Expected hashCode(new long[]{-1, 1L << 40}) to be 1217, got %v", got)
+	}
+	if got := arraysHashCode([]interface{}{object.Null}, 'I'); got != int64(0) {
+		t.Errorf("Expected the hash of a null array to be 0, got %v", got)
+	}
+}
+
+func TestArraysExceptions(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	ints := intArrayOf(1, 2, 3)
+	err, _ := arraysSort([]interface{}{ints, int64(2), int64(1)}, 'I').(error)
+	if err == nil || err.Error() != "java.lang.IllegalArgumentException: fromIndex(2) > toIndex(1)" {
+		t.Errorf("Expected IllegalArgumentException from sort(a, 2, 1), got %v", err)
+	}
+	err, _ = arraysFill([]interface{}{ints, int64(0), int64(4), int64(0)}, true).(error)
+	if err == nil || err.Error() != "java.lang.ArrayIndexOutOfBoundsException: Array index out of range: 4" {
+		t.Errorf("Expected ArrayIndexOutOfBoundsException from fill(a, 0, 4, 0), got %v", err)
+	}
+	err, _ = arraysCopyOf([]interface{}{ints, int64(-1)}).(error)
+	if err == nil || err.Error() != "java.lang.NegativeArraySizeException: -1" {
+		t.Errorf("Expected NegativeArraySizeException from copyOf(a, -1), got %v", err)
+	}
+	strs := object.Make1DimArray(object.REF, 2)
+	strs.Fields[0].Ftype = "[Ljava/lang/String;"
+	err, _ = arraysFill([]interface{}{strs, ints}, false).(error)
+	if err == nil || err.Error() != "java.lang.ArrayStoreException: [I" {
+		t.Errorf("Expected ArrayStoreException from filling a String[] with an int[], got %v", err)
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
@@ -0,0 +1,1299 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Implementation of java/util/Formatter, whose format strings are also used by
+// String.format() and PrintStream.printf(). A format specifier has the syntax
+//
+//	%[argument_index$][flags][width][.precision][t]conversion
+//
+// Formatting is locale-neutral: the grouping separator is always ',', the
+// decimal separator is '.', and the names of months and days are in English.
+// Errors in the format string throw the same IllegalFormatException subclasses,
+// with the same messages, as the JDK does.
+
+func Load_Util_Formatter() map[string]GMeth {
+
+	MethodSignatures["java/util/Formatter.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterInit,
+		}
+
+	MethodSignatures["java/util/Formatter.<init>(Ljava/lang/Appendable;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  formatterInitAppendable,
+		}
+
+	MethodSignatures["java/util/Formatter.<init>(Ljava/util/Locale;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  formatterInit,
+		}
+
+	MethodSignatures["java/util/Formatter.<init>(Ljava/lang/Appendable;Ljava/util/Locale;)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  formatterInitAppendable,
+		}
+
+	MethodSignatures["java/util/Formatter.<init>(Ljava/io/PrintStream;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  formatterInitPrintStream,
+		}
+
+	MethodSignatures["java/util/Formatter.format(Ljava/lang/String;[Ljava/lang/Object;)Ljava/util/Formatter;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  formatterFormat,
+		}
+
+	MethodSignatures["java/util/Formatter.format(Ljava/util/Locale;Ljava/lang/String;[Ljava/lang/Object;)Ljava/util/Formatter;"] =
+		GMeth{
+			ParamSlots: 4,
+			GFunction:  formatterFormatLocale,
+		}
+
+	MethodSignatures["java/util/Formatter.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterToString,
+		}
+
+	MethodSignatures["java/util/Formatter.out()Ljava/lang/Appendable;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterOut,
+		}
+
+	MethodSignatures["java/util/Formatter.flush()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterFlush,
+		}
+
+	MethodSignatures["java/util/Formatter.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterClose,
+		}
+
+	MethodSignatures["java/util/Formatter.ioException()Ljava/io/IOException;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  formatterIOException,
+		}
+
+	return MethodSignatures
+}
+
+// === the format string engine ===
+
+// formatFlags is the set of flags in a format specifier
+type formatFlags int
+
+const (
+	flagLeftJustify  formatFlags = 1 << iota // '-'
+	flagUpperCase                            // set by an upper-case conversion, e.g. %S
+	flagAlternate                            // '#'
+	flagPlus                                 // '+'
+	flagLeadingSpace                         // ' '
+	flagZeroPad                              // '0'
+	flagGroup                                // ','
+	flagParentheses                          // '('
+	flagPrevious                             // '<'
+)
+
+// the chars for the flags, in the order of the bits above. The JDK shows
+// flagUpperCase as '^' in exception messages; it can't be written in a format.
+const formatFlagChars = "-^#+ 0,(<"
+
+func (f formatFlags) has(flag formatFlags) bool {
+	return f&flag != 0
+}
+
+// String returns the flags as the JDK's Flags.toString() does
+func (f formatFlags) String() string {
+	var sb strings.Builder
+	for i := 0; i < len(formatFlagChars); i++ {
+		if f.has(1 << i) {
+			sb.WriteByte(formatFlagChars[i])
+		}
+	}
+	return sb.String()
+}
+
+// the valid conversions (other than the t and T date/time prefixes), and the
+// valid date/time suffixes
+const formatConversions = "bBhHsScCdoxXeEfgGaA%n"
+const formatDateTimeConversions = "HIklMSLNpzZsQBbhAaCYyjmdeRTrDFc"
+
+// formatSpec is a parsed format specifier
+type formatSpec struct {
+	index     int // the explicit argument index; 0 if none, -1 for '<', the previous argument
+	flags     formatFlags
+	width     int // -1 if not specified
+	precision int // -1 if not specified
+	dateTime  bool
+	conv      byte // lower case, except for the date/time suffixes, which are case-sensitive
+}
+
+// formatPart is a piece of a parsed format string: either fixed text or a specifier
+type formatPart struct {
+	text string
+	spec *formatSpec
+}
+
+// String returns the specifier as the JDK shows it in exception messages
+func (spec *formatSpec) String() string {
+	var sb strings.Builder
+	sb.WriteByte('%')
+	sb.WriteString((spec.flags &^ flagUpperCase).String())
+	if spec.index > 0 {
+		sb.WriteString(strconv.Itoa(spec.index))
+		sb.WriteByte('$')
+	}
+	if spec.width != -1 {
+		sb.WriteString(strconv.Itoa(spec.width))
+	}
+	if spec.precision != -1 {
+		sb.WriteByte('.')
+		sb.WriteString(strconv.Itoa(spec.precision))
+	}
+	upper := spec.flags.has(flagUpperCase)
+	if spec.dateTime {
+		if upper {
+			sb.WriteByte('T')
+		} else {
+			sb.WriteByte('t')
+		}
+		sb.WriteByte(spec.conv)
+	} else if upper {
+		sb.WriteString(strings.ToUpper(string(spec.conv)))
+	} else {
+		sb.WriteByte(spec.conv)
+	}
+	return sb.String()
+}
+
+// isText is true for %% and %n, which don't use an argument
+func (spec *formatSpec) isText() bool {
+	return !spec.dateTime && (spec.conv == '%' || spec.conv == 'n')
+}
+
+// formatException throws one of the subclasses of java.util.IllegalFormatException
+func formatException(name, msg string) error {
+	return throwNativeException(exceptions.IllegalFormatException, "java.util."+name, msg)
+}
+
+func unknownConversion(conv string) error {
+	return formatException("UnknownFormatConversionException", "Conversion = '"+conv+"'")
+}
+
+// flagMismatch throws the exception for a flag that the conversion doesn't allow
+func flagMismatch(flag formatFlags, conv byte) error {
+	return formatException("FormatFlagsConversionMismatchException",
+		fmt.Sprintf("Conversion = %c, Flags = %s", conv, flag))
+}
+
+// conversionMismatch throws the exception for an argument of the wrong type
+func conversionMismatch(conv byte, arg *object.Object) error {
+	return formatException("IllegalFormatConversionException",
+		fmt.Sprintf("%c != %s", conv, javaClassName(arg)))
+}
+
+// javaFormat formats the arguments as String.format() does
+func javaFormat(format string, args []*object.Object) (string, error) {
+	parts, err := parseFormat(format)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	last, ordinary := -1, -1 // the indexes of the last argument used and the last ordinary argument
+	for _, part := range parts {
+		spec := part.spec
+		if spec == nil {
+			sb.WriteString(part.text)
+			continue
+		}
+
+		var arg *object.Object
+		if !spec.isText() {
+			switch spec.index {
+			case -1:
+				// last stays the same
+			case 0:
+				ordinary++
+				last = ordinary
+			default:
+				last = spec.index - 1
+			}
+			if last < 0 || last >= len(args) {
+				return "", formatException("MissingFormatArgumentException",
+					"Format specifier '"+spec.String()+"'")
+			}
+			arg = args[last]
+		}
+
+		str, err := spec.format(arg)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(str)
+	}
+	return sb.String(), nil
+}
+
+// parseFormat splits the format string into fixed text and format specifiers,
+// checking that each specifier is valid. As in the JDK, the whole string is
+// checked before any argument is formatted.
+func parseFormat(format string) ([]formatPart, error) {
+	var parts []formatPart
+	for pos := 0; pos < len(format); {
+		pct := strings.IndexByte(format[pos:], '%')
+		if pct < 0 {
+			parts = append(parts, formatPart{text: format[pos:]})
+			break
+		}
+		if pct > 0 {
+			parts = append(parts, formatPart{text: format[pos : pos+pct]})
+		}
+		spec, next, err := parseFormatSpec(format, pos+pct)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, formatPart{spec: spec})
+		pos = next
+	}
+	return parts, nil
+}
+
+// skipDigits returns the position of the first non-digit at or after pos
+func skipDigits(str string, pos int) int {
+	for pos < len(str) && str[pos] >= '0' && str[pos] <= '9' {
+		pos++
+	}
+	return pos
+}
+
+// parseFormatSpec parses the specifier that starts with the '%' at pos and
+// returns it with the position just after it
+func parseFormatSpec(format string, pos int) (*formatSpec, int, error) {
+	// if the specifier doesn't fit the syntax, the JDK reports the char after the %
+	badSyntax := func() error {
+		if pos+1 < len(format) {
+			return unknownConversion(string(format[pos+1]))
+		}
+		return unknownConversion("%")
+	}
+
+	spec := &formatSpec{width: -1, precision: -1}
+	i := pos + 1
+
+	// argument index
+	if end := skipDigits(format, i); end > i && end < len(format) && format[end] == '$' {
+		n, err := strconv.ParseInt(format[i:end], 10, 32)
+		if err != nil {
+			return nil, 0, formatException("IllegalFormatArgumentIndexException",
+				"Format argument index: (not representable as int)")
+		}
+		if n <= 0 {
+			return nil, 0, formatException("IllegalFormatArgumentIndexException",
+				fmt.Sprintf("Illegal format argument index = %d", n))
+		}
+		spec.index = int(n)
+		i = end + 1
+	}
+
+	// flags
+	for ; i < len(format); i++ {
+		bit := strings.IndexByte(formatFlagChars, format[i])
+		if bit < 0 || format[i] == '^' {
+			break
+		}
+		flag := formatFlags(1 << bit)
+		if spec.flags.has(flag) {
+			return nil, 0, formatException("DuplicateFormatFlagsException", "Flags = '"+flag.String()+"'")
+		}
+		spec.flags |= flag
+	}
+	if spec.flags.has(flagPrevious) {
+		spec.index = -1
+	}
+
+	// width
+	if end := skipDigits(format, i); end > i {
+		width, err := strconv.ParseInt(format[i:end], 10, 32)
+		if err != nil {
+			return nil, 0, formatException("IllegalFormatWidthException", strconv.Itoa(math.MinInt32))
+		}
+		spec.width = int(width)
+		i = end
+	}
+
+	// precision
+	if i < len(format) && format[i] == '.' {
+		end := skipDigits(format, i+1)
+		if end == i+1 {
+			return nil, 0, badSyntax()
+		}
+		precision, err := strconv.ParseInt(format[i+1:end], 10, 32)
+		if err != nil {
+			return nil, 0, formatException("IllegalFormatPrecisionException", strconv.Itoa(math.MinInt32))
+		}
+		spec.precision = int(precision)
+		i = end
+	}
+
+	// the t or T prefix of the date/time conversions
+	if i < len(format) && (format[i] == 't' || format[i] == 'T') {
+		spec.dateTime = true
+		if format[i] == 'T' {
+			spec.flags |= flagUpperCase
+		}
+		i++
+	}
+
+	// the conversion
+	if i >= len(format) {
+		return nil, 0, badSyntax()
+	}
+	conv := format[i]
+	if !(conv >= 'a' && conv <= 'z' || conv >= 'A' && conv <= 'Z' || conv == '%') {
+		return nil, 0, badSyntax()
+	}
+	if spec.dateTime {
+		spec.conv = conv
+	} else {
+		if strings.IndexByte(formatConversions, conv) < 0 {
+			return nil, 0, unknownConversion(string(conv))
+		}
+		if conv >= 'A' && conv <= 'Z' {
+			spec.flags |= flagUpperCase
+			conv += 'a' - 'A'
+		}
+		spec.conv = conv
+	}
+
+	if err := spec.check(); err != nil {
+		return nil, 0, err
+	}
+	return spec, i + 1, nil
+}
+
+// check validates the combination of flags, width and precision for the conversion
+func (spec *formatSpec) check() error {
+	if spec.dateTime {
+		return spec.checkDateTime()
+	}
+	switch spec.conv {
+	case 'b', 'h', 's':
+		return spec.checkGeneral()
+	case 'c':
+		return spec.checkCharacter()
+	case 'd', 'o', 'x':
+		return spec.checkInteger()
+	case 'e', 'f', 'g', 'a':
+		return spec.checkFloat()
+	default:
+		return spec.checkText()
+	}
+}
+
+// checkBadFlags reports the first of the flags that the specifier contains
+func (spec *formatSpec) checkBadFlags(flags ...formatFlags) error {
+	for _, flag := range flags {
+		if spec.flags.has(flag) {
+			return flagMismatch(flag, spec.conv)
+		}
+	}
+	return nil
+}
+
+func (spec *formatSpec) precisionNotAllowed() error {
+	if spec.precision != -1 {
+		return formatException("IllegalFormatPrecisionException", strconv.Itoa(spec.precision))
+	}
+	return nil
+}
+
+// checkNeedsWidth reports a flag, such as '-', that requires a width
+func (spec *formatSpec) checkNeedsWidth(flags formatFlags) error {
+	if spec.width == -1 && spec.flags&flags != 0 {
+		return formatException("MissingFormatWidthException", spec.String())
+	}
+	return nil
+}
+
+func (spec *formatSpec) checkGeneral() error {
+	if (spec.conv == 'b' || spec.conv == 'h') && spec.flags.has(flagAlternate) {
+		return flagMismatch(flagAlternate, spec.conv)
+	}
+	if err := spec.checkNeedsWidth(flagLeftJustify); err != nil {
+		return err
+	}
+	return spec.checkBadFlags(flagPlus, flagLeadingSpace, flagZeroPad, flagGroup, flagParentheses)
+}
+
+func (spec *formatSpec) checkCharacter() error {
+	if err := spec.precisionNotAllowed(); err != nil {
+		return err
+	}
+	if err := spec.checkBadFlags(flagAlternate, flagPlus, flagLeadingSpace,
+		flagZeroPad, flagGroup, flagParentheses); err != nil {
+		return err
+	}
+	return spec.checkNeedsWidth(flagLeftJustify)
+}
+
+func (spec *formatSpec) checkNumeric() error {
+	if err := spec.checkNeedsWidth(flagLeftJustify | flagZeroPad); err != nil {
+		return err
+	}
+	if (spec.flags.has(flagPlus) && spec.flags.has(flagLeadingSpace)) ||
+		(spec.flags.has(flagLeftJustify) && spec.flags.has(flagZeroPad)) {
+		return formatException("IllegalFormatFlagsException", "Flags = '"+spec.flags.String()+"'")
+	}
+	return nil
+}
+
+func (spec *formatSpec) checkInteger() error {
+	if err := spec.checkNumeric(); err != nil {
+		return err
+	}
+	if err := spec.precisionNotAllowed(); err != nil {
+		return err
+	}
+	if spec.conv == 'd' {
+		return spec.checkBadFlags(flagAlternate)
+	}
+	return spec.checkBadFlags(flagGroup)
+}
+
+func (spec *formatSpec) checkFloat() error {
+	if err := spec.checkNumeric(); err != nil {
+		return err
+	}
+	switch spec.conv {
+	case 'a':
+		return spec.checkBadFlags(flagParentheses, flagGroup)
+	case 'e':
+		return spec.checkBadFlags(flagGroup)
+	case 'g':
+		return spec.checkBadFlags(flagAlternate)
+	}
+	return nil
+}
+
+func (spec *formatSpec) checkDateTime() error {
+	if err := spec.precisionNotAllowed(); err != nil {
+		return err
+	}
+	if strings.IndexByte(formatDateTimeConversions, spec.conv) < 0 {
+		return unknownConversion("t" + string(spec.conv))
+	}
+	if err := spec.checkBadFlags(flagAlternate, flagPlus, flagLeadingSpace,
+		flagZeroPad, flagGroup, flagParentheses); err != nil {
+		return err
+	}
+	return spec.checkNeedsWidth(flagLeftJustify)
+}
+
+// checkText checks %% and %n
+func (spec *formatSpec) checkText() error {
+	if err := spec.precisionNotAllowed(); err != nil {
+		return err
+	}
+	if spec.conv == '%' {
+		if spec.flags&^flagLeftJustify != 0 {
+			return formatException("IllegalFormatFlagsException", "Flags = '"+spec.flags.String()+"'")
+		}
+		return spec.checkNeedsWidth(flagLeftJustify)
+	}
+	if spec.width != -1 {
+		return formatException("IllegalFormatWidthException", strconv.Itoa(spec.width))
+	}
+	if spec.flags != 0 {
+		return formatException("IllegalFormatFlagsException", "Flags = '"+spec.flags.String()+"'")
+	}
+	return nil
+}
+
+// format formats one argument, which is nil for %% and %n as well as for null
+func (spec *formatSpec) format(arg *object.Object) (string, error) {
+	if spec.dateTime {
+		return spec.formatDateTime(arg)
+	}
+
+	switch spec.conv {
+	case 'b':
+		val, ok := unboxValue(arg)
+		switch {
+		case arg == nil:
+			return spec.formatString("false"), nil
+		case ok && *arg.Klass == "java/lang/Boolean":
+			return spec.formatString(strconv.FormatBool(val.(int64) == types.JavaBoolTrue)), nil
+		default:
+			return spec.formatString("true"), nil
+		}
+	case 'h':
+		if arg == nil {
+			return spec.formatString("null"), nil
+		}
+		return spec.formatString(strconv.FormatUint(uint64(uint32(javaHashCode(arg))), 16)), nil
+	case 's':
+		// '#' is only for objects that implement Formattable, which isn't supported
+		if spec.flags.has(flagAlternate) {
+			return "", flagMismatch(flagAlternate, 's')
+		}
+		return spec.formatString(javaToString(arg)), nil
+	case 'c':
+		return spec.formatCharacter(arg)
+	case 'd', 'o', 'x':
+		if arg == nil {
+			return spec.formatString("null"), nil
+		}
+		value, bits, ok := integralArg(arg)
+		if !ok {
+			return "", conversionMismatch(spec.conv, arg)
+		}
+		return spec.formatInteger(value, bits)
+	case 'e', 'f', 'g', 'a':
+		if arg == nil {
+			return spec.formatString("null"), nil
+		}
+		val, ok := unboxValue(arg)
+		if !ok || (*arg.Klass != "java/lang/Double" && *arg.Klass != "java/lang/Float") {
+			return "", conversionMismatch(spec.conv, arg)
+		}
+		return spec.formatFloat(val.(float64)), nil
+	case '%':
+		return spec.formatString("%"), nil
+	default: // 'n'
+		return printStreamNewline, nil
+	}
+}
+
+// javaHashCode returns the value of hashCode() for the objects whose hashCode()
+// is implemented in Go, and the identity hash code for all others
+func javaHashCode(obj *object.Object) int64 {
+	if object.IsJavaString(obj) {
+		return stringHashCode([]interface{}{obj}).(int64)
+	}
+	if hash, ok := boxedHashCode(obj); ok {
+		return hash
+	}
+	return int64(object.IdentityHash(obj))
+}
+
+// integralArg returns the value of an Integer, Long, Short or Byte and the
+// number of bits in its type
+func integralArg(arg *object.Object) (int64, int, bool) {
+	val, ok := unboxValue(arg)
+	if !ok {
+		return 0, 0, false
+	}
+	switch *arg.Klass {
+	case "java/lang/Integer":
+		return val.(int64), 32, true
+	case "java/lang/Long":
+		return val.(int64), 64, true
+	case "java/lang/Short":
+		return val.(int64), 16, true
+	case "java/lang/Byte":
+		return val.(int64), 8, true
+	}
+	return 0, 0, false
+}
+
+// utf16Len returns the length of the string in Java chars
+func utf16Len(str string) int {
+	n := 0
+	for _, r := range str {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// justify pads the string with spaces to the width, on the left unless the
+// '-' flag is present
+func (spec *formatSpec) justify(str string) string {
+	pad := spec.width - utf16Len(str)
+	if pad <= 0 {
+		return str
+	}
+	if spec.flags.has(flagLeftJustify) {
+		return str + strings.Repeat(" ", pad)
+	}
+	return strings.Repeat(" ", pad) + str
+}
+
+// formatString truncates the string to the precision, converts it to upper
+// case if required, and justifies it
+func (spec *formatSpec) formatString(str string) string {
+	if spec.precision != -1 && spec.precision < utf16Len(str) {
+		str = string(utf16.Decode(utf16.Encode([]rune(str))[:spec.precision]))
+	}
+	if spec.flags.has(flagUpperCase) {
+		str = javaToUpperCase(str)
+	}
+	return spec.justify(str)
+}
+
+// formatCharacter implements %c, whose argument is a Character or a code point
+// in a Byte, Short or Integer
+func (spec *formatSpec) formatCharacter(arg *object.Object) (string, error) {
+	if arg == nil {
+		return spec.formatString("null"), nil
+	}
+	val, ok := unboxValue(arg)
+	if !ok {
+		return "", conversionMismatch('c', arg)
+	}
+	switch *arg.Klass {
+	case "java/lang/Character":
+		return spec.formatString(string(rune(val.(int64)))), nil
+	case "java/lang/Byte", "java/lang/Short", "java/lang/Integer":
+		cp := val.(int64)
+		if cp < 0 || cp > 0x10FFFF {
+			return "", formatException("IllegalFormatCodePointException",
+				fmt.Sprintf("Code point = 0x%x", uint32(cp)))
+		}
+		return spec.formatString(string(rune(cp))), nil
+	}
+	return "", conversionMismatch('c', arg)
+}
+
+// leadingSign writes the sign, or the opening parenthesis of a negative number
+func (spec *formatSpec) leadingSign(sb *strings.Builder, neg bool) {
+	switch {
+	case neg && spec.flags.has(flagParentheses):
+		sb.WriteByte('(')
+	case neg:
+		sb.WriteByte('-')
+	case spec.flags.has(flagPlus):
+		sb.WriteByte('+')
+	case spec.flags.has(flagLeadingSpace):
+		sb.WriteByte(' ')
+	}
+}
+
+func (spec *formatSpec) trailingSign(sb *strings.Builder, neg bool) {
+	if neg && spec.flags.has(flagParentheses) {
+		sb.WriteByte(')')
+	}
+}
+
+// adjustWidth leaves room in the width for the closing parenthesis of a negative number
+func (spec *formatSpec) adjustWidth(width int, neg bool) int {
+	if width != -1 && neg && spec.flags.has(flagParentheses) {
+		return width - 1
+	}
+	return width
+}
+
+// magnitude writes the digits of a number, which may contain a decimal point,
+// adding grouping separators to the integer part if the ',' flag is present
+// and zeros after the sign to fill the width if the '0' flag is present
+func (spec *formatSpec) magnitude(sb *strings.Builder, value string, width int) {
+	dot := strings.IndexByte(value, '.')
+	if dot < 0 {
+		dot = len(value)
+	}
+
+	var digits strings.Builder
+	for i := 0; i < len(value); i++ {
+		digits.WriteByte(value[i])
+		if spec.flags.has(flagGroup) && i < dot-1 && (dot-i)%3 == 1 {
+			digits.WriteByte(',')
+		}
+	}
+
+	if width != -1 && spec.flags.has(flagZeroPad) {
+		for n := sb.Len() + digits.Len(); n < width; n++ {
+			sb.WriteByte('0')
+		}
+	}
+	sb.WriteString(digits.String())
+}
+
+// formatInteger implements %d, %o and %x. Octal and hex show the two's
+// complement of negative numbers in the number of bits of the argument's type.
+func (spec *formatSpec) formatInteger(value int64, bits int) (string, error) {
+	var sb strings.Builder
+	if spec.conv == 'd' {
+		neg := value < 0
+		digits := strconv.FormatInt(value, 10)
+		if neg {
+			digits = digits[1:]
+		}
+		spec.leadingSign(&sb, neg)
+		spec.magnitude(&sb, digits, spec.adjustWidth(spec.width, neg))
+		spec.trailingSign(&sb, neg)
+		return spec.justify(sb.String()), nil
+	}
+
+	if err := spec.checkBadFlags(flagParentheses, flagLeadingSpace, flagPlus); err != nil {
+		return "", err
+	}
+	unsigned := uint64(value)
+	if bits < 64 {
+		unsigned &= 1<<bits - 1
+	}
+
+	var digits, prefix string
+	if spec.conv == 'o' {
+		digits = strconv.FormatUint(unsigned, 8)
+		if spec.flags.has(flagAlternate) {
+			prefix = "0"
+		}
+	} else {
+		digits = strconv.FormatUint(unsigned, 16)
+		if spec.flags.has(flagAlternate) {
+			prefix = "0x"
+		}
+		if spec.flags.has(flagUpperCase) {
+			digits = strings.ToUpper(digits)
+			prefix = strings.ToUpper(prefix)
+		}
+	}
+	sb.WriteString(prefix)
+	if spec.flags.has(flagZeroPad) {
+		sb.WriteString(strings.Repeat("0", max(0, spec.width-len(prefix)-len(digits))))
+	}
+	sb.WriteString(digits)
+	return spec.justify(sb.String()), nil
+}
+
+// formatFloat implements %e, %f, %g and %a
+func (spec *formatSpec) formatFloat(value float64) string {
+	upper := spec.flags.has(flagUpperCase)
+	var sb strings.Builder
+	if math.IsNaN(value) {
+		if upper {
+			sb.WriteString("NAN")
+		} else {
+			sb.WriteString("NaN")
+		}
+		return spec.justify(sb.String())
+	}
+
+	neg := value < 0 || (value == 0 && math.Signbit(value))
+	abs := math.Abs(value)
+	spec.leadingSign(&sb, neg)
+	switch {
+	case math.IsInf(abs, 1) && upper:
+		sb.WriteString("INFINITY")
+	case math.IsInf(abs, 1):
+		sb.WriteString("Infinity")
+	case spec.conv == 'a':
+		spec.formatHexFloat(&sb, abs, neg)
+	default:
+		spec.formatDecimalFloat(&sb, abs, neg)
+	}
+	spec.trailingSign(&sb, neg)
+	return spec.justify(sb.String())
+}
+
+// roundDigits rounds the significant digits of a number, whose value is
+// 0.digits * 10^decExp, to the first keep digits. As in the JDK, the
+// rounding is HALF_UP and is applied to the digits of Double.toString(),
+// so %.1f of 0.15 is 0.2 even though the double is slightly below 0.15.
+// It returns the rounded digits and the decimal exponent, which grows by
+// one if the rounding carries into a new digit.
+func roundDigits(digits string, decExp, keep int) (string, int) {
+	if keep < 0 || keep >= len(digits) {
+		return digits, decExp
+	}
+	if digits[keep] < '5' {
+		if keep == 0 {
+			return "0", decExp
+		}
+		return digits[:keep], decExp
+	}
+
+	rounded := []byte(digits[:keep])
+	i := keep - 1
+	for i >= 0 && rounded[i] == '9' {
+		rounded[i] = '0'
+		i--
+	}
+	if i < 0 {
+		return "1", decExp + 1
+	}
+	rounded[i]++
+	return string(rounded), decExp
+}
+
+// decimalDigits returns the digits of a number in plain notation, with the
+// given number of digits after the decimal point
+func decimalDigits(digits string, decExp, fraction int, alternate bool) string {
+	digitAt := func(i int) byte {
+		if i >= 0 && i < len(digits) {
+			return digits[i]
+		}
+		return '0'
+	}
+
+	var sb strings.Builder
+	if decExp <= 0 {
+		sb.WriteByte('0')
+	}
+	for i := 0; i < decExp; i++ {
+		sb.WriteByte(digitAt(i))
+	}
+	if fraction > 0 || alternate {
+		sb.WriteByte('.')
+	}
+	for i := 0; i < fraction; i++ {
+		sb.WriteByte(digitAt(decExp + i))
+	}
+	return sb.String()
+}
+
+// formatDecimalFloat writes the magnitude of a finite number for %e, %f and %g
+func (spec *formatSpec) formatDecimalFloat(sb *strings.Builder, abs float64, neg bool) {
+	digits, decExp := "0", 1
+	if abs != 0 {
+		var sciExp int
+		digits, sciExp = shortestDigits(abs, 64)
+		decExp = sciExp + 1
+	}
+	alternate := spec.flags.has(flagAlternate)
+
+	precision := spec.precision
+	scientific := spec.conv == 'e'
+	switch spec.conv {
+	case 'e', 'f':
+		if precision == -1 {
+			precision = 6
+		}
+	case 'g':
+		// the precision is the total number of significant digits
+		if precision == -1 {
+			precision = 6
+		} else if precision == 0 {
+			precision = 1
+		}
+		if abs != 0 {
+			digits, decExp = roundDigits(digits, decExp, precision)
+			if decExp-1 < -4 || decExp-1 >= precision {
+				scientific = true
+			}
+		}
+		if scientific {
+			precision--
+		} else {
+			precision -= decExp
+		}
+	}
+
+	if !scientific {
+		if spec.conv == 'f' {
+			digits, decExp = roundDigits(digits, decExp, decExp+precision)
+		}
+		spec.magnitude(sb, decimalDigits(digits, decExp, precision, alternate), spec.adjustWidth(spec.width, neg))
+		return
+	}
+
+	digits, decExp = roundDigits(digits, decExp, precision+1)
+	exp := decExp - 1
+	if abs == 0 {
+		exp = 0
+	}
+	expSign := byte('+')
+	if exp < 0 {
+		expSign = '-'
+		exp = -exp
+	}
+	expDigits := fmt.Sprintf("%02d", exp)
+
+	width := spec.width
+	if width != -1 {
+		width = spec.adjustWidth(width-len(expDigits)-2, neg)
+	}
+	spec.magnitude(sb, decimalDigits(digits, 1, precision, alternate), width)
+	if spec.flags.has(flagUpperCase) {
+		sb.WriteByte('E')
+	} else {
+		sb.WriteByte('e')
+	}
+	sb.WriteByte(expSign)
+	sb.WriteString(expDigits)
+}
+
+// formatHexFloat writes the magnitude of a finite number for %a, which, unless
+// a precision is given, is Double.toHexString() without the sign
+func (spec *formatSpec) formatHexFloat(sb *strings.Builder, abs float64, neg bool) {
+	precision := spec.precision
+	if precision == 0 {
+		precision = 1
+	}
+	str := hexDouble(abs, precision)
+	upper := spec.flags.has(flagUpperCase)
+
+	if upper {
+		sb.WriteString("0X")
+	} else {
+		sb.WriteString("0x")
+	}
+	if spec.flags.has(flagZeroPad) {
+		leading := 2
+		if neg || spec.flags.has(flagPlus) || spec.flags.has(flagLeadingSpace) {
+			leading = 3
+		}
+		sb.WriteString(strings.Repeat("0", max(0, spec.width-len(str)-leading)))
+	}
+
+	mantissa, exp, _ := strings.Cut(str, "p")
+	if precision > 0 {
+		_, fraction, _ := strings.Cut(mantissa, ".")
+		mantissa += strings.Repeat("0", max(0, precision-len(fraction)))
+	}
+	if upper {
+		sb.WriteString(strings.ToUpper(mantissa))
+		sb.WriteByte('P')
+	} else {
+		sb.WriteString(mantissa)
+		sb.WriteByte('p')
+	}
+	sb.WriteString(exp)
+}
+
+// doubleToHexString returns a positive, finite double in the format of
+// Double.toHexString(), without the leading 0x, e.g. 1.0p0 or 0.0000000000001p-1022
+func doubleToHexString(d float64) string {
+	if d == 0 {
+		return "0.0p0"
+	}
+	bits := math.Float64bits(d)
+	biasedExp := int(bits >> 52 & 0x7FF)
+	signif := strings.TrimRight(fmt.Sprintf("%013x", bits&(1<<52-1)), "0")
+	if signif == "" {
+		signif = "0"
+	}
+	if biasedExp == 0 { // subnormal
+		return "0." + signif + "p-1022"
+	}
+	return "1." + signif + "p" + strconv.Itoa(biasedExp-1023)
+}
+
+// hexDouble returns a positive, finite double in hex with the given number of
+// hex digits after the point, rounding half-even, as the JDK's Formatter does.
+// A precision of -1 (or 13 or more) keeps all the digits. Unlike
+// Double.toHexString(), a subnormal value is normalized when it's rounded.
+func hexDouble(d float64, precision int) string {
+	if d == 0 || precision == -1 || precision >= 13 {
+		return doubleToHexString(d)
+	}
+
+	subnormal := math.Float64bits(d)>>52 == 0
+	if subnormal {
+		d *= math.Ldexp(1, 54)
+	}
+
+	shift := uint(52 - precision*4)
+	bits := math.Float64bits(d)
+	signif := bits >> shift
+	roundingBits := bits & (1<<shift - 1)
+	leastZero := signif&1 == 0
+	round := roundingBits&(1<<(shift-1)) != 0
+	sticky := shift > 1 && roundingBits&^(1<<(shift-1)) != 0
+	if (leastZero && round && sticky) || (!leastZero && round) {
+		signif++
+	}
+
+	result := math.Float64frombits(signif << shift)
+	if math.IsInf(result, 1) {
+		return "1.0p1024"
+	}
+	str := doubleToHexString(result)
+	if !subnormal {
+		return str
+	}
+	mantissa, exp, _ := strings.Cut(str, "p")
+	e, _ := strconv.Atoi(exp)
+	return mantissa + "p" + strconv.Itoa(e-54)
+}
+
+// === date/time conversions ===
+
+// formatDateTime implements the %t conversions. The argument is a Long holding
+// milliseconds since the epoch, which is shown in the local time zone.
+func (spec *formatSpec) formatDateTime(arg *object.Object) (string, error) {
+	if arg == nil {
+		return spec.formatString("null"), nil
+	}
+	val, ok := unboxValue(arg)
+	if !ok || *arg.Klass != "java/lang/Long" {
+		return "", conversionMismatch(spec.conv, arg)
+	}
+
+	str := formatTime(time.UnixMilli(val.(int64)).In(time.Local), spec.conv)
+	if spec.flags.has(flagUpperCase) {
+		str = javaToUpperCase(str)
+	}
+	return spec.justify(str), nil
+}
+
+// formatTime formats the time for one of the date/time suffixes
+func formatTime(t time.Time, conv byte) string {
+	hour12 := t.Hour() % 12
+	if hour12 == 0 {
+		hour12 = 12
+	}
+
+	switch conv {
+	case 'H':
+		return fmt.Sprintf("%02d", t.Hour())
+	case 'I':
+		return fmt.Sprintf("%02d", hour12)
+	case 'k':
+		return strconv.Itoa(t.Hour())
+	case 'l':
+		return strconv.Itoa(hour12)
+	case 'M':
+		return fmt.Sprintf("%02d", t.Minute())
+	case 'S':
+		return fmt.Sprintf("%02d", t.Second())
+	case 'L':
+		return fmt.Sprintf("%03d", t.Nanosecond()/1_000_000)
+	case 'N':
+		return fmt.Sprintf("%09d", t.Nanosecond())
+	case 'p':
+		if t.Hour() < 12 {
+			return "am"
+		}
+		return "pm"
+	case 'z':
+		return t.Format("-0700")
+	case 'Z':
+		return t.Format("MST")
+	case 's':
+		return strconv.FormatInt(t.Unix(), 10)
+	case 'Q':
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case 'B':
+		return t.Month().String()
+	case 'b', 'h':
+		return t.Month().String()[:3]
+	case 'A':
+		return t.Weekday().String()
+	case 'a':
+		return t.Weekday().String()[:3]
+	case 'C':
+		return fmt.Sprintf("%02d", t.Year()/100)
+	case 'Y':
+		return fmt.Sprintf("%04d", t.Year())
+	case 'y':
+		return fmt.Sprintf("%02d", t.Year()%100)
+	case 'j':
+		return fmt.Sprintf("%03d", t.YearDay())
+	case 'm':
+		return fmt.Sprintf("%02d", int(t.Month()))
+	case 'd':
+		return fmt.Sprintf("%02d", t.Day())
+	case 'e':
+		return strconv.Itoa(t.Day())
+	case 'R':
+		return formatTime(t, 'H') + ":" + formatTime(t, 'M')
+	case 'T':
+		return formatTime(t, 'H') + ":" + formatTime(t, 'M') + ":" + formatTime(t, 'S')
+	case 'r':
+		return formatTime(t, 'I') + ":" + formatTime(t, 'M') + ":" + formatTime(t, 'S') + " " +
+			strings.ToUpper(formatTime(t, 'p'))
+	case 'D':
+		return formatTime(t, 'm') + "/" + formatTime(t, 'd') + "/" + formatTime(t, 'y')
+	case 'F':
+		return formatTime(t, 'Y') + "-" + formatTime(t, 'm') + "-" + formatTime(t, 'd')
+	default: // 'c'
+		return formatTime(t, 'a') + " " + formatTime(t, 'b') + " " + formatTime(t, 'd') + " " +
+			formatTime(t, 'T') + " " + formatTime(t, 'Z') + " " + formatTime(t, 'Y')
+	}
+}
+
+// === java/util/Formatter ===
+
+// the field of a Formatter object that holds its Go state
+const formatterField = "a"
+
+// formatter is the Go state of a java.util.Formatter. The destination is a
+// StringBuilder, a StringBuffer, or a PrintStream.
+type formatter struct {
+	dest   *object.Object
+	closed bool
+}
+
+// the exception thrown when a closed Formatter is used
+func formatterClosed() error {
+	return throwNativeException(exceptions.IllegalStateException,
+		"java.util.FormatterClosedException", "")
+}
+
+func setFormatter(this, dest *object.Object) {
+	if this.FieldTable == nil {
+		this.FieldTable = make(map[string]object.Field)
+	}
+	this.FieldTable[formatterField] = object.Field{Ftype: types.GoObject, Fvalue: &formatter{dest: dest}}
+}
+
+// newStringBuilderObject creates a java.lang.StringBuilder, the default destination
+func newStringBuilderObject() *object.Object {
+	obj := object.MakeEmptyObject()
+	name := "java/lang/StringBuilder"
+	obj.Klass = &name
+	initBuilder(obj, nil)
+	return obj
+}
+
+// getFormatter returns the Go state of the Formatter in params[0], and an
+// exception if the Formatter has been closed
+func getFormatter(params []interface{}) (*formatter, error) {
+	this := params[0].(*object.Object)
+	f, ok := this.FieldTable[formatterField].Fvalue.(*formatter)
+	if !ok {
+		// not constructed by one of the constructors here, so format to a new builder
+		setFormatter(this, newStringBuilderObject())
+		f = this.FieldTable[formatterField].Fvalue.(*formatter)
+	}
+	if f.closed {
+		return nil, formatterClosed()
+	}
+	return f, nil
+}
+
+// isPrintStream is true if the object is a PrintStream implemented in Go
+func isPrintStream(obj *object.Object) bool {
+	_, ok := printStreamOf(obj)
+	return ok
+}
+
+// Formatter(), Formatter(Locale): formats to a new StringBuilder
+func formatterInit(params []interface{}) interface{} {
+	setFormatter(params[0].(*object.Object), newStringBuilderObject())
+	return nil
+}
+
+// Formatter(Appendable) and Formatter(Appendable, Locale). A null Appendable
+// means a new StringBuilder. Only StringBuilder, StringBuffer and PrintStream
+// are supported.
+func formatterInitAppendable(params []interface{}) interface{} {
+	dest, _ := params[1].(*object.Object)
+	if dest == nil {
+		dest = newStringBuilderObject()
+	} else if _, ok := builderChars(dest); !ok && !isPrintStream(dest) {
+		return throwNativeException(exceptions.UnsupportedOperationException,
+			"java.lang.UnsupportedOperationException",
+			"Formatter: unsupported Appendable "+javaClassName(dest))
+	}
+	setFormatter(params[0].(*object.Object), dest)
+	return nil
+}
+
+// Formatter(PrintStream)
+func formatterInitPrintStream(params []interface{}) interface{} {
+	dest, _ := params[1].(*object.Object)
+	if dest == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Formatter.<init>(): null PrintStream")
+	}
+	setFormatter(params[0].(*object.Object), dest)
+	return nil
+}
+
+// format(String, Object...) writes the formatted string to the destination and
+// returns the Formatter
+func formatterFormat(params []interface{}) interface{} {
+	f, err := getFormatter(params)
+	if err != nil {
+		return err
+	}
+	str := stringFormat(params[1:])
+	if err, ok := str.(error); ok {
+		return err
+	}
+	if ps, ok := printStreamOf(f.dest); ok {
+		ps.writeString(javaToString(str.(*object.Object)))
+	} else {
+		appendChars([]interface{}{f.dest}, object.GetStringUTF16(str.(*object.Object)))
+	}
+	return params[0]
+}
+
+// format(Locale, String, Object...)
+func formatterFormatLocale(params []interface{}) interface{} {
+	return formatterFormat([]interface{}{params[0], params[2], params[3]})
+}
+
+// toString() returns the contents of the destination
+func formatterToString(params []interface{}) interface{} {
+	f, err := getFormatter(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava(javaToString(f.dest))
+}
+
+// out() returns the destination
+func formatterOut(params []interface{}) interface{} {
+	f, err := getFormatter(params)
+	if err != nil {
+		return err
+	}
+	return f.dest
+}
+
+func formatterFlush(params []interface{}) interface{} {
+	f, err := getFormatter(params)
+	if err != nil {
+		return err
+	}
+	if ps, ok := printStreamOf(f.dest); ok {
+		ps.flush()
+	}
+	return nil
+}
+
+// close() closes the destination if it's a PrintStream. Closing a closed
+// Formatter has no effect.
+func formatterClose(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	f, ok := this.FieldTable[formatterField].Fvalue.(*formatter)
+	if !ok || f.closed {
+		return nil
+	}
+	if isPrintStream(f.dest) {
+		PrintStreamClose([]interface{}{f.dest})
+	}
+	f.closed = true
+	return nil
+}
+
+// ioException() returns the last IOException thrown by the destination; the
+// supported destinations never throw one
+func formatterIOException([]interface{}) interface{} {
+	return object.Null
+}
@@ -0,0 +1,188 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func boxedInt(i int64) *object.Object      { return newBox("java/lang/Integer", i) }
+func boxedLong(l int64) *object.Object     { return newBox("java/lang/Long", l) }
+func boxedDouble(d float64) *object.Object { return newBox("java/lang/Double", d) }
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		args     []*object.Object
+		expected string
+	}{
+		{"%-10s %8.2f %,d", []*object.Object{jstr("abc"), boxedDouble(3.14159), boxedInt(1234567)},
+			"abc            3.14 1,234,567"},
+		{"%2$s %1$s", []*object.Object{jstr("a"), jstr("b")}, "b a"},
+		{"%s %<s %s", []*object.Object{jstr("a"), jstr("b")}, "a a b"},
+		{"%.3s|%5s|%-5s|", []*object.Object{jstr("abcdef"), jstr("ab"), jstr("ab")}, "abc|   ab|ab   |"},
+		{"%S", []*object.Object{jstr("straße")}, "STRASSE"},
+		{"%s", []*object.Object{nil}, "null"},
+		{"%b %b %B", []*object.Object{nil, jstr("x"), boxBoolean(true)}, "false true TRUE"},
+		{"%c%c", []*object.Object{newBox("java/lang/Character", int64('J')), boxedInt(0x1F600)}, "J\U0001F600"},
+		{"%h", []*object.Object{jstr("hi")}, "d01"},
+		{"%5%|%-3%|%n", nil, "    %|%  |\n"},
+
+		// integers
+		{"%d %+d % d %(d", []*object.Object{boxedInt(7), boxedInt(7), boxedInt(42), boxedInt(-42)}, "7 +7  42 (42)"},
+		{"%010d|%-6d|", []*object.Object{boxedInt(-42), boxedInt(5)}, "-000000042|5     |"},
+		{"%,d", []*object.Object{boxedLong(-1234567890123)}, "-1,234,567,890,123"},
+		{"%x %x %x", []*object.Object{boxedInt(-1), boxedLong(-1), newBox("java/lang/Byte", int64(-1))},
+			"ffffffff ffffffffffffffff ff"},
+		{"%#X %08x %o %#o", []*object.Object{boxedInt(255), boxedInt(255), boxedInt(8), boxedInt(8)},
+			"0XFF 000000ff 10 010"},
+
+		// floating point
+		{"%f %.0f %.2f", []*object.Object{boxedDouble(1.5), boxedDouble(2.5), boxedDouble(math.Copysign(0, -1))}, "1.500000 3 -0.00"},
+		{"%.1f %.2f %.2f %.3f", []*object.Object{boxedDouble(0.15), boxedDouble(0.005),
+			boxedDouble(0.125), boxedDouble(1.0005)}, "0.2 0.01 0.13 1.001"},
+		{"%.2f %.1f", []*object.Object{boxedDouble(0.0001), boxedDouble(9.96)}, "0.00 10.0"},
+		{"%(,.2f %08.2f %+.1f", []*object.Object{boxedDouble(-1234.5), boxedDouble(-3.5), boxedDouble(2)},
+			"(1,234.50) -0003.50 +2.0"},
+		{"%f", []*object.Object{newBox("java/lang/Float", float64(float32(0.1)))}, "0.100000"},
+		{"%e %.3E %e", []*object.Object{boxedDouble(12345.678), boxedDouble(0.000123), boxedDouble(0)},
+			"1.234568e+04 1.230E-04 0.000000e+00"},
+		{"%12.2e|", []*object.Object{boxedDouble(-1e100)}, "  -1.00e+100|"},
+		{"%g %g %g %g", []*object.Object{boxedDouble(12345.678), boxedDouble(0.0001),
+			boxedDouble(1e-5), boxedDouble(0)}, "12345.7 0.000100000 1.00000e-05 0.00000"},
+		{"%.3g %G", []*object.Object{boxedDouble(999.9), boxedDouble(1e10)}, "1.00e+03 1.00000E+10"},
+		{"%a %a %.2a %A", []*object.Object{boxedDouble(1), boxedDouble(-0.5), boxedDouble(1),
+			boxedDouble(255)}, "0x1.0p0 -0x1.0p-1 0x1.00p0 0X1.FEP7"},
+		{"%a %.1a", []*object.Object{boxedDouble(math.SmallestNonzeroFloat64),
+			boxedDouble(math.SmallestNonzeroFloat64)}, "0x0.0000000000001p-1022 0x1.0p-1074"},
+		{"%f %+f %10.2f|", []*object.Object{boxedDouble(math.NaN()), boxedDouble(math.Inf(1)),
+			boxedDouble(math.Inf(-1))}, "NaN +Infinity  -Infinity|"},
+	}
+
+	for _, tc := range tests {
+		got, err := javaFormat(tc.format, tc.args)
+		if err != nil {
+			t.Errorf("format(%q): unexpected error: %s", tc.format, err.Error())
+		} else if got != tc.expected {
+			t.Errorf("format(%q): expected %q, got %q", tc.format, tc.expected, got)
+		}
+	}
+}
+
+func TestFormatDateTime(t *testing.T) {
+	normalLocal := time.Local
+	time.Local = time.UTC
+	defer func() { time.Local = normalLocal }()
+
+	millis := boxedLong(time.Date(2023, time.March, 5, 13, 5, 9, 42_000_000, time.UTC).UnixMilli())
+	tests := map[string]string{
+		"%tF %<tT.%<tL":    "2023-03-05 13:05:09.042",
+		"%tr %<Tp %<tl":    "01:05:09 PM PM 1",
+		"%tc":              "Sun Mar 05 13:05:09 UTC 2023",
+		"%tA, %<tB %<te":   "Sunday, March 5",
+		"%tD %<tj %<tz":    "03/05/23 064 +0000",
+		"%-12tR|%<Tb|%<tQ": "13:05       |MAR|1678021509042",
+	}
+	for format, expected := range tests {
+		got, err := javaFormat(format, []*object.Object{millis})
+		if err != nil {
+			t.Errorf("format(%q): unexpected error: %s", format, err.Error())
+		} else if got != expected {
+			t.Errorf("format(%q): expected %q, got %q", format, expected, got)
+		}
+	}
+}
+
+func TestFormatExceptions(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	tests := []struct {
+		format   string
+		args     []*object.Object
+		expected string
+	}{
+		{"%q", nil, "java.util.UnknownFormatConversionException: Conversion = 'q'"},
+		{"abc%", nil, "java.util.UnknownFormatConversionException: Conversion = '%'"},
+		{"%tq", nil, "java.util.UnknownFormatConversionException: Conversion = 'tq'"},
+		{"%s %s", []*object.Object{jstr("a")}, "java.util.MissingFormatArgumentException: Format specifier '%s'"},
+		{"%<s", []*object.Object{jstr("a")}, "java.util.MissingFormatArgumentException: Format specifier '%<s'"},
+		{"%d", []*object.Object{jstr("a")}, "java.util.IllegalFormatConversionException: d != java.lang.String"},
+		{"%f", []*object.Object{boxedInt(1)}, "java.util.IllegalFormatConversionException: f != java.lang.Integer"},
+		{"%-d", nil, "java.util.MissingFormatWidthException: %-d"},
+		{"%.2d", nil, "java.util.IllegalFormatPrecisionException: 2"},
+		{"%#d", nil, "java.util.FormatFlagsConversionMismatchException: Conversion = d, Flags = #"},
+		{"%,e", nil, "java.util.FormatFlagsConversionMismatchException: Conversion = e, Flags = ,"},
+		{"%+x", []*object.Object{boxedInt(1)}, "java.util.FormatFlagsConversionMismatchException: Conversion = x, Flags = +"},
+		{"%+ d", nil, "java.util.IllegalFormatFlagsException: Flags = '+ '"},
+		{"%--5d", nil, "java.util.DuplicateFormatFlagsException: Flags = '-'"},
+		{"%5n", nil, "java.util.IllegalFormatWidthException: 5"},
+		{"%0$s", nil, "java.util.IllegalFormatArgumentIndexException: Illegal format argument index = 0"},
+		{"%c", []*object.Object{boxedInt(0x110000)}, "java.util.IllegalFormatCodePointException: Code point = 0x110000"},
+	}
+	for _, tc := range tests {
+		_, err := javaFormat(tc.format, tc.args)
+		if err == nil {
+			t.Errorf("format(%q): expected %s, got no error", tc.format, tc.expected)
+		} else if err.Error() != tc.expected {
+			t.Errorf("format(%q): expected %s, got %s", tc.format, tc.expected, err.Error())
+		}
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
+
+func TestFormatterToStringBuilder(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	fmtr := object.MakeEmptyObject()
+	formatterInit([]interface{}{fmtr})
+	if ret := formatterFormat([]interface{}{fmtr, jstr("%d-"), refArray(boxedInt(1))}); ret != fmtr {
+		t.Errorf("Expected format() to return the Formatter, got %v", ret)
+	}
+	formatterFormat([]interface{}{fmtr, jstr("%s"), refArray(jstr("two"))})
+	if got := javaToString(formatterToString([]interface{}{fmtr}).(*object.Object)); got != "1-two" {
+		t.Errorf("Expected Formatter.toString() to be \"1-two\", got %q", got)
+	}
+
+	// the destination is the StringBuilder passed to the constructor
+	sb := object.MakeEmptyObject()
+	sbInit([]interface{}{sb})
+	formatterInitAppendable([]interface{}{fmtr, sb})
+	formatterFormat([]interface{}{fmtr, jstr("%05.1f"), refArray(boxedDouble(2.25))})
+	if got := javaToString(sb); got != "002.3" {
+		t.Errorf("Expected the StringBuilder to hold \"002.3\", got %q", got)
+	}
+	if formatterOut([]interface{}{fmtr}) != sb {
+		t.Errorf("Expected out() to return the StringBuilder")
+	}
+
+	formatterClose([]interface{}{fmtr})
+	formatterClose([]interface{}{fmtr})
+	if _, ok := formatterFormat([]interface{}{fmtr, jstr("x"), refArray()}).(error); !ok {
+		t.Errorf("Expected FormatterClosedException from a closed Formatter")
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
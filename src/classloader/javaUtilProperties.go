@@ -0,0 +1,367 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Implementation of java/util/Properties in Go. The keys and values are held
+// in a Go map, in the object's "map" field (the field that holds them in the
+// JDK). Only strings can be stored. The system properties are held in one of
+// these maps, so the Properties object System.getProperties() returns is a
+// live view of them, as in the JDK.
+
+const propertiesMapField = "map"
+
+type properties struct {
+	values map[string]string
+	mutex  sync.RWMutex
+}
+
+func newProperties() *properties {
+	return &properties{values: make(map[string]string)}
+}
+
+func (p *properties) get(key string) (string, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	value, ok := p.values[key]
+	return value, ok
+}
+
+// set sets the value of the key and returns the previous value, if any
+func (p *properties) set(key, value string) (string, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	prev, ok := p.values[key]
+	p.values[key] = value
+	return prev, ok
+}
+
+// remove removes the key and returns its value, if any
+func (p *properties) remove(key string) (string, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	prev, ok := p.values[key]
+	delete(p.values, key)
+	return prev, ok
+}
+
+// sortedKeys returns the keys in order, so that listings are repeatable
+func (p *properties) sortedKeys() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	keys := make([]string, 0, len(p.values))
+	for key := range p.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// newPropertiesObject creates a java/util/Properties object for the Go state
+func newPropertiesObject(p *properties) *object.Object {
+	obj := object.MakeEmptyObject()
+	className := "java/util/Properties"
+	obj.Klass = &className
+	setProperties(obj, p)
+	return obj
+}
+
+func setProperties(obj *object.Object, p *properties) {
+	if obj.FieldTable == nil {
+		obj.FieldTable = make(map[string]object.Field)
+	}
+	obj.FieldTable[propertiesMapField] = object.Field{Ftype: types.GoObject, Fvalue: p}
+}
+
+// getProperties returns the Go state of the Properties object in params[0]
+func getProperties(params []interface{}) (*properties, error) {
+	obj, _ := params[0].(*object.Object)
+	if obj != nil && obj.FieldTable != nil {
+		if p, ok := obj.FieldTable[propertiesMapField].Fvalue.(*properties); ok {
+			return p, nil
+		}
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of this Properties object")
+}
+
+// propertyString returns the Go string of a key or value, and an exception if
+// it's null or not a string
+func propertyString(param interface{}) (string, error) {
+	obj, _ := param.(*object.Object)
+	if obj == nil {
+		return "", throwNativeException(exceptions.NullPointerException, "java.lang.NullPointerException", "")
+	}
+	if !object.IsJavaString(obj) {
+		return "", throwNativeException(exceptions.ClassCastException, "java.lang.ClassCastException",
+			"class "+javaClassName(obj)+" cannot be cast to class java.lang.String")
+	}
+	return object.GetGoStringFromJavaStringPtr(obj), nil
+}
+
+// stringOrJavaNull returns the string as a Java string, or null if ok is false
+func stringOrJavaNull(str string, ok bool) *object.Object {
+	if !ok {
+		return object.Null
+	}
+	return goStringToJava(str)
+}
+
+func Load_Util_Properties() map[string]GMeth {
+
+	MethodSignatures["java/util/Properties.<init>()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  propertiesInit,
+		}
+
+	MethodSignatures["java/util/Properties.<init>(I)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesInit,
+		}
+
+	MethodSignatures["java/util/Properties.getProperty(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesGetProperty,
+		}
+
+	MethodSignatures["java/util/Properties.getProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  propertiesGetProperty,
+		}
+
+	MethodSignatures["java/util/Properties.setProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  propertiesPut,
+		}
+
+	MethodSignatures["java/util/Properties.put(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  propertiesPut,
+		}
+
+	MethodSignatures["java/util/Properties.get(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesGet,
+		}
+
+	MethodSignatures["java/util/Properties.remove(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesRemove,
+		}
+
+	MethodSignatures["java/util/Properties.containsKey(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesContainsKey,
+		}
+
+	MethodSignatures["java/util/Properties.size()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  propertiesSize,
+		}
+
+	MethodSignatures["java/util/Properties.isEmpty()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  propertiesIsEmpty,
+		}
+
+	MethodSignatures["java/util/Properties.clear()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  propertiesClear,
+		}
+
+	MethodSignatures["java/util/Properties.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  propertiesToString,
+		}
+
+	MethodSignatures["java/util/Properties.list(Ljava/io/PrintStream;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  propertiesList,
+		}
+
+	return MethodSignatures
+}
+
+// Properties() and Properties(int initialCapacity)
+func propertiesInit(params []interface{}) interface{} {
+	setProperties(params[0].(*object.Object), newProperties())
+	return nil
+}
+
+// getProperty(key) and getProperty(key, defaultValue) return the value of the
+// key, or the default (which defaults to null) if there is none
+func propertiesGetProperty(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	key, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	if value, ok := p.get(key); ok {
+		return goStringToJava(value)
+	}
+	if len(params) > 2 {
+		return params[2]
+	}
+	return object.Null
+}
+
+// setProperty(key, value) and put(key, value) return the previous value, or null
+func propertiesPut(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	key, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	value, err := propertyString(params[2])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(p.set(key, value))
+}
+
+// get(key) returns the value, or null
+func propertiesGet(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	key, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(p.get(key))
+}
+
+// remove(key) returns the value that was removed, or null
+func propertiesRemove(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	key, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	return stringOrJavaNull(p.remove(key))
+}
+
+func propertiesContainsKey(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	key, err := propertyString(params[1])
+	if err != nil {
+		return err
+	}
+	_, ok := p.get(key)
+	return types.ConvertGoBoolToJavaBool(ok)
+}
+
+func propertiesSize(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return int64(len(p.values))
+}
+
+func propertiesIsEmpty(params []interface{}) interface{} {
+	size := propertiesSize(params)
+	if err, ok := size.(error); ok {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(size.(int64) == 0)
+}
+
+func propertiesClear(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.values = make(map[string]string)
+	return nil
+}
+
+// toString() returns the entries as {key1=value1, key2=value2}, in key order
+func propertiesToString(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, key := range p.sortedKeys() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		value, _ := p.get(key)
+		sb.WriteString(key + "=" + value)
+	}
+	sb.WriteByte('}')
+	return goStringToJava(sb.String())
+}
+
+// list(PrintStream) prints the entries one per line, in key order. As in the
+// JDK, values longer than 40 chars are cut to 37 chars followed by "...".
+func propertiesList(params []interface{}) interface{} {
+	p, err := getProperties(params)
+	if err != nil {
+		return err
+	}
+	out, _ := params[1].(*object.Object)
+	if out == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Properties.list(): null PrintStream")
+	}
+	ps, err := printStreamThis([]interface{}{out})
+	if err != nil {
+		return err
+	}
+	ps.writeString("-- listing properties --" + printStreamNewline)
+	for _, key := range p.sortedKeys() {
+		value, _ := p.get(key)
+		if chars := utf16.Encode([]rune(value)); len(chars) > 40 {
+			value = string(utf16.Decode(chars[:37])) + "..."
+		}
+		ps.writeString(key + "=" + value + printStreamNewline)
+	}
+	return nil
+}
@@ -0,0 +1,605 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bufio"
+	"errors"
+	"jacobin/exceptions"
+	"jacobin/object"
+	"jacobin/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Implementation of java/util/Scanner over a String or over an InputStream
+// implemented in Go, such as System.in. Tokens are separated by whitespace,
+// the default delimiter; other delimiters are not yet supported. Numbers are
+// parsed as in the US locale, so "1,234" is accepted by nextInt().
+//
+// The scanner reads ahead only as far as it must to find the end of a token
+// or line, so it doesn't wait for input beyond the end of the current line.
+
+// the field of a Scanner that holds its Go state
+const scannerField = "source"
+
+type scanner struct {
+	src    *inputStream
+	buf    []rune // chars read from the source but not yet consumed
+	eof    bool
+	closed bool
+}
+
+func Load_Util_Scanner() map[string]GMeth {
+
+	MethodSignatures["java/util/Scanner.<init>(Ljava/io/InputStream;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  scannerInitStream,
+			Accepts:    wrapsInputStream,
+		}
+
+	MethodSignatures["java/util/Scanner.<init>(Ljava/io/InputStream;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 3,
+			GFunction:  scannerInitStream,
+			Accepts:    wrapsInputStream,
+		}
+
+	MethodSignatures["java/util/Scanner.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  scannerInitString,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNext()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerHasNext,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.next()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerNext,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextLine()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerHasNextLine,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextLine()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerNextLine,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextBoolean()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerHasNextBoolean,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextBoolean()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerNextBoolean,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.close()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  scannerClose,
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextInt()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextInt(I)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextInt()I"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextInt(I)I"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextLong()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextLong(I)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextLong()J"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextLong(I)J"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextShort()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 16) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextShort(I)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 16) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextShort()S"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 16) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextShort(I)S"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 16) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextByte()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 8) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextByte(I)Z"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextIntegral(params, 8) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextByte()B"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 8) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextByte(I)B"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextIntegral(params, 8) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextDouble()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextFloating(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextDouble()D"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextFloating(params, 64) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.hasNextFloat()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerHasNextFloating(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	MethodSignatures["java/util/Scanner.nextFloat()F"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  func(params []interface{}) interface{} { return scannerNextFloating(params, 32) },
+			Accepts:    hasScanner,
+		}
+
+	return MethodSignatures
+}
+
+// === reading tokens and lines ===
+
+// isScannerDelimiter is true for the chars of the default delimiter, whitespace
+func isScannerDelimiter(r rune) bool {
+	return r < 0x10000 && isJavaWhitespace(uint16(r))
+}
+
+// fill reads from the source until the buffer holds at least n chars, and
+// returns false if the source ends first
+func (s *scanner) fill(n int) bool {
+	if len(s.buf) >= n {
+		return true
+	}
+	s.src.mutex.Lock()
+	defer s.src.mutex.Unlock()
+	for len(s.buf) < n && !s.eof {
+		if s.src.closed {
+			s.eof = true
+			break
+		}
+		r, _, err := s.src.in.ReadRune()
+		if err != nil {
+			s.eof = true
+			break
+		}
+		s.buf = append(s.buf, r)
+	}
+	return len(s.buf) >= n
+}
+
+func (s *scanner) consume(n int) {
+	s.buf = s.buf[n:]
+}
+
+// token returns the next token and the number of chars in the buffer up to
+// its end, which must be consumed to skip it. It returns false if there are
+// no more tokens.
+func (s *scanner) token() (string, int, bool) {
+	start := 0
+	for s.fill(start+1) && isScannerDelimiter(s.buf[start]) {
+		start++
+	}
+	if !s.fill(start + 1) {
+		return "", 0, false
+	}
+	end := start
+	for s.fill(end+1) && !isScannerDelimiter(s.buf[end]) {
+		end++
+	}
+	return string(s.buf[start:end]), end, true
+}
+
+// line returns the rest of the current line, consuming its terminator, and
+// false if there is no more input. The terminators are those of the JDK's
+// Scanner: \r\n, \n, \r, \u2028, \u2029, and \u0085.
+func (s *scanner) line() (string, bool) {
+	i := 0
+	for ; s.fill(i + 1); i++ {
+		switch s.buf[i] {
+		case '\r':
+			line := string(s.buf[:i])
+			if s.fill(i+2) && s.buf[i+1] == '\n' {
+				s.consume(i + 2)
+			} else {
+				s.consume(i + 1)
+			}
+			return line, true
+		case '\n', '\u2028', '\u2029', '\u0085':
+			line := string(s.buf[:i])
+			s.consume(i + 1)
+			return line, true
+		}
+	}
+	if i == 0 {
+		return "", false
+	}
+	line := string(s.buf[:i])
+	s.consume(i)
+	return line, true
+}
+
+// === parsing tokens ===
+
+// a number with grouping separators, such as 1,234,567
+var groupedNumber = regexp.MustCompile(`^[+-]?\d{1,3}(,\d{3})+(\.\d*)?$`)
+
+// the numbers that nextDouble() accepts, once grouping separators are removed
+var scannerFloating = regexp.MustCompile(`^[+-]?(NaN|Infinity|(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?)$`)
+
+// errInputMismatch is returned when a token is not of the type wanted
+var errInputMismatch = errors.New("input mismatch")
+
+func removeGrouping(token string) string {
+	if groupedNumber.MatchString(token) {
+		return strings.ReplaceAll(token, ",", "")
+	}
+	return token
+}
+
+// parseIntegral parses a token as an integer of the given number of bits. A
+// token that is not an integer is an errInputMismatch; one that is out of
+// range is the NumberFormatException message, as in the JDK.
+func parseIntegral(token string, radix int64, bits int) (int64, error) {
+	digits := token
+	if radix == 10 {
+		digits = removeGrouping(token)
+	}
+	value, err := strconv.ParseInt(digits, int(radix), bits)
+	if err == nil {
+		return value, nil
+	}
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		msg := "For input string: \"" + digits + "\""
+		if radix != 10 {
+			msg += " under radix " + strconv.FormatInt(radix, 10)
+		}
+		return 0, errors.New(msg)
+	}
+	return 0, errInputMismatch
+}
+
+func parseScannerFloating(token string, bits int) (float64, error) {
+	token = removeGrouping(token)
+	if !scannerFloating.MatchString(token) {
+		return 0, errInputMismatch
+	}
+	return parseFloating(token, bits)
+}
+
+// === natives ===
+
+// the exceptions the Scanner throws
+func noSuchElement(msg string) error {
+	return throwNativeException(exceptions.NoSuchElementException, "java.util.NoSuchElementException", msg)
+}
+
+func inputMismatch(msg string) error {
+	return throwNativeException(exceptions.NoSuchElementException, "java.util.InputMismatchException", msg)
+}
+
+// scannerThis returns the Go state of the Scanner in params[0], and an
+// exception if it's been closed
+// hasScanner is true if the Scanner in params[0] was created by the Go
+// functions here, which handle only those Scanners. The JDK's code handles
+// the Scanners of other sources, such as a FileInputStream.
+func hasScanner(params []interface{}) bool {
+	this, _ := params[0].(*object.Object)
+	if this == nil || this.FieldTable == nil {
+		return false
+	}
+	_, ok := this.FieldTable[scannerField].Fvalue.(*scanner)
+	return ok
+}
+
+func scannerThis(params []interface{}) (*scanner, error) {
+	this := params[0].(*object.Object)
+	s, ok := this.FieldTable[scannerField].Fvalue.(*scanner)
+	if !ok || s.closed {
+		return nil, throwNativeException(exceptions.IllegalStateException,
+			"java.lang.IllegalStateException", "Scanner closed")
+	}
+	return s, nil
+}
+
+func setScanner(this *object.Object, s *scanner) {
+	if this.FieldTable == nil {
+		this.FieldTable = make(map[string]object.Field)
+	}
+	this.FieldTable[scannerField] = object.Field{Ftype: types.GoObject, Fvalue: s}
+}
+
+// Scanner(InputStream) and Scanner(InputStream, String charsetName). The
+// input is always decoded as UTF-8.
+func scannerInitStream(params []interface{}) interface{} {
+	in, _ := params[1].(*object.Object)
+	if in == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Scanner.<init>(): null InputStream")
+	}
+	is, ok := getInputStream(in)
+	if !ok {
+		return unsupportedStream(in)
+	}
+	setScanner(params[0].(*object.Object), &scanner{src: is})
+	return nil
+}
+
+// Scanner(String)
+func scannerInitString(params []interface{}) interface{} {
+	str, _ := params[1].(*object.Object)
+	if str == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "Scanner.<init>(): null String")
+	}
+	src := &inputStream{in: bufio.NewReader(strings.NewReader(object.GetGoStringFromJavaStringPtr(str)))}
+	setScanner(params[0].(*object.Object), &scanner{src: src})
+	return nil
+}
+
+func scannerHasNext(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	_, _, ok := s.token()
+	return types.ConvertGoBoolToJavaBool(ok)
+}
+
+func scannerNext(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, end, ok := s.token()
+	if !ok {
+		return noSuchElement("")
+	}
+	s.consume(end)
+	return goStringToJava(token)
+}
+
+func scannerHasNextLine(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	return types.ConvertGoBoolToJavaBool(s.fill(1))
+}
+
+func scannerNextLine(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	line, ok := s.line()
+	if !ok {
+		return noSuchElement("No line found")
+	}
+	return goStringToJava(line)
+}
+
+// radixParam returns the radix passed to methods such as nextInt(int radix), or 10
+func radixParam(params []interface{}) int64 {
+	if len(params) > 1 {
+		return params[1].(int64)
+	}
+	return 10
+}
+
+// hasNextInt(), hasNextLong(), etc., with or without a radix
+func scannerHasNextIntegral(params []interface{}, bits int) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, _, ok := s.token()
+	if !ok {
+		return types.JavaBoolFalse
+	}
+	_, err = parseIntegral(token, radixParam(params), bits)
+	return types.ConvertGoBoolToJavaBool(err == nil)
+}
+
+// nextInt(), nextLong(), etc., with or without a radix. If the token is not
+// an integer in range, an InputMismatchException is thrown and the token is
+// not consumed.
+func scannerNextIntegral(params []interface{}, bits int) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, end, ok := s.token()
+	if !ok {
+		return noSuchElement("")
+	}
+	value, err := parseIntegral(token, radixParam(params), bits)
+	if err == errInputMismatch {
+		return inputMismatch("")
+	} else if err != nil {
+		return inputMismatch(err.Error())
+	}
+	s.consume(end)
+	return value
+}
+
+func scannerHasNextFloating(params []interface{}, bits int) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, _, ok := s.token()
+	if !ok {
+		return types.JavaBoolFalse
+	}
+	_, err = parseScannerFloating(token, bits)
+	return types.ConvertGoBoolToJavaBool(err == nil)
+}
+
+// nextDouble() and nextFloat()
+func scannerNextFloating(params []interface{}, bits int) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, end, ok := s.token()
+	if !ok {
+		return noSuchElement("")
+	}
+	value, err := parseScannerFloating(token, bits)
+	if err != nil {
+		return inputMismatch("")
+	}
+	s.consume(end)
+	return value
+}
+
+func scannerHasNextBoolean(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, _, ok := s.token()
+	return types.ConvertGoBoolToJavaBool(ok && (strings.EqualFold(token, "true") || strings.EqualFold(token, "false")))
+}
+
+// nextBoolean() accepts "true" and "false", ignoring case
+func scannerNextBoolean(params []interface{}) interface{} {
+	s, err := scannerThis(params)
+	if err != nil {
+		return err
+	}
+	token, end, ok := s.token()
+	if !ok {
+		return noSuchElement("")
+	}
+	if !strings.EqualFold(token, "true") && !strings.EqualFold(token, "false") {
+		return inputMismatch("")
+	}
+	s.consume(end)
+	return types.ConvertGoBoolToJavaBool(strings.EqualFold(token, "true"))
+}
+
+// close() closes the Scanner and, as in the JDK, its source. Closing a closed
+// Scanner has no effect.
+func scannerClose(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	if s, ok := this.FieldTable[scannerField].Fvalue.(*scanner); ok && !s.closed {
+		s.src.mutex.Lock()
+		s.src.closed = true
+		s.src.mutex.Unlock()
+		s.closed = true
+	}
+	return nil
+}
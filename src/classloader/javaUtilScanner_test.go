@@ -0,0 +1,115 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"testing"
+)
+
+func scannerOf(s string) *object.Object {
+	sc := object.MakeEmptyObject()
+	scannerInitStream([]interface{}{sc, inputStreamOf(s)})
+	return sc
+}
+
+func TestScannerTokensAndLines(t *testing.T) {
+	sc := scannerOf("  42 -7 1,234\tword\nthe rest of the line\n3.5 true\n")
+
+	if got := scannerHasNextIntegral([]interface{}{sc}, 32); got != types.JavaBoolTrue {
+		t.Errorf("Expected hasNextInt() to be true")
+	}
+	for _, exp := range []int64{42, -7, 1234} {
+		if got := scannerNextIntegral([]interface{}{sc}, 32); got != exp {
+			t.Errorf("Expected nextInt() to return %d, got %v", exp, got)
+		}
+	}
+	if got := scannerHasNextIntegral([]interface{}{sc}, 32); got != types.JavaBoolFalse {
+		t.Errorf("Expected hasNextInt() to be false before a word")
+	}
+	if got := object.GetGoStringFromJavaStringPtr(scannerNext([]interface{}{sc}).(*object.Object)); got != "word" {
+		t.Errorf("Expected next() to return \"word\", got %q", got)
+	}
+
+	// nextLine() after next() returns the rest of the current line, which is empty
+	for _, exp := range []string{"", "the rest of the line"} {
+		if got := object.GetGoStringFromJavaStringPtr(scannerNextLine([]interface{}{sc}).(*object.Object)); got != exp {
+			t.Errorf("Expected nextLine() to return %q, got %q", exp, got)
+		}
+	}
+
+	if got := scannerNextFloating([]interface{}{sc}, 64); got != 3.5 {
+		t.Errorf("Expected nextDouble() to return 3.5, got %v", got)
+	}
+	if got := scannerNextBoolean([]interface{}{sc}); got != types.JavaBoolTrue {
+		t.Errorf("Expected nextBoolean() to return true, got %v", got)
+	}
+	if got := scannerHasNext([]interface{}{sc}); got != types.JavaBoolFalse {
+		t.Errorf("Expected hasNext() to be false at the end of the input")
+	}
+	if got := scannerHasNextLine([]interface{}{sc}); got != types.JavaBoolTrue {
+		t.Errorf("Expected hasNextLine() to be true before the last line terminator")
+	}
+	scannerNextLine([]interface{}{sc})
+	if got := scannerHasNextLine([]interface{}{sc}); got != types.JavaBoolFalse {
+		t.Errorf("Expected hasNextLine() to be false at the end of the input")
+	}
+}
+
+func TestScannerString(t *testing.T) {
+	sc := object.MakeEmptyObject()
+	scannerInitString([]interface{}{sc, jstr("ff 9223372036854775807")})
+
+	if got := scannerNextIntegral([]interface{}{sc, int64(16)}, 32); got != int64(255) {
+		t.Errorf("Expected nextInt(16) to return 255, got %v", got)
+	}
+	if got := scannerNextIntegral([]interface{}{sc}, 64); got != int64(9223372036854775807) {
+		t.Errorf("Expected nextLong() to return Long.MAX_VALUE, got %v", got)
+	}
+}
+
+func TestScannerExceptions(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	sc := scannerOf("abc 99999999999")
+	err, ok := scannerNextIntegral([]interface{}{sc}, 32).(error)
+	if !ok || err.Error() != "java.util.InputMismatchException" {
+		t.Errorf("Expected InputMismatchException from nextInt() on a word, got %v", err)
+	}
+	// the token that didn't match is not consumed
+	if got := object.GetGoStringFromJavaStringPtr(scannerNext([]interface{}{sc}).(*object.Object)); got != "abc" {
+		t.Errorf("Expected next() to return \"abc\", got %q", got)
+	}
+	err, ok = scannerNextIntegral([]interface{}{sc}, 32).(error)
+	if !ok || err.Error() != "java.util.InputMismatchException: For input string: \"99999999999\"" {
+		t.Errorf("Expected InputMismatchException from nextInt() out of range, got %v", err)
+	}
+	scannerNext([]interface{}{sc})
+
+	err, ok = scannerNextLine([]interface{}{sc}).(error)
+	if !ok || err.Error() != "java.util.NoSuchElementException: No line found" {
+		t.Errorf("Expected NoSuchElementException from nextLine() at the end, got %v", err)
+	}
+
+	scannerClose([]interface{}{sc})
+	err, ok = scannerHasNext([]interface{}{sc}).(error)
+	if !ok || err.Error() != "java.lang.IllegalStateException: Scanner closed" {
+		t.Errorf("Expected IllegalStateException from a closed Scanner, got %v", err)
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
@@ -0,0 +1,455 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"jacobin/exceptions"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/types"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Implementation of java/util/ServiceLoader, which finds the providers of a
+// service: the classes that implement an interface (or extend a class). As in
+// the JDK, the providers are found in two places, in this order:
+//   - the provides clauses of the descriptors of the JDK's modules
+//   - the provider-configuration files META-INF/services/<service> that the
+//     ServiceLoader's classloader finds as resources, which list the providers'
+//     names one per line, with # starting a comment
+//
+// Nothing is looked up until the ServiceLoader is iterated, and each provider
+// is instantiated only when the iterator gets to it: with its static provider()
+// method, if it's in a module and has one, or else with its public no-arg
+// constructor. The providers are cached, so later iterations return the same
+// objects, until reload() is called.
+
+const serviceLoaderField = "serviceLoader"
+const serviceIteratorField = "iterator"
+
+// JavaObjectFactory creates an object of the class with its no-arg constructor
+type JavaObjectFactory func(className string) (*object.Object, error)
+
+// StaticMethodRunner runs the static Java method of the class with the given
+// name and type, passing it the arguments, and returns the value it returns.
+type StaticMethodRunner func(className, methName, methType string, args ...interface{}) (interface{}, error)
+
+var javaObjectFactory JavaObjectFactory
+var staticMethodRunner StaticMethodRunner
+
+// SetServiceProviderRunners registers the functions that instantiate service
+// providers, which run Java code. They're set by the jvm package at start-up.
+func SetServiceProviderRunners(factory JavaObjectFactory, runner StaticMethodRunner) {
+	javaObjectFactory = factory
+	staticMethodRunner = runner
+}
+
+// serviceProvider is a provider's class name, in the form com/acme/Impl, and
+// whether it was declared by a module
+type serviceProvider struct {
+	className string
+	inModule  bool
+}
+
+// serviceLoader is the Go state of a ServiceLoader object
+type serviceLoader struct {
+	service   string // in the form com/acme/Service
+	cl        *Classloader
+	providers []serviceProvider // nil until the first lookup
+	instances []*object.Object  // the providers instantiated so far, in order
+	looked    bool
+	mutex     sync.Mutex
+}
+
+// serviceIterator is the Go state of an Iterator of a ServiceLoader
+type serviceIterator struct {
+	sl   *serviceLoader
+	next int
+}
+
+func Load_Util_ServiceLoader() map[string]GMeth {
+
+	MethodSignatures["java/util/ServiceLoader.load(Ljava/lang/Class;)Ljava/util/ServiceLoader;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceLoaderLoad,
+		}
+
+	MethodSignatures["java/util/ServiceLoader.load(Ljava/lang/Class;Ljava/lang/ClassLoader;)Ljava/util/ServiceLoader;"] =
+		GMeth{
+			ParamSlots: 2, // a null classloader stands for the system classloader
+			GFunction:  serviceLoaderLoad,
+		}
+
+	MethodSignatures["java/util/ServiceLoader.loadInstalled(Ljava/lang/Class;)Ljava/util/ServiceLoader;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceLoaderLoadInstalled,
+		}
+
+	MethodSignatures["java/util/ServiceLoader.iterator()Ljava/util/Iterator;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceLoaderIterator,
+		}
+
+	MethodSignatures["java/util/ServiceLoader.reload()V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceLoaderReload,
+		}
+
+	MethodSignatures["java/util/ServiceLoader.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceLoaderToString,
+		}
+
+	MethodSignatures[serviceIteratorClass+".hasNext()Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceIteratorHasNext,
+		}
+
+	MethodSignatures[serviceIteratorClass+".next()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  serviceIteratorNext,
+		}
+
+	return MethodSignatures
+}
+
+// the class of the Iterator objects, which is one of the JDK's iterators of ServiceLoader
+const serviceIteratorClass = "java/util/ServiceLoader$LazyClassPathLookupIterator"
+
+// ---- creating ServiceLoaders ----
+
+func serviceLoaderLoad(params []interface{}) interface{} {
+	cl := &AppCL
+	if len(params) > 1 {
+		if loader, _ := params[1].(*object.Object); loader != nil {
+			if cl = goClassloader(loader); cl == nil {
+				return throwNativeException(exceptions.SecurityException,
+					"java.lang.SecurityException", "ClassLoader object not initialized")
+			}
+		}
+	}
+	return newServiceLoader(params[0], cl)
+}
+
+// loadInstalled() finds only the providers in the JDK, with the platform classloader
+func serviceLoaderLoadInstalled(params []interface{}) interface{} {
+	return newServiceLoader(params[0], &ExtensionCL)
+}
+
+func newServiceLoader(service interface{}, cl *Classloader) interface{} {
+	clazz, _ := service.(*object.Object)
+	if clazz == nil || clazz.FieldTable == nil {
+		return throwNativeException(exceptions.NullPointerException,
+			"java.lang.NullPointerException", "ServiceLoader.load(): null service")
+	}
+	name, _ := clazz.FieldTable["name"].Fvalue.(*object.Object)
+	sl := &serviceLoader{service: strings.ReplaceAll(javaToString(name), ".", "/"), cl: cl}
+
+	obj := object.MakeEmptyObject()
+	className := "java/util/ServiceLoader"
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		serviceLoaderField: {Ftype: types.GoObject, Fvalue: sl},
+	}
+	return obj
+}
+
+func serviceLoaderThis(params []interface{}) (*serviceLoader, error) {
+	obj, _ := params[0].(*object.Object)
+	if obj != nil && obj.FieldTable != nil {
+		if sl, ok := obj.FieldTable[serviceLoaderField].Fvalue.(*serviceLoader); ok {
+			return sl, nil
+		}
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of the ServiceLoader")
+}
+
+// ---- iterating ----
+
+func serviceLoaderIterator(params []interface{}) interface{} {
+	sl, err := serviceLoaderThis(params)
+	if err != nil {
+		return err
+	}
+	obj := object.MakeEmptyObject()
+	className := serviceIteratorClass
+	obj.Klass = &className
+	obj.FieldTable = map[string]object.Field{
+		serviceIteratorField: {Ftype: types.GoObject, Fvalue: &serviceIterator{sl: sl}},
+	}
+	return obj
+}
+
+// reload() clears the cache of providers, so they're looked up again
+func serviceLoaderReload(params []interface{}) interface{} {
+	sl, err := serviceLoaderThis(params)
+	if err != nil {
+		return err
+	}
+	sl.mutex.Lock()
+	sl.providers, sl.instances, sl.looked = nil, nil, false
+	sl.mutex.Unlock()
+	return nil
+}
+
+func serviceLoaderToString(params []interface{}) interface{} {
+	sl, err := serviceLoaderThis(params)
+	if err != nil {
+		return err
+	}
+	return goStringToJava("java.util.ServiceLoader[" + strings.ReplaceAll(sl.service, "/", ".") + "]")
+}
+
+func serviceIteratorThis(params []interface{}) (*serviceIterator, error) {
+	obj, _ := params[0].(*object.Object)
+	if obj != nil && obj.FieldTable != nil {
+		if it, ok := obj.FieldTable[serviceIteratorField].Fvalue.(*serviceIterator); ok {
+			return it, nil
+		}
+	}
+	return nil, throwNativeException(exceptions.UnsupportedOperationException,
+		"java.lang.UnsupportedOperationException", "no Go implementation of the Iterator")
+}
+
+func serviceIteratorHasNext(params []interface{}) interface{} {
+	it, err := serviceIteratorThis(params)
+	if err != nil {
+		return err
+	}
+	it.sl.mutex.Lock()
+	defer it.sl.mutex.Unlock()
+	it.sl.lookup()
+	return types.ConvertGoBoolToJavaBool(it.next < len(it.sl.providers))
+}
+
+// next() returns the next provider, instantiating it if no iterator has done so yet.
+// A provider that can't be instantiated is a ServiceConfigurationError, after
+// which the iterator goes on to the next provider.
+func serviceIteratorNext(params []interface{}) interface{} {
+	it, err := serviceIteratorThis(params)
+	if err != nil {
+		return err
+	}
+	sl := it.sl
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+	sl.lookup()
+	if it.next >= len(sl.providers) {
+		return throwNativeException(exceptions.NoSuchElementException, "java.util.NoSuchElementException", "")
+	}
+
+	index := it.next
+	if index < len(sl.instances) {
+		it.next++
+		return sl.instances[index]
+	}
+	provider, err := sl.instantiate(sl.providers[index])
+	if err != nil { // the provider is dropped, so the iterator is then at the next one
+		sl.providers = append(sl.providers[:index], sl.providers[index+1:]...)
+		return throwNativeException(exceptions.ServiceConfigurationError, "java.util.ServiceConfigurationError",
+			strings.ReplaceAll(sl.service, "/", ".")+": "+err.Error())
+	}
+	it.next++
+	sl.instances = append(sl.instances, provider)
+	return provider
+}
+
+// ---- finding and instantiating providers ----
+
+// lookup finds the names of the providers, the first time it's called. The
+// caller holds the mutex.
+func (sl *serviceLoader) lookup() {
+	if sl.looked {
+		return
+	}
+	sl.looked = true
+	seen := make(map[string]bool)
+	for _, className := range moduleProviders()[sl.service] {
+		if !seen[className] {
+			seen[className] = true
+			sl.providers = append(sl.providers, serviceProvider{className: className, inModule: true})
+		}
+	}
+	configName := "META-INF/services/" + strings.ReplaceAll(sl.service, "/", ".")
+	for _, r := range sl.cl.getResources(configName) {
+		data, err := r.read()
+		if err != nil {
+			_ = log.Log("ServiceLoader: cannot read "+r.url+": "+err.Error(), log.WARNING)
+			continue
+		}
+		for _, className := range parseProviderConfig(data) {
+			if !seen[className] {
+				seen[className] = true
+				sl.providers = append(sl.providers, serviceProvider{className: className})
+			}
+		}
+	}
+	_ = log.Log(fmt.Sprintf("ServiceLoader: %d providers of %s", len(sl.providers), sl.service), log.CLASS)
+}
+
+// parseProviderConfig returns the class names, in the form com/acme/Impl, in a
+// provider-configuration file
+func parseProviderConfig(data []byte) []string {
+	var names []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\uFEFF")) // the file is UTF-8, possibly with a BOM
+		if line != "" {
+			names = append(names, strings.ReplaceAll(line, ".", "/"))
+		}
+	}
+	return names
+}
+
+// instantiate loads the provider's class and creates the provider. The error
+// messages are those of the JDK's ServiceConfigurationErrors.
+func (sl *serviceLoader) instantiate(p serviceProvider) (*object.Object, error) {
+	dottedName := strings.ReplaceAll(p.className, "/", ".")
+	if strings.ContainsAny(p.className, " \t") {
+		return nil, fmt.Errorf("Illegal provider-class name: %s", dottedName)
+	}
+	cl := sl.cl
+	if p.inModule {
+		cl = &BootstrapCL
+	}
+	k, err := cl.LoadClass(p.className)
+	if err != nil {
+		return nil, fmt.Errorf("Provider %s not found", dottedName)
+	}
+	loadSupertypes(cl, k)
+	if !implementsInterface(k.Key(), k.RefKey(sl.service)) {
+		return nil, fmt.Errorf("Provider %s not a subtype", dottedName)
+	}
+	if javaObjectFactory == nil || staticMethodRunner == nil {
+		return nil, fmt.Errorf("Provider %s could not be instantiated", dottedName)
+	}
+
+	var ret interface{}
+	if methType, ok := providerMethod(k); ok && p.inModule {
+		ret, err = staticMethodRunner(k.Key(), "provider", methType)
+	} else if hasPublicNoArgConstructor(k) && k.Data.Access.ClassIsPublic {
+		ret, err = javaObjectFactory(k.Key())
+	} else {
+		return nil, fmt.Errorf("Provider %s does not have a public no-arg constructor", dottedName)
+	}
+	provider, _ := ret.(*object.Object)
+	if err != nil || provider == nil {
+		return nil, fmt.Errorf("Provider %s could not be instantiated", dottedName)
+	}
+	return provider, nil
+}
+
+// loadSupertypes loads the superclasses and the interfaces of the class, so that
+// implementsInterface() can check the whole hierarchy
+func loadSupertypes(cl *Classloader, k *Klass) {
+	supertypes := []string{k.Data.Superclass}
+	for _, idx := range k.Data.Interfaces {
+		if int(idx) < len(k.Data.CP.Utf8Refs) {
+			supertypes = append(supertypes, k.Data.CP.Utf8Refs[idx])
+		}
+	}
+	for _, name := range supertypes {
+		if name == "" || name == "java/lang/Object" || MethAreaFetch(k.RefKey(name)) != nil {
+			continue
+		}
+		if super, err := cl.LoadClass(name); err == nil {
+			loadSupertypes(cl, super)
+		}
+	}
+}
+
+// the access flags of methods
+const accPublic = 0x0001
+const accStatic = 0x0008
+
+// providerMethod returns the type of the class's public static provider()
+// method, which takes no arguments, if it has one
+func providerMethod(k *Klass) (string, bool) {
+	for _, m := range k.Data.Methods {
+		desc := k.Data.CP.Utf8Refs[m.Desc]
+		if k.Data.CP.Utf8Refs[m.Name] == "provider" && strings.HasPrefix(desc, "()") &&
+			m.AccessFlags&(accPublic|accStatic) == accPublic|accStatic {
+			return desc, true
+		}
+	}
+	return "", false
+}
+
+func hasPublicNoArgConstructor(k *Klass) bool {
+	for _, m := range k.Data.Methods {
+		if k.Data.CP.Utf8Refs[m.Name] == "<init>" && k.Data.CP.Utf8Refs[m.Desc] == "()V" &&
+			m.AccessFlags&accPublic != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// the service providers declared by the JDK's modules, by service, which are
+// read from the modules' descriptors on first use
+var moduleProvidersMap map[string][]string
+var moduleProvidersMutex sync.Mutex
+
+func moduleProviders() map[string][]string {
+	moduleProvidersMutex.Lock()
+	defer moduleProvidersMutex.Unlock()
+	if moduleProvidersMap != nil {
+		return moduleProvidersMap
+	}
+
+	moduleProvidersMap = make(map[string][]string)
+	if RuntimeImage != nil {
+		for _, module := range RuntimeImage.Modules() {
+			data, err := RuntimeImage.ReadResource("/" + module + "/module-info.class")
+			if err == nil {
+				addModuleProviders(module, data)
+			}
+		}
+		return moduleProvidersMap
+	}
+
+	jmodsDir := filepath.Join(globals.GetGlobalRef().JavaHome, "jmods")
+	jmods, _ := filepath.Glob(filepath.Join(jmodsDir, "*.jmod"))
+	sort.Strings(jmods)
+	for _, jmod := range jmods {
+		jmodFileName := filepath.Base(jmod)
+		data, err := getJmodEntryBytes(jmodFileName, "classes/module-info.class")
+		if err != nil {
+			continue
+		}
+		addModuleProviders(jmodFileName, data)
+	}
+	return moduleProvidersMap
+}
+
+// addModuleProviders adds the providers in a module's descriptor to moduleProvidersMap
+func addModuleProviders(source string, moduleInfoBytes []byte) {
+	info, err := parseModuleInfo(moduleInfoBytes)
+	if err != nil {
+		_ = log.Log("ServiceLoader: "+source+": "+err.Error(), log.WARNING)
+		return
+	}
+	for service, providers := range info.provides {
+		moduleProvidersMap[service] = append(moduleProvidersMap[service], providers...)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"encoding/binary"
+	"errors"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// moduleInfoBytes returns a module-info.class of the module com.acme, which
+// provides com/acme/Service with com/acme/Impl and com/acme/Impl2
+func moduleInfoBytes() []byte {
+	var b []byte
+	u2 := func(v int) { b = binary.BigEndian.AppendUint16(b, uint16(v)) }
+	utf8 := func(s string) { b = append(b, cpUtf8); u2(len(s)); b = append(b, s...) }
+
+	b = append(b, 0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 61)
+	u2(14)
+	utf8("module-info")                           // 1
+	b = append(b, cpClass, 0, 1)                  // 2
+	utf8("Module")                                // 3
+	utf8("com.acme")                              // 4
+	b = append(b, cpModule, 0, 4)                 // 5
+	utf8("com/acme/Service")                      // 6
+	b = append(b, cpClass, 0, 6)                  // 7
+	utf8("com/acme/Impl")                         // 8
+	b = append(b, cpClass, 0, 8)                  // 9
+	b = append(b, cpLong, 0, 0, 0, 0, 0, 0, 0, 1) // 10 and 11
+	utf8("com/acme/Impl2")                        // 12
+	b = append(b, cpClass, 0, 12)                 // 13
+	u2(0x8000)                                    // ACC_MODULE
+	u2(2)
+	u2(0)
+	u2(0) // interfaces
+	u2(0) // fields
+	u2(0) // methods
+
+	var attr []byte
+	for _, v := range []int{5, 0, 0, 1, 5, 0, 0, 0, 0, 1, 7, 1, 7, 2, 9, 13} {
+		attr = binary.BigEndian.AppendUint16(attr, uint16(v))
+	}
+	u2(1)
+	u2(3)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(attr)))
+	return append(b, attr...)
+}
+
+func TestParseModuleInfo(t *testing.T) {
+	info, err := parseModuleInfo(moduleInfoBytes())
+	if err != nil {
+		t.Fatalf("Unexpected error parsing the module descriptor: %v", err)
+	}
+	providers := info.provides["com/acme/Service"]
+	if info.name != "com.acme" || len(providers) != 2 || providers[0] != "com/acme/Impl" ||
+		providers[1] != "com/acme/Impl2" {
+		t.Errorf("Expected com.acme to provide com/acme/Service with two classes, got %s %v", info.name, info.provides)
+	}
+
+	truncated := moduleInfoBytes()
+	if _, err = parseModuleInfo(truncated[:len(truncated)-1]); !errors.Is(err, errBadModuleInfo) {
+		t.Errorf("Expected an error for a truncated module descriptor, got %v", err)
+	}
+}
+
+func TestParseProviderConfig(t *testing.T) {
+	names := parseProviderConfig([]byte("\uFEFF# providers\ncom.acme.Impl  # the default\n\n\tcom.acme.Impl2\r\n"))
+	if len(names) != 2 || names[0] != "com/acme/Impl" || names[1] != "com/acme/Impl2" {
+		t.Errorf("Expected two provider names, got %v", names)
+	}
+}
+
+// serviceClass defines a class of the app classloader, which implements com/acme/Service if impl is true
+func serviceClass(name string, impl bool, methods ...Method) {
+	k := &Klass{Status: 'X', Loader: "app", Data: &ClData{
+		Name:       name,
+		Superclass: "java/lang/Object",
+		CP:         CPool{Utf8Refs: []string{"com/acme/Service", "<init>", "()V", "provider", "()Lcom/acme/Service;"}},
+		Methods:    methods,
+		Access:     AccessFlags{ClassIsPublic: true},
+	}}
+	if impl {
+		k.Data.Interfaces = []uint16{0}
+	}
+	MethAreaInsert(name, k)
+	AppCL.recordClass(name, k)
+}
+
+func TestServiceLoader(t *testing.T) {
+	defer initClassLoaderTest(t)()
+	defer func() { moduleProvidersMap = nil }()
+	defer SetServiceProviderRunners(nil, nil)
+
+	dir := globals.GetGlobalRef().Classpath[0]
+	_ = os.MkdirAll(filepath.Join(dir, "META-INF", "services"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "META-INF", "services", "com.acme.Service"),
+		[]byte("com.acme.Impl\ncom.acme.Missing\ncom.acme.NotImpl\ncom.acme.Impl # again\n"), 0644)
+
+	constructor := Method{AccessFlags: accPublic, Name: 1, Desc: 2}
+	provider := Method{AccessFlags: accPublic | accStatic, Name: 3, Desc: 4}
+	serviceClass("com/acme/Service", false)
+	serviceClass("com/acme/Impl", true, constructor)
+	serviceClass("com/acme/NotImpl", false, constructor)
+	serviceClass("com/acme/ModuleImpl", true, provider)
+	BootstrapCL.recordClass("com/acme/ModuleImpl", MethAreaFetch("com/acme/ModuleImpl")) // as if it were in a jmod
+	moduleProvidersMap = map[string][]string{"com/acme/Service": {"com/acme/ModuleImpl"}}
+
+	var created []string
+	SetServiceProviderRunners(func(className string) (*object.Object, error) {
+		created = append(created, "new "+className)
+		obj := object.MakeEmptyObject()
+		obj.Klass = &className
+		return obj, nil
+	}, func(className, methName, methType string, args ...interface{}) (interface{}, error) {
+		created = append(created, className+"."+methName+methType)
+		obj := object.MakeEmptyObject()
+		obj.Klass = &className
+		return obj, nil
+	})
+
+	sl := serviceLoaderLoad([]interface{}{getClassObject("com/acme/Service"), object.Null})
+	it := serviceLoaderIterator([]interface{}{sl})
+	if len(created) != 0 {
+		t.Errorf("Expected no providers to be instantiated before iterating, got %v", created)
+	}
+
+	// the module's provider comes first, then the class path's, with errors for the bad ones
+	var results []interface{}
+	for serviceIteratorHasNext([]interface{}{it}) == types.JavaBoolTrue {
+		results = append(results, serviceIteratorNext([]interface{}{it}))
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected four results, got %v", results)
+	}
+	if obj, ok := results[0].(*object.Object); !ok || *obj.Klass != "com/acme/ModuleImpl" {
+		t.Errorf("Expected the module's provider first, got %v", results[0])
+	}
+	if obj, ok := results[1].(*object.Object); !ok || *obj.Klass != "com/acme/Impl" {
+		t.Errorf("Expected the class path's provider next, got %v", results[1])
+	}
+	if err, ok := results[2].(error); !ok ||
+		err.Error() != "java.util.ServiceConfigurationError: com.acme.Service: Provider com.acme.Missing not found" {
+		t.Errorf("Expected a ServiceConfigurationError for a missing provider, got %v", results[2])
+	}
+	if err, ok := results[3].(error); !ok ||
+		err.Error() != "java.util.ServiceConfigurationError: com.acme.Service: Provider com.acme.NotImpl not a subtype" {
+		t.Errorf("Expected a ServiceConfigurationError for a class that's not a provider, got %v", results[3])
+	}
+	if len(created) != 2 || created[0] != "com/acme/ModuleImpl.provider()Lcom/acme/Service;" ||
+		created[1] != "new com/acme/Impl" {
+		t.Errorf("Expected the providers to be created by provider() and the constructor, got %v", created)
+	}
+
+	// a second iteration returns the same providers, until reload()
+	it = serviceLoaderIterator([]interface{}{sl})
+	serviceIteratorNext([]interface{}{it})
+	if serviceIteratorNext([]interface{}{it}) != results[1] || len(created) != 2 {
+		t.Errorf("Expected a second iteration to return the cached providers")
+	}
+	if serviceIteratorHasNext([]interface{}{it}) != types.JavaBoolFalse {
+		t.Errorf("Expected the providers that failed to be dropped")
+	}
+	serviceLoaderReload([]interface{}{sl})
+	it = serviceLoaderIterator([]interface{}{sl})
+	serviceIteratorNext([]interface{}{it})
+	if len(created) != 3 {
+		t.Errorf("Expected reload() to clear the cache of providers, got %v", created)
+	}
+}
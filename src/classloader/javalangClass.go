@@ -12,6 +12,9 @@ import (
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/shutdown"
+	"jacobin/types"
+	"strings"
+	"sync"
 )
 
 // Implementation of some of the functions in in Java/lang/Class.
@@ -28,24 +31,56 @@ func Load_Lang_Class() map[string]GMeth {
 }
 
 // getPrimitiveClass() takes a one-word descriptor of a primitive and
-// returns the native primitive class that corresponds to it. Not
-// quite sure how this class is used, but it needs to implemented.
+// returns the Class object that represents it. The wrapper classes use
+// this to initialize their TYPE fields, e.g., Integer.TYPE is int.class.
 func getPrimitiveClass(params []interface{}) interface{} {
 	primitive := params[0].(*object.Object)
 	str := object.GetGoStringFromJavaStringPtr(primitive)
-	if str == "int" {
-		k, err := simpleClassLoadByName("java/lang/Integer")
-		if err != nil {
-			return errors.New("getPrimitiveClass() could not load java/lang/Integer")
-		} else {
-			return k
-		}
-	} else {
+	switch str {
+	case "int", "long", "short", "byte", "char", "boolean", "float", "double", "void":
+		return getClassObject(str)
+	default:
 		errMsg := fmt.Sprintf("getPrimitiveClass() does not handle: %s", str)
 		_ = log.Log(errMsg, log.SEVERE)
 		return errors.New(errMsg)
+	}
+}
+
+// classObjects holds the java/lang/Class instance for each class, keyed by
+// the class's key in the method area (see ClassKey), so that classes with the
+// same name defined by different classloaders have different Class objects.
+// Java requires that there be only one Class object per class, so that, e.g.,
+// a.getClass() == b.getClass() for objects of the same class.
+var classObjects = make(map[string]*object.Object)
+var classObjectsMutex sync.Mutex
+
+var classClassName = "java/lang/Class"
+
+// the field of a Class object that holds the key of its class
+const classKeyField = "key"
+
+// getClassObject returns the java/lang/Class instance for the class with the
+// key, creating it on first reference. The instance's name field holds the class
+// name in the format returned by Class.getName(), e.g. java.lang.String
+func getClassObject(key string) *object.Object {
+	classObjectsMutex.Lock()
+	defer classObjectsMutex.Unlock()
+
+	if clazz, ok := classObjects[key]; ok {
+		return clazz
+	}
 
+	name := strings.ReplaceAll(ClassNameOfKey(key), "/", ".")
+	clazz := object.MakeEmptyObject()
+	clazz.Klass = &classClassName
+	clazz.FieldTable = make(map[string]object.Field)
+	clazz.FieldTable["name"] = object.Field{
+		Ftype:  "Ljava/lang/String;",
+		Fvalue: object.CreateCompactStringFromGoString(&name),
 	}
+	clazz.FieldTable[classKeyField] = object.Field{Ftype: types.GoObject, Fvalue: key}
+	classObjects[key] = clazz
+	return clazz
 }
 
 // simpleClassLoadByName() just checks the MethodArea cache for the loaded
@@ -0,0 +1,520 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A jimage is the file lib/modules of a JDK or JRE, which holds the classes and
+// resources of all its modules. Unlike the jmod files, it's in every runtime
+// image, including those built by jlink for containers. It's laid out as:
+//
+//	header       7 ints: magic, version, flags, resource count, table length,
+//	             size of the locations and size of the strings
+//	redirect     table length ints, which lead from a name's hash to its slot
+//	offsets      table length ints, the offset of each slot's location
+//	locations    the attributes of each resource: its name, which is in four
+//	             parts (module, parent, base and extension), each of which is
+//	             an offset into the strings; and its offset and sizes
+//	strings      the strings, each terminated by a zero byte
+//	resources    the contents of the resources, some of them compressed
+//
+// The header and tables are in the byte order of the platform on which the
+// image was built. A resource is found by hashing its full name, which is of
+// the form /java.base/java/lang/Object.class, and looking up the hash in the
+// redirect table, which is a perfect hash. Jacobin reads the index into memory
+// and the resources from the file when they're needed.
+// Reference: the JDK's jdk.internal.jimage package
+
+const jimageMagic = 0xCAFEDADA
+const jimageHeaderSize = 7 * 4
+const jimageMajorVersion = 1
+
+// the kinds of a location's attributes
+const (
+	locEnd = iota
+	locModule
+	locParent
+	locBase
+	locExtension
+	locOffset
+	locCompressed
+	locUncompressed
+	locCount
+)
+
+// the multiplier and initial seed of the hash of names
+const jimageHashMultiplier = 0x01000193
+
+// a compressed resource starts with a header: magic, compressed size,
+// uncompressed size, the decompressor's name (an offset into the strings), the
+// decompressor's configuration and whether it's the last compression
+const compressedMagic = 0xCAFEFAFA
+const compressedHeaderSize = 4 + 8 + 8 + 4 + 4 + 1
+
+// JImage is an open jimage file.
+type JImage struct {
+	Filename  string
+	file      *os.File
+	order     binary.ByteOrder
+	redirect  []int32
+	offsets   []uint32
+	locations []byte
+	strings   []byte
+	dataStart int64             // the offset of the resources in the file
+	packages  map[string]string // the module of each package, by package name in the form java/lang
+	modules   []string          // the modules, in the order they're first found
+}
+
+// jimageLocation holds the attributes of a resource, indexed by their kinds
+type jimageLocation [locCount]uint64
+
+// OpenJImage opens the jimage file and reads its index.
+func OpenJImage(filename string) (*JImage, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	img, err := readJImageIndex(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("jimage %s: %w", filename, err)
+	}
+	img.Filename = filename
+	return img, nil
+}
+
+func readJImageIndex(file *os.File) (*JImage, error) {
+	header := make([]byte, jimageHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, errors.New("cannot read header")
+	}
+	img := &JImage{file: file}
+	if binary.LittleEndian.Uint32(header) == jimageMagic {
+		img.order = binary.LittleEndian
+	} else if binary.BigEndian.Uint32(header) == jimageMagic {
+		img.order = binary.BigEndian
+	} else {
+		return nil, errors.New("not a jimage file")
+	}
+	if version := img.order.Uint32(header[4:]); version>>16 != jimageMajorVersion {
+		return nil, fmt.Errorf("unsupported version %d.%d", version>>16, version&0xFFFF)
+	}
+	tableLength := int64(img.order.Uint32(header[16:]))
+	locationsSize := int64(img.order.Uint32(header[20:]))
+	stringsSize := int64(img.order.Uint32(header[24:]))
+
+	index := make([]byte, 8*tableLength+locationsSize+stringsSize)
+	if _, err := file.ReadAt(index, jimageHeaderSize); err != nil {
+		return nil, errors.New("cannot read index")
+	}
+	img.redirect = make([]int32, tableLength)
+	img.offsets = make([]uint32, tableLength)
+	for i := int64(0); i < tableLength; i++ {
+		img.redirect[i] = int32(img.order.Uint32(index[4*i:]))
+		img.offsets[i] = img.order.Uint32(index[4*(tableLength+i):])
+	}
+	img.locations = index[8*tableLength : 8*tableLength+locationsSize]
+	img.strings = index[8*tableLength+locationsSize:]
+	img.dataStart = jimageHeaderSize + int64(len(index))
+
+	img.packages = make(map[string]string)
+	seen := make(map[string]bool)
+	for slot := range img.offsets {
+		loc, err := img.location(slot)
+		if err != nil {
+			return nil, err
+		}
+		module := img.getString(loc[locModule])
+		if module == "" || module == "modules" || module == "packages" { // the directories of the image
+			continue
+		}
+		if !seen[module] {
+			seen[module] = true
+			img.modules = append(img.modules, module)
+		}
+		if pkg := img.getString(loc[locParent]); pkg != "" && img.getString(loc[locExtension]) == "class" {
+			img.packages[pkg] = module
+		}
+	}
+	return img, nil
+}
+
+// Close closes the jimage file.
+func (img *JImage) Close() error {
+	return img.file.Close()
+}
+
+// getString returns the string at the offset in the strings
+func (img *JImage) getString(offset uint64) string {
+	if offset >= uint64(len(img.strings)) {
+		return ""
+	}
+	str := img.strings[offset:]
+	if end := bytes.IndexByte(str, 0); end >= 0 {
+		str = str[:end]
+	}
+	return string(str)
+}
+
+// location decodes the attributes of the location in the slot. Each attribute
+// is a byte, whose top five bits are its kind and bottom three its length less
+// one, followed by its value in that many big-endian bytes.
+func (img *JImage) location(slot int) (jimageLocation, error) {
+	var loc jimageLocation
+	pos := int(img.offsets[slot])
+	for pos < len(img.locations) {
+		b := img.locations[pos]
+		kind, length := int(b>>3), int(b&7)+1
+		if kind == locEnd {
+			break
+		}
+		if kind >= locCount || pos+length >= len(img.locations) {
+			return loc, fmt.Errorf("invalid location attribute %d", kind)
+		}
+		var value uint64
+		for _, v := range img.locations[pos+1 : pos+1+length] {
+			value = value<<8 | uint64(v)
+		}
+		loc[kind] = value
+		pos += 1 + length
+	}
+	return loc, nil
+}
+
+// name returns the full name of the resource at the location
+func (img *JImage) name(loc jimageLocation) string {
+	var name strings.Builder
+	if loc[locModule] != 0 {
+		name.WriteString("/" + img.getString(loc[locModule]) + "/")
+	}
+	if loc[locParent] != 0 {
+		name.WriteString(img.getString(loc[locParent]) + "/")
+	}
+	name.WriteString(img.getString(loc[locBase]))
+	if loc[locExtension] != 0 {
+		name.WriteString("." + img.getString(loc[locExtension]))
+	}
+	return name.String()
+}
+
+// jimageHash is the hash of names in the redirect table. The JDK hashes the
+// modified UTF-8 of the name, which is the same as UTF-8 except for characters
+// outside the Basic Multilingual Plane, which don't occur in class names.
+func jimageHash(name string, seed int32) int32 {
+	for _, b := range []byte(name) {
+		seed = seed*jimageHashMultiplier ^ int32(b)
+	}
+	return seed & 0x7FFFFFFF
+}
+
+// findLocation returns the location of the resource with the full name
+func (img *JImage) findLocation(name string) (jimageLocation, bool) {
+	length := int32(len(img.redirect))
+	if length == 0 {
+		return jimageLocation{}, false
+	}
+	slot := img.redirect[jimageHash(name, jimageHashMultiplier)%length]
+	if slot < 0 {
+		slot = -slot - 1
+	} else if slot > 0 {
+		slot = jimageHash(name, slot) % length
+	} else {
+		return jimageLocation{}, false
+	}
+
+	loc, err := img.location(int(slot))
+	if err != nil || img.name(loc) != name { // a name not in the image can hash to any slot
+		return jimageLocation{}, false
+	}
+	return loc, true
+}
+
+// ReadResource returns the contents of the resource with the full name, such
+// as /java.base/java/lang/Object.class. If there's no such resource, the error
+// wraps fs.ErrNotExist.
+func (img *JImage) ReadResource(name string) ([]byte, error) {
+	loc, ok := img.findLocation(name)
+	if !ok {
+		return nil, fmt.Errorf("%s in jimage %s: %w", name, img.Filename, fs.ErrNotExist)
+	}
+
+	size := loc[locUncompressed]
+	if loc[locCompressed] != 0 {
+		size = loc[locCompressed]
+	}
+	data := make([]byte, size)
+	if _, err := img.file.ReadAt(data, img.dataStart+int64(loc[locOffset])); err != nil {
+		return nil, fmt.Errorf("%s in jimage %s: %w", name, img.Filename, err)
+	}
+	if loc[locCompressed] == 0 {
+		return data, nil
+	}
+
+	data, err := img.decompress(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s in jimage %s: %w", name, img.Filename, err)
+	}
+	if uint64(len(data)) != loc[locUncompressed] {
+		return nil, fmt.Errorf("%s in jimage %s: decompressed to %d bytes rather than %d",
+			name, img.Filename, len(data), loc[locUncompressed])
+	}
+	return data, nil
+}
+
+// ClassBytes returns the class file of the class, which is in the form java/lang/String.
+func (img *JImage) ClassBytes(className string) ([]byte, error) {
+	module := img.ModuleOf(className)
+	if module == "" {
+		return nil, fmt.Errorf("%s in jimage %s: %w", className, img.Filename, fs.ErrNotExist)
+	}
+	return img.ReadResource("/" + module + "/" + className + ".class")
+}
+
+// ModuleOf returns the module that has the class or the resource, whose name
+// is in the form java/lang/String or java/lang/uniName.dat, or "" if none does.
+func (img *JImage) ModuleOf(name string) string {
+	slash := strings.LastIndex(name, "/")
+	if slash < 0 {
+		return ""
+	}
+	return img.packages[name[:slash]]
+}
+
+// Modules returns the names of the modules in the image.
+func (img *JImage) Modules() []string {
+	return img.modules
+}
+
+// decompress undoes the compression of a resource, which can be compressed
+// more than once, so each compression has its own header. The JDK's
+// compressions are zip and compact-cp (string sharing).
+func (img *JImage) decompress(data []byte) ([]byte, error) {
+	for len(data) >= compressedHeaderSize && img.order.Uint32(data) == compressedMagic {
+		compressedSize := img.order.Uint64(data[4:])
+		decompressor := img.getString(uint64(img.order.Uint32(data[20:])))
+		if compressedSize > uint64(len(data)-compressedHeaderSize) {
+			return nil, errors.New("truncated compressed resource")
+		}
+		content := data[compressedHeaderSize : compressedHeaderSize+compressedSize]
+
+		var err error
+		switch decompressor {
+		case "zip":
+			data, err = inflate(content)
+		case "compact-cp":
+			data, err = img.expandSharedStrings(content)
+		default:
+			err = fmt.Errorf("unsupported decompressor %q", decompressor)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data, which is what the JDK's Inflater reads
+func inflate(content []byte) ([]byte, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// the constant pool tags that compact-cp adds for the strings it shares
+const cpExternalizedString = 23
+const cpExternalizedDescriptor = 25
+
+// the size of each kind of constant pool entry, after its tag
+var cpEntrySizes = map[byte]int{
+	cpInteger: 4, cpFloat: 4, cpLong: 8, cpDouble: 8, cpClass: 2, cpString: 2, cpFieldRef: 4, cpMethodRef: 4,
+	cpInterfaceRef: 4, cpNameAndType: 4, cpMethodHandle: 3, cpMethodType: 2, cpDynamic: 4, cpInvokeDynamic: 4,
+	cpModule: 2, cpPackage: 2,
+}
+
+// expandSharedStrings restores the constant pool of a class file that the
+// compact-cp compression has replaced strings of with references to the jimage's
+// strings. A shared string is an index; a shared descriptor is the descriptor
+// without its class names, followed by the package and name of each class.
+func (img *JImage) expandSharedStrings(content []byte) ([]byte, error) {
+	in := &moduleInfoReader{bytes: content}
+	out := bytes.NewBuffer(make([]byte, 0, len(content)))
+	utf8 := func(str []byte) {
+		out.WriteByte(cpUtf8)
+		_ = binary.Write(out, binary.BigEndian, uint16(len(str)))
+		out.Write(str)
+	}
+
+	in.skip(8) // magic and version
+	count := in.u2()
+	out.Write(content[:10])
+	for i := 1; i < count && in.err == nil; i++ {
+		tag := byte(in.u1())
+		switch tag {
+		case cpUtf8:
+			start := in.pos
+			in.skip(in.u2())
+			out.WriteByte(tag)
+			if in.err == nil {
+				out.Write(content[start:in.pos])
+			}
+		case cpExternalizedString:
+			utf8([]byte(img.getString(uint64(readCompressedIndex(in)))))
+		case cpExternalizedDescriptor:
+			desc := img.getString(uint64(readCompressedIndex(in)))
+			length := readCompressedIndex(in)
+			start := in.pos
+			in.skip(length)
+			if in.err == nil {
+				utf8(img.expandDescriptor(desc, content[start:in.pos]))
+			}
+		default:
+			size, ok := cpEntrySizes[tag]
+			if !ok {
+				return nil, fmt.Errorf("invalid constant pool tag %d", tag)
+			}
+			start := in.pos
+			in.skip(size)
+			out.WriteByte(tag)
+			if in.err == nil {
+				out.Write(content[start:in.pos])
+			}
+			if tag == cpLong || tag == cpDouble {
+				i++
+			}
+		}
+	}
+	if in.err != nil {
+		return nil, in.err
+	}
+	out.Write(content[in.pos:])
+	return out.Bytes(), nil
+}
+
+// expandDescriptor puts the class names back into a descriptor, after each L
+func (img *JImage) expandDescriptor(desc string, indexes []byte) []byte {
+	var expanded []byte
+	r := &moduleInfoReader{bytes: indexes}
+	for i := 0; i < len(desc); i++ {
+		expanded = append(expanded, desc[i])
+		if desc[i] != 'L' {
+			continue
+		}
+		if pkg := img.getString(uint64(readCompressedIndex(r))); pkg != "" {
+			expanded = append(expanded, pkg+"/"...)
+		}
+		expanded = append(expanded, img.getString(uint64(readCompressedIndex(r)))...)
+	}
+	return expanded
+}
+
+// readCompressedIndex reads an index in the compact form used by compact-cp.
+// If the top bit of the first byte is set, the next two bits are the number
+// of bytes, including the first, and its bottom five bits are the top bits of
+// the value. Otherwise, the value is in four bytes.
+func readCompressedIndex(r *moduleInfoReader) int {
+	first := r.u1()
+	length, value := 4, first
+	if first&0x80 != 0 {
+		length, value = (first>>5)&3, first&0x1F
+	}
+	for i := 1; i < length; i++ {
+		value = value<<8 | r.u1()
+	}
+	return value
+}
+
+// RuntimeImage is the jimage that the JDK's classes are loaded from, if
+// $JAVA_HOME has no jmods, as in JREs and the images built by jlink. It's nil
+// when they're loaded from the jmod files.
+var RuntimeImage *JImage
+
+// openRuntimeImage opens $JAVA_HOME/lib/modules, if there's no java.base.jmod,
+// and returns whether it did.
+func openRuntimeImage() bool {
+	if RuntimeImage != nil {
+		_ = RuntimeImage.Close()
+		RuntimeImage = nil
+	}
+	javaHome := globals.GetGlobalRef().JavaHome
+	if _, err := os.Stat(filepath.Join(javaHome, "jmods", BaseJmodFileName)); err == nil {
+		return false
+	}
+	img, err := OpenJImage(filepath.Join(javaHome, "lib", "modules"))
+	if err != nil {
+		_ = log.Log("openRuntimeImage: no java.base.jmod and no usable jimage: "+err.Error(), log.SEVERE)
+		return false
+	}
+	RuntimeImage = img
+	_ = log.Log(fmt.Sprintf("openRuntimeImage: loading the JDK's classes from %s, which has %d modules",
+		img.Filename, len(img.modules)), log.CLASS)
+	return true
+}
+
+// loadBaseClassesFromImage loads the classes of java.base that are in the
+// JDK's lib/classlist, or all of them if there's no classlist, as WalkBaseJmod
+// does for java.base.jmod.
+func loadBaseClassesFromImage() {
+	walkBaseImageClasses(func(className string, classBytes []byte) {
+		// as for the jmod, errors are discarded b/c it's not clear yet a given class is needed.
+		_, _ = ParseAndPostClass(&BootstrapCL, className+".class", classBytes)
+	})
+}
+
+// walkBaseImageClasses calls visit with each class of java.base in the jimage
+// that's in the classlist, or with every class if there's no classlist.
+func walkBaseImageClasses(visit func(className string, classBytes []byte)) {
+	var classes []string
+	classlist, err := os.ReadFile(filepath.Join(globals.GetGlobalRef().JavaHome, "lib", "classlist"))
+	if err == nil {
+		classes = parseClasslist(classlist)
+	} else {
+		_ = log.Log("Unable to read lib/classlist. Loading all classes in java.base.", log.CLASS)
+		classes = RuntimeImage.classesOf("java.base")
+	}
+
+	for _, className := range classes {
+		if RuntimeImage.ModuleOf(className) != "java.base" {
+			continue
+		}
+		classBytes, err := RuntimeImage.ClassBytes(className)
+		if err != nil {
+			continue
+		}
+		visit(className, classBytes)
+	}
+}
+
+// classesOf returns the names of the classes of the module, in the form java/lang/String
+func (img *JImage) classesOf(module string) []string {
+	var classes []string
+	for slot := range img.offsets {
+		loc, err := img.location(slot)
+		if err != nil || img.getString(loc[locModule]) != module || img.getString(loc[locExtension]) != "class" {
+			continue
+		}
+		if parent := img.getString(loc[locParent]); parent != "" {
+			classes = append(classes, parent+"/"+img.getString(loc[locBase]))
+		}
+	}
+	return classes
+}
@@ -0,0 +1,311 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// jimageBuilder writes jimage files for the tests, in little-endian order
+type jimageBuilder struct {
+	strings  []byte
+	offsets  map[string]int
+	names    []string
+	contents [][]byte
+	sizes    []int // the uncompressed sizes of the compressed resources, else 0
+}
+
+func newJImageBuilder() *jimageBuilder {
+	return &jimageBuilder{strings: []byte{0}, offsets: map[string]int{"": 0}}
+}
+
+func (b *jimageBuilder) str(s string) int {
+	if offset, ok := b.offsets[s]; ok {
+		return offset
+	}
+	b.offsets[s] = len(b.strings)
+	b.strings = append(append(b.strings, s...), 0)
+	return b.offsets[s]
+}
+
+func (b *jimageBuilder) add(name string, content []byte) {
+	b.names = append(b.names, name)
+	b.contents = append(b.contents, content)
+	b.sizes = append(b.sizes, 0)
+}
+
+// addCompressed adds a resource compressed by the decompressors, innermost first
+func (b *jimageBuilder) addCompressed(name string, content []byte, decompressors ...string) {
+	size := len(content)
+	for _, decompressor := range decompressors {
+		var compressed []byte
+		if decompressor == "zip" {
+			var buf bytes.Buffer
+			w := zlib.NewWriter(&buf)
+			_, _ = w.Write(content)
+			_ = w.Close()
+			compressed = buf.Bytes()
+		} else {
+			compressed = content // already transformed by the test
+		}
+		header := binary.LittleEndian.AppendUint32(nil, compressedMagic)
+		header = binary.LittleEndian.AppendUint64(header, uint64(len(compressed)))
+		header = binary.LittleEndian.AppendUint64(header, uint64(len(content)))
+		header = binary.LittleEndian.AppendUint32(header, uint32(b.str(decompressor)))
+		header = binary.LittleEndian.AppendUint32(header, 0)
+		content = append(append(header, 0), compressed...)
+	}
+	b.add(name, content)
+	b.sizes[len(b.sizes)-1] = size
+}
+
+func (b *jimageBuilder) write(t *testing.T, filename string) {
+	n := len(b.names)
+	var locations, resources []byte
+	locationOffsets := make([]int, n)
+	for i, name := range b.names {
+		module, rest, _ := strings.Cut(strings.TrimPrefix(name, "/"), "/")
+		parent, file := path.Split(rest)
+		base, ext := file, ""
+		if dot := strings.LastIndex(file, "."); dot >= 0 {
+			base, ext = file[:dot], file[dot+1:]
+		}
+		attrs := [locCount]uint64{0, uint64(b.str(module)), uint64(b.str(strings.TrimSuffix(parent, "/"))),
+			uint64(b.str(base)), uint64(b.str(ext)), uint64(len(resources)), 0, uint64(len(b.contents[i]))}
+		if b.sizes[i] > 0 {
+			attrs[locCompressed], attrs[locUncompressed] = uint64(len(b.contents[i])), uint64(b.sizes[i])
+		}
+		locationOffsets[i] = len(locations)
+		for kind := locModule; kind < locCount; kind++ {
+			if attrs[kind] == 0 {
+				continue
+			}
+			value := binary.BigEndian.AppendUint64(nil, attrs[kind])
+			value = bytes.TrimLeft(value, "\x00")
+			locations = append(append(locations, byte(kind<<3|(len(value)-1))), value...)
+		}
+		locations = append(locations, locEnd)
+		resources = append(resources, b.contents[i]...)
+	}
+
+	// the perfect hash: the buckets with collisions get a seed that spreads
+	// their names over free slots, and the other names get the slots left
+	redirect := make([]int32, n)
+	slots := make([]int, n)
+	for i := range slots {
+		slots[i] = -1
+	}
+	buckets := make(map[int32][]int)
+	for i, name := range b.names {
+		h := jimageHash(name, jimageHashMultiplier) % int32(n)
+		buckets[h] = append(buckets[h], i)
+	}
+	var order []int32
+	for h := range buckets {
+		order = append(order, h)
+	}
+	sort.Slice(order, func(i, j int) bool { return len(buckets[order[i]]) > len(buckets[order[j]]) })
+	for _, h := range order {
+		entries := buckets[h]
+		if len(entries) == 1 {
+			continue
+		}
+	seeds:
+		for seed := int32(1); ; seed++ {
+			used := make(map[int32]bool)
+			for _, i := range entries {
+				slot := jimageHash(b.names[i], seed) % int32(n)
+				if slots[slot] >= 0 || used[slot] {
+					continue seeds
+				}
+				used[slot] = true
+			}
+			for _, i := range entries {
+				slots[jimageHash(b.names[i], seed)%int32(n)] = i
+			}
+			redirect[h] = seed
+			break
+		}
+	}
+	free := 0
+	for _, h := range order {
+		if entries := buckets[h]; len(entries) == 1 {
+			for slots[free] >= 0 {
+				free++
+			}
+			slots[free] = entries[0]
+			redirect[h] = int32(-free - 1)
+		}
+	}
+
+	var image []byte
+	for _, v := range []int{jimageMagic, jimageMajorVersion << 16, 0, n, n, len(locations), len(b.strings)} {
+		image = binary.LittleEndian.AppendUint32(image, uint32(v))
+	}
+	for _, r := range redirect {
+		image = binary.LittleEndian.AppendUint32(image, uint32(r))
+	}
+	for _, i := range slots {
+		image = binary.LittleEndian.AppendUint32(image, uint32(locationOffsets[i]))
+	}
+	image = append(append(append(image, locations...), b.strings...), resources...)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, image, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJImageReadResource(t *testing.T) {
+	b := newJImageBuilder()
+	b.add("/java.base/java/lang/Class.class", ClassBytes)
+	b.add("/java.base/java/lang/uniName.dat", []byte("names"))
+	b.add("/java.base/module-info.class", []byte{0xCA, 0xFE})
+	b.add("/jdk.zipfs/jdk/nio/zipfs/ZipFileSystem.class", Hello2Bytes)
+	for i := 0; i < 100; i++ { // enough names for collisions in the hash table
+		b.add(fmt.Sprintf("/java.base/java/util/C%d.class", i), []byte{byte(i)})
+	}
+	b.addCompressed("/java.base/java/lang/zipped.txt", []byte(strings.Repeat("zipped ", 100)), "zip")
+	filename := filepath.Join(t.TempDir(), "lib", "modules")
+	b.write(t, filename)
+
+	img, err := OpenJImage(filename)
+	if err != nil {
+		t.Fatalf("Unexpected error opening the jimage: %v", err)
+	}
+	defer img.Close()
+
+	for i, name := range b.names {
+		data, err := img.ReadResource(name)
+		if b.sizes[i] == 0 && (err != nil || !bytes.Equal(data, b.contents[i])) {
+			t.Errorf("Expected to read %s, got %v", name, err)
+		}
+	}
+	data, err := img.ReadResource("/java.base/java/lang/zipped.txt")
+	if err != nil || string(data) != strings.Repeat("zipped ", 100) {
+		t.Errorf("Expected the zip-compressed resource to be decompressed, got %q, %v", data, err)
+	}
+	if _, err = img.ReadResource("/java.base/java/lang/Missing.class"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing resource not to exist, got %v", err)
+	}
+
+	data, err = img.ClassBytes("jdk/nio/zipfs/ZipFileSystem")
+	if err != nil || !bytes.Equal(data, Hello2Bytes) {
+		t.Errorf("Expected the class in jdk.zipfs, got %v", err)
+	}
+	if img.ModuleOf("java/lang/Thread") != "java.base" || img.ModuleOf("com/acme/Main") != "" {
+		t.Errorf("Expected the module of a package to be found from its classes")
+	}
+	if modules := img.Modules(); len(modules) != 2 || modules[0] != "java.base" || modules[1] != "jdk.zipfs" {
+		t.Errorf("Expected the modules java.base and jdk.zipfs, got %v", modules)
+	}
+
+	if _, err = OpenJImage(filepath.Join(t.TempDir(), "modules")); err == nil {
+		t.Errorf("Expected an error for a jimage that doesn't exist")
+	}
+	notImage := filepath.Join(t.TempDir(), "modules")
+	_ = os.WriteFile(notImage, make([]byte, 64), 0644)
+	if _, err = OpenJImage(notImage); err == nil || !strings.Contains(err.Error(), "not a jimage file") {
+		t.Errorf("Expected an error for a file that's not a jimage, got %v", err)
+	}
+}
+
+func TestJImageSharedStrings(t *testing.T) {
+	b := newJImageBuilder()
+	name := b.str("java/lang/Object")
+	desc := b.str("(L;)V")
+	pkg := b.str("java/lang")
+	class := b.str("String")
+
+	// a constant pool of a Utf8, a long, a shared string and a shared descriptor, then the rest of the class
+	var shared []byte
+	shared = append(shared, 0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 61, 0, 6)
+	shared = append(shared, cpUtf8, 0, 2, 'h', 'i')
+	shared = append(shared, cpLong, 0, 0, 0, 0, 0, 0, 0, 7)
+	shared = append(shared, cpExternalizedString, 0xC0|byte(name>>8), byte(name))
+	shared = append(shared, cpExternalizedDescriptor, 0, 0, 0, byte(desc), 0xA4)
+	shared = append(shared, 0xC0|byte(pkg>>8), byte(pkg), 0xC0|byte(class>>8), byte(class))
+	shared = append(shared, 0, 0x21)
+
+	var expected []byte
+	expected = append(expected, 0xCA, 0xFE, 0xBA, 0xBE, 0, 0, 0, 61, 0, 6)
+	expected = append(expected, cpUtf8, 0, 2, 'h', 'i')
+	expected = append(expected, cpLong, 0, 0, 0, 0, 0, 0, 0, 7)
+	expected = append(expected, cpUtf8, 0, 16)
+	expected = append(expected, "java/lang/Object"...)
+	expected = append(expected, cpUtf8, 0, 21)
+	expected = append(expected, "(Ljava/lang/String;)V"...)
+	expected = append(expected, 0, 0x21)
+
+	b.addCompressed("/java.base/java/lang/Shared.class", shared, "compact-cp", "zip")
+	filename := filepath.Join(t.TempDir(), "modules")
+	b.write(t, filename)
+	img, err := OpenJImage(filename)
+	if err != nil {
+		t.Fatalf("Unexpected error opening the jimage: %v", err)
+	}
+	defer img.Close()
+
+	data, err := img.decompress(b.contents[0])
+	if err != nil || !bytes.Equal(data, expected) {
+		t.Errorf("Expected the shared strings to be restored, got %v, %v", data, err)
+	}
+}
+
+func TestLoadClassesFromJImage(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	javaHome := g.JavaHome
+	defer func() {
+		globals.GetGlobalRef().JavaHome = javaHome
+		_ = Init()
+	}()
+
+	// a JRE, with lib/modules and lib/classlist, but no jmods
+	jre := t.TempDir()
+	b := newJImageBuilder()
+	b.add("/java.base/java/lang/Class.class", ClassBytes)
+	b.add("/java.base/java/lang/uniName.dat", []byte("names"))
+	b.add("/java.base/java/lang/Thread.class", ClassBytes) // with two names, the parity of their hashes never differs
+	b.write(t, filepath.Join(jre, "lib", "modules"))
+	_ = os.WriteFile(filepath.Join(jre, "lib", "classlist"), []byte("java/lang/Class\n@lambda-proxy java/lang/Class x\n"), 0644)
+	globals.GetGlobalRef().JavaHome = jre
+
+	_ = Init()
+	if RuntimeImage == nil {
+		t.Fatalf("Expected the classes to be loaded from the jimage")
+	}
+	globals.GetGlobalRef().PreloadClasslist = true
+	defer func() { globals.GetGlobalRef().PreloadClasslist = false }()
+	LoadBaseClasses()
+	if k := BootstrapCL.FindLoadedClass("java/lang/Class"); k == nil || k.Loader != "bootstrap" {
+		t.Errorf("Expected LoadBaseClasses() to load java/lang/Class from the jimage")
+	}
+
+	InitMethodArea()
+	BootstrapCL.classes = make(map[string]*Klass)
+	if err := LoadClassFromNameOnly("java/lang/Class"); err != nil || MethAreaFetch("java/lang/Class") == nil {
+		t.Errorf("Expected LoadClassFromNameOnly() to load java/lang/Class from the jimage, got %v", err)
+	}
+	if r := findJmodResource("java/lang/uniName.dat"); r == nil || r.url != "jrt:/java.base/java/lang/uniName.dat" {
+		t.Errorf("Expected the resource in the jimage to be found")
+	}
+}
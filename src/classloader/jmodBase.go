@@ -7,107 +7,78 @@
 package classloader
 
 import (
-	"archive/zip"
-	"bytes"
-	"io"
-	"jacobin/globals"
 	"jacobin/log"
 	"strings"
 )
 
 // Walk the Base Jmod file and invoke ParseAndPostClass for each class found in the classlist
-// Only called in one place: LoadBaseClasses.
+// Only called in one place: LoadBaseClasses, and only if the classlist is to be preloaded.
 func WalkBaseJmod() error {
+	return walkBaseJmodClasses(func(className string, classBytes []byte) {
+		// Parse and post class into MethArea
+		_, _ = ParseAndPostClass(&BootstrapCL, className+".class", classBytes)
+	})
+}
 
-	// Skip over the JMOD header so that it is recognized as a ZIP file
-	global := globals.GetGlobalRef()
-	ioReader := bytes.NewReader(global.JmodBaseBytes[4:])
-	zipReader, err := zip.NewReader(ioReader, int64(len(global.JmodBaseBytes)-4))
+// walkBaseJmodClasses calls visit with each class of the base jmod that's in
+// the classlist, or with every class if there's no classlist.
+func walkBaseJmodClasses(visit func(className string, classBytes []byte)) error {
+
+	archive, err := getJmodArchive(BaseJmodFileName)
 	if err != nil {
 		_ = log.Log(err.Error(), log.WARNING)
 		return err
 	}
 
-	// Get the lib/classlist (bootstrap set of classes) if it exists
-	bootstrapSet := getClasslist(*zipReader)
-	useBootstrapSet := len(bootstrapSet) > 0
-
-	// For each class file in the base jmod,
-	// if it is in the classlist
-	for _, classFile := range zipReader.File {
-
-		// If not prefixed by "classes" or suffixed by ".class", skip this file
-		if !strings.HasPrefix(classFile.Name, "classes") {
-			continue
-		}
-		if !strings.HasSuffix(classFile.Name, ".class") {
-			continue
-		}
-
-		// Remove prefix for bootstrap list check
-		strapFileName := strings.Replace(classFile.Name, "classes/", "", 1)
-
-		// Is there a bootstrap list?
-		if useBootstrapSet {
-			// Yes, make sure that this class is on the list
-			_, onList := bootstrapSet[strapFileName]
-			if !onList {
-				continue
+	// Get the lib/classlist (bootstrap set of classes) if it exists.
+	// Otherwise, every class in the base jmod is loaded.
+	classes := getClasslist(archive)
+	if len(classes) == 0 {
+		for name := range archive.entries {
+			className, isClass := strings.CutSuffix(name, ".class")
+			if strings.HasPrefix(name, "classes/") && isClass {
+				classes = append(classes, strings.TrimPrefix(className, "classes/"))
 			}
 		}
+	}
 
-		// Open the class file
-		rc, err := classFile.Open()
-		if err != nil {
-			return err
-		}
-
-		// Read all of the bytes
-		classBytes, err := io.ReadAll(rc)
+	// The classes are found through the index of the jmod's entries,
+	// so only the classes that are loaded are read.
+	for _, className := range classes {
+		classBytes, err := archive.readEntry("classes/" + className + ".class")
 		if err != nil {
-			return err
+			continue // not every class in the classlist is in java.base
 		}
-		_ = rc.Close()
-
-		// Parse and post class into MethArea
-		ParseAndPostClass(&BootstrapCL, classFile.Name, classBytes)
-
+		visit(className, classBytes)
 	}
 
 	return nil
 }
 
-// getClasslist returns the bootstrap lib/classlist as a Go-language map from the Java installation.
+// getClasslist returns the bootstrap lib/classlist from the Java installation.
 // There is a lib/classlist under the Java installation.
 // However, that file only has entries from jmods/java.base.jmod and this classlist is duplicated as a member in that file.
-// So, this function uses jmods/java.base.jmod to fetch the bootstrap map.
-func getClasslist(reader zip.Reader) map[string]struct{} {
-	classSet := make(map[string]struct{})
-
-	classlist, err := reader.Open("lib/classlist")
+// So, this function uses jmods/java.base.jmod to fetch the bootstrap list.
+func getClasslist(archive *jmodArchive) []string {
+	classlistContent, err := archive.readEntry("lib/classlist")
 	if err != nil {
 		_ = log.Log(err.Error(), log.CLASS)
 		_ = log.Log("Unable to read lib/classlist from jmod file. Loading all classes in jmod file.", log.CLASS)
-		return classSet
+		return nil
 	}
+	return parseClasslist(classlistContent)
+}
 
-	classlistContent, err := io.ReadAll(classlist)
-	if err != nil {
-		_ = log.Log(err.Error(), log.CLASS)
-		_ = log.Log("Unable to read lib/classlist from jmod file. Loading all classes in jmod file.", log.CLASS)
-		return classSet
-	}
-
-	classes := strings.Split(string(classlistContent), "\n")
-
-	var empty struct{}
-
-	for _, c := range classes {
-		if strings.HasSuffix(c, "\r") || strings.HasSuffix(c, "\n") {
-			c = strings.TrimRight(c, "\r\n")
+// parseClasslist returns the names of the classes in a classlist, in the form
+// java/lang/String. Lines that begin with @ (such as @lambda-proxy) describe
+// other things for CDS, and lines that begin with # are comments.
+func parseClasslist(content []byte) []string {
+	var classes []string
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && !strings.HasPrefix(fields[0], "@") && !strings.HasPrefix(fields[0], "#") {
+			classes = append(classes, fields[0])
 		}
-		classSet[c+".class"] = empty
 	}
-
-	return classSet
+	return classes
 }
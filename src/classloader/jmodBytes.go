@@ -8,138 +8,158 @@ package classloader
 
 import (
 	"archive/zip"
-	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/shutdown"
 	"os"
+	"sync"
 )
 
 const ExpectedMagicNumber = 0x4A4D
 const BaseJmodFileName = "java.base.jmod"
 
-// Load the entirety of the base jmod file into the byte cache: JmodBaseBytes
-// Called during classloader initialisation
-// Any error --> shutdown
-func GetBaseJmodBytes() {
+// A jmod file is a zip archive behind a 4-byte header. Rather than reading
+// whole jmods into memory, the jmod files are kept open and the entries of
+// their zip directories are indexed, so that a class is read from its jmod
+// (found in JMODMAP) only when it's first needed.
+type jmodArchive struct {
+	file    *os.File
+	entries map[string]*zip.File // by name, such as classes/java/lang/String.class
+}
 
-	var err error
-	global := globals.GetGlobalRef()
-	jmodBasePath := global.JavaHome + string(os.PathSeparator) + "jmods" + string(os.PathSeparator) + BaseJmodFileName
-
-	// Stat the base jmod file
-	//jmodStat, err := os.Stat(jmodBasePath)
-	//if err != nil {
-	//	msg := fmt.Sprintf("GetBaseJmodBytes: os.Stat(%s) failed", jmodBasePath)
-	//	_ = log.Log(msg, log.SEVERE)
-	//	_ = log.Log(err.Error(), log.SEVERE)
-	//	shutdown.Exit(shutdown.JVM_EXCEPTION)
-	//}
-
-	// Allocate byte array, JmodBaseBytes
-	//global.JmodBaseBytes = make([]byte, jmodStat.Size())
-
-	// Read the entire base jmod file contents (huge!)
-	global.JmodBaseBytes, err = os.ReadFile(jmodBasePath)
+// the open jmod files, by file name, such as java.base.jmod
+var jmodArchives = make(map[string]*jmodArchive)
+var jmodArchivesMutex sync.Mutex
+
+// openJmodZip opens the jmod file at the path and returns the zip archive in
+// it. Only the zip directory is read; the entries are read when they're opened.
+func openJmodZip(jmodPath string) (*os.File, *zip.Reader, error) {
+	file, err := os.Open(jmodPath)
 	if err != nil {
-		msg := fmt.Sprintf("GetBaseJmodBytes: os.ReadFile(%s) failed", jmodBasePath)
-		_ = log.Log(msg, log.SEVERE)
-		_ = log.Log(err.Error(), log.SEVERE)
-		shutdown.Exit(shutdown.JVM_EXCEPTION)
+		return nil, nil, err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
 	}
 
 	// Validate the file's magic number
-	fileMagicNumber := binary.BigEndian.Uint16(global.JmodBaseBytes[:2])
-	if fileMagicNumber != ExpectedMagicNumber {
-		msg := fmt.Sprintf("GetBaseJmodBytes: fileMagicNumber != ExpectedMagicNumber in jmod file %s", jmodBasePath)
-		_ = log.Log(msg, log.SEVERE)
-		_ = log.Log(err.Error(), log.SEVERE)
-		shutdown.Exit(shutdown.JVM_EXCEPTION)
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(file, header); err != nil ||
+		binary.BigEndian.Uint16(header) != ExpectedMagicNumber {
+		_ = file.Close()
+		return nil, nil, fmt.Errorf("fileMagicNumber != ExpectedMagicNumber in jmod file %s", jmodPath)
 	}
 
-	msg := fmt.Sprintf("GetBaseJmodBytes: jmodPath %s is loaded, %d bytes", jmodBasePath, len(global.JmodBaseBytes))
-	_ = log.Log(msg, log.CLASS)
-
+	// Skip over the jmod header so that it is recognized as a ZIP file
+	zipReader, err := zip.NewReader(io.NewSectionReader(file, 4, stat.Size()-4), stat.Size()-4)
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	return file, zipReader, nil
 }
 
-// For the given jmod and class name, return the class byte array to caller
-func GetClassBytes(jmodFileName string, className string) ([]byte, error) {
-
-	var jmodBytes []byte // <-- used if jmod file is not java.base.jmod
-	var err error
-	var ioReader *bytes.Reader
-	var newReaderLength int64
+// getJmodArchive returns the open jmod file, opening and indexing it the first time
+func getJmodArchive(jmodFileName string) (*jmodArchive, error) {
+	jmodArchivesMutex.Lock()
+	defer jmodArchivesMutex.Unlock()
+	if archive, ok := jmodArchives[jmodFileName]; ok {
+		return archive, nil
+	}
 
 	global := globals.GetGlobalRef()
 	jmodPath := global.JavaHome + string(os.PathSeparator) + "jmods" + string(os.PathSeparator) + jmodFileName
-	classFileName := "classes/" + className + ".class"
-
-	//fmt.Printf("DEBUG GetClassBytes: jmod=%s, class=%s\n", jmodFileName, className)
-	if jmodFileName == BaseJmodFileName {
-		// Already loaded in JmodBaseBytes during classloader initialisation
-		// Skip over the jmod header so that it is recognized as a ZIP file
-		ioReader = bytes.NewReader(global.JmodBaseBytes[4:])
-		newReaderLength = int64(len(global.JmodBaseBytes) - 4)
-	} else {
-		// Not the base jmod
-		// Read entire jmod file contents
-		jmodBytes, err = os.ReadFile(jmodPath)
-		if err != nil {
-			msg := fmt.Sprintf("GetClassBytes: os.ReadFile(%s) failed", jmodPath)
-			_ = log.Log(msg, log.SEVERE)
-			_ = log.Log(err.Error(), log.SEVERE)
-			return nil, err
-		}
+	file, zipReader, err := openJmodZip(jmodPath)
+	if err != nil {
+		return nil, err
+	}
+	archive := &jmodArchive{file: file, entries: make(map[string]*zip.File, len(zipReader.File))}
+	for _, entry := range zipReader.File {
+		archive.entries[entry.Name] = entry
+	}
+	jmodArchives[jmodFileName] = archive
 
-		// Validate the file's magic number
-		fileMagicNumber := binary.BigEndian.Uint16(jmodBytes[:2])
-		if fileMagicNumber != ExpectedMagicNumber {
-			msg := fmt.Sprintf("GetClassBytes: fileMagicNumber != ExpectedMagicNumber in jmod file %s", jmodPath)
-			_ = log.Log(msg, log.SEVERE)
-			_ = log.Log(err.Error(), log.SEVERE)
-			return nil, err
-		}
+	msg := fmt.Sprintf("getJmodArchive: jmodPath %s is open, %d entries", jmodPath, len(archive.entries))
+	_ = log.Log(msg, log.CLASS)
+	return archive, nil
+}
 
-		// Skip over the jmod header so that it is recognized as a ZIP file
-		ioReader = bytes.NewReader(jmodBytes[4:])
-		newReaderLength = int64(len(jmodBytes) - 4)
+// readEntry returns the contents of the named entry. If there's no such
+// entry, the error wraps fs.ErrNotExist.
+func (archive *jmodArchive) readEntry(name string) ([]byte, error) {
+	entry, ok := archive.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%s in jmod file %s: %w", name, archive.file.Name(), fs.ErrNotExist)
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
 
+// CloseJmods closes the open jmod files, which are reopened if they're needed again
+func CloseJmods() {
+	jmodArchivesMutex.Lock()
+	defer jmodArchivesMutex.Unlock()
+	for name, archive := range jmodArchives {
+		_ = archive.file.Close()
+		delete(jmodArchives, name)
 	}
+}
 
-	// Prepare the reader for the zip archive
-	//fmt.Printf("DEBUG GetClassBytes: zip.NewReader newReaderLength=%d\n", newReaderLength)
-	zipReader, err := zip.NewReader(ioReader, newReaderLength)
-	if err != nil {
-		msg := fmt.Sprintf("GetClassBytes: zip.NewReader(%s) failed", jmodPath)
+// Open the base jmod file and index its entries. No classes are read.
+// Called during classloader initialisation
+// Any error --> shutdown
+func OpenBaseJmod() {
+	if _, err := getJmodArchive(BaseJmodFileName); err != nil {
+		global := globals.GetGlobalRef()
+		jmodBasePath := global.JavaHome + string(os.PathSeparator) + "jmods" + string(os.PathSeparator) + BaseJmodFileName
+		msg := fmt.Sprintf("OpenBaseJmod: opening %s failed", jmodBasePath)
 		_ = log.Log(msg, log.SEVERE)
 		_ = log.Log(err.Error(), log.SEVERE)
-		return nil, err
+		shutdown.Exit(shutdown.JVM_EXCEPTION)
 	}
+}
+
+// For the given jmod and class name, return the class byte array to caller
+func GetClassBytes(jmodFileName string, className string) ([]byte, error) {
+	return getJmodEntryBytes(jmodFileName, "classes/"+className+".class")
+}
 
-	// Open the file within the zip archive
-	fileHandle, err := zipReader.Open(classFileName)
+// getJmodEntryBytes returns the contents of the named entry of the jmod file,
+// in which classes and resources are in the directory classes/.
+func getJmodEntryBytes(jmodFileName string, classFileName string) ([]byte, error) {
+	archive, err := getJmodArchive(jmodFileName)
 	if err != nil {
-		msg := fmt.Sprintf("GetClassBytes: zipReader.Open(class file %s in jmod file %s) failed", classFileName, jmodPath)
+		msg := fmt.Sprintf("GetClassBytes: opening jmod file %s failed", jmodFileName)
 		_ = log.Log(msg, log.SEVERE)
 		_ = log.Log(err.Error(), log.SEVERE)
 		return nil, err
 	}
 
-	// Read entire class file contents
-	classBytes, err := io.ReadAll(fileHandle)
+	classBytes, err := archive.readEntry(classFileName)
 	if err != nil {
-		msg := fmt.Sprintf("GetClassBytes: os.ReadAll(class file %s in jmod file %s) failed", classFileName, jmodPath)
-		_ = log.Log(msg, log.SEVERE)
-		_ = log.Log(err.Error(), log.SEVERE)
+		msg := fmt.Sprintf("GetClassBytes: reading class file %s in jmod file %s failed", classFileName, jmodFileName)
+		level := log.SEVERE
+		if errors.Is(err, fs.ErrNotExist) { // resources are looked for in jmods that might not have them
+			level = log.CLASS
+		}
+		_ = log.Log(msg, level)
+		_ = log.Log(err.Error(), level)
 		return nil, err
 	}
 
 	// Success!
-	msg := fmt.Sprintf("GetClassBytes: jmodPath %s, className %s was loaded", jmodPath, className)
+	msg := fmt.Sprintf("GetClassBytes: jmod file %s, entry %s was loaded", jmodFileName, classFileName)
 	_ = log.Log(msg, log.CLASS)
 	return classBytes, nil
-
 }
@@ -0,0 +1,121 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io/fs"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeJmod writes a jmod file: the jmod header, then a zip archive of the files
+func writeJmod(t *testing.T, jmodName string, files map[string][]byte) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, contents := range files {
+		w, _ := zw.Create(name)
+		_, _ = w.Write(contents)
+	}
+	_ = zw.Close()
+	if err := os.MkdirAll(filepath.Dir(jmodName), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(jmodName, append([]byte{'J', 'M', 1, 0}, buf.Bytes()...), 0644); err != nil {
+		t.Fatalf("Cannot create %s: %v", jmodName, err)
+	}
+}
+
+func TestJmodEntriesAreReadOnDemand(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	CloseJmods()
+	defer CloseJmods()
+
+	javaHome := t.TempDir()
+	globals.GetGlobalRef().JavaHome = javaHome
+	writeJmod(t, filepath.Join(javaHome, "jmods", "java.base.jmod"), map[string][]byte{
+		"classes/java/lang/Class.class": ClassBytes,
+		"classes/java/lang/names.txt":   []byte("names"),
+	})
+	_ = os.WriteFile(filepath.Join(javaHome, "jmods", "bad.jmod"), []byte("PK\x03\x04"), 0644)
+
+	classBytes, err := GetClassBytes("java.base.jmod", "java/lang/Class")
+	if err != nil || !bytes.Equal(classBytes, ClassBytes) {
+		t.Errorf("Expected the class to be read from the jmod, got %v", err)
+	}
+	if jmodArchives["java.base.jmod"] == nil {
+		t.Errorf("Expected the jmod to be kept open")
+	}
+	if _, err = GetClassBytes("java.base.jmod", "java/lang/Missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing class not to exist, got %v", err)
+	}
+	if _, err = GetClassBytes("bad.jmod", "java/lang/Class"); err == nil {
+		t.Errorf("Expected an error for a file that's not a jmod")
+	}
+
+	CloseJmods()
+	if len(jmodArchives) != 0 {
+		t.Errorf("Expected CloseJmods() to close the jmods")
+	}
+	data, err := getJmodEntryBytes("java.base.jmod", "classes/java/lang/names.txt")
+	if err != nil || string(data) != "names" {
+		t.Errorf("Expected a closed jmod to be reopened, got %q, %v", data, err)
+	}
+}
+
+func TestBaseClassesAreLoadedLazily(t *testing.T) {
+	g := globals.InitGlobals("test")
+	log.Init()
+	javaHome, jacobinHome := g.JavaHome, g.JacobinHome
+	defer func() {
+		globals.GetGlobalRef().JavaHome, globals.GetGlobalRef().JacobinHome = javaHome, jacobinHome
+		_ = Init()
+	}()
+
+	// a JDK with only java.base.jmod, whose classlist has java/lang/Class
+	jdk := t.TempDir()
+	writeJmod(t, filepath.Join(jdk, "jmods", "java.base.jmod"), map[string][]byte{
+		"classes/java/lang/Class.class":  ClassBytes,
+		"classes/java/lang/String.class": Hello2Bytes,
+		"lib/classlist":                  []byte("# the bootstrap classes\njava/lang/Class\n@lambda-proxy java/lang/Class x\n"),
+	})
+	globals.GetGlobalRef().JavaHome = jdk
+	globals.GetGlobalRef().JacobinHome = t.TempDir() // for the gob of JMODMAP
+
+	_ = Init()
+	LoadBaseClasses()
+	if MethAreaFetch("java/lang/Class") != nil {
+		t.Errorf("Expected no classes to be loaded at startup by default")
+	}
+	if err := LoadClassFromNameOnly("java/lang/Class"); err != nil || MethAreaFetch("java/lang/Class") == nil {
+		t.Errorf("Expected java/lang/Class to be loaded on first reference, got %v", err)
+	}
+
+	_ = Init()
+	globals.GetGlobalRef().PreloadClasslist = true
+	defer func() { globals.GetGlobalRef().PreloadClasslist = false }()
+	LoadBaseClasses()
+	if MethAreaFetch("java/lang/Class") == nil || BootstrapCL.FindLoadedClass("java/lang/Class") == nil {
+		t.Errorf("Expected -XX:+PreloadClasslist to load the classes in the classlist")
+	}
+	if MethAreaFetch("java/lang/String") != nil {
+		t.Errorf("Expected only the classes in the classlist to be preloaded")
+	}
+}
+
+func TestParseClasslist(t *testing.T) {
+	classes := parseClasslist([]byte("# comment\njava/lang/Object\r\n@lambda-proxy java/lang/Object run\n\njava/lang/String\n"))
+	if len(classes) != 2 || classes[0] != "java/lang/Object" || classes[1] != "java/lang/String" {
+		t.Errorf("Expected two classes in the classlist, got %v", classes)
+	}
+}
@@ -155,6 +155,7 @@ func buildMapFromGob(gobFilePath string) bool {
 	jmodMapMutex.Lock()
 	defer jmodMapMutex.Unlock()
 	JMODMAP = make(map[string]string)
+	jmodPackages = nil
 	jmodMapSize = 0
 
 	// Open input file
@@ -207,6 +208,7 @@ func buildMapFromJmods() {
 	jmodMapMutex.Lock()
 	defer jmodMapMutex.Unlock()
 	JMODMAP = make(map[string]string)
+	jmodPackages = nil
 	jmodMapSize = 0
 
 	// Get path of jmods directory
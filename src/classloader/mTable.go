@@ -42,9 +42,15 @@ type mData interface{}
 // GmEntry is the entry in the MTable for Go functions. See MTable comments for details.
 // Fu is a go function. All go functions accept a possibly empty slice of interface{} and
 // return a possibly nil interface{}
+//
+// If Accepts is set, Fu is run only if Accepts returns true for the arguments (which,
+// for an instance method, begin with the object). Otherwise, the method's Java code
+// is run. This lets a Go function stand in for a method of the objects whose state
+// is held in Go, such as System.in, while the JDK's code handles all the others.
 type GmEntry struct {
 	ParamSlots int
 	Fu         func([]interface{}) interface{}
+	Accepts    func([]interface{}) bool
 }
 
 // JmEntry is the entry in the Mtable for Java methods.
@@ -65,18 +71,46 @@ type JmEntry struct {
 // stack rather than actually returned to a caller).
 type Function func([]interface{}) interface{}
 
-// MTmutex is used for updates to the MTable because multiple threads could be
-// updating it simultaneously.
-var MTmutex sync.Mutex
+// MTmutex guards the MTable, because multiple threads (such as the shutdown
+// hooks) can look up and add methods simultaneously.
+var MTmutex sync.RWMutex
+
+// MTableFetch returns the MTable entry of the method, whose name is in the form
+// class.nameType, or the zero MTentry if there's none
+func MTableFetch(methFQN string) MTentry {
+	MTmutex.RLock()
+	defer MTmutex.RUnlock()
+	return MTable[methFQN]
+}
 
 // MTableLoadNatives loads the Go methods from files that contain them. It does this
 // by calling the Load_* function in each of those files to load whatever Go functions
 // they make available.
 func MTableLoadNatives() {
-	loadlib(&MTable, Load_Io_PrintStream()) // load the java.io.prinstream golang functions
-	loadlib(&MTable, Load_Lang_Class())     // load the java.lang.Class golang functions
-	loadlib(&MTable, Load_Lang_System())    // load the java.lang.system golang functions
-	loadlib(&MTable, Load_Lang_Math())      // load the java.lang.system golang functions
+	loadlib(&MTable, Load_Io_PrintStream())          // load the java.io.prinstream golang functions
+	loadlib(&MTable, Load_Io_InputStream())          // load the java.io.InputStream golang functions (System.in)
+	loadlib(&MTable, Load_Io_Reader())               // load the java.io.InputStreamReader/BufferedReader golang functions
+	loadlib(&MTable, Load_Lang_Class())              // load the java.lang.Class golang functions
+	loadlib(&MTable, Load_Lang_ClassLoader())        // load the java.lang.ClassLoader golang functions
+	loadlib(&MTable, Load_Lang_System())             // load the java.lang.system golang functions
+	loadlib(&MTable, Load_Lang_ProcessEnvironment()) // load the java.lang.ProcessEnvironment golang functions (System.getenv)
+	loadlib(&MTable, Load_Lang_Runtime())            // load the java.lang.Runtime golang functions
+	loadlib(&MTable, Load_Lang_ProcessHandle())      // load the java.lang.ProcessHandle golang functions
+	loadlib(&MTable, Load_Lang_Thread())             // load the java.lang.Thread golang functions
+	loadlib(&MTable, Load_Lang_Math())               // load the java.lang.system golang functions
+	loadlib(&MTable, Load_Lang_Object())             // load the java.lang.Object golang functions
+	loadlib(&MTable, Load_Lang_String())             // load the java.lang.String golang functions
+	loadlib(&MTable, Load_Lang_StringBuilder())      // load the java.lang.StringBuilder/Buffer golang functions
+	loadlib(&MTable, Load_Lang_Integer())            // load the java.lang.Integer/Long/Short/Byte golang functions
+	loadlib(&MTable, Load_Lang_Double())             // load the java.lang.Double/Float golang functions
+	loadlib(&MTable, Load_Lang_Character())          // load the java.lang.Character golang functions
+	loadlib(&MTable, Load_Lang_Boolean())            // load the java.lang.Boolean golang functions
+	loadlib(&MTable, Load_Util_Formatter())          // load the java.util.Formatter golang functions
+	loadlib(&MTable, Load_Util_Properties())         // load the java.util.Properties golang functions
+	loadlib(&MTable, Load_Util_Arrays())             // load the java.util.Arrays golang functions
+	loadlib(&MTable, Load_Util_Scanner())            // load the java.util.Scanner golang functions
+	loadlib(&MTable, Load_Util_ServiceLoader())      // load the java.util.ServiceLoader golang functions
+	loadlib(&MTable, Load_Net_URL())                 // load the java.net.URL golang functions (resources)
 }
 
 func loadlib(tbl *MT, libMeths map[string]GMeth) {
@@ -84,6 +118,7 @@ func loadlib(tbl *MT, libMeths map[string]GMeth) {
 		gme := GmEntry{}
 		gme.ParamSlots = val.ParamSlots
 		gme.Fu = val.GFunction
+		gme.Accepts = val.Accepts
 
 		tableEntry := MTentry{
 			MType: 'G',
@@ -96,9 +131,7 @@ func loadlib(tbl *MT, libMeths map[string]GMeth) {
 
 // adds an entry to the MTable, using a mutex
 func addEntry(tbl *MT, key string, mte MTentry) {
-	mt := *tbl
-
 	MTmutex.Lock()
-	mt[key] = mte
+	(*tbl)[key] = mte
 	MTmutex.Unlock()
 }
@@ -12,7 +12,6 @@ import (
 	"jacobin/log"
 	"jacobin/types"
 	"sync"
-	"time"
 )
 
 // MethArea contains all the loaded classes. Key is the class name in java/lang/Object format.
@@ -61,26 +60,18 @@ func MethAreaSize() int {
 	return size
 }
 
-// Wait for klass.Status to no longer be "I"
-// TODO: must be a better way to do this!
+// WaitForClassStatus waits for the loads of the class that are in progress, in
+// this or other goroutines, to finish. It returns an error if the class isn't
+// then in the method area.
 func WaitForClassStatus(className string) error {
 	_ = log.Log("WaitForClassStatus: class name: "+className, log.CLASS)
-	klass := MethAreaFetch(className)
-	if klass == nil { // class not there yet
-		time.Sleep(100 * time.Millisecond) // sleep 100 milliseconds
-		klass = MethAreaFetch(className)
-		if klass == nil {
-			msg := fmt.Sprintf("WaitClassStatus: Timeout waiting for class {%s} to load", className)
-			return errors.New(msg)
-		}
+	if MethAreaFetch(className) != nil {
+		return nil
 	}
-	if klass.Status == 'I' { // class is being initialized by a loader, so wait
-		time.Sleep(100 * time.Millisecond) // sleep 100 milliseconds
-		klass = MethAreaFetch(className)
-		if klass.Status == 'I' {
-			msg := fmt.Sprintf("WaitClassStatus: Timeout waiting for class {%s} status", className)
-			return errors.New(msg)
-		}
+	waitForClassLoads(className)
+	if MethAreaFetch(className) == nil {
+		msg := fmt.Sprintf("WaitClassStatus: class {%s} is not loaded", className)
+		return errors.New(msg)
 	}
 	return nil
 }
@@ -0,0 +1,194 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// A module's descriptor is its module-info.class, a class file that has no
+// fields or methods, but a Module attribute, which lists what the module
+// requires, exports, opens, uses and provides. Only the provides clauses are
+// needed (by ServiceLoader), so this is a parser for just those, rather than
+// an extension of the class parser, which expects a class.
+// Reference: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.25
+
+// moduleInfo is what's used of a module descriptor
+type moduleInfo struct {
+	name     string
+	provides map[string][]string // the service providers, by service, in the form java/lang/String
+}
+
+// the constant pool tags, which determine the size of the entries
+const (
+	cpUtf8          = 1
+	cpInteger       = 3
+	cpFloat         = 4
+	cpLong          = 5
+	cpDouble        = 6
+	cpClass         = 7
+	cpString        = 8
+	cpFieldRef      = 9
+	cpMethodRef     = 10
+	cpInterfaceRef  = 11
+	cpNameAndType   = 12
+	cpMethodHandle  = 15
+	cpMethodType    = 16
+	cpDynamic       = 17
+	cpInvokeDynamic = 18
+	cpModule        = 19
+	cpPackage       = 20
+)
+
+var errBadModuleInfo = errors.New("malformed module-info.class")
+
+// moduleInfoReader reads the big-endian values of a class file. Reading past
+// its end sets err and returns zeros, so it only needs to be checked at the end.
+type moduleInfoReader struct {
+	bytes []byte
+	pos   int
+	err   error
+}
+
+func (r *moduleInfoReader) skip(n int) {
+	if r.err != nil || r.pos+n > len(r.bytes) {
+		r.err = errBadModuleInfo
+		return
+	}
+	r.pos += n
+}
+
+func (r *moduleInfoReader) u1() int {
+	start := r.pos
+	r.skip(1)
+	if r.err != nil {
+		return 0
+	}
+	return int(r.bytes[start])
+}
+
+func (r *moduleInfoReader) u2() int {
+	start := r.pos
+	r.skip(2)
+	if r.err != nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(r.bytes[start:]))
+}
+
+func (r *moduleInfoReader) u4() int {
+	start := r.pos
+	r.skip(4)
+	if r.err != nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(r.bytes[start:]))
+}
+
+// skipMembers skips the fields or the methods, with their attributes
+func (r *moduleInfoReader) skipMembers() {
+	count := r.u2()
+	for i := 0; i < count && r.err == nil; i++ {
+		r.skip(6) // access flags, name and descriptor
+		r.skipAttributes()
+	}
+}
+
+func (r *moduleInfoReader) skipAttributes() {
+	count := r.u2()
+	for i := 0; i < count && r.err == nil; i++ {
+		r.skip(2)
+		r.skip(r.u4())
+	}
+}
+
+// parseModuleInfo parses the module descriptor in the bytes of a module-info.class
+func parseModuleInfo(bytes []byte) (*moduleInfo, error) {
+	r := &moduleInfoReader{bytes: bytes}
+	if r.u4() != 0xCAFEBABE {
+		return nil, errBadModuleInfo
+	}
+	r.skip(4) // the version
+
+	// the constant pool: the strings are kept, as are the indexes of the names
+	// of classes and modules, which are what the Module attribute refers to
+	cpCount := r.u2()
+	utf8 := make(map[int]string)
+	names := make(map[int]int)
+	for i := 1; i < cpCount && r.err == nil; i++ {
+		tag := r.u1()
+		switch tag {
+		case cpUtf8:
+			length := r.u2()
+			start := r.pos
+			r.skip(length)
+			if r.err == nil {
+				utf8[i] = string(r.bytes[start:r.pos])
+			}
+		case cpClass, cpModule, cpPackage:
+			names[i] = r.u2()
+		case cpString, cpMethodType:
+			r.skip(2)
+		case cpMethodHandle:
+			r.skip(3)
+		case cpInteger, cpFloat, cpFieldRef, cpMethodRef, cpInterfaceRef, cpNameAndType, cpDynamic, cpInvokeDynamic:
+			r.skip(4)
+		case cpLong, cpDouble: // these take two slots
+			r.skip(8)
+			i++
+		default:
+			return nil, fmt.Errorf("%w: invalid constant pool tag %d", errBadModuleInfo, tag)
+		}
+	}
+	name := func(index int) string { return utf8[names[index]] }
+
+	r.skip(6) // access flags, this class and superclass
+	r.skip(2 * r.u2())
+	r.skipMembers() // fields
+	r.skipMembers() // methods
+
+	info := &moduleInfo{provides: make(map[string][]string)}
+	attrCount := r.u2()
+	for i := 0; i < attrCount && r.err == nil; i++ {
+		attrName := utf8[r.u2()]
+		length := r.u4()
+		if attrName != "Module" {
+			r.skip(length)
+			continue
+		}
+
+		info.name = name(r.u2())
+		r.skip(4)                // the module's flags and version
+		r.skip(6 * r.u2())       // requires
+		for n := 0; n < 2; n++ { // the exports and then the opens
+			count := r.u2()
+			for j := 0; j < count && r.err == nil; j++ {
+				r.skip(4) // the package and the flags
+				r.skip(2 * r.u2())
+			}
+		}
+		r.skip(2 * r.u2()) // uses
+		count := r.u2()
+		for j := 0; j < count && r.err == nil; j++ {
+			service := name(r.u2())
+			withCount := r.u2()
+			for k := 0; k < withCount && r.err == nil; k++ {
+				info.provides[service] = append(info.provides[service], name(r.u2()))
+			}
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if info.name == "" {
+		return nil, fmt.Errorf("%w: no Module attribute", errBadModuleInfo)
+	}
+	return info, nil
+}
@@ -0,0 +1,224 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Resources are the files, other than classes, that are found where classes
+// are: in the directories and jars of the class path and in the JDK's modules.
+// A resource's name is a path separated by /, such as com/acme/app.properties,
+// and class files can be read as resources too, e.g. com/acme/Main.class. As
+// for classes, a classloader asked for a resource asks its parent first, so a
+// resource in the JDK's modules hides one of the same name on the class path.
+//
+// A resource is identified by a URL, whose form depends on where it was found:
+//    file:/home/user/app/com/acme/app.properties  in a directory
+//    jar:file:/home/user/app.jar!/com/acme/app.properties  in a jar
+//    jrt:/java.base/java/lang/Object.class  in one of the JDK's modules
+
+// resource is a resource that a classloader found, with the function that reads it.
+type resource struct {
+	url  string
+	read func() ([]byte, error)
+}
+
+// getResource returns the first resource with the name that the classloader
+// and its parents have, or nil if there's none.
+func (cl *Classloader) getResource(name string) *resource {
+	if found := cl.getResources(name); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// getResources returns all the resources with the name that the classloader
+// and its parents have, starting with those of the bootstrap classloader.
+func (cl *Classloader) getResources(name string) []*resource {
+	var found []*resource
+	if parent := getClassloader(cl.Parent); parent != nil && parent != cl {
+		found = parent.getResources(name)
+	}
+	return append(found, cl.findResources(name)...)
+}
+
+// findResources looks for the resource where the classloader itself finds
+// classes. A classloader written in Java is asked through its findResource().
+func (cl *Classloader) findResources(name string) []*resource {
+	if cl.javaLoader != nil {
+		return cl.findResourceInJava(name)
+	}
+	switch cl.Name {
+	case "bootstrap":
+		if r := findJmodResource(name); r != nil {
+			return []*resource{r}
+		}
+	case "app":
+		return cl.findClasspathResources(name)
+	}
+	return nil
+}
+
+// findClasspathResources returns the resources with the name in the entries
+// of the class path, in class-path order.
+func (cl *Classloader) findClasspathResources(name string) []*resource {
+	classpath := globals.GetGlobalRef().Classpath
+	if len(classpath) == 0 {
+		classpath = []string{"."}
+	}
+	var found []*resource
+	for _, entry := range classpath {
+		info, err := os.Stat(entry)
+		if err != nil {
+			continue
+		}
+		var r *resource
+		if info.IsDir() {
+			r = findDirectoryResource(entry, name)
+		} else if jar, err := getJarFile(cl, entry); err == nil {
+			r = findJarResource(jar, name)
+		}
+		if r != nil {
+			_ = log.Log("getResource: "+name+" found in "+entry, log.CLASS)
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// findDirectoryResource returns the resource if it's a file in the directory.
+// A name that leads out of the directory, such as ../secret, isn't found.
+func findDirectoryResource(dir, name string) *resource {
+	if name == "" || path.IsAbs(name) || strings.HasPrefix(path.Clean(name), "..") {
+		return nil
+	}
+	filename := filepath.Join(dir, filepath.FromSlash(name))
+	if info, err := os.Stat(filename); err != nil || info.IsDir() {
+		return nil
+	}
+	absName, err := filepath.Abs(filename)
+	if err != nil {
+		return nil
+	}
+	return &resource{
+		url:  "file:" + fileURLPath(absName),
+		read: func() ([]byte, error) { return os.ReadFile(filename) },
+	}
+}
+
+// findJarResource returns the resource if it's an entry of the jar. Jars
+// record class files under the class name, e.g. com.acme.Main.
+func findJarResource(jar *Archive, name string) *resource {
+	entryName := name
+	if strings.HasSuffix(name, ".class") {
+		entryName = strings.ReplaceAll(strings.TrimSuffix(name, ".class"), "/", ".")
+	}
+	item, ok := jar.lookup(entryName)
+	if !ok {
+		return nil
+	}
+	absName, err := filepath.Abs(jar.Filename)
+	if err != nil {
+		return nil
+	}
+	return &resource{
+		url:  "jar:file:" + fileURLPath(absName) + "!/" + encodeURLPath(name),
+		read: func() ([]byte, error) { return jar.readEntry(item) },
+	}
+}
+
+// findJmodResource returns the resource if it's in one of the JDK's modules.
+// Only classes are listed in JMODMAP, so other resources are looked for in the
+// module that has the classes of the resource's package. The entry is read
+// only when the resource is.
+func findJmodResource(name string) *resource {
+	if RuntimeImage != nil {
+		return findImageResource(name)
+	}
+	var jmodFileName string
+	if className, isClass := strings.CutSuffix(name, ".class"); isClass {
+		jmodFileName = JmodMapFetch(className)
+	} else if pkg := path.Dir(name); pkg != "." {
+		jmodFileName = jmodOfPackage(pkg)
+	}
+	if jmodFileName == "" {
+		return nil
+	}
+	archive, err := getJmodArchive(jmodFileName)
+	if err != nil {
+		return nil
+	}
+	if _, ok := archive.entries["classes/"+name]; !ok {
+		return nil
+	}
+	return &resource{
+		url:  "jrt:/" + strings.TrimSuffix(jmodFileName, ".jmod") + "/" + encodeURLPath(name),
+		read: func() ([]byte, error) { return archive.readEntry("classes/" + name) },
+	}
+}
+
+// findImageResource returns the resource if it's in the jimage. It's looked
+// for in the module that has the classes of its package, like a jmod resource.
+func findImageResource(name string) *resource {
+	module := RuntimeImage.ModuleOf(name)
+	if module == "" {
+		return nil
+	}
+	data, err := RuntimeImage.ReadResource("/" + module + "/" + name)
+	if err != nil {
+		return nil
+	}
+	return &resource{
+		url:  "jrt:/" + module + "/" + encodeURLPath(name),
+		read: func() ([]byte, error) { return data, nil },
+	}
+}
+
+// jmodPackages maps each package, in the form java/lang, to the jmod file that
+// holds its classes. It's built from JMODMAP when it's first needed, and is
+// discarded whenever JMODMAP is rebuilt. Like JMODMAP, it's guarded by jmodMapMutex.
+var jmodPackages map[string]string
+
+// jmodOfPackage returns the jmod file that holds the classes of the package,
+// whose name is in the form java/lang, or "" if there's none.
+func jmodOfPackage(pkg string) string {
+	jmodMapMutex.Lock()
+	defer jmodMapMutex.Unlock()
+	if jmodPackages == nil {
+		jmodPackages = make(map[string]string)
+		for entry, jmodFileName := range JMODMAP {
+			if className, ok := strings.CutSuffix(entry, ".class"); ok {
+				jmodPackages[path.Dir(className)] = jmodFileName
+			}
+		}
+	}
+	return jmodPackages[pkg]
+}
+
+// fileURLPath returns an absolute file name in the form used in file: URLs,
+// which always starts with / and uses / as the separator, even on Windows.
+func fileURLPath(absName string) string {
+	urlPath := filepath.ToSlash(absName)
+	if !strings.HasPrefix(urlPath, "/") {
+		urlPath = "/" + urlPath
+	}
+	return encodeURLPath(urlPath)
+}
+
+// encodeURLPath percent-encodes the chars of a path that can't appear as they
+// are in a URL, such as spaces and non-ASCII chars, as the JDK does in the URLs
+// of resources. Thus, a/b c.txt becomes a/b%20c.txt.
+func encodeURLPath(urlPath string) string {
+	return (&url.URL{Path: urlPath}).EscapedPath()
+}
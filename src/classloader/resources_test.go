@@ -0,0 +1,196 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readStream returns the contents of an InputStream object
+func readStream(t *testing.T, stream interface{}) string {
+	obj, ok := stream.(*object.Object)
+	if !ok || obj == object.Null {
+		t.Fatalf("Expected an InputStream, got %v", stream)
+	}
+	arr := inputStreamReadAllBytes([]interface{}{obj}).(*object.Object)
+	return string(*(arr.Fields[0].Fvalue.(*[]byte)))
+}
+
+func urlString(t *testing.T, url interface{}) string {
+	obj, ok := url.(*object.Object)
+	if !ok || obj == object.Null {
+		t.Fatalf("Expected a URL, got %v", url)
+	}
+	return javaToString(urlToString([]interface{}{obj}).(*object.Object))
+}
+
+// initResourceTest puts a directory and a jar, which both have com/acme/app.properties, on the class path
+func initResourceTest(t *testing.T) (dir, jarName string) {
+	dir = t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "com", "acme"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "com", "acme", "app.properties"), []byte("from=dir"), 0644)
+	jarName = filepath.Join(t.TempDir(), "lib.jar")
+	writeJar(t, jarName, map[string][]byte{
+		"com/acme/app.properties": []byte("from=jar"),
+		"com/acme/Main.class":     Hello2Bytes,
+	})
+	globals.GetGlobalRef().Classpath = []string{dir, jarName}
+	return dir, jarName
+}
+
+func TestGetSystemResource(t *testing.T) {
+	defer initClassLoaderTest(t)()
+	dir, jarName := initResourceTest(t)
+	absDir, _ := filepath.Abs(dir)
+	absJar, _ := filepath.Abs(jarName)
+
+	url := getSystemResource([]interface{}{jstr("com/acme/app.properties")})
+	if urlString(t, url) != "file:"+filepath.ToSlash(absDir)+"/com/acme/app.properties" {
+		t.Errorf("Expected the URL of the file in the directory, got %s", urlString(t, url))
+	}
+	if readStream(t, urlOpenStream([]interface{}{url})) != "from=dir" {
+		t.Errorf("Expected openStream() to read the file in the directory")
+	}
+	protocol := urlGetProtocol([]interface{}{url}).(*object.Object)
+	if javaToString(protocol) != "file" {
+		t.Errorf("Expected the protocol file, got %s", javaToString(protocol))
+	}
+
+	// a class file is found in the jar, under its path
+	url = getSystemResource([]interface{}{jstr("com/acme/Main.class")})
+	if urlString(t, url) != "jar:file:"+filepath.ToSlash(absJar)+"!/com/acme/Main.class" {
+		t.Errorf("Expected the URL of the entry in the jar, got %s", urlString(t, url))
+	}
+	if readStream(t, getSystemResourceAsStream([]interface{}{jstr("com/acme/Main.class")})) != string(Hello2Bytes) {
+		t.Errorf("Expected getSystemResourceAsStream() to read the class file in the jar")
+	}
+
+	for _, name := range []string{"com/acme/missing.txt", "../" + filepath.Base(dir) + "/com/acme/app.properties"} {
+		if getSystemResource([]interface{}{jstr(name)}) != object.Null ||
+			getSystemResourceAsStream([]interface{}{jstr(name)}) != object.Null {
+			t.Errorf("Expected no resource %s", name)
+		}
+	}
+	if _, ok := getSystemResource([]interface{}{object.Null}).(error); !ok {
+		t.Errorf("Expected a NullPointerException for a null name")
+	}
+}
+
+func TestGetResources(t *testing.T) {
+	defer initClassLoaderTest(t)()
+	initResourceTest(t)
+
+	// the resources are in class-path order
+	system := systemClassLoader()
+	resources := classLoaderGetResources([]interface{}{system, jstr("com/acme/app.properties")})
+	var contents []string
+	for enumerationHasMoreElements([]interface{}{resources}) == types.JavaBoolTrue {
+		url := enumerationNextElement([]interface{}{resources})
+		contents = append(contents, readStream(t, urlOpenStream([]interface{}{url})))
+	}
+	if len(contents) != 2 || contents[0] != "from=dir" || contents[1] != "from=jar" {
+		t.Errorf("Expected the resources in the directory and then in the jar, got %v", contents)
+	}
+	if _, ok := enumerationNextElement([]interface{}{resources}).(error); !ok {
+		t.Errorf("Expected a NoSuchElementException after the last element")
+	}
+
+	// a classloader written in Java finds its parent's resources
+	loader := newClassLoaderObject("com/acme/PluginLoader")
+	stream := classLoaderGetResourceAsStream([]interface{}{loader, jstr("com/acme/app.properties")})
+	if readStream(t, stream) != "from=dir" {
+		t.Errorf("Expected a classloader to find the resources of its parent")
+	}
+	if classLoaderGetResource([]interface{}{getPlatformClassLoader(nil), jstr("com/acme/app.properties")}) !=
+		object.Null {
+		t.Errorf("Expected the platform classloader not to find the resources on the class path")
+	}
+}
+
+func TestClassGetResource(t *testing.T) {
+	defer initClassLoaderTest(t)()
+	initResourceTest(t)
+	MethAreaInsert("com/acme/Main", &Klass{Status: 'X', Loader: "app", Data: &ClData{Name: "com/acme/Main"}})
+	clazz := getClassObject("com/acme/Main")
+
+	// a relative name is in the package of the class; an absolute one starts with /
+	for _, name := range []string{"app.properties", "/com/acme/app.properties"} {
+		if readStream(t, classGetResourceAsStream([]interface{}{clazz, jstr(name)})) != "from=dir" {
+			t.Errorf("Expected Class.getResourceAsStream() to find %s", name)
+		}
+	}
+	if classGetResource([]interface{}{clazz, jstr("com/acme/app.properties")}) != object.Null {
+		t.Errorf("Expected a relative name to be in the package of the class")
+	}
+}
+
+// the chars of a resource's path that can't be in a URL are percent-encoded
+func TestResourceURLsAreEncoded(t *testing.T) {
+	defer initClassLoaderTest(t)()
+	dir := filepath.Join(t.TempDir(), "my classes")
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.WriteFile(filepath.Join(dir, "data", "a b€.txt"), []byte("x"), 0644)
+	globals.GetGlobalRef().Classpath = []string{dir}
+	absDir, _ := filepath.Abs(dir)
+
+	url := getSystemResource([]interface{}{jstr("data/a b€.txt")})
+	expected := "file:" + encodeURLPath(filepath.ToSlash(absDir)) + "/data/a%20b%E2%82%AC.txt"
+	if urlString(t, url) != expected || !strings.Contains(urlString(t, url), "/my%20classes/") {
+		t.Errorf("Expected the URL %s, got %s", expected, urlString(t, url))
+	}
+	if readStream(t, urlOpenStream([]interface{}{url})) != "x" {
+		t.Errorf("Expected openStream() to read the file whose name was encoded")
+	}
+}
+
+// only the URLs and Enumerations created for resources are handled in Go
+func TestURLsWithoutGoStateAreNotAccepted(t *testing.T) {
+	r := &resource{url: "file:/x"}
+	if !hasURLResource([]interface{}{newURLObject(r)}) || hasURLResource([]interface{}{object.MakeEmptyObject()}) {
+		t.Errorf("Expected only a URL created for a resource to be accepted")
+	}
+	if !hasURLEnumeration([]interface{}{newURLEnumeration([]*resource{r})}) ||
+		hasURLEnumeration([]interface{}{object.MakeEmptyObject()}) {
+		t.Errorf("Expected only an Enumeration created for resources to be accepted")
+	}
+}
+
+func TestJmodOfPackage(t *testing.T) {
+	jmodMapMutex.Lock()
+	savedMap := JMODMAP
+	JMODMAP = map[string]string{
+		"java/lang/String.class":        "java.base.jmod",
+		"java/lang/invoke/Handle.class": "java.base.jmod",
+		"java/sql/Date.class":           "java.sql.jmod",
+		counterElementName:              "3",
+	}
+	jmodPackages = nil
+	jmodMapMutex.Unlock()
+	defer func() {
+		jmodMapMutex.Lock()
+		JMODMAP = savedMap
+		jmodPackages = nil
+		jmodMapMutex.Unlock()
+	}()
+
+	for pkg, expected := range map[string]string{"java/lang": "java.base.jmod", "java/lang/invoke": "java.base.jmod",
+		"java/sql": "java.sql.jmod", "java": "", "com/acme": ""} {
+		if got := jmodOfPackage(pkg); got != expected {
+			t.Errorf("Expected package %s to be in %q, got %q", pkg, expected, got)
+		}
+	}
+}
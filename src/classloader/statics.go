@@ -9,6 +9,7 @@ package classloader
 import (
 	"errors"
 	"jacobin/types"
+	"math"
 	"sync"
 )
 
@@ -65,6 +66,8 @@ func AddStatic(name string, s Static) error {
 // immediately necessary statics. It's called in jvmStart.go
 func StaticsPreload() {
 	LoadStringStatics()
+	LoadWrapperStatics()
+	LoadSystemStatics()
 }
 
 // normally the following function would be in String.go, but this
@@ -81,3 +84,56 @@ func LoadStringStatics() {
 	_ = AddStatic("java/lang/String.LATIN1",
 		Static{Type: types.Byte, Value: int64(0)})
 }
+
+// LoadWrapperStatics loads the constants of the classes that box primitives,
+// such as Integer.MAX_VALUE and Double.NaN, whose methods are implemented in Go
+func LoadWrapperStatics() {
+	integrals := []struct {
+		class    string
+		primType string
+		bits     int64
+		min, max int64
+	}{
+		{"java/lang/Integer", types.Int, 32, math.MinInt32, math.MaxInt32},
+		{"java/lang/Long", types.Long, 64, math.MinInt64, math.MaxInt64},
+		{"java/lang/Short", types.Short, 16, math.MinInt16, math.MaxInt16},
+		{"java/lang/Byte", types.Byte, 8, math.MinInt8, math.MaxInt8},
+		{"java/lang/Character", types.Char, 16, 0, math.MaxUint16},
+	}
+	for _, w := range integrals {
+		_ = AddStatic(w.class+".MIN_VALUE", Static{Type: w.primType, Value: w.min})
+		_ = AddStatic(w.class+".MAX_VALUE", Static{Type: w.primType, Value: w.max})
+		_ = AddStatic(w.class+".SIZE", Static{Type: types.Int, Value: w.bits})
+		_ = AddStatic(w.class+".BYTES", Static{Type: types.Int, Value: w.bits / 8})
+	}
+	_ = AddStatic("java/lang/Character.MIN_RADIX", Static{Type: types.Int, Value: int64(2)})
+	_ = AddStatic("java/lang/Character.MAX_RADIX", Static{Type: types.Int, Value: int64(36)})
+
+	_ = AddStatic("java/lang/Double.MIN_VALUE", Static{Type: types.Double, Value: math.SmallestNonzeroFloat64})
+	_ = AddStatic("java/lang/Double.MAX_VALUE", Static{Type: types.Double, Value: math.MaxFloat64})
+	_ = AddStatic("java/lang/Float.MIN_VALUE", Static{Type: types.Float, Value: float64(math.SmallestNonzeroFloat32)})
+	_ = AddStatic("java/lang/Float.MAX_VALUE", Static{Type: types.Float, Value: float64(math.MaxFloat32)})
+	for class, primType := range map[string]string{"java/lang/Double": types.Double, "java/lang/Float": types.Float} {
+		_ = AddStatic(class+".POSITIVE_INFINITY", Static{Type: primType, Value: math.Inf(1)})
+		_ = AddStatic(class+".NEGATIVE_INFINITY", Static{Type: primType, Value: math.Inf(-1)})
+		_ = AddStatic(class+".NaN", Static{Type: primType, Value: math.NaN()})
+	}
+	_ = AddStatic("java/lang/Double.SIZE", Static{Type: types.Int, Value: int64(64)})
+	_ = AddStatic("java/lang/Double.BYTES", Static{Type: types.Int, Value: int64(8)})
+	_ = AddStatic("java/lang/Float.SIZE", Static{Type: types.Int, Value: int64(32)})
+	_ = AddStatic("java/lang/Float.BYTES", Static{Type: types.Int, Value: int64(4)})
+
+	_ = AddStatic("java/lang/Boolean.TRUE", Static{Type: "Ljava/lang/Boolean;", Value: boxBoolean(true)})
+	_ = AddStatic("java/lang/Boolean.FALSE", Static{Type: "Ljava/lang/Boolean;", Value: boxBoolean(false)})
+}
+
+// LoadSystemStatics loads System.in, System.out and System.err, whose streams
+// are implemented in Go
+func LoadSystemStatics() {
+	_ = AddStatic("java/lang/System.in",
+		Static{Type: "Ljava/io/InputStream;", Value: NewSystemIn()})
+	_ = AddStatic("java/lang/System.out",
+		Static{Type: "Ljava/io/PrintStream;", Value: NewPrintStream(1)})
+	_ = AddStatic("java/lang/System.err",
+		Static{Type: "Ljava/io/PrintStream;", Value: NewPrintStream(2)})
+}
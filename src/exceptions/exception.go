@@ -41,6 +41,7 @@ const (
 	FindException
 	IllegalArgumentException
 	IllegalCallerException
+	IllegalFormatException
 	IllegalMonitorStateException
 	IllegalPathStateException
 	IllegalStateException
@@ -72,7 +73,9 @@ const (
 	NoSuchElementException
 	NoSuchMechanismException
 	NullPointerException
+	NumberFormatException
 	ObjectCollectedException
+	PatternSyntaxException
 	ProfileDataException
 	ProviderException
 	ProviderNotFoundException
@@ -82,6 +85,7 @@ const (
 	ResolutionException
 	SecurityException
 	SPIResolutionException
+	StringIndexOutOfBoundsException
 	TypeNotPresentException
 	UncheckedIOException
 	UndeclaredThrowableException
@@ -35,10 +35,20 @@ type Globals struct {
 	Args        []string
 	CommandLine string
 
-	StartingClass string
+	StartingClass string // the .class file to run, or the name of the main class, such as com.acme.Main
 	StartingJar   string
+	Classpath     []string // the directories and jars in which classes are looked for, from -cp or CLASSPATH
 	AppArgs       []string
 	Options       map[string]Option
+	Properties    map[string]string  // system properties set with -Dkey=value
+	EnvOverrides  map[string]*string // environment variables set (or, if nil, masked) by -setenv and -unsetenv
+
+	// ---- module access granted by the manifest of the jar run with -jar ----
+	// Jacobin doesn't enforce the encapsulation of modules, so these are
+	// recorded, but all packages are accessible whether or not they're listed.
+	AddOpens           []string // Add-Opens: the packages, as module/package, opened to the app
+	AddExports         []string // Add-Exports: the packages, as module/package, exported to the app
+	EnableNativeAccess bool     // Enable-Native-Access: ALL-UNNAMED
 
 	// ---- classloading items ----
 	MaxJavaVersion    int // the Java version as commonly known, i.e. Java 11
@@ -64,14 +74,16 @@ type Globals struct {
 	// ---- list of addresses of arrays, see jvm/arrays.go for info ----
 	ArrayAddressList *list.List
 
-	// ----- Byte cache for java.base.jmod
-	JmodBaseBytes []byte
+	// ---- class loading ----
+	PreloadClasslist  bool   // load the classes in the JDK's lib/classlist at startup, rather than on first reference
+	SharedArchive     string // -Xshare: whether to use the archive of parsed classes: auto, off, or dump to create it
+	PreloadReferenced bool   // load the classes the main class refers to in the background
 
 	// ---- misc properties
 	FileEncoding string // what file encoding are we using?
 }
 
-// LoaderWg is a wait group for various channels used for parallel loading of classes.
+// LoaderWg is a wait group for the goroutines that preload classes in parallel.
 var LoaderWg sync.WaitGroup
 
 var global Globals
@@ -87,6 +99,8 @@ func InitGlobals(progName string) Globals {
 		JavaHome:          "",
 		JavaVersion:       "",
 		Options:           make(map[string]Option),
+		Properties:        make(map[string]string),
+		EnvOverrides:      make(map[string]*string),
 		StartingClass:     "",
 		StartingJar:       "",
 		MaxJavaVersion:    17, // this value and MaxJavaVersionRaw must *always* be in sync
@@ -95,7 +109,9 @@ func InitGlobals(progName string) Globals {
 		JacobinBuildData:  nil,
 		StrictJDK:         false,
 		ArrayAddressList:  InitArrayAddressList(),
-		JmodBaseBytes:     nil,
+		PreloadClasslist:  false,
+		SharedArchive:     "auto",
+		PreloadReferenced: false,
 	}
 
 	InitJavaHome()
@@ -9,6 +9,7 @@ package jvm
 import (
 	"errors"
 	"fmt"
+	"jacobin/classloader"
 	"jacobin/execdata"
 	"jacobin/globals"
 	"jacobin/log"
@@ -48,7 +49,11 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 		var option, arg string
 		// if it's a JVM option (so, it begins with a hyphen)
 		// break the option into the option and any embedded arg values, if any
-		if strings.HasPrefix(args[i], "-") {
+		if strings.HasPrefix(args[i], "-D") {
+			option, arg = "-D", args[i][2:] // the value of -Dkey=value can contain : and =
+		} else if strings.HasPrefix(args[i], "--class-path=") {
+			option, arg = "--class-path", args[i][len("--class-path="):] // the class path can contain :
+		} else if strings.HasPrefix(args[i], "-") {
 			option, arg, err = getOptionRootAndArgs(args[i])
 		} else {
 			option = args[i]
@@ -58,9 +63,10 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 			continue // skip the arg if there was a problem. (Might want to revisit this.)
 		}
 
-		// if the option is the name of the class to execute, note that then get
-		// all successive arguments and store them as app args in Global
-		if strings.HasSuffix(option, ".class") {
+		// if the option is the class to execute--either a .class file or the name
+		// of a class on the class path--note that then get all successive
+		// arguments and store them as app args in Global
+		if strings.HasSuffix(option, ".class") || !strings.HasPrefix(option, "-") {
 			Global.StartingClass = option
 			for i = i + 1; i < len(args); i++ {
 				Global.AppArgs = append(Global.AppArgs, args[i])
@@ -75,15 +81,30 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 			_, _ = fmt.Fprintf(os.Stderr, "%s is not a recognized option. Exiting.\n", args[i])
 			shutdown.Exit(shutdown.JVM_EXCEPTION)
 		}
-
-		// TODO: check for JAR specified and process the JAR. At present, it will
-		// recognize the JAR file and insert it into Global, and copy all succeeding args
-		// to app args. However, it does not recognize the JAR file as an executable.
-
 	}
+	setClasspathDefault(Global)
 	return nil
 }
 
+// setClasspathDefault sets the class path if it wasn't set by -cp (or its
+// synonyms). As in the JDK, when a jar is run with -jar, its class path is the
+// jar; otherwise, the class path is the CLASSPATH environment variable or, if
+// that's not set, the current directory. Then the jars that the manifests of
+// jars on the class path list in their Class-Path attributes are added.
+func setClasspathDefault(Global *globals.Globals) {
+	if Global.StartingJar != "" {
+		Global.Classpath = []string{Global.StartingJar}
+	} else if Global.Classpath == nil {
+		if classpath := os.Getenv("CLASSPATH"); classpath != "" {
+			Global.Classpath = classloader.ExpandClasspath(classpath)
+		} else {
+			Global.Classpath = []string{"."}
+		}
+	}
+	Global.Classpath = classloader.AddManifestClasspaths(Global.Classpath)
+	_ = log.Log("Class path: "+strings.Join(Global.Classpath, string(os.PathListSeparator)), log.FINE)
+}
+
 // pass in the option potentially with embedded arguments and get back
 // the option name and the embedded argument(s), if any
 func getOptionRootAndArgs(option string) (string, string, error) {
@@ -151,6 +172,13 @@ are passed as the arguments to main class.
 
 where options include:
 	-client       to select the "client" VM
+	-cp <class search path of directories and zip/jar files>
+	-classpath <class search path of directories and zip/jar files>
+	--class-path <class search path of directories and zip/jar files>
+	              A ` + string(os.PathListSeparator) + ` separated list of directories, JAR archives,
+	              and ZIP archives to search for class files.
+	-D<name>=<value>
+	              set a system property
 	-verbose:[class|info|fine|finest]  enable verbose output
                   info, fine, finest are Jacobin-specific options providing
                     increasing amounts of detail. The finest level is used
@@ -164,8 +192,20 @@ where options include:
 				  print product version to the output stream and continue
 
 Jacobin-specific options:
+	-setenv:<name>=<value>
+	              make System.getenv() return the value for the variable
 	-strictJDK    make user messages conform closely to the JDK's format
-	-trace:inst   display instruction-level tracing data to the console`
+	-trace:inst   display instruction-level tracing data to the console
+	-unsetenv:<name>
+	              hide the environment variable from System.getenv()
+	-Xshare:[auto|off|dump]
+	              use (auto, the default) or ignore the archive of the parsed
+	              JDK classes in JACOBIN_HOME, or create it (dump) and exit
+	-XX:+PreloadClasslist
+	              load the JDK's lib/classlist classes at startup, rather
+	              than when each class is first referenced
+	-XX:+PreloadReferencedClasses
+	              load the classes the main class refers to in the background`
 
 	_, _ = fmt.Fprintln(outStream, userMessage)
 }
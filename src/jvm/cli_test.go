@@ -541,3 +541,159 @@ func TestEmptyOptionForEmbeddedArg(t *testing.T) {
 		t.Error("Empty option should fail test for embedded args, but did not.")
 	}
 }
+
+func TestSystemPropertiesFromCommandLine(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	normalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+
+	args := []string{"jacobin", "-Dapp.url=http://localhost:8080/?a=b", "-Dapp.flag", "a.class"}
+	_ = HandleCli(args, &global)
+
+	_ = w.Close()
+	os.Stdout = normalStdout
+
+	if global.Properties["app.url"] != "http://localhost:8080/?a=b" {
+		t.Errorf("Expected -Dapp.url to set the property, got %q", global.Properties["app.url"])
+	}
+	if value, ok := global.Properties["app.flag"]; !ok || value != "" {
+		t.Errorf("Expected -Dapp.flag to set the property to \"\", got %q", value)
+	}
+	if global.StartingClass != "a.class" {
+		t.Errorf("Expected a.class to be the starting class, got %q", global.StartingClass)
+	}
+}
+
+func TestEnvironmentOverridesFromCommandLine(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	normalStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+
+	args := []string{"jacobin", "-setenv:TZ=UTC", "-setenv:APP_URL=http://x:1/?a=b", "-unsetenv:HOME", "a.class"}
+	_ = HandleCli(args, &global)
+
+	_ = w.Close()
+	os.Stdout = normalStdout
+
+	if value := global.EnvOverrides["TZ"]; value == nil || *value != "UTC" {
+		t.Errorf("Expected -setenv:TZ=UTC to override TZ, got %v", value)
+	}
+	if value := global.EnvOverrides["APP_URL"]; value == nil || *value != "http://x:1/?a=b" {
+		t.Errorf("Expected -setenv:APP_URL to keep the : and = in its value, got %v", value)
+	}
+	if value, ok := global.EnvOverrides["HOME"]; !ok || value != nil {
+		t.Errorf("Expected -unsetenv:HOME to mask HOME, got %v", value)
+	}
+}
+
+func TestXXOptions(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	args := []string{"jacobin", "-XX:+PreloadClasslist", "-XX:+UseG1GC", "-XX:+PreloadReferencedClasses", "a.class"}
+	_ = HandleCli(args, &global)
+	if !global.PreloadClasslist {
+		t.Errorf("Expected -XX:+PreloadClasslist to turn on the preloading of the classlist")
+	}
+	if !global.PreloadReferenced {
+		t.Errorf("Expected -XX:+PreloadReferencedClasses to turn on the preloading of referenced classes")
+	}
+
+	args = []string{"jacobin", "-XX:+PreloadClasslist", "-XX:-PreloadClasslist", "a.class"}
+	_ = HandleCli(args, &global)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if global.PreloadClasslist {
+		t.Errorf("Expected -XX:-PreloadClasslist to turn off the preloading of the classlist")
+	}
+	if global.StartingClass != "a.class" {
+		t.Errorf("Expected a.class to be the starting class, got %q", global.StartingClass)
+	}
+}
+
+func TestSharedArchiveOption(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+	if global.SharedArchive != "auto" {
+		t.Errorf("Expected the shared archive to be used by default, got %q", global.SharedArchive)
+	}
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	_ = HandleCli([]string{"jacobin", "-Xshare:dump"}, &global)
+	dump := global.SharedArchive
+	_ = HandleCli([]string{"jacobin", "-Xshare:off", "-Xshare:sometimes", "a.class"}, &global)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if dump != "dump" {
+		t.Errorf("Expected -Xshare:dump to create the shared archive, got %q", dump)
+	}
+	if global.SharedArchive != "off" {
+		t.Errorf("Expected -Xshare:off to turn off the shared archive and an invalid value to be ignored, got %q",
+			global.SharedArchive)
+	}
+}
+
+func TestClasspathAndMainClassName(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	tests := []struct {
+		args      []string
+		classpath string // "" if CLASSPATH is not set
+		expected  []string
+	}{
+		{[]string{"-cp", "classes" + sep + "lib/x.jar", "com.acme.Main", "a1"}, "env", []string{"classes", "lib/x.jar"}},
+		{[]string{"-classpath", "classes", "com.acme.Main", "a1"}, "", []string{"classes"}},
+		{[]string{"--class-path", "classes", "com.acme.Main", "a1"}, "", []string{"classes"}},
+		{[]string{"--class-path=classes" + sep + "d", "com.acme.Main", "a1"}, "", []string{"classes", "d"}},
+		{[]string{"com.acme.Main", "a1"}, "env" + sep + "x.jar", []string{"env", "x.jar"}},
+		{[]string{"com.acme.Main", "a1"}, "", []string{"."}},
+	}
+
+	for _, test := range tests {
+		global := globals.InitGlobals("test")
+		LoadOptionsTable(global)
+		if test.classpath == "" {
+			t.Setenv("CLASSPATH", "")
+		} else {
+			t.Setenv("CLASSPATH", test.classpath)
+		}
+
+		_ = HandleCli(append([]string{"jacobin"}, test.args...), &global)
+
+		if strings.Join(global.Classpath, "|") != strings.Join(test.expected, "|") {
+			t.Errorf("%v: expected class path %v, got %v", test.args, test.expected, global.Classpath)
+		}
+		if global.StartingClass != "com.acme.Main" || len(global.AppArgs) != 1 || global.AppArgs[0] != "a1" {
+			t.Errorf("%v: expected main class com.acme.Main with arg a1, got %s with %v",
+				test.args, global.StartingClass, global.AppArgs)
+		}
+	}
+}
+
+func TestJarIsTheClasspath(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	args := []string{"jacobin", "-cp", "ignored", "-jar", "app.jar", "appArg1"}
+	_ = HandleCli(args, &global)
+
+	if len(global.Classpath) != 1 || global.Classpath[0] != "app.jar" {
+		t.Errorf("Expected the class path to be app.jar, got %v", global.Classpath)
+	}
+}
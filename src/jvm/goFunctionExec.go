@@ -12,6 +12,7 @@ import (
 	"jacobin/classloader"
 	"jacobin/frames"
 	"jacobin/log"
+	"jacobin/object"
 	"strings"
 )
 
@@ -24,7 +25,7 @@ import (
 // by run() on the operand stack of the calling function.
 func runGframe(fr *frames.Frame) (interface{}, int, error) {
 	// get the go method from the MTable
-	me := classloader.MTable[fr.ClName+"."+fr.MethName]
+	me := classloader.MTableFetch(fr.ClName + "." + fr.MethName)
 	if me.Meth == nil {
 		return nil, 0, errors.New("runGframe: go method not found: " +
 			fr.ClName + "." + fr.MethName)
@@ -39,6 +40,12 @@ func runGframe(fr *frames.Frame) (interface{}, int, error) {
 	// call the function passing a pointer to the slice of arguments
 	ret := me.Meth.(classloader.GmEntry).Fu(*params)
 
+	// go functions that throw an exception return an error, whose
+	// message has already been displayed to the user. So, just pass it on.
+	if err, ok := ret.(error); ok {
+		return nil, 0, err
+	}
+
 	// how many slots does the return value consume on the op stack?
 	// the last char in the method name indicates the data type of the return
 	// value. If it's 'J' (a long) or 'D' (a double), it will require two
@@ -107,3 +114,34 @@ func runGmethod(mt classloader.MTentry, fs *list.List, className, methodName, me
 	f = fs.Front().Value.(*frames.Frame) // point f the head again
 	return f, nil
 }
+
+// goOrJavaMethod returns the method to run for a call whose arguments are on the
+// operand stack of the frame. This is the MTable entry, unless it's a Go function
+// that doesn't accept the arguments (see classloader.GmEntry), in which case it's
+// the method's Java code. For a virtual call, the Java code is looked up starting
+// from the class of the object, rather than from the class named in the call.
+// The name of the class in which the method to run was found is also returned.
+func goOrJavaMethod(mt classloader.MTentry, f *frames.Frame, className, methName, methType string,
+	virtual bool) (classloader.MTentry, string, error) {
+	if mt.MType != 'G' {
+		return mt, className, nil
+	}
+	gme := mt.Meth.(classloader.GmEntry)
+	if gme.Accepts == nil || gme.ParamSlots == 0 || gme.ParamSlots > f.TOS+1 {
+		return mt, className, nil
+	}
+	args := f.OpStack[f.TOS-gme.ParamSlots+1 : f.TOS+1]
+	if gme.Accepts(args) {
+		return mt, className, nil
+	}
+
+	if obj, ok := args[0].(*object.Object); virtual && ok && obj != nil && obj.Klass != nil {
+		className = *obj.Klass
+	}
+	jme, class, err := classloader.FetchJavaMethod(className, methName, methType)
+	if err != nil {
+		_ = log.Log(err.Error(), log.SEVERE)
+		return mt, className, err
+	}
+	return classloader.MTentry{Meth: jme, MType: 'J'}, class, nil
+}
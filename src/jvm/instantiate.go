@@ -19,7 +19,6 @@ import (
 	"jacobin/types"
 	// "strconv"
 	"strings"
-	"unsafe"
 )
 
 // instantiating an object is a two-part process (except for arrays, which are handled
@@ -56,7 +55,7 @@ func instantiateClass(classname string) (*object.Object, error) {
 
 	// go up the chain of superclasses until we hit java/lang/Object
 	superclasses := []string{}
-	superclass := k.Data.Superclass
+	superclass := k.RefKey(k.Data.Superclass)
 	for {
 		if superclass == "java/lang/Object" {
 			break
@@ -71,13 +70,11 @@ func instantiateClass(classname string) (*object.Object, error) {
 
 		loadedSuperclass := classloader.MethAreaFetch(superclass)
 		// now loop to see whether this superclass has a superclass
-		superclass = loadedSuperclass.Data.Superclass
+		superclass = loadedSuperclass.RefKey(loadedSuperclass.Data.Superclass)
 	}
 
-	// the object's mark field contains the lower 32-bits of the object's
-	// address, which serves as the hash code for the object
-	uintp := uintptr(unsafe.Pointer(&obj))
-	obj.Mark.Hash = uint32(uintp)
+	// note: the object's identity hash code (in the mark word) is not set
+	// here. It's generated the first time it's requested. See object.IdentityHash()
 
 	// handle the fields. If the object has no superclass other than Object,
 	// the fields are in an array in the order they're declared in the CP.
@@ -282,7 +279,7 @@ func createField(f classloader.Field, k *classloader.Klass, classname string) (*
 					fieldToAdd.Fvalue = k.Data.CP.Doubles[valueSlot]
 				case classloader.StringConst:
 					str := k.Data.CP.Utf8Refs[valueSlot]
-					fieldToAdd.Fvalue = object.NewStringFromGoString(str)
+					fieldToAdd.Fvalue = object.InternGoString(str) // constant strings are always interned
 				default:
 					errMsg := fmt.Sprintf(
 						"Unexpected ConstantValue type in instantiate: %d", valueType)
@@ -0,0 +1,117 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"errors"
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/log"
+	"jacobin/object"
+)
+
+// runJavaMethod runs a method of the object's class on behalf of Go code and
+// returns the value the method returns. It's how the classloader package calls
+// the loadClass() and findClass() methods of classloaders written in Java. The
+// arguments must be references or ints, each of which takes a single slot.
+func runJavaMethod(obj *object.Object, methName, methType string, args ...interface{}) (interface{}, error) {
+	if obj == nil || obj.Klass == nil {
+		return nil, errors.New("runJavaMethod: null object")
+	}
+	return runMethod(*obj.Klass, methName, methType, append([]interface{}{obj}, args...))
+}
+
+// runStaticJavaMethod runs a static method of the class on behalf of Go code,
+// as runJavaMethod() does an instance method. ServiceLoader uses it to call the
+// provider() methods of service providers.
+func runStaticJavaMethod(className, methName, methType string, args ...interface{}) (interface{}, error) {
+	return runMethod(className, methName, methType, args)
+}
+
+// newJavaObject creates an object of the class and runs its no-arg constructor
+func newJavaObject(className string) (*object.Object, error) {
+	obj, err := instantiateClass(className)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = runJavaMethod(obj, "<init>", "()V"); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// runMethod runs the method of the class with the parameters, the first of
+// which is the object, for an instance method, and returns its return value.
+func runMethod(className, methName, methType string, params []interface{}) (interface{}, error) {
+	me, err := classloader.FetchMethodAndCP(className, methName, methType)
+	if err != nil {
+		return nil, err
+	}
+
+	var m classloader.JmEntry
+	if me.MType == 'G' {
+		gme := me.Meth.(classloader.GmEntry)
+		if gme.Accepts == nil || gme.Accepts(params) {
+			ret := gme.Fu(params)
+			if err, ok := ret.(error); ok {
+				return nil, err
+			}
+			return ret, nil
+		}
+		// the Go function doesn't handle these arguments, so run the Java code
+		if obj, ok := params[0].(*object.Object); ok && obj != nil && obj.Klass != nil {
+			className = *obj.Klass
+		}
+		if m, className, err = classloader.FetchJavaMethod(className, methName, methType); err != nil {
+			return nil, err
+		}
+	} else {
+		m = me.Meth.(classloader.JmEntry)
+	}
+
+	// the method's frame returns its value onto the operand stack of the frame below it
+	caller := frames.CreateFrame(1)
+	caller.Thread = MainThread.ID
+
+	f := frames.CreateFrame(m.MaxStack)
+	f.MethName = methName
+	f.ClName = className
+	f.CP = m.Cp
+	f.Meth = append(f.Meth, m.Code...)
+	f.Thread = MainThread.ID
+	for k := 0; k < m.MaxLocals; k++ {
+		f.Locals = append(f.Locals, 0)
+	}
+	copy(f.Locals, params)
+
+	fs := frames.CreateFrameStack()
+	if frames.PushFrame(fs, caller) != nil || frames.PushFrame(fs, f) != nil {
+		return nil, errors.New("outOfMemory Exception")
+	}
+	if err = runFrame(fs); err != nil {
+		return nil, err
+	}
+	if caller.TOS < 0 {
+		return nil, nil
+	}
+	return pop(caller), nil
+}
+
+// resolveClassRef returns the key of the class that the code in the frame refers
+// to by name in the CP entry (see classloader.ClassKey). If the frame's class was
+// defined by a classloader written in Java, the class is loaded by that classloader.
+// Otherwise, the class is loaded as usual when it's first used. The reference is
+// resolved only once per CP entry.
+func resolveClassRef(f *frames.Frame, cpEntry int, className string) (string, error) {
+	key, err := classloader.ResolveCPClassRef(f.CP, cpEntry, className, f.ClName)
+	if err != nil {
+		_ = log.Log("Error: could not load class "+className+" referenced by "+f.ClName+": "+err.Error(),
+			log.SEVERE)
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,151 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"testing"
+)
+
+func TestRunJavaMethodReturnsValue(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	classloader.InitMethodArea()
+	classloader.MTable = make(map[string]classloader.MTentry)
+
+	// test/Loader.loadClass(String) returns its argument; test/Loader.toString() is a Go function
+	classloader.MethAreaInsert("test/Loader", &classloader.Klass{
+		Status: 'X',
+		Loader: "bootstrap",
+		Data: &classloader.ClData{
+			Name:       "test/Loader",
+			Superclass: "java/lang/Object",
+			CP:         classloader.CPool{Utf8Refs: []string{"loadClass", "(Ljava/lang/String;)Ljava/lang/Class;"}},
+			Methods: []classloader.Method{{
+				Name:     0,
+				Desc:     1,
+				CodeAttr: classloader.CodeAttrib{MaxStack: 1, MaxLocals: 2, Code: []byte{ALOAD_1, ARETURN}},
+			}},
+		},
+	})
+	classloader.MTable["test/Loader.toString()Ljava/lang/String;"] = classloader.MTentry{
+		Meth: classloader.GmEntry{ParamSlots: 1, Fu: func(params []interface{}) interface{} {
+			return object.NewStringFromGoString("loader")
+		}},
+		MType: 'G',
+	}
+
+	loader := newObjectOfClass("test/Loader")
+	arg := object.NewStringFromGoString("com.acme.Plugin")
+	ret, err := runJavaMethod(loader, "loadClass", "(Ljava/lang/String;)Ljava/lang/Class;", arg)
+	if err != nil || ret != arg {
+		t.Errorf("Expected the Java method to return its argument, got %v, %v", ret, err)
+	}
+
+	ret, err = runJavaMethod(loader, "toString", "()Ljava/lang/String;")
+	if str, ok := ret.(*object.Object); err != nil || !ok || object.GetGoStringFromJavaStringPtr(str) != "loader" {
+		t.Errorf("Expected the Go method's return value, got %v, %v", ret, err)
+	}
+}
+
+func TestRunStaticJavaMethod(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	classloader.InitMethodArea()
+	classloader.MTable = make(map[string]classloader.MTentry)
+
+	// test/Factory.twice(int) returns twice its argument
+	classloader.MethAreaInsert("test/Factory", &classloader.Klass{
+		Status: 'X',
+		Loader: "bootstrap",
+		Data: &classloader.ClData{
+			Name:       "test/Factory",
+			Superclass: "java/lang/Object",
+			CP:         classloader.CPool{Utf8Refs: []string{"twice", "(I)I"}},
+			Methods: []classloader.Method{{
+				Name:     0,
+				Desc:     1,
+				CodeAttr: classloader.CodeAttrib{MaxStack: 2, MaxLocals: 1, Code: []byte{ILOAD_0, ILOAD_0, IADD, IRETURN}},
+			}},
+		},
+	})
+
+	ret, err := runStaticJavaMethod("test/Factory", "twice", "(I)I", int64(21))
+	if err != nil || ret != int64(42) {
+		t.Errorf("Expected the static method to return 42, got %v, %v", ret, err)
+	}
+}
+
+func TestGoFunctionFallsBackToJavaCode(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	classloader.InitMethodArea()
+	classloader.MTable = make(map[string]classloader.MTentry)
+
+	// test/Stream.name(String) returns its argument in Java; the Go function
+	// that stands in for it accepts only the objects that have Go state
+	classloader.MethAreaInsert("test/Stream", &classloader.Klass{
+		Status: 'X',
+		Loader: "bootstrap",
+		Data: &classloader.ClData{
+			Name:       "test/Stream",
+			Superclass: "java/lang/Object",
+			CP:         classloader.CPool{Utf8Refs: []string{"name", "(Ljava/lang/String;)Ljava/lang/String;"}},
+			Methods: []classloader.Method{{
+				Name:     0,
+				Desc:     1,
+				CodeAttr: classloader.CodeAttrib{MaxStack: 1, MaxLocals: 2, Code: []byte{ALOAD_1, ARETURN}},
+			}},
+		},
+	})
+	classloader.MTable["test/Stream.name(Ljava/lang/String;)Ljava/lang/String;"] = classloader.MTentry{
+		Meth: classloader.GmEntry{
+			ParamSlots: 2,
+			Fu: func(params []interface{}) interface{} {
+				return object.NewStringFromGoString("go")
+			},
+			Accepts: func(params []interface{}) bool {
+				return params[0].(*object.Object).FieldTable != nil
+			},
+		},
+		MType: 'G',
+	}
+
+	goStream := newObjectOfClass("test/Stream")
+	goStream.FieldTable = map[string]object.Field{"state": {}}
+	javaStream := newObjectOfClass("test/Stream")
+	arg := object.NewStringFromGoString("java")
+
+	ret, err := runJavaMethod(goStream, "name", "(Ljava/lang/String;)Ljava/lang/String;", arg)
+	if str, ok := ret.(*object.Object); err != nil || !ok || object.GetGoStringFromJavaStringPtr(str) != "go" {
+		t.Errorf("Expected the Go function to handle an object with Go state, got %v, %v", ret, err)
+	}
+	ret, err = runJavaMethod(javaStream, "name", "(Ljava/lang/String;)Ljava/lang/String;", arg)
+	if err != nil || ret != arg {
+		t.Errorf("Expected the Java code to handle an object without Go state, got %v, %v", ret, err)
+	}
+
+	// the choice made for a call whose arguments are on the operand stack
+	f := frames.CreateFrame(2)
+	push(f, javaStream)
+	push(f, arg)
+	mt := classloader.MTable["test/Stream.name(Ljava/lang/String;)Ljava/lang/String;"]
+	if chosen, class, err := goOrJavaMethod(mt, f, "test/Stream", "name",
+		"(Ljava/lang/String;)Ljava/lang/String;", true); err != nil || chosen.MType != 'J' || class != "test/Stream" {
+		t.Errorf("Expected the Java code to be chosen for an object without Go state, got %c in %s, %v",
+			chosen.MType, class, err)
+	}
+	f.OpStack[0] = goStream
+	if chosen, _, _ := goOrJavaMethod(mt, f, "test/Stream", "name",
+		"(Ljava/lang/String;)Ljava/lang/String;", true); chosen.MType != 'G' {
+		t.Errorf("Expected the Go function to be chosen for an object with Go state, got %c", chosen.MType)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"jacobin/log"
 	"jacobin/shutdown"
 	"os"
+	"strings"
 )
 
 var Global globals.Globals
@@ -44,18 +45,31 @@ func JVMrun() int {
 		return shutdown.Exit(shutdown.OK)
 	}
 
+	// shut down in an orderly way, running the shutdown hooks, on exit and on SIGINT or SIGTERM
+	shutdown.SetHookRunner(runShutdownHook)
+	classloader.SetJavaMethodRunner(runJavaMethod)
+	classloader.SetServiceProviderRunners(newJavaObject, runStaticJavaMethod)
+	shutdown.HandleSignals()
+
 	// Init classloader and load base classes
 	err = classloader.Init() // must precede classloader.LoadBaseClasses
 	if err != nil {
 		return shutdown.Exit(shutdown.JVM_EXCEPTION)
 	}
+	if Global.SharedArchive == classloader.ShareDump { // as in the JDK, -Xshare:dump creates the archive and exits
+		if err = classloader.DumpSharedArchive(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			return shutdown.Exit(shutdown.JVM_EXCEPTION)
+		}
+		return shutdown.Exit(shutdown.OK)
+	}
 	classloader.LoadBaseClasses() // must follow classloader.Init
 	classloader.StaticsPreload()
 
 	var mainClass string
 
 	if Global.StartingJar != "" {
-		manifestClass, err := classloader.GetMainClassFromJar(classloader.BootstrapCL, Global.StartingJar)
+		manifestClass, err := classloader.GetMainClassFromJar(&classloader.AppCL, Global.StartingJar)
 
 		if err != nil {
 			_ = log.Log(err.Error(), log.INFO)
@@ -66,29 +80,46 @@ func JVMrun() int {
 			_ = log.Log(fmt.Sprintf("no main manifest attribute, in %s", Global.StartingJar), log.INFO)
 			return shutdown.Exit(shutdown.APP_EXCEPTION)
 		}
-		mainClass, err = classloader.LoadClassFromJar(classloader.BootstrapCL, manifestClass, Global.StartingJar)
-		if err != nil { // the exceptions message will already have been shown to user
+		err = classloader.ApplyJarManifestAttributes(&classloader.AppCL, Global.StartingJar)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			return shutdown.Exit(shutdown.JVM_EXCEPTION)
+		}
+
+		// the main class can be in the jar or in a jar listed in its Class-Path
+		mainClass, err = loadMainClass(manifestClass)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: Could not find or load main class %s\n", manifestClass)
+			_, _ = fmt.Fprintf(os.Stderr, "Caused by: java.lang.%s\n", err.Error())
 			return shutdown.Exit(shutdown.JVM_EXCEPTION)
 		}
-	} else if Global.StartingClass != "" {
-		mainClass, err = classloader.LoadClassFromFile(classloader.BootstrapCL, Global.StartingClass)
+	} else if strings.HasSuffix(Global.StartingClass, ".class") {
+		mainClass, err = classloader.LoadClassFromFile(&classloader.AppCL, Global.StartingClass)
 		if err != nil { // the exceptions message will already have been shown to user
 			return shutdown.Exit(shutdown.JVM_EXCEPTION)
 		}
+	} else if Global.StartingClass != "" { // the name of a class on the class path
+		mainClass, err = loadMainClass(Global.StartingClass)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: Could not find or load main class %s\n", Global.StartingClass)
+			_, _ = fmt.Fprintf(os.Stderr, "Caused by: java.lang.%s\n", err.Error())
+			return shutdown.Exit(shutdown.JVM_EXCEPTION)
+		}
 	} else {
 		_ = log.Log("Error: No executable program specified. Exiting.", log.INFO)
 		ShowUsage(os.Stdout)
 		return shutdown.Exit(shutdown.APP_EXCEPTION)
 	}
 
-	// the following was commented out per JACOBIN-327.
-	// Likely to be reinstated at some later point
-	// classloader.LoadReferencedClasses(mainClass)
-
 	// initialize the MTable (table caching methods)
 	classloader.MTable = make(map[string]classloader.MTentry)
 	classloader.MTableLoadNatives()
 
+	// load the classes the main class refers to in the background, while it runs
+	if Global.PreloadReferenced {
+		classloader.LoadReferencedClasses(mainClass)
+	}
+
 	// begin execution
 	_ = log.Log("Starting execution with: "+mainClass, log.INFO)
 	status := StartExec(mainClass, &Global)
@@ -98,3 +129,13 @@ func JVMrun() int {
 	}
 	return shutdown.Exit(shutdown.OK)
 }
+
+// loadMainClass loads the main class, given by name, through the application
+// classloader and returns the name under which it was loaded.
+func loadMainClass(className string) (string, error) {
+	k, err := classloader.AppCL.LoadClass(className)
+	if err != nil {
+		return "", err
+	}
+	return k.Data.Name, nil
+}
@@ -9,10 +9,12 @@ package jvm
 import (
 	"errors"
 	"fmt"
+	"jacobin/classloader"
 	"jacobin/execdata"
 	"jacobin/globals"
 	"jacobin/log"
 	"os"
+	"strings"
 )
 
 // This set of routines loads the Global.Options table with the various
@@ -66,6 +68,14 @@ func LoadOptionsTable(Global globals.Globals) {
 	Global.Options["-client"] = client
 	client.Set = true
 
+	classpath := globals.Option{true, false, 4, setClasspath}
+	Global.Options["-cp"] = classpath
+	Global.Options["-classpath"] = classpath
+	Global.Options["--class-path"] = classpath
+
+	defineProperty := globals.Option{true, false, 0, setSystemProperty}
+	Global.Options["-D"] = defineProperty
+
 	dryRun := globals.Option{false, false, 0, notSupported}
 	Global.Options["--dry-run"] = dryRun
 	dryRun.Set = true
@@ -85,6 +95,9 @@ func LoadOptionsTable(Global globals.Globals) {
 	Global.Options["-jar"] = jarFile
 	jarFile.Set = true
 
+	setEnv := globals.Option{true, false, 1, setEnvVariable}
+	Global.Options["-setenv"] = setEnv
+
 	showversion := globals.Option{true, false, 0, showVersionStderr}
 	Global.Options["-showversion"] = showversion
 
@@ -97,6 +110,9 @@ func LoadOptionsTable(Global globals.Globals) {
 	traceInstruction := globals.Option{true, false, 1, enableTraceInstructions}
 	Global.Options["-trace"] = traceInstruction
 
+	unsetEnv := globals.Option{true, false, 1, unsetEnvVariable}
+	Global.Options["-unsetenv"] = unsetEnv
+
 	verboseClass := globals.Option{true, false, 1, verbosityLevel}
 	Global.Options["-verbose"] = verboseClass
 
@@ -105,6 +121,12 @@ func LoadOptionsTable(Global globals.Globals) {
 
 	vversion := globals.Option{true, false, 1, versionStdoutThenExit}
 	Global.Options["--version"] = vversion
+
+	xshare := globals.Option{true, false, 1, setSharedArchiveMode}
+	Global.Options["-Xshare"] = xshare
+
+	xxOption := globals.Option{true, false, 1, setXXOption}
+	Global.Options["-XX"] = xxOption
 }
 
 // ---- the functions for the supported CLI options, in alphabetic order ----
@@ -117,6 +139,38 @@ func clientVM(pos int, name string, gl *globals.Globals) (int, error) {
 	return pos, nil
 }
 
+// for -cp, -classpath and --class-path, which are followed by the class path.
+// (--class-path=path is also accepted.) The class path overrides CLASSPATH.
+func setClasspath(pos int, arg string, gl *globals.Globals) (int, error) {
+	option := gl.Args[pos]
+	if arg == "" {
+		if len(gl.Args) <= pos+1 {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s requires class path specification\n", option)
+			return pos, os.ErrInvalid
+		}
+		pos++
+		arg = gl.Args[pos]
+	}
+	gl.Classpath = classloader.ExpandClasspath(arg)
+	if gl.Classpath == nil {
+		gl.Classpath = []string{"."} // an empty class path is the current directory
+	}
+	setOptionToSeen("-cp", gl)
+	return pos, nil
+}
+
+// for -Dkey=value, which sets a system property. -Dkey sets it to "".
+func setSystemProperty(pos int, arg string, gl *globals.Globals) (int, error) {
+	key, value, _ := strings.Cut(arg, "=")
+	if key == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s requires a property name. Ignored.\n", gl.Args[pos])
+		return pos, errors.New("missing property name in " + gl.Args[pos])
+	}
+	gl.Properties[key] = value
+	setOptionToSeen("-D", gl)
+	return pos, nil
+}
+
 // for -jar option. Get the next arg, which must be the JAR filename, and then all remaining args
 // are app args, which are duly added to Global.appArgs
 func getJarFilename(pos int, name string, gl *globals.Globals) (int, error) {
@@ -152,6 +206,30 @@ func showHelpStdoutAndExit(pos int, name string, gl *globals.Globals) (int, erro
 	return pos, nil
 }
 
+// for -setenv:NAME=value, which makes the environment variable appear to the
+// app (via System.getenv()) to have the value, whatever its actual value
+func setEnvVariable(pos int, arg string, gl *globals.Globals) (int, error) {
+	name, value, found := strings.Cut(arg, "=")
+	if name == "" || !found {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s must be in the form -setenv:NAME=value. Ignored.\n", gl.Args[pos])
+		return pos, errors.New("invalid environment variable setting: " + gl.Args[pos])
+	}
+	gl.EnvOverrides[name] = &value
+	setOptionToSeen("-setenv", gl)
+	return pos, nil
+}
+
+// for -unsetenv:NAME, which hides the environment variable from the app
+func unsetEnvVariable(pos int, arg string, gl *globals.Globals) (int, error) {
+	if arg == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s must be in the form -unsetenv:NAME. Ignored.\n", gl.Args[pos])
+		return pos, errors.New("invalid environment variable name: " + gl.Args[pos])
+	}
+	gl.EnvOverrides[arg] = nil
+	setOptionToSeen("-unsetenv", gl)
+	return pos, nil
+}
+
 func showVersionStderr(pos int, name string, gl *globals.Globals) (int, error) {
 	showVersion(os.Stderr, gl)
 	setOptionToSeen("-showversion", gl)
@@ -224,6 +302,41 @@ func verbosityLevel(pos int, argValue string, gl *globals.Globals) (int, error)
 	return pos, nil
 }
 
+// set whether the shared archive of parsed base classes is used (-Xshare:auto,
+// the default), ignored (-Xshare:off), or created (-Xshare:dump)
+func setSharedArchiveMode(pos int, argValue string, gl *globals.Globals) (int, error) {
+	switch argValue {
+	case classloader.ShareAuto, classloader.ShareOff, classloader.ShareDump:
+		gl.SharedArchive = argValue
+	default:
+		log.Log("Error: -Xshare:"+argValue+" is not a valid option. Ignored.", log.WARNING)
+		return pos, errors.New("Invalid -Xshare option specified: " + argValue)
+	}
+	setOptionToSeen("-Xshare", gl)
+	return pos, nil
+}
+
+// set a -XX: option, which turns a feature on (-XX:+Name) or off (-XX:-Name).
+// The JDK's -XX options that Jacobin doesn't have are ignored, with a warning.
+func setXXOption(pos int, argValue string, gl *globals.Globals) (int, error) {
+	if len(argValue) < 2 || (argValue[0] != '+' && argValue[0] != '-') {
+		log.Log("Error: -XX:"+argValue+" is not a valid option. Ignored.", log.WARNING)
+		return pos, errors.New("Invalid -XX option specified: " + argValue)
+	}
+	enable := argValue[0] == '+'
+	switch argValue[1:] {
+	case "PreloadClasslist":
+		gl.PreloadClasslist = enable
+	case "PreloadReferencedClasses":
+		gl.PreloadReferenced = enable
+	default:
+		log.Log("Warning: -XX:"+argValue+" is not supported by Jacobin. Ignored.", log.WARNING)
+		return pos, nil
+	}
+	setOptionToSeen("-XX", gl)
+	return pos, nil
+}
+
 // Marks the given option as having been 'set' that is, specified on the command line
 func setOptionToSeen(optionKey string, gl *globals.Globals) {
 	o := gl.Options[optionKey]
@@ -54,6 +54,16 @@ func StartExec(className string, globals *globals.Globals) error {
 		f.Locals = append(f.Locals, 0)
 	}
 
+	// main()'s parameter, the String[] of the app's arguments, is local 0
+	if len(f.Locals) > 0 {
+		args := object.Make1DimArray(object.REF, int64(len(globals.AppArgs)))
+		argsArray := *(args.Fields[0].Fvalue.(*[]*object.Object))
+		for i, arg := range globals.AppArgs {
+			argsArray[i] = object.NewStringFromGoString(arg)
+		}
+		f.Locals[0] = args
+	}
+
 	// create the first thread and place its first frame on it
 	MainThread = thread.CreateThread()
 	MainThread.Stack = frames.CreateFrameStack()
@@ -215,9 +225,12 @@ func runFrame(fs *list.List) error {
 				} else if CPe.retType == IS_STRUCT_ADDR {
 					push(f, (*object.Object)(unsafe.Pointer(CPe.addrVal)))
 				} else if CPe.retType == IS_STRING_ADDR {
-					stringAddr :=
-						object.CreateCompactStringFromGoString(CPe.stringVal)
-					stringAddr.Klass = &object.StringClassName
+					var stringAddr *object.Object
+					if CPe.entryType == classloader.UTF8 { // a string constant: use the interned string
+						stringAddr = classloader.FetchStringFromCPEntryNumber(f.CP, uint16(idx))
+					} else {
+						stringAddr = object.CreateCompactStringFromGoString(CPe.stringVal)
+					}
 					if classloader.MethAreaFetch(*stringAddr.Klass) == nil {
 						msg := fmt.Sprintf("LDC: MethAreaFetch could not find class java/lang/String")
 						_ = log.Log(msg, log.SEVERE)
@@ -248,9 +261,12 @@ func runFrame(fs *list.List) error {
 				} else if CPe.retType == IS_STRUCT_ADDR {
 					push(f, (*object.Object)(unsafe.Pointer(CPe.addrVal)))
 				} else if CPe.retType == IS_STRING_ADDR {
-					stringAddr :=
-						object.CreateCompactStringFromGoString(CPe.stringVal)
-					stringAddr.Klass = &object.StringClassName
+					var stringAddr *object.Object
+					if CPe.entryType == classloader.UTF8 { // a string constant: use the interned string
+						stringAddr = classloader.FetchStringFromCPEntryNumber(f.CP, uint16(idx))
+					} else {
+						stringAddr = object.CreateCompactStringFromGoString(CPe.stringVal)
+					}
 					if classloader.MethAreaFetch(*stringAddr.Klass) == nil {
 						msg := fmt.Sprintf("LDC_W: MethAreaFetch could not find class java/lang/String")
 						_ = log.Log(msg, log.SEVERE)
@@ -1377,6 +1393,10 @@ func runFrame(fs *list.List) error {
 			classNameIndex := f.CP.ClassRefs[f.CP.CpIndex[classRef].Slot]
 			classNameEntry := f.CP.CpIndex[classNameIndex]
 			className := f.CP.Utf8Refs[classNameEntry.Slot]
+			className, err := resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			// process the name and type entry for this field
 			nAndTindex := field.NameAndType
@@ -1457,6 +1477,10 @@ func runFrame(fs *list.List) error {
 			classNameIndex := f.CP.ClassRefs[f.CP.CpIndex[classRef].Slot]
 			classNameEntry := f.CP.CpIndex[classNameIndex]
 			className := f.CP.Utf8Refs[classNameEntry.Slot]
+			className, err := resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			// process the name and type entry for this field
 			nAndTindex := field.NameAndType
@@ -1672,6 +1696,10 @@ func runFrame(fs *list.List) error {
 			classNameIndex := f.CP.ClassRefs[f.CP.CpIndex[classRef].Slot]
 			classNameEntry := f.CP.CpIndex[classNameIndex]
 			className := f.CP.Utf8Refs[classNameEntry.Slot]
+			className, err = resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			// get the method name for this method
 			nAndTindex := method.NameAndType
@@ -1686,7 +1714,7 @@ func runFrame(fs *list.List) error {
 			methodSigIndex := nAndT.DescIndex
 			methodType := classloader.FetchUTF8stringFromCPEntryNumber(f.CP, methodSigIndex)
 
-			mtEntry := classloader.MTable[className+"."+methodName+methodType]
+			mtEntry := classloader.MTableFetch(className + "." + methodName + methodType)
 			if mtEntry.Meth == nil { // if the method is not in the method table, find it
 				mtEntry, err = classloader.FetchMethodAndCP(className, methodName, methodType)
 				if err != nil || mtEntry.Meth == nil {
@@ -1694,6 +1722,10 @@ func runFrame(fs *list.List) error {
 					return errors.New("INVOKEVIRTUAL: Class not found: " + className + "." + methodName)
 				}
 			}
+			mtEntry, className, err = goOrJavaMethod(mtEntry, f, className, methodName, methodType, true)
+			if err != nil {
+				return errors.New("INVOKEVIRTUAL: Method not found: " + className + "." + methodName)
+			}
 
 			if mtEntry.MType == 'G' { // so we have a golang function
 				_, err := runGmethod(mtEntry, fs, className, methodName, methodType)
@@ -1742,6 +1774,10 @@ func runFrame(fs *list.List) error {
 			CPslot := (int(f.Meth[f.PC+1]) * 256) + int(f.Meth[f.PC+2]) // next 2 bytes point to CP entry
 			f.PC += 2
 			className, methName, methSig := getMethInfoFromCPmethref(f.CP, CPslot)
+			className, err := resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			// if it's a call to java/lang/Object."<init>":()V, which happens frequently,
 			// that function simply returns. So test for it here and if it is, skip the rest
@@ -1754,9 +1790,13 @@ func runFrame(fs *list.List) error {
 			if err != nil {
 				return errors.New("INVOKESPECIAL: Class not found: " + className + "." + methName)
 			}
+			mtEntry, className, err = goOrJavaMethod(mtEntry, f, className, methName, methSig, false)
+			if err != nil {
+				return errors.New("INVOKESPECIAL: Method not found: " + className + "." + methName)
+			}
 
 			if mtEntry.MType == 'G' { // it's a golang method
-				f, err = runGmethod(mtEntry, fs, className, className+"."+methName, methSig)
+				f, err = runGmethod(mtEntry, fs, className, methName, methSig)
 				if err != nil {
 					// any exceptions message will already have been displayed to the user
 					return errors.New("INVOKESPECIAL: Error encountered in: " +
@@ -1790,18 +1830,98 @@ func runFrame(fs *list.List) error {
 					return nil
 				}
 			}
+		case INVOKEINTERFACE: // 0xB9 invokeinterface (invoke a method declared in an interface)
+			CPslot := (int(f.Meth[f.PC+1]) * 256) + int(f.Meth[f.PC+2]) // next 2 bytes point to CP entry
+			count := int(f.Meth[f.PC+3])                                // the slots of the args, including the object ref
+			f.PC += 4                                                   // the fourth byte is always zero
+
+			interfaceName, methodName, methodType := getMethInfoFromCPinterfaceRef(f.CP, CPslot)
+			if methodName == "" {
+				errMsg := fmt.Sprintf("INVOKEINTERFACE: Expected an interface method ref at CP entry %d "+
+					"in method %s of class %s", CPslot, f.MethName, f.ClName)
+				_ = log.Log(errMsg, log.SEVERE)
+				return errors.New(errMsg)
+			}
+
+			// the method is looked up in the class of the object it's invoked on
+			objRef, _ := f.OpStack[f.TOS-count+1].(*object.Object)
+			if objRef == nil || objRef.Klass == nil {
+				errMsg := "INVOKEINTERFACE: Invalid (null) object reference in call to " +
+					interfaceName + "." + methodName
+				exceptions.Throw(exceptions.NullPointerException, errMsg)
+				return errors.New(errMsg)
+			}
+			className := *objRef.Klass
+
+			// which is in the class, a superclass, or a default method of an interface
+			mtEntry, methClass, err := classloader.FetchInterfaceMethod(className, methodName, methodType)
+			if err != nil {
+				errMsg := "INVOKEINTERFACE: " + err.Error()
+				if strings.HasPrefix(err.Error(), "java.lang.") { // AbstractMethodError, IncompatibleClassChangeError
+					exceptions.Throw(exceptions.LinkageError, err.Error())
+				} else {
+					_ = log.Log(errMsg, log.SEVERE)
+				}
+				return errors.New(errMsg)
+			}
+			mtEntry, className, err = goOrJavaMethod(mtEntry, f, methClass, methodName, methodType, true)
+			if err != nil {
+				return errors.New("INVOKEINTERFACE: Method not found: " + className + "." + methodName)
+			}
+
+			if mtEntry.MType == 'G' { // so we have a golang function
+				_, err := runGmethod(mtEntry, fs, className, methodName, methodType)
+				if err != nil {
+					// any exception message will already have been displayed to the user
+					return errors.New("INVOKEINTERFACE: Error encountered in: " +
+						className + "." + methodName)
+				}
+				break
+			}
+
+			if mtEntry.MType == 'J' { // it's a Java function (that is, non-native)
+				m := mtEntry.Meth.(classloader.JmEntry)
+				fram, err := createAndInitNewFrame(
+					className, methodName, methodType, &m, true, f)
+				if err != nil {
+					return errors.New("INVOKEINTERFACE: Error creating frame in: " +
+						className + "." + methodName)
+				}
+
+				fs.PushFront(fram)                   // push the new frame
+				f = fs.Front().Value.(*frames.Frame) // point f to the new head
+				err = runFrame(fs)
+				if err != nil {
+					return err
+				}
+				fs.Remove(fs.Front()) // pop the frame off
+				f = fs.Front().Value.(*frames.Frame)
+			}
+
 		case INVOKESTATIC: // 	0xB8 invokestatic (create new frame, invoke static function)
 			CPslot := (int(f.Meth[f.PC+1]) * 256) + int(f.Meth[f.PC+2]) // next 2 bytes point to CP entry
 			f.PC += 2
 			CPentry := f.CP.CpIndex[CPslot]
-			// get the methodRef entry
-			method := f.CP.MethodRefs[CPentry.Slot]
+			// get the methodRef entry. Static methods of interfaces, such as
+			// ProcessHandle.current(), are referred to by interface method refs.
+			method := classloader.MethodRefEntry{}
+			if CPentry.Type == classloader.Interface {
+				interfaceRef := f.CP.InterfaceRefs[CPentry.Slot]
+				method.ClassIndex = interfaceRef.ClassIndex
+				method.NameAndType = interfaceRef.NameAndType
+			} else {
+				method = f.CP.MethodRefs[CPentry.Slot]
+			}
 
 			// get the class entry from this method
 			classRef := method.ClassIndex
 			classNameIndex := f.CP.ClassRefs[f.CP.CpIndex[classRef].Slot]
 			classNameEntry := f.CP.CpIndex[classNameIndex]
 			className := f.CP.Utf8Refs[classNameEntry.Slot]
+			className, err := resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			// get the method name for this method
 			nAndTindex := method.NameAndType
@@ -1878,6 +1998,10 @@ func runFrame(fs *list.List) error {
 				utf8Index := f.CP.ClassRefs[CPentry.Slot]
 				className = classloader.FetchUTF8stringFromCPEntryNumber(f.CP, utf8Index)
 			}
+			className, err := resolveClassRef(f, CPslot, className)
+			if err != nil {
+				return err
+			}
 
 			ref, err := instantiateClass(className)
 			if err != nil {
@@ -2041,6 +2165,10 @@ func runFrame(fs *list.List) error {
 						return errors.New(errMsg)
 					}
 				} else { // the object being checked is a class
+					className, err := resolveClassRef(f, CPslot, className)
+					if err != nil {
+						return err
+					}
 					classPtr := classloader.MethAreaFetch(className)
 					if classPtr == nil { // class wasn't loaded, so load it now
 						if classloader.LoadClassFromNameOnly(className) != nil {
@@ -2097,6 +2225,10 @@ func runFrame(fs *list.List) error {
 								_ = log.Log(msg, log.TRACE_INST)
 							}
 						}
+						className, err := resolveClassRef(f, CPslot, className)
+						if err != nil {
+							return err
+						}
 						classPtr := classloader.MethAreaFetch(className)
 						if classPtr == nil { // class wasn't loaded, so load it now
 							if classloader.LoadClassFromNameOnly(className) != nil {
@@ -174,3 +174,26 @@ func getMethInfoFromCPmethref(CP *classloader.CPool, cpIndex int) (string, strin
 
 	return className, methName, methSig
 }
+
+// getMethInfoFromCPinterfaceRef returns the interface name, method name, and
+// method signature of an interface method ref in the CP, or three empty
+// strings if the entry is not an interface method ref
+func getMethInfoFromCPinterfaceRef(CP *classloader.CPool, cpIndex int) (string, string, string) {
+	if cpIndex < 1 || cpIndex >= len(CP.CpIndex) {
+		return "", "", ""
+	}
+
+	if CP.CpIndex[cpIndex].Type != classloader.Interface {
+		return "", "", ""
+	}
+	interfaceRef := CP.InterfaceRefs[CP.CpIndex[cpIndex].Slot]
+
+	classIdx := CP.ClassRefs[CP.CpIndex[interfaceRef.ClassIndex].Slot]
+	className := CP.Utf8Refs[CP.CpIndex[classIdx].Slot]
+
+	nameAndType := CP.NameAndTypes[CP.CpIndex[interfaceRef.NameAndType].Slot]
+	methName := CP.Utf8Refs[CP.CpIndex[nameAndType.NameIndex].Slot]
+	methSig := CP.Utf8Refs[CP.CpIndex[nameAndType.DescIndex].Slot]
+
+	return className, methName, methSig
+}
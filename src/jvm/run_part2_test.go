@@ -267,6 +267,113 @@ func TestInstanceofString(t *testing.T) {
 	}
 }
 
+// interfaceRefCP creates a CP whose entry [6] is an interface method ref to
+// java/util/Map.size()I
+func interfaceRefCP() *classloader.CPool {
+	CP := classloader.CPool{}
+	CP.CpIndex = make([]classloader.CpEntry, 7, 7)
+	CP.CpIndex[0] = classloader.CpEntry{Type: 0, Slot: 0}
+	CP.CpIndex[1] = classloader.CpEntry{Type: classloader.UTF8, Slot: 0}
+	CP.CpIndex[2] = classloader.CpEntry{Type: classloader.ClassRef, Slot: 0}
+	CP.CpIndex[3] = classloader.CpEntry{Type: classloader.UTF8, Slot: 1}
+	CP.CpIndex[4] = classloader.CpEntry{Type: classloader.UTF8, Slot: 2}
+	CP.CpIndex[5] = classloader.CpEntry{Type: classloader.NameAndType, Slot: 0}
+	CP.CpIndex[6] = classloader.CpEntry{Type: classloader.Interface, Slot: 0}
+	CP.Utf8Refs = []string{"java/util/Map", "size", "()I"}
+	CP.ClassRefs = []uint16{1}
+	CP.NameAndTypes = []classloader.NameAndTypeEntry{{NameIndex: 3, DescIndex: 4}}
+	CP.InterfaceRefs = []classloader.InterfaceRefEntry{{ClassIndex: 2, NameAndType: 5}}
+	return &CP
+}
+
+// INVOKEINTERFACE: the method is looked up in the class of the object
+func TestInvokeinterfaceGoMethod(t *testing.T) {
+	classloader.MTable["test/SizedMap.size()I"] = classloader.MTentry{
+		Meth: classloader.GmEntry{ParamSlots: 1, Fu: func(params []interface{}) interface{} {
+			return int64(len(params[0].(*object.Object).Fields))
+		}},
+		MType: 'G',
+	}
+	obj := object.MakeEmptyObject()
+	className := "test/SizedMap"
+	obj.Klass = &className
+	obj.Fields = make([]object.Field, 3)
+
+	f := newFrame(INVOKEINTERFACE)
+	f.Meth = append(f.Meth, 0x00, 0x06, 0x01, 0x00) // CP entry 6, 1 slot of args, and a zero
+	f.CP = interfaceRefCP()
+	push(&f, obj)
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&f) // push the new frame
+	if err := runFrame(fs); err != nil {
+		t.Fatalf("INVOKEINTERFACE: Unexpected error: %s", err.Error())
+	}
+
+	if value := pop(&f).(int64); value != 3 {
+		t.Errorf("INVOKEINTERFACE: Expected the method of test/SizedMap to return 3, got %d", value)
+	}
+}
+
+// INVOKEINTERFACE: invoking a method on null is an error
+func TestInvokeinterfaceNullObject(t *testing.T) {
+	g := globals.GetGlobalRef()
+	globals.InitGlobals("test")
+	g.JacobinName = "test"
+	log.Init()
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	f := newFrame(INVOKEINTERFACE)
+	f.Meth = append(f.Meth, 0x00, 0x06, 0x01, 0x00)
+	f.CP = interfaceRefCP()
+	push(&f, object.Null)
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&f) // push the new frame
+	err := runFrame(fs)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if err == nil || !strings.Contains(err.Error(), "null) object reference in call to java/util/Map.size") {
+		t.Errorf("INVOKEINTERFACE: Expected an error for a null object, got %v", err)
+	}
+}
+
+// INVOKESTATIC: static methods of interfaces are referred to by interface method refs
+func TestInvokestaticInterfaceMethod(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	classloader.InitMethodArea()
+	classloader.MethAreaInsert("test/Clock",
+		&(classloader.Klass{
+			Status: 'X', // use a status that's not subsequently tested for.
+			Loader: "bootstrap",
+			Data:   nil,
+		}))
+	classloader.MTable["test/Clock.now()I"] = classloader.MTentry{
+		Meth:  classloader.GmEntry{ParamSlots: 0, Fu: func([]interface{}) interface{} { return int64(42) }},
+		MType: 'G',
+	}
+
+	f := newFrame(INVOKESTATIC)
+	f.Meth = append(f.Meth, 0x00, 0x06) // CP entry 6, an interface method ref
+	f.CP = interfaceRefCP()
+	f.CP.Utf8Refs = []string{"test/Clock", "now", "()I"}
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&f) // push the new frame
+	if err := runFrame(fs); err != nil {
+		t.Fatalf("INVOKESTATIC: Unexpected error: %s", err.Error())
+	}
+	if value := pop(&f).(int64); value != 42 {
+		t.Errorf("INVOKESTATIC: Expected test/Clock.now() to return 42, got %d", value)
+	}
+}
+
 // INVOKEVIRTUAL : invoke method -- here testing for error
 func TestInvokevirtualInvalid(t *testing.T) {
 	f := newFrame(INVOKEVIRTUAL)
@@ -842,6 +949,54 @@ func TestLdc(t *testing.T) {
 	}
 }
 
+// LDC: a string constant evaluates to the same (interned) object every time,
+// and in every class that uses the same literal
+func TestLdcStringIsInterned(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	classloader.InitMethodArea()
+	classloader.MethAreaInsert("java/lang/String",
+		&(classloader.Klass{
+			Status: 'X', // use a status that's not subsequently tested for.
+			Loader: "bootstrap",
+			Data:   nil,
+		}))
+
+	makeCP := func() *classloader.CPool {
+		cp := classloader.CPool{}
+		cp.CpIndex = append(cp.CpIndex, classloader.CpEntry{})
+		cp.CpIndex = append(cp.CpIndex, classloader.CpEntry{Type: classloader.UTF8, Slot: 0})
+		cp.Utf8Refs = append(cp.Utf8Refs, "interned literal")
+		return &cp
+	}
+
+	ldcString := func(cp *classloader.CPool) *object.Object {
+		f := newFrame(LDC)
+		f.Meth = append(f.Meth, 0x01)
+		f.CP = cp
+		fs := frames.CreateFrameStack()
+		fs.PushFront(&f) // push the new frame
+		_ = runFrame(fs)
+		return pop(&f).(*object.Object)
+	}
+
+	cp1 := makeCP()
+	first := ldcString(cp1)
+	if object.GetGoStringFromJavaStringPtr(first) != "interned literal" {
+		t.Errorf("LDC: Expected string 'interned literal', got: %s",
+			object.GetGoStringFromJavaStringPtr(first))
+	}
+	if ldcString(cp1) != first {
+		t.Errorf("LDC: Expected the same literal to produce the same object")
+	}
+	if cp1.Strings[1] != first {
+		t.Errorf("LDC: Expected the string to be cached in the CP")
+	}
+	if ldcString(makeCP()) != first {
+		t.Errorf("LDC: Expected the same literal in another class to produce the same object")
+	}
+}
+
 // Test LDC_W: get int64 CP entry indexed by two bytes
 func TestLdcw(t *testing.T) {
 	f := newFrame(LDC_W)
@@ -1974,3 +2129,45 @@ func TestConvertInterfaceToUint64(t *testing.T) {
 		t.Error("Expected TestConvertInterfaceToUint64() to !=0, got 0\n")
 	}
 }
+
+// StartExec: main() gets the app's arguments as a String[]
+func TestStartExecPassesAppArgs(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	g.JacobinName = "test"
+	g.AppArgs = []string{"--port", "8080"}
+	log.Init()
+	classloader.InitMethodArea()
+
+	// main() passes its args to test/Recorder.record(String[]), by means of an interface method ref
+	CP := interfaceRefCP()
+	CP.Utf8Refs = []string{"test/Recorder", "record", "([Ljava/lang/String;)V", "main"}
+	classloader.MethAreaInsert("test/App", &(classloader.Klass{
+		Status: 'X', // use a status that's not subsequently tested for.
+		Loader: "bootstrap",
+		Data: &classloader.ClData{Name: "test/App", Superclass: "java/lang/Object", CP: *CP,
+			Methods: []classloader.Method{{Name: 3, Desc: 2, CodeAttr: classloader.CodeAttrib{
+				MaxStack: 1, MaxLocals: 1, Code: []byte{ALOAD_0, INVOKESTATIC, 0x00, 0x06, RETURN},
+			}}},
+		},
+	}))
+	classloader.MethAreaInsert("test/Recorder", &(classloader.Klass{Status: 'X', Loader: "bootstrap"}))
+
+	var received []string
+	classloader.MTable["test/Recorder.record([Ljava/lang/String;)V"] = classloader.MTentry{
+		Meth: classloader.GmEntry{ParamSlots: 1, Fu: func(params []interface{}) interface{} {
+			for _, arg := range *(params[0].(*object.Object).Fields[0].Fvalue.(*[]*object.Object)) {
+				received = append(received, object.GetGoStringFromJavaStringPtr(arg))
+			}
+			return nil
+		}},
+		MType: 'G',
+	}
+
+	if err := StartExec("test/App", g); err != nil {
+		t.Fatalf("Unexpected error from StartExec(): %v", err)
+	}
+	if strings.Join(received, " ") != "--port 8080" {
+		t.Errorf("Expected main() to get the args --port 8080, got %v", received)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/thread"
+	"strconv"
+)
+
+// runShutdownHook runs a shutdown hook registered with Runtime.addShutdownHook().
+// It's called by the shutdown package, on a goroutine of its own, when the JVM
+// exits. As in the JDK, an exception in one hook doesn't stop the others.
+func runShutdownHook(hook interface{}) {
+	t, ok := hook.(*object.Object)
+	if !ok || t == nil {
+		return
+	}
+	if err := runThreadObject(t, &Global); err != nil {
+		_ = log.Log("Exception in shutdown hook: "+err.Error(), log.SEVERE)
+	}
+}
+
+// runThreadObject runs the run() method of a java/lang/Thread object on a new
+// thread of execution and returns when it's done. If the class of the object
+// doesn't override Thread.run(), the run() method of the thread's Runnable
+// is run, as Thread.run() does.
+func runThreadObject(t *object.Object, glob *globals.Globals) error {
+	obj := t
+	className := *t.Klass
+	me, err := classloader.FetchMethodAndCP(className, "run", "()V")
+	if err != nil {
+		return err
+	}
+	if isThreadRun(me) {
+		target, _ := t.FieldTable[classloader.ThreadTargetField].Fvalue.(*object.Object)
+		if target == nil || target.Klass == nil {
+			return nil // a Thread with no Runnable does nothing
+		}
+		obj = target
+		className = *target.Klass
+		me, err = classloader.FetchMethodAndCP(className, "run", "()V")
+		if err != nil {
+			return err
+		}
+	}
+	if me.MType != 'J' {
+		return errors.New("runThreadObject: no Java run() method in " + className)
+	}
+
+	m := me.Meth.(classloader.JmEntry)
+	f := frames.CreateFrame(m.MaxStack)
+	f.MethName = "run"
+	f.ClName = className
+	f.CP = m.Cp
+	f.Meth = append(f.Meth, m.Code...)
+	for k := 0; k < m.MaxLocals; k++ {
+		f.Locals = append(f.Locals, 0)
+	}
+	if len(f.Locals) > 0 {
+		f.Locals[0] = obj // this
+	}
+
+	execThread := thread.CreateThread()
+	execThread.Stack = frames.CreateFrameStack()
+	execThread.ID = thread.AddThreadToTable(&execThread, &glob.Threads)
+	if trace, exists := glob.Options["-trace"]; exists {
+		execThread.Trace = trace.Set
+	}
+	f.Thread = execThread.ID
+
+	if frames.PushFrame(execThread.Stack, f) != nil {
+		_ = log.Log("Memory exceptions allocating frame on thread: "+strconv.Itoa(execThread.ID),
+			log.SEVERE)
+		return errors.New("outOfMemory Exception")
+	}
+
+	_ = log.Log(fmt.Sprintf("runThreadObject: running %s.run() on thread %d", className, execThread.ID), log.FINE)
+	return runThread(&execThread)
+}
+
+// isThreadRun returns whether the method is java/lang/Thread's own run()
+func isThreadRun(me classloader.MTentry) bool {
+	if me.MType != 'J' {
+		return false
+	}
+	k := classloader.MethAreaFetch("java/lang/Thread")
+	return k != nil && k.Data != nil && me.Meth.(classloader.JmEntry).Cp == &k.Data.CP
+}
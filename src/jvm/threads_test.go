@@ -0,0 +1,103 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"jacobin/classloader"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"testing"
+)
+
+// insertRunClass puts a class in the method area whose run() method calls
+// ran() on this, by means of INVOKEINTERFACE. If withRun is false, the class has no run() method.
+func insertRunClass(className, superclass string, withRun bool) {
+	CP := interfaceRefCP() // entry 6 is an interface method ref, here to test/Recorder.ran()V
+	CP.Utf8Refs = []string{"test/Recorder", "ran", "()V", "run"}
+
+	k := classloader.Klass{
+		Status: 'X', // use a status that's not subsequently tested for.
+		Loader: "bootstrap",
+		Data:   &classloader.ClData{Name: className, Superclass: superclass, CP: *CP},
+	}
+	if withRun {
+		k.Data.Methods = []classloader.Method{{
+			Name: 3,
+			Desc: 2,
+			CodeAttr: classloader.CodeAttrib{
+				MaxStack:  2,
+				MaxLocals: 1,
+				Code:      []byte{ALOAD_0, INVOKEINTERFACE, 0x00, 0x06, 0x01, 0x00, RETURN},
+			},
+		}}
+	}
+	classloader.MethAreaInsert(className, &k)
+}
+
+func newObjectOfClass(className string) *object.Object {
+	obj := object.MakeEmptyObject()
+	obj.Klass = &className
+	return obj
+}
+
+func TestRunThreadObject(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	g.JacobinName = "test"
+	log.Init()
+	classloader.InitMethodArea()
+
+	insertRunClass("java/lang/Thread", "java/lang/Object", true)
+	insertRunClass("test/Hook", "java/lang/Thread", true)
+	insertRunClass("test/PlainThread", "java/lang/Thread", false)
+	insertRunClass("test/Task", "java/lang/Object", true)
+
+	var ranOn []*object.Object
+	recordRun := classloader.MTentry{
+		Meth: classloader.GmEntry{ParamSlots: 1, Fu: func(params []interface{}) interface{} {
+			ranOn = append(ranOn, params[0].(*object.Object))
+			return nil
+		}},
+		MType: 'G',
+	}
+	for _, className := range []string{"java/lang/Thread", "test/Hook", "test/PlainThread", "test/Task"} {
+		classloader.MTable[className+".ran()V"] = recordRun
+	}
+
+	// a subclass of Thread that overrides run() runs its own run(), even if it has a Runnable
+	hook := newObjectOfClass("test/Hook")
+	task := newObjectOfClass("test/Task")
+	hook.FieldTable = map[string]object.Field{classloader.ThreadTargetField: {Fvalue: task}}
+	if err := runThreadObject(hook, g); err != nil {
+		t.Fatalf("Unexpected error running test/Hook: %v", err)
+	}
+	if len(ranOn) != 1 || ranOn[0] != hook {
+		t.Errorf("Expected the run() of test/Hook to run on the hook, got %v", ranOn)
+	}
+
+	// Thread and subclasses that don't override run() run the run() of their Runnable
+	for _, className := range []string{"java/lang/Thread", "test/PlainThread"} {
+		ranOn = nil
+		thread := newObjectOfClass(className)
+		thread.FieldTable = map[string]object.Field{classloader.ThreadTargetField: {Fvalue: task}}
+		if err := runThreadObject(thread, g); err != nil {
+			t.Fatalf("Unexpected error running %s: %v", className, err)
+		}
+		if len(ranOn) != 1 || ranOn[0] != task {
+			t.Errorf("Expected %s to run the run() of its Runnable, got %v", className, ranOn)
+		}
+	}
+
+	// and without a Runnable, they do nothing
+	ranOn = nil
+	thread := newObjectOfClass("test/PlainThread")
+	thread.FieldTable = map[string]object.Field{classloader.ThreadTargetField: {Fvalue: object.Null}}
+	if err := runThreadObject(thread, g); err != nil || len(ranOn) != 0 {
+		t.Errorf("Expected a thread with no Runnable to do nothing, got %v, %v", err, ranOn)
+	}
+}
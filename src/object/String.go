@@ -8,6 +8,7 @@ package object
 
 import (
 	"jacobin/types"
+	"unicode/utf16"
 )
 
 // Strings are so commonly used in Java, that it makes sense
@@ -28,17 +29,16 @@ func NewString() *Object {
 
 	// ==== now the fields ====
 
-	// field 00 -- value: the content of the string as array of chars
+	// field 00 -- value: the content of the string as array of bytes
 	// Note: Post JDK9, this field is an array of bytes, so as to
-	// enable compact strings. Here, for better compatibility with
-	// go, for the nonce we make it an array of Java chars's
-	// equivalent in go: runes.
-	array := make([]byte, 10)
+	// enable compact strings. How the bytes are interpreted depends
+	// on the coder in field 01.
+	array := make([]byte, 0)
 	s.Fields = append(s.Fields,
 		Field{Ftype: types.ByteArray, Fvalue: &array})
 
 	// field 01 -- coder LATIN(=bytes, for compact strings) is 0; UTF16 is 1
-	s.Fields = append(s.Fields, Field{Ftype: types.Byte, Fvalue: int64(1)})
+	s.Fields = append(s.Fields, Field{Ftype: types.Byte, Fvalue: CoderLatin1})
 
 	// field 02 -- string hash
 	s.Fields = append(s.Fields, Field{Ftype: types.Int, Fvalue: int64(0)})
@@ -71,35 +71,126 @@ func NewString() *Object {
 	return s
 }
 
-// NewStringFromGoString converts a go string to a Java string-like
-// entity, in which the chars are stored as runes, rather than chars.
-// TODO: it needs to determine whether a string can be stored as bytes or
-// chars and set the flags in the String instance correctly.
+// The coder field of a String identifies how the chars are stored in the
+// value byte array. For compact strings, in which every char fits in a
+// byte (i.e., is <= 0xFF), the coder is LATIN1 and there is one byte per
+// char. For all other strings, it's UTF16 with two bytes per char, stored
+// high byte first (so StringUTF16.isBigEndian() is true in Jacobin).
+const (
+	CoderLatin1 = int64(0)
+	CoderUTF16  = int64(1)
+)
+
+// NewStringFromGoString converts a go string to a Java string. The
+// string is stored as a compact (LATIN1) string if all its chars fit in a
+// byte; otherwise, it's stored as UTF16.
 func NewStringFromGoString(in string) *Object {
-	s := NewString()
-	s.Fields[0].Ftype = types.RuneArray
-	s.Fields[0].Fvalue = in // test for compact strings and use GoStringToBytes() if on
-	return s
+	return NewStringFromUTF16(utf16.Encode([]rune(in)))
 }
 
 // CreateCompactStringFromGoString creates a string in which the chars
-// are stored as bytes--that is, a compact string.
+// are stored as bytes--that is, a compact string--whenever the chars
+// permit it. Strings containing chars > 0xFF are stored as UTF16.
 func CreateCompactStringFromGoString(in *string) *Object {
-	stringBytes := []byte(*in)
+	return NewStringFromGoString(*in)
+}
+
+// NewStringFromUTF16 creates a Java string from an array of Java chars
+func NewStringFromUTF16(chars []uint16) *Object {
 	s := NewString()
+	SetStringValue(s, chars)
+	return s
+}
 
-	// set the value of the string
-	s.Fields[0].Ftype = types.ByteArray
-	s.Fields[0].Fvalue = &stringBytes
+// SetStringValue sets the value and coder fields of a string to hold
+// the given Java chars and clears the cached hash. If the object does
+// not yet have the String fields (as when it was created by the NEW
+// bytecode, prior to a call to a constructor), they are created.
+func SetStringValue(s *Object, chars []uint16) {
+	if len(s.Fields) < 10 || s.Fields[0].Ftype != types.ByteArray {
+		s.Fields = NewString().Fields
+	}
+	s.Klass = &StringClassName
+
+	coder := CoderLatin1
+	for _, c := range chars {
+		if c > 0xFF {
+			coder = CoderUTF16
+			break
+		}
+	}
+
+	var value []byte
+	if coder == CoderLatin1 {
+		value = make([]byte, len(chars))
+		for i, c := range chars {
+			value[i] = byte(c)
+		}
+	} else {
+		value = make([]byte, 2*len(chars))
+		for i, c := range chars {
+			value[2*i] = byte(c >> 8)
+			value[2*i+1] = byte(c)
+		}
+	}
+
+	s.Fields[0] = Field{Ftype: types.ByteArray, Fvalue: &value}
+	s.Fields[1] = Field{Ftype: types.Byte, Fvalue: coder}
+	s.Fields[2] = Field{Ftype: types.Int, Fvalue: int64(0)}
+	s.Fields[9] = Field{Ftype: types.Bool, Fvalue: types.JavaBoolFalse}
+}
 
-	// set the string to LATIN
-	s.Fields[1].Fvalue = int64(0)
-	return s
+// IsJavaString returns true if the object is an instance of java/lang/String
+func IsJavaString(obj *Object) bool {
+	return obj != nil && obj.Klass != nil && *obj.Klass == StringClassName &&
+		len(obj.Fields) > 1 && obj.Fields[0].Ftype == types.ByteArray
+}
+
+// GetStringUTF16 returns the chars of a Java string as Java chars,
+// regardless of whether the string is stored as LATIN1 or UTF16
+func GetStringUTF16(str *Object) []uint16 {
+	bytes := *(str.Fields[0].Fvalue.(*[]byte))
+	if GetStringCoder(str) == CoderLatin1 {
+		chars := make([]uint16, len(bytes))
+		for i, b := range bytes {
+			chars[i] = uint16(b)
+		}
+		return chars
+	}
+
+	chars := make([]uint16, len(bytes)/2)
+	for i := range chars {
+		chars[i] = uint16(bytes[2*i])<<8 | uint16(bytes[2*i+1])
+	}
+	return chars
+}
+
+// GetStringCoder returns the coder of a Java string: LATIN1 or UTF16
+func GetStringCoder(str *Object) int64 {
+	if coder, ok := str.Fields[1].Fvalue.(int64); ok {
+		return coder
+	}
+	return CoderLatin1
+}
+
+// GetStringLength returns the length of a Java string in Java chars
+func GetStringLength(str *Object) int {
+	bytes := *(str.Fields[0].Fvalue.(*[]byte))
+	if GetStringCoder(str) == CoderLatin1 {
+		return len(bytes)
+	}
+	return len(bytes) / 2
 }
 
 // convenience method to extract a Go string from a Java string
 func GetGoStringFromJavaStringPtr(strPtr *Object) string {
-	s := *strPtr
-	bytes := s.Fields[0].Fvalue.(*[]byte)
-	return string(*bytes)
+	if GetStringCoder(strPtr) == CoderLatin1 {
+		bytes := *(strPtr.Fields[0].Fvalue.(*[]byte))
+		runes := make([]rune, len(bytes))
+		for i, b := range bytes {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return string(utf16.Decode(GetStringUTF16(strPtr)))
 }
@@ -7,7 +7,7 @@
 package object
 
 import (
-	"unsafe"
+	"sync/atomic"
 )
 
 // With regard to the layout of a created object in Jacobin, note that
@@ -22,11 +22,12 @@ type Object struct {
 }
 
 // These mark word contains values for different purposes. Here,
-// we use the first four bytes for a hash value, which is taken
-// from the address of the object. The 'misc' field will eventually
+// we use the first four bytes for the identity hash value, which is
+// generated the first time it's requested (see IdentityHash()) and
+// then kept for the life of the object. The 'misc' field will eventually
 // contain other values, such as locking and monitoring items.
 type MarkWord struct {
-	Hash uint32 // contains hash code which is the lower 32 bits of the address
+	Hash uint32 // contains the identity hash code; 0 = not yet assigned
 	Misc uint32 // at present unused
 }
 
@@ -45,8 +46,49 @@ var Null *Object = nil
 // code will fill in the fields and the Klass field.
 func MakeEmptyObject() *Object {
 	o := Object{}
-	h := uintptr(unsafe.Pointer(&o))
-	o.Mark.Hash = uint32(h)
 	o.Klass = &EmptyString // s/be filled in later, when class is filled in.
 	return &o
 }
+
+// hashState is the running state of the identity-hash generator
+var hashState uint64
+
+// IdentityHash returns the identity hash code of an object, which is
+// what Object.hashCode() and System.identityHashCode() return. The hash
+// is not derived from the object's address (which, in principle, the Go
+// runtime is free to change), but is generated on first use and stored
+// in the mark word, so it remains the same for the life of the object.
+// As in HotSpot, the value is a non-zero, 31-bit positive integer.
+func IdentityHash(obj *Object) uint32 {
+	if obj == nil {
+		return 0
+	}
+
+	h := atomic.LoadUint32(&obj.Mark.Hash)
+	if h != 0 {
+		return h
+	}
+
+	newHash := nextHash()
+	if atomic.CompareAndSwapUint32(&obj.Mark.Hash, 0, newHash) {
+		return newHash
+	}
+	// another thread got there first, so use the hash it stored
+	return atomic.LoadUint32(&obj.Mark.Hash)
+}
+
+// nextHash generates the next identity hash using the splitmix64
+// mixing function over an atomically incremented counter. This is
+// thread-safe and gives well-distributed values.
+func nextHash() uint32 {
+	for {
+		z := atomic.AddUint64(&hashState, 0x9E3779B97F4A7C15)
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		z = z ^ (z >> 31)
+		h := uint32(z) & 0x7FFFFFFF
+		if h != 0 {
+			return h
+		}
+	}
+}
@@ -0,0 +1,58 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package object
+
+import "sync"
+
+// The string pool holds the interned strings: the canonical instance of every
+// string literal, plus every string on which String.intern() has been called.
+// It is shared by all classes and all threads, so that a literal evaluates to
+// the same object wherever it appears, as the JLS (3.10.5) requires.
+//
+// The key is the string's coder followed by its bytes. Because the coder is
+// LATIN1 whenever the chars permit it, equal strings always have the same key.
+var stringPool = make(map[string]*Object)
+var stringPoolMutex sync.RWMutex
+
+// InternString returns the canonical instance of a string: the first string
+// with the same chars to be interned. This is what String.intern() returns.
+func InternString(str *Object) *Object {
+	key := stringPoolKey(str)
+
+	stringPoolMutex.RLock()
+	canonical, ok := stringPool[key]
+	stringPoolMutex.RUnlock()
+	if ok {
+		return canonical
+	}
+
+	stringPoolMutex.Lock()
+	defer stringPoolMutex.Unlock()
+	if canonical, ok = stringPool[key]; ok { // another thread might have added it
+		return canonical
+	}
+	stringPool[key] = str
+	return str
+}
+
+// InternGoString returns the canonical instance of the Java string having the
+// same chars as the Go string. Used for string literals.
+func InternGoString(str string) *Object {
+	return InternString(NewStringFromGoString(str))
+}
+
+// StringPoolSize returns the number of strings in the string pool
+func StringPoolSize() int {
+	stringPoolMutex.RLock()
+	defer stringPoolMutex.RUnlock()
+	return len(stringPool)
+}
+
+func stringPoolKey(str *Object) string {
+	bytes := *(str.Fields[0].Fvalue.(*[]byte))
+	return string(rune('0'+GetStringCoder(str))) + string(bytes)
+}
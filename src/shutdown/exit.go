@@ -7,10 +7,12 @@
 package shutdown
 
 import (
+	"errors"
 	"fmt"
 	"jacobin/globals"
 	"jacobin/log"
 	"os"
+	"sync"
 )
 
 // The various flags that can be passed to the exit() function, reflecting
@@ -26,12 +28,186 @@ const (
 	UNKNOWN_ERROR
 )
 
-// Shutdown is the exit function. Later on, this will check a list of JVM Shutdown hooks
-// before closing down in order to have an orderly exit
+// exitFuncs are run just before the JVM exits. They're used for work that must
+// be completed on every exit, such as flushing the output buffered by System.out.
+var exitFuncs []func()
+var exitFuncsMutex sync.Mutex
+
+// OnExit registers a function to be run when the JVM exits
+func OnExit(f func()) {
+	exitFuncsMutex.Lock()
+	exitFuncs = append(exitFuncs, f)
+	exitFuncsMutex.Unlock()
+}
+
+func runExitFuncs() {
+	exitFuncsMutex.Lock()
+	defer exitFuncsMutex.Unlock()
+	for _, f := range exitFuncs {
+		f()
+	}
+}
+
+// ErrShutdownInProgress and ErrHookRegistered are the errors that AddHook() and
+// RemoveHook() return. The messages are those of the JDK's exceptions.
+var ErrShutdownInProgress = errors.New("Shutdown in progress")
+var ErrHookRegistered = errors.New("Hook previously registered")
+
+// The shutdown hooks, which are java/lang/Thread objects. They're run by
+// hookRunner, which is set by the jvm package, as the classes that run Java
+// code are there.
+var hooks []interface{}
+var hooksRunning bool
+var hooksMutex sync.Mutex
+var hookRunner func(hook interface{})
+
+// shutdownInProgress is the shutdown under way, if any. It's guarded by exitMutex.
+var shutdownInProgress *shutdownState
+var exitMutex sync.Mutex
+
+// shutdownState is the state of a shutdown, which other threads that call
+// Exit() while the hooks run use to end the JVM
+type shutdownState struct {
+	requests chan ExitStatus // the status of the first such call
+	done     chan struct{}   // closed when exit() returns, which it does only in tests
+	ret      int
+}
+
+// SetHookRunner sets the function that runs a shutdown hook on a new thread
+// and returns when the hook has finished
+func SetHookRunner(runner func(hook interface{})) {
+	hooksMutex.Lock()
+	hookRunner = runner
+	hooksMutex.Unlock()
+}
+
+// AddHook registers a shutdown hook. A hook can be registered only once, and
+// none can be added once shutdown has begun.
+func AddHook(hook interface{}) error {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	if hooksRunning {
+		return ErrShutdownInProgress
+	}
+	for _, h := range hooks {
+		if h == hook {
+			return ErrHookRegistered
+		}
+	}
+	hooks = append(hooks, hook)
+	return nil
+}
+
+// RemoveHook de-registers a shutdown hook. It returns false if the hook was
+// not registered.
+func RemoveHook(hook interface{}) (bool, error) {
+	hooksMutex.Lock()
+	defer hooksMutex.Unlock()
+	if hooksRunning {
+		return false, ErrShutdownInProgress
+	}
+	for i, h := range hooks {
+		if h == hook {
+			hooks = append(hooks[:i], hooks[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// runHooks starts all the shutdown hooks at once, in no particular order (as
+// in the JDK), and waits for them to finish. If Exit() is called while they
+// run, it stops waiting and returns the status of that call.
+func runHooks(requests chan ExitStatus) (ExitStatus, bool) {
+	hooksMutex.Lock()
+	hooksRunning = true
+	toRun := hooks
+	hooks = nil
+	runner := hookRunner
+	hooksMutex.Unlock()
+
+	if runner == nil {
+		return OK, false
+	}
+	var wg sync.WaitGroup
+	for _, hook := range toRun {
+		wg.Add(1)
+		go func(hook interface{}) {
+			defer wg.Done()
+			runner(hook)
+		}(hook)
+	}
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return OK, false
+	case status := <-requests:
+		return status, true
+	}
+}
+
+// Exit runs the shutdown hooks and exits the JVM with the given status, as
+// Runtime.exit() does. It can be called from any thread. Shutdown proceeds as
+// in the JDK: the hooks that the program registered with Runtime.addShutdownHook()
+// are started, each on its own thread, and Exit() waits for them to finish; then
+// it runs the exit functions and ends the program.
+//
+// A call to Exit() while the hooks run, whether from a hook (for instance, on a
+// fatal error in the JVM) or from another thread, doesn't wait for them: the JVM
+// exits right away with the status of that call. (In the JDK, such a call blocks
+// forever, which here would deadlock the shutdown.)
+//
+// Exit() doesn't wait for the classes being preloaded (see globals.LoaderWg):
+// they're only loaded in case they're needed, and the thread exiting could be
+// in the middle of loading one they're waiting for.
 func Exit(errorCondition ExitStatus) int {
-	globals.LoaderWg.Wait()
+	exitMutex.Lock()
+	if s := shutdownInProgress; s != nil {
+		exitMutex.Unlock()
+		select {
+		case s.requests <- errorCondition:
+		default: // another thread has already asked for the JVM to exit
+		}
+		<-s.done
+		return s.ret
+	}
+	s := &shutdownState{requests: make(chan ExitStatus, 1), done: make(chan struct{})}
+	shutdownInProgress = s
+	exitMutex.Unlock()
+
+	if status, requested := runHooks(s.requests); requested {
+		errorCondition = status
+	}
+	s.ret = exit(errorCondition)
+
+	exitMutex.Lock()
+	shutdownInProgress = nil
+	exitMutex.Unlock()
+	close(s.done)
+	return s.ret
+}
+
+// Halt exits the JVM without running the shutdown hooks, as Runtime.halt()
+// does. Output that the JVM has buffered for System.out and System.err is
+// still written.
+func Halt(errorCondition ExitStatus) int {
+	return exit(errorCondition)
+}
+
+func exit(errorCondition ExitStatus) int {
+	runExitFuncs()
+
+	// the tests run in one process, so in a test, exit() returns 0 or 1 rather
+	// than ending the process. The statuses that stand for these are used only
+	// in tests, so that a program can exit with any status.
 	g := globals.GetGlobalRef()
-	if g.JacobinName == "test" {
+	testing := g.JacobinName == "test"
+	if testing {
 		if errorCondition == OK {
 			errorCondition = TEST_OK
 		} else {
@@ -44,9 +220,15 @@ func Exit(errorCondition ExitStatus) int {
 		errorCondition = UNKNOWN_ERROR
 	}
 
-	if errorCondition == TEST_OK {
-		return 0
-	} else if errorCondition == TEST_ERR {
+	if testing {
+		// every test gets a JVM that is not shutting down
+		hooksMutex.Lock()
+		hooksRunning = false
+		hooksMutex.Unlock()
+
+		if errorCondition == TEST_OK {
+			return 0
+		}
 		return 1
 	}
 
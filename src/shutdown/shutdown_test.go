@@ -11,8 +11,10 @@ import (
 	"jacobin/globals"
 	"jacobin/log"
 	"os"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestShutdownOK(t *testing.T) {
@@ -77,3 +79,116 @@ func TestShutdownReturn(t *testing.T) {
 		t.Errorf("Expecting exit() return value of 0, but got %d", ret)
 	}
 }
+
+func TestShutdownHooks(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+	gl.JacobinName = "test"
+	log.Init()
+	defer SetHookRunner(nil)
+
+	// the hooks wait for each other, so they finish only if they run at the same time
+	ran := make(chan string, 3)
+	started := make(chan bool)
+	addErrs := make(chan error, 2)
+	SetHookRunner(func(hook interface{}) {
+		addErrs <- AddHook("late")
+		select {
+		case started <- true:
+		case <-started:
+		case <-time.After(2 * time.Second):
+		}
+		ran <- hook.(string)
+	})
+
+	for _, hook := range []string{"flush", "close", "unused"} {
+		if err := AddHook(hook); err != nil {
+			t.Fatalf("Unexpected error adding hook %s: %v", hook, err)
+		}
+	}
+	if err := AddHook("flush"); err != ErrHookRegistered {
+		t.Errorf("Expected adding a hook twice to return ErrHookRegistered, got %v", err)
+	}
+	if removed, _ := RemoveHook("unused"); !removed {
+		t.Errorf("Expected RemoveHook() of a registered hook to return true")
+	}
+	if removed, _ := RemoveHook("never added"); removed {
+		t.Errorf("Expected RemoveHook() of an unregistered hook to return false")
+	}
+
+	start := time.Now()
+	Exit(OK)
+	close(ran)
+	if time.Since(start) >= 2*time.Second {
+		t.Errorf("Expected the shutdown hooks to run at the same time")
+	}
+	var hooksRun []string
+	for hook := range ran {
+		hooksRun = append(hooksRun, hook)
+	}
+	sort.Strings(hooksRun)
+	if strings.Join(hooksRun, ",") != "close,flush" {
+		t.Errorf("Expected the hooks close and flush to run, got %v", hooksRun)
+	}
+	if err := <-addErrs; err != ErrShutdownInProgress {
+		t.Errorf("Expected adding a hook during shutdown to return ErrShutdownInProgress, got %v", err)
+	}
+}
+
+func TestHaltSkipsShutdownHooks(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+	gl.JacobinName = "test"
+	log.Init()
+	defer SetHookRunner(nil)
+
+	hookRan := false
+	SetHookRunner(func(hook interface{}) { hookRan = true })
+	_ = AddHook("hook")
+	exitFuncRan := false
+	OnExit(func() { exitFuncRan = true })
+
+	if ret := Halt(OK); ret != 0 {
+		t.Errorf("Expecting Halt() return value of 0, but got %d", ret)
+	}
+	if hookRan {
+		t.Errorf("Expected Halt() not to run the shutdown hooks")
+	}
+	if !exitFuncRan {
+		t.Errorf("Expected Halt() to run the exit functions")
+	}
+	_, _ = RemoveHook("hook")
+}
+
+// a hook that calls Exit(), as one does on a fatal error in the JVM, ends the shutdown
+func TestExitFromShutdownHook(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+	gl.JacobinName = "test"
+	log.Init()
+	defer SetHookRunner(nil)
+
+	hookRet := make(chan int, 1)
+	SetHookRunner(func(hook interface{}) {
+		hookRet <- Exit(JVM_EXCEPTION)
+	})
+	_ = AddHook("failing")
+
+	ret := make(chan int, 1)
+	go func() { ret <- Exit(OK) }()
+	select {
+	case r := <-ret:
+		if r != 1 {
+			t.Errorf("Expected the status of the hook's call to Exit(), 1, got %d", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Expected Exit() not to deadlock when a shutdown hook calls Exit()")
+	}
+	if r := <-hookRet; r != 1 {
+		t.Errorf("Expected the hook's call to Exit() to return 1, got %d", r)
+	}
+	if err := AddHook("next"); err != nil {
+		t.Errorf("Expected hooks to be accepted after the shutdown in a test, got %v", err)
+	}
+	_, _ = RemoveHook("next")
+}
@@ -0,0 +1,34 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2023 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package shutdown
+
+import (
+	"fmt"
+	"jacobin/log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals makes SIGINT (Ctrl-C) and SIGTERM shut down the JVM in an
+// orderly way, so that the shutdown hooks run. As in the JDK, the exit status
+// is 128 plus the number of the signal. Signals received while the JVM is
+// already shutting down are ignored.
+func HandleSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		signal.Ignore(syscall.SIGINT, syscall.SIGTERM)
+		_ = log.Log(fmt.Sprintf("received signal %s, shutting down", sig), log.FINE)
+		status := 128
+		if num, ok := sig.(syscall.Signal); ok {
+			status += int(num)
+		}
+		Exit(status)
+	}()
+}
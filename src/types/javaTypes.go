@@ -29,7 +29,8 @@ const RuneArray = "[R" // used only in strings that are not compact
 const String = "T"
 const Static = "X"
 
-const GoMeth = "G" // a go mehod
+const GoMeth = "G"   // a go mehod
+const GoObject = "g" // a Go value that holds the state of a Go-backed object, e.g. a StringBuilder
 
 const Error = "0"  // if an error occurred in getting a type
 const Struct = "9" // used primarily in returning items from the CP